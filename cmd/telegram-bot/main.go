@@ -3,14 +3,20 @@ package main
 import (
 	"context"
 	"log"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/SobolevTim/finance_bot/internal/delivery/telegram"
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
 	"github.com/SobolevTim/finance_bot/internal/pkg/config"
 	"github.com/SobolevTim/finance_bot/internal/pkg/logger"
 	"github.com/SobolevTim/finance_bot/internal/repository/database"
+	"github.com/SobolevTim/finance_bot/internal/repository/file"
 	"github.com/SobolevTim/finance_bot/internal/repository/memory"
+	"github.com/SobolevTim/finance_bot/internal/scheduler"
 	"github.com/SobolevTim/finance_bot/internal/service"
+	"github.com/mymmrac/telego"
 )
 
 func main() {
@@ -26,10 +32,14 @@ func main() {
 	bdlogger := logger.GetLogger("database")
 	memlogger := logger.GetLogger("memorydb")
 
+	// Корневой контекст отменяется по SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	// Подключаем репозиторий
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	initCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
-	repo, err := database.NewUserRepository(ctx, *config, bdlogger)
+	repo, err := database.NewUserRepository(initCtx, *config, bdlogger)
 	if err != nil {
 		bdlogger.Error("ошибка при создании user repository", "error", err)
 		return
@@ -37,31 +47,57 @@ func main() {
 	defer repo.Close()
 
 	// Подключаем репозиторий Статистики
-	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	initCtx, cancel = context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
-	StatRepo, err := memory.NewMemoryRepository(ctx, *config, memlogger)
+	StatRepo, err := memory.NewMemoryRepository(initCtx, *config, memlogger)
 	if err != nil {
 		memlogger.Error("ошибка при создании memory repository", "error", err)
 		return
 	}
 	defer StatRepo.Close()
-	defer StatRepo.Close()
+
+	// Подключаем источник курсов валют
+	var rates currency.RateProvider = repo
+	if config.Currency.RatesSource == "file" {
+		rateslogger := logger.GetLogger("rates")
+		rates, err = file.NewRatesRepository(config.Currency.RatesFile, rateslogger)
+		if err != nil {
+			rateslogger.Error("ошибка при загрузке курсов валют", "error", err)
+			return
+		}
+	}
 
 	// Подключаем сервисы
-	service := service.NewService(repo, repo, StatRepo, repo, repo)
+	service := service.NewService(repo, repo, StatRepo, repo, repo, repo, repo, repo, repo, repo, rates, config.Currency.Default)
 
 	// Создаем бота
-	bot, err := telegram.NewBot(config.TG.Token, service, tglogger, config.TG.Debug)
+	var botOptions []telego.BotOption
+	if config.TG.APIURL != "" {
+		botOptions = append(botOptions, telego.WithAPIServer(config.TG.APIURL))
+	}
+	bot, err := telegram.NewBot(config.TG.Token, service, tglogger, config.TG.Debug, botOptions...)
 	if err != nil {
 		tglogger.Error("ошибка создания бота", "error", err)
 		return
 	}
 
-	// Запускаем бота
-	bot.StartBot(config.TG.TypePolling)
+	// Запускаем планировщик фоновых задач
+	sched := scheduler.New(logger.GetLogger("scheduler"))
+	sched.Add("recurring_expenses", config.Scheduler.RecurringInterval, bot.ProcessRecurringExpenses)
+	sched.Add("dispatcher_stats", config.Scheduler.StatsInterval, bot.ReportDispatcherStats)
+	sched.Add("notifications", config.Scheduler.NotifyInterval, bot.SendNotifications)
+	sched.Add("budget_periods", config.Scheduler.BudgetInterval, bot.RollBudgets)
+	sched.Start(ctx)
+
+	// Запускаем бота и ожидаем завершения работы.
+	// StartBot возвращает управление после отмены ctx и обработки полученных обновлений
 	tglogger.Info("Бот запущен")
+	if err := bot.StartBot(ctx, config.TG); err != nil {
+		tglogger.Error("ошибка работы бота", "error", err)
+	}
+	stop()
+	sched.Wait()
+	tglogger.Info("Бот остановлен")
 
-	// Ожидаем завершения работы
-	// TODO добавить завершение работы
-	select {}
+	// Подключения к Redis и БД закрываются в отложенных вызовах
 }
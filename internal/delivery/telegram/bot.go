@@ -1,14 +1,36 @@
 package telegram
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/SobolevTim/finance_bot/internal/domain/account"
+	"github.com/SobolevTim/finance_bot/internal/domain/budget"
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/SobolevTim/finance_bot/internal/domain/notification"
+	"github.com/SobolevTim/finance_bot/internal/pkg/chart"
+	"github.com/SobolevTim/finance_bot/internal/pkg/config"
+	"github.com/SobolevTim/finance_bot/internal/pkg/dispatcher"
 	"github.com/SobolevTim/finance_bot/internal/service"
 	"github.com/mymmrac/telego"
 	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultShutdownTimeout = 10 * time.Second // Время на обработку полученных обновлений при завершении работы
+	defaultWorkers         = 8                // Количество параллельных обработчиков обновлений
+	defaultQueueSize       = 100              // Емкость очереди одного обработчика
 )
 
 // Bot структура бота
@@ -16,6 +38,14 @@ type Bot struct {
 	Client  *telego.Bot      // Клиент телеграма
 	Service *service.Service // Сервис
 	logger  *slog.Logger     // Логгер
+
+	id       int64  // ID бота в Telegram
+	username string // Имя бота для упоминаний в группах
+
+	shutdownTimeout time.Duration                         // Время на обработку полученных обновлений при завершении работы
+	workers         int                                   // Количество параллельных обработчиков обновлений
+	queueSize       int                                   // Емкость очереди одного обработчика
+	dispatcher      atomic.Pointer[dispatcher.Dispatcher] // Диспетчер обновлений, nil - бот не запущен
 }
 
 // NewBot создает новый экземпляр бота
@@ -25,14 +55,15 @@ type Bot struct {
 // statusMem - сервис для работы со статусами
 // logger - логгер
 // debug - режим отладки
+// options - дополнительные опции клиента, например telego.WithAPIServer
 //
 // Возвращает новый экземпляр бота или ошибку
-func NewBot(token string, service *service.Service, logger *slog.Logger, debug bool) (*Bot, error) {
+func NewBot(token string, service *service.Service, logger *slog.Logger, debug bool, options ...telego.BotOption) (*Bot, error) {
 	logger.Debug("Создание бота с токеном", "token", token)
 	logger.Debug("Дебаг режим бота", "debug", debug)
 
 	// Создаем бота
-	client, err := telego.NewBot(token, telego.WithDefaultLogger(debug, true))
+	client, err := telego.NewBot(token, append([]telego.BotOption{telego.WithDefaultLogger(debug, true)}, options...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -46,29 +77,48 @@ func NewBot(token string, service *service.Service, logger *slog.Logger, debug b
 		Client:  client,
 		Service: service,
 		logger:  logger,
+
+		id:       bot.ID,
+		username: bot.Username,
+
+		shutdownTimeout: defaultShutdownTimeout,
+		workers:         defaultWorkers,
+		queueSize:       defaultQueueSize,
 	}, nil
 }
 
-// StartBot запускает бота
+// StartBot запускает бота и блокирует выполнение до отмены ctx
 //
-// polingType - тип работы бота
-func (b *Bot) StartBot(polingType string) {
-	if polingType == "longpolling" {
-		b.logger.Debug("Запуск бота", "polingType", "longpolling")
-		b.StartPooling()
+// cfg - конфигурация Telegram, тип работы бота задается в cfg.TypePolling
+//
+// После отмены ctx бот перестает принимать обновления и дорабатывает уже
+// полученные не дольше cfg.ShutdownTimeout
+func (b *Bot) StartBot(ctx context.Context, cfg config.TGConfig) error {
+	if cfg.ShutdownTimeout > 0 {
+		b.shutdownTimeout = cfg.ShutdownTimeout
+	}
+	if cfg.Workers > 0 {
+		b.workers = cfg.Workers
+	}
+	if cfg.QueueSize > 0 {
+		b.queueSize = cfg.QueueSize
 	}
-	if polingType == "webhook" {
+	switch cfg.TypePolling {
+	case "longpolling":
+		b.logger.Debug("Запуск бота", "polingType", "longpolling")
+		return b.StartPooling(ctx)
+	case "webhook":
 		b.logger.Debug("Запуск бота", "polingType", "webhook")
-		// TODO webhook
+		return b.StartWebhook(ctx, cfg.Webhook)
 	}
-
+	return fmt.Errorf("неизвестный тип работы бота: %s", cfg.TypePolling)
 }
 
 // StartPooling запускает бота с использованием longpolling
-func (b *Bot) StartPooling() {
+// и обрабатывает обновления до отмены ctx
+func (b *Bot) StartPooling(ctx context.Context) error {
 	updates, err := b.Client.UpdatesViaLongPolling(
-		// TODO добавить контекст для завершения работы
-		context.Background(),
+		ctx,
 
 		&telego.GetUpdatesParams{
 			Offset:  0,
@@ -80,17 +130,164 @@ func (b *Bot) StartPooling() {
 		telego.WithLongPollingBuffer(100),
 	)
 	if err != nil {
-		b.logger.Error("ошибка при получении обновлений", "error", err)
+		return fmt.Errorf("ошибка при получении обновлений: %w", err)
+	}
+
+	b.serve(ctx, updates)
+	b.logger.Info("Longpolling остановлен")
+	return nil
+}
+
+// StartWebhook запускает бота с использованием webhook
+//
+// Поднимает HTTP-сервер на cfg.Listen, регистрирует webhook cfg.URL в Telegram
+// и обрабатывает обновления до отмены ctx. При завершении останавливает
+// сервер и удаляет webhook
+//
+// Запросы без верного секрета cfg.SecretToken в заголовке
+// X-Telegram-Bot-Api-Secret-Token отклоняются с кодом 401
+func (b *Bot) StartWebhook(ctx context.Context, cfg config.WebhookConfig) error {
+	listener, err := net.Listen("tcp", cfg.Listen)
+	if err != nil {
+		return fmt.Errorf("ошибка при запуске HTTP-сервера: %w", err)
+	}
+	server := &http.Server{ReadHeaderTimeout: 10 * time.Second}
+
+	// Канал обновлений закрывается только после остановки HTTP-сервера,
+	// чтобы запросы, пришедшие во время завершения, не писали в закрытый канал
+	updatesCtx, stopUpdates := context.WithCancel(context.Background())
+	defer stopUpdates()
+
+	setCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	updates, err := b.Client.UpdatesViaWebhook(
+		updatesCtx,
+		telego.WebhookHTTPServer(server, cfg.Path, cfg.SecretToken),
+		telego.WithWebhookSet(setCtx, &telego.SetWebhookParams{
+			URL:         cfg.URL,
+			SecretToken: cfg.SecretToken,
+		}),
+		telego.WithWebhookBuffer(100),
+	)
+	if err != nil {
+		listener.Close()
+		return fmt.Errorf("ошибка при установке webhook: %w", err)
 	}
 
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			b.logger.Error("ошибка HTTP-сервера webhook", "error", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			b.logger.Error("ошибка при остановке HTTP-сервера webhook", "error", err)
+		}
+		stopUpdates()
+	}()
+	b.logger.Info("Webhook запущен", "listen", listener.Addr().String(), "path", cfg.Path)
+
+	b.serve(ctx, updates)
+
+	deleteCtx, deleteCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer deleteCancel()
+	if err := b.Client.DeleteWebhook(deleteCtx, &telego.DeleteWebhookParams{}); err != nil {
+		return fmt.Errorf("ошибка при удалении webhook: %w", err)
+	}
+	b.logger.Info("Webhook остановлен")
+	return nil
+}
+
+// serve обрабатывает обновления из updates до закрытия канала
+//
+// Обновления обрабатываются параллельно b.workers обработчиками, обновления
+// одного чата - строго по порядку, чтобы шаги ввода в статусе не перемешивались
+//
+// Обработчики получают контекст, производный от ctx, но не отменяемый вместе
+// с ним: после отмены ctx уже полученные обновления дорабатываются. Если это
+// занимает дольше b.shutdownTimeout, контекст обработчиков отменяется,
+// а оставшиеся обновления отбрасываются
+func (b *Bot) serve(ctx context.Context, updates <-chan telego.Update) {
+	handlerCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-handlerCtx.Done():
+			return
+		}
+		timer := time.NewTimer(b.shutdownTimeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			b.logger.Warn("Истекло время завершения обработчиков", "timeout", b.shutdownTimeout)
+			cancel()
+		case <-handlerCtx.Done():
+		}
+	}()
+
+	d := dispatcher.New(b.workers, b.queueSize, b.logger)
+	b.dispatcher.Store(d)
+	defer b.dispatcher.Store(nil)
+
 	for update := range updates {
-		b.logger.Debug("Получено обновление", "update", update)
-		if update.Message != nil {
-			b.handlers(update)
+		if handlerCtx.Err() != nil {
+			b.logger.Warn("Обновление отброшено при завершении работы", "updateID", update.UpdateID)
+			continue
+		}
+		err := d.Dispatch(handlerCtx, updateChatID(update), func(ctx context.Context) {
+			b.handleUpdate(ctx, update)
+		})
+		if err != nil {
+			b.logger.Warn("Обновление отброшено", "updateID", update.UpdateID, "error", err)
 		}
-		if update.CallbackQuery != nil {
-			b.inlinehandlers(update)
+	}
+	d.Close()
+}
+
+// updateChatID возвращает ID чата, к которому относится обновление
+func updateChatID(update telego.Update) int64 {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return update.CallbackQuery.Message.GetChat().ID
+	case update.CallbackQuery != nil:
+		return update.CallbackQuery.From.ID
+	}
+	return 0
+}
+
+// handleUpdate передает обновление в обработчики сообщений и инлайн-событий
+func (b *Bot) handleUpdate(ctx context.Context, update telego.Update) {
+	b.logger.Debug("Получено обновление", "update", update)
+	if update.Message != nil {
+		b.handlers(ctx, update)
+	}
+	if update.CallbackQuery != nil {
+		b.inlinehandlers(ctx, update)
+	}
+}
+
+// sendContext возвращает контекст отправки сообщения с таймаутом timeout
+//
+// Отправка отменяется вместе с ctx, но не ограничивается его сроком:
+// сообщение об ошибке должно дойти, даже если операция обработчика
+// завершилась по таймауту
+func sendContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	stop := context.AfterFunc(ctx, func() {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			cancel()
 		}
+	})
+	return sendCtx, func() {
+		stop()
+		cancel()
 	}
 }
 
@@ -98,9 +295,9 @@ func (b *Bot) StartPooling() {
 //
 // id - идентификатор чата
 // text - текст сообщения
-func (b *Bot) SendErrorMessage(id int64, text string) {
+func (b *Bot) SendErrorMessage(ctx context.Context, id int64, text string) {
 	msg := tu.Message(tu.ID(id), "❌ "+text)
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	ctx, cancel := sendContext(ctx, 2*time.Second)
 	defer cancel()
 	_, err := b.Client.SendMessage(ctx, msg)
 	if err != nil {
@@ -114,9 +311,9 @@ func (b *Bot) SendErrorMessage(id int64, text string) {
 //
 // id - идентификатор чата
 // text - текст сообщения
-func (b *Bot) SendMessage(id int64, text string) {
+func (b *Bot) SendMessage(ctx context.Context, id int64, text string) {
 	msg := tu.Message(tu.ID(id), text)
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	ctx, cancel := sendContext(ctx, 2*time.Second)
 	defer cancel()
 	_, err := b.Client.SendMessage(ctx, msg)
 	if err != nil {
@@ -126,9 +323,9 @@ func (b *Bot) SendMessage(id int64, text string) {
 	b.logger.Debug("Отправка сообщения", "message", msg.Text, "chatID", msg.ChatID)
 }
 
-func (b *Bot) SendMessageWithKeyboard(id int64, text string, keyboard telego.ReplyMarkup) {
+func (b *Bot) SendMessageWithKeyboard(ctx context.Context, id int64, text string, keyboard telego.ReplyMarkup) {
 	msg := tu.Message(tu.ID(id), text).WithReplyMarkup(keyboard)
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	ctx, cancel := sendContext(ctx, 2*time.Second)
 	defer cancel()
 	_, err := b.Client.SendMessage(ctx, msg)
 	if err != nil {
@@ -138,14 +335,197 @@ func (b *Bot) SendMessageWithKeyboard(id int64, text string, keyboard telego.Rep
 	b.logger.Debug("Отправка сообщения", "message", msg.Text, "chatID", msg.ChatID)
 }
 
-func (b *Bot) sendAmountPrompt(chatID int64) {
+// SendDocument отправляет файл документом
+//
+// id - идентификатор чата
+// name - имя файла
+// data - содержимое файла
+func (b *Bot) SendDocument(ctx context.Context, id int64, name string, data []byte) {
+	doc := tu.Document(tu.ID(id), tu.File(tu.NameReader(bytes.NewReader(data), name)))
+	ctx, cancel := sendContext(ctx, 10*time.Second)
+	defer cancel()
+	_, err := b.Client.SendDocument(ctx, doc)
+	if err != nil {
+		b.logger.Error("Ошибка отправки документа", "error", err)
+		return
+	}
+	b.logger.Debug("Отправка документа", "name", name, "size", len(data), "chatID", id)
+}
+
+// SendPhoto отправляет изображение с подписью
+//
+// id - идентификатор чата
+// name - имя файла
+// data - содержимое изображения
+// caption - подпись под изображением
+func (b *Bot) SendPhoto(ctx context.Context, id int64, name string, data []byte, caption string) {
+	photo := tu.Photo(tu.ID(id), tu.File(tu.NameReader(bytes.NewReader(data), name))).WithCaption(caption)
+	ctx, cancel := sendContext(ctx, 10*time.Second)
+	defer cancel()
+	_, err := b.Client.SendPhoto(ctx, photo)
+	if err != nil {
+		b.logger.Error("Ошибка отправки изображения", "error", err)
+		return
+	}
+	b.logger.Debug("Отправка изображения", "name", name, "size", len(data), "chatID", id)
+}
+
+// sendSearchResults отправляет страницу результатов поиска с кнопками редактирования и перелистывания
+func (b *Bot) sendSearchResults(ctx context.Context, chatID int64, result *service.SearchResultDTO) {
+	if result.Total == 0 || len(result.Expenses) == 0 {
+		b.SendMessage(ctx, chatID, fmt.Sprintf("🔎 По запросу «%s» ничего не найдено", result.Query))
+		return
+	}
+
+	text := fmt.Sprintf("🔎 Найдено трат по запросу «%s»: %d (стр. %d из %d)\n\n", result.Query, result.Total, result.Page+1, result.Pages)
+	for _, exp := range result.Expenses {
+		text += fmt.Sprintf("📅 %s: %s - %s", exp.Date.Format("02.01.2006"), currency.Format(exp.Amount, exp.Currency), expenseCategory(exp))
+		if exp.Description != "" {
+			text += " - " + exp.Description
+		}
+		text += "\n"
+	}
+
+	keyboard := expenseKeyboard(result.Expenses)
+	pages := make([]telego.InlineKeyboardButton, 0, 2)
+	if result.Page > 0 {
+		pages = append(pages, tu.InlineKeyboardButton("⬅ Пред.").WithCallbackData(fmt.Sprintf("find_page_%d", result.Page-1)))
+	}
+	if result.Page+1 < result.Pages {
+		pages = append(pages, tu.InlineKeyboardButton("След. ➡").WithCallbackData(fmt.Sprintf("find_page_%d", result.Page+1)))
+	}
+	if len(pages) > 0 {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, pages)
+	}
+	b.SendMessageWithKeyboard(ctx, chatID, text, keyboard)
+}
+
+// statsKeyboard возвращает кнопки диаграмм под отчетами
+func statsKeyboard() *telego.InlineKeyboardMarkup {
+	return tu.InlineKeyboard(statsKeyboardRow())
+}
+
+// statsKeyboardRow возвращает ряд кнопок диаграмм для добавления к другим кнопкам
+func statsKeyboardRow() []telego.InlineKeyboardButton {
+	return tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("🥧 Категории").WithCallbackData("stats_"+service.ChartCategories),
+		tu.InlineKeyboardButton("📊 По дням").WithCallbackData("stats_"+service.ChartDaily),
+		tu.InlineKeyboardButton("📈 По месяцам").WithCallbackData("stats_"+service.ChartTrend),
+	)
+}
+
+// statsCaption формирует подпись к диаграмме вида kind
+func statsCaption(stats *service.StatsDTO, kind string) string {
+	period := fmt.Sprintf("%s - %s", stats.StartDate.Format("02.01"), stats.EndDate.Format("02.01"))
+	switch kind {
+	case service.ChartCategories:
+		text := fmt.Sprintf("🥧 Траты по категориям за %s: %s\n", period, currency.Format(stats.Total, stats.Currency))
+		for i, c := range stats.Categories {
+			text += fmt.Sprintf("%s %s %s: %s (%.0f%%)\n", chart.Marks[i%len(chart.Marks)], c.CategoryIcon, c.Category,
+				currency.Format(c.Amount, stats.Currency), c.Amount.Div(stats.Total).Mul(decimal.NewFromInt(100)).InexactFloat64())
+		}
+		return text
+	case service.ChartDaily:
+		text := fmt.Sprintf("📊 Траты по дням за %s: %s\n", period, currency.Format(stats.Total, stats.Currency))
+		if stats.Allowance.IsPositive() {
+			over := 0
+			for _, d := range stats.Daily {
+				if d.Amount.GreaterThan(stats.Allowance) {
+					over++
+				}
+			}
+			text += fmt.Sprintf("🟧 Пунктир - бюджет на день: %s\n🟥 Дней с перерасходом: %d", currency.Format(stats.Allowance, stats.Currency), over)
+		}
+		return text
+	default:
+		text := "📈 Траты по месяцам:\n"
+		for _, m := range stats.Trend {
+			text += fmt.Sprintf("%s: %s\n", m.Date.Format("01.2006"), currency.Format(m.Amount, stats.Currency))
+		}
+		return text
+	}
+}
+
+// sendExportFormatPrompt предлагает выбрать формат файла выгрузки за период
+func (b *Bot) sendExportFormatPrompt(ctx context.Context, chatID int64, period string) {
+	keyboard := tu.InlineKeyboard(
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("CSV").WithCallbackData("export_f_csv_"+period),
+			tu.InlineKeyboardButton("Excel (XLSX)").WithCallbackData("export_f_xlsx_"+period),
+		),
+	)
+	b.SendMessageWithKeyboard(ctx, chatID, "Выберите формат файла:", keyboard)
+}
+
+// sendImportPreview показывает предпросмотр импорта выписки
+//
+// Если колонки не определены, просит указать их вручную
+func (b *Bot) sendImportPreview(ctx context.Context, chatID int64, preview *service.ImportPreviewDTO) {
+	if !preview.MappingDetected {
+		b.sendImportMappingPrompt(ctx, chatID, preview)
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📥 Выписка %s\n", preview.FileName)
+	fmt.Fprintf(&sb, "Колонки: дата - %s, сумма - %s", columnTitle(preview.Header, preview.Date), columnTitle(preview.Header, preview.Amount))
+	if preview.Description >= 0 {
+		fmt.Fprintf(&sb, ", описание - %s", columnTitle(preview.Header, preview.Description))
+	}
+	fmt.Fprintf(&sb, "\n\nНовых трат: %d\nУже записаны: %d\nПропущено строк: %d\n", preview.Total, preview.Duplicates, preview.Skipped)
+	if len(preview.Rows) > 0 {
+		sb.WriteString("\nПервые траты:\n")
+		for _, r := range preview.Rows {
+			fmt.Fprintf(&sb, "%s  %s  %s  %s\n", r.Date.Format("02.01.2006"), currency.Format(r.Amount, r.Currency), r.Category, r.Description)
+		}
+	}
+
+	rows := make([][]telego.InlineKeyboardButton, 0, 2)
+	if preview.Total > 0 {
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(fmt.Sprintf("✅ Импортировать (%d)", preview.Total)).WithCallbackData("import_confirm"),
+		))
+	}
+	rows = append(rows, tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("🔧 Колонки").WithCallbackData("import_map"),
+		tu.InlineKeyboardButton("✖️ Отмена").WithCallbackData("import_cancel"),
+	))
+	b.SendMessageWithKeyboard(ctx, chatID, sb.String(), tu.InlineKeyboard(rows...))
+}
+
+// sendImportMappingPrompt показывает колонки выписки и просит указать дату, сумму и описание
+func (b *Bot) sendImportMappingPrompt(ctx context.Context, chatID int64, preview *service.ImportPreviewDTO) {
+	if err := b.Service.SetStatus(ctx, chatID, StatusImportMapping); err != nil {
+		b.logger.Error("Ошибка установки статуса", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Колонки файла %s:\n", preview.FileName)
+	for i, h := range preview.Header {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, h)
+	}
+	sb.WriteString("\nВведите номера колонок даты, суммы и описания через пробел, например: 1 3 5\nОписание можно не указывать")
+	b.sendTextPrompt(ctx, chatID, sb.String())
+}
+
+// columnTitle возвращает название колонки выписки по номеру
+func columnTitle(header []string, i int) string {
+	if i < 0 || i >= len(header) {
+		return "-"
+	}
+	return fmt.Sprintf("\"%s\"", header[i])
+}
+
+func (b *Bot) sendAmountPrompt(ctx context.Context, chatID int64) {
 	b.logger.Debug("Запрос суммы расхода", "chatID", chatID)
-	b.sendTextPrompt(chatID, "Введите сумму расхода (можно использовать математическое выражение, например, 150+20):")
+	b.sendTextPrompt(ctx, chatID, "Введите сумму расхода (можно использовать математическое выражение, например, 150+20, и указать валюту: 20 USD):")
 }
 
-func (b *Bot) sendTextPrompt(chatID int64, prompt string) {
+func (b *Bot) sendTextPrompt(ctx context.Context, chatID int64, prompt string) {
 	msg := tu.Message(tu.ID(chatID), prompt)
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	ctx, cancel := sendContext(ctx, 2*time.Second)
 	defer cancel()
 	_, err := b.Client.SendMessage(ctx, msg)
 	if err != nil {
@@ -155,20 +535,275 @@ func (b *Bot) sendTextPrompt(chatID int64, prompt string) {
 	b.logger.Debug("Отправка сообщения", "message", msg.Text, "chatID", msg.ChatID)
 }
 
-func (b *Bot) sendConfirmation(chatID int64, entry *service.ExpenseEntryDTO) {
+func (b *Bot) sendConfirmation(ctx context.Context, chatID int64, entry *service.ExpenseEntryDTO) {
 	b.logger.Debug("Подтверждение записи расхода", "chatID", chatID, "entry", entry)
-	summary := fmt.Sprintf("Подтвердите запись расхода:\nДата: %s\nСумма: %.2f₽\nКатегория: %s\nПримечание: %s",
+	summary := fmt.Sprintf("Подтвердите запись расхода:\nДата: %s\nСумма: %s\nКатегория: %s\nПримечание: %s",
 		entry.Date.Format("02.01.2006"),
-		entry.Amount,
+		currency.Format(entry.Amount, entry.Currency),
 		entry.Category,
 		entry.Note,
 	)
+	if entry.Recurrence != "" {
+		summary += "\nПовторение: " + describeRecurrence(entry.Recurrence)
+	}
+	if entry.Account != "" {
+		summary += "\nСчет: " + entry.Account
+	}
+	if entry.Receipt != nil {
+		summary += fmt.Sprintf("\nЧек: ФН %s, ФД %s", entry.Receipt.FN, entry.Receipt.FD)
+	}
+	splitRow := tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("✂️ Разделить по категориям").WithCallbackData("add_split"),
+	)
+	if len(entry.Splits) > 0 {
+		summary += "\nРаспределение:"
+		for _, split := range entry.Splits {
+			summary += fmt.Sprintf("\n- %s: %s", split.Category, currency.Format(split.Amount, entry.Currency))
+		}
+		splitRow = append(splitRow, tu.InlineKeyboardButton("Без разделения").WithCallbackData("add_split_reset"))
+	}
 	keyboard := tu.InlineKeyboard(
 		tu.InlineKeyboardRow(
 			tu.InlineKeyboardButton("Записать").WithCallbackData("add_confirm"),
 			tu.InlineKeyboardButton("Отменить").WithCallbackData("add_cancel"),
 		),
+		splitRow,
+	)
+
+	b.SendMessageWithKeyboard(ctx, chatID, summary, keyboard)
+}
+
+// sendCategoryPrompt показывает кнопки базовых и пользовательских категорий для записи расхода
+func (b *Bot) sendCategoryPrompt(ctx context.Context, chatID int64, text string) {
+	userCategories, err := b.Service.GetUserCategories(ctx, chatID)
+	if err != nil {
+		b.logger.Error("Ошибка получения категорий", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз.")
+		return
+	}
+
+	keyboards := tu.InlineKeyboard()
+	for _, cat := range userCategories {
+		keyboards.InlineKeyboard = append(keyboards.InlineKeyboard, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(cat.Icon+" "+cat.Name).WithCallbackData("add_category_"+cat.Name),
+		))
+	}
+
+	b.SendMessageWithKeyboard(ctx, chatID, text, keyboards)
+}
+
+// sendRecurrencePrompt предлагает сделать расход повторяющимся
+func (b *Bot) sendRecurrencePrompt(ctx context.Context, chatID int64, entry *service.ExpenseEntryDTO) {
+	keyboard := tu.InlineKeyboard(
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("Не повторять").WithCallbackData("add_recur_none"),
+		),
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("Каждую неделю ("+weekdayNames[entry.Date.Weekday()]+")").WithCallbackData("add_recur_weekly"),
+			tu.InlineKeyboardButton(fmt.Sprintf("Каждый месяц (%d числа)", entry.Date.Day())).WithCallbackData("add_recur_monthly"),
+		),
 	)
 
-	b.SendMessageWithKeyboard(chatID, summary, keyboard)
+	b.SendMessageWithKeyboard(ctx, chatID, "Повторять этот расход?", keyboard)
+}
+
+// sendNotificationsMenu отправляет меню настроек уведомлений.
+// Нажатие на уведомление включает или выключает его
+func (b *Bot) sendNotificationsMenu(ctx context.Context, chatID int64, settings *notification.Settings) {
+	text := "🔔 Уведомления (время по вашему часовому поясу):\n" +
+		fmt.Sprintf("- Вечернее напоминание в %d:00, если за день нет расходов\n", notification.ReminderHour) +
+		fmt.Sprintf("- Обзор прошлой недели по понедельникам в %d:00\n", notification.DigestHour) +
+		fmt.Sprintf("- Итоги месяца первого числа в %d:00\n\n", notification.DigestHour) +
+		"Нажмите, чтобы включить или выключить:"
+
+	keyboard := tu.InlineKeyboard()
+	for _, kind := range notification.Kinds {
+		mark := "❌"
+		if settings.Enabled(kind) {
+			mark = "✅"
+		}
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(mark+" "+notification.KindTitle(kind)).WithCallbackData("notify_"+kind),
+		))
+	}
+	b.SendMessageWithKeyboard(ctx, chatID, text, keyboard)
+}
+
+// sendAccountPrompt предлагает выбрать счет, с которого оплачен расход
+func (b *Bot) sendAccountPrompt(ctx context.Context, chatID int64, accounts []*account.Account) {
+	keyboard := tu.InlineKeyboard()
+	for _, a := range accounts {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(a.Name).WithCallbackData("add_account_"+a.ID.String()),
+		))
+	}
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("Не указывать").WithCallbackData("add_account_none"),
+	))
+
+	b.SendMessageWithKeyboard(ctx, chatID, "С какого счета оплачен расход?", keyboard)
+}
+
+// sendBudgetAlerts отправляет предупреждения о пересечении порогов бюджета и лимитов категорий
+func (b *Bot) sendBudgetAlerts(ctx context.Context, chatID int64, alerts []*service.BudgetAlertDTO) {
+	for _, a := range alerts {
+		scope := "бюджета"
+		if a.Category != "" {
+			scope = fmt.Sprintf("лимита %s %s", a.CategoryIcon, a.Category)
+		}
+		var text string
+		if a.Threshold == budget.ThresholdOver {
+			text = fmt.Sprintf("🚨 Превышение %s на %s: %s / %s", scope,
+				currency.Format(a.Spent.Sub(a.Limit), a.Currency),
+				currency.Format(a.Spent, a.Currency),
+				currency.Format(a.Limit, a.Currency))
+		} else {
+			text = fmt.Sprintf("⚠️ Потрачено %d%% %s: %s / %s", a.Threshold, scope,
+				currency.Format(a.Spent, a.Currency),
+				currency.Format(a.Limit, a.Currency))
+		}
+		b.SendMessage(ctx, chatID, text)
+	}
+}
+
+// progressBarWidth - число делений в полосе прогресса цели
+const progressBarWidth = 10
+
+// progressBar возвращает полосу прогресса "▓▓▓░░░░░░░ 30%" для доли p от 0 до 1
+func progressBar(p float64) string {
+	filled := int(math.Round(p * progressBarWidth))
+	return fmt.Sprintf("%s%s %d%%", strings.Repeat("▓", filled), strings.Repeat("░", progressBarWidth-filled), int(math.Floor(p*100)))
+}
+
+// formatGoals формирует список целей с прогрессом и рекомендуемыми взносами
+func formatGoals(overview *service.GoalsOverviewDTO) string {
+	var text string
+	for _, d := range overview.Goals {
+		g := d.Goal
+		text += fmt.Sprintf("%s: %s / %s\n%s\n", g.Name,
+			currency.Format(g.Saved, g.Currency),
+			currency.Format(g.Target, g.Currency),
+			progressBar(d.Progress))
+		switch {
+		case g.IsReached():
+			text += "✅ Цель достигнута\n"
+		case d.MonthsLeft == 0:
+			text += fmt.Sprintf("⏰ Срок %s прошел, осталось накопить %s\n", g.Deadline.Format("02.01.2006"), currency.Format(g.Remaining(), g.Currency))
+		default:
+			text += fmt.Sprintf("До %s: %s в месяц", g.Deadline.Format("02.01.2006"), currency.Format(d.Monthly, g.Currency))
+			if d.Suggested.LessThan(d.Monthly) {
+				text += fmt.Sprintf(", из остатка бюджета - %s", currency.Format(d.Suggested, g.Currency))
+			}
+			text += "\n"
+		}
+	}
+	if overview.HasBudget && overview.Required.IsPositive() {
+		if overview.Headroom.GreaterThanOrEqual(overview.Required) {
+			text += fmt.Sprintf("💡 Остаток бюджета %s покрывает взносы %s\n", currency.Format(overview.Headroom, overview.Currency), currency.Format(overview.Required, overview.Currency))
+		} else {
+			text += fmt.Sprintf("💡 Остаток бюджета %s меньше нужных взносов %s\n", currency.Format(overview.Headroom, overview.Currency), currency.Format(overview.Required, overview.Currency))
+		}
+	}
+	return text
+}
+
+var weekdayNames = map[time.Weekday]string{
+	time.Monday:    "понедельник",
+	time.Tuesday:   "вторник",
+	time.Wednesday: "среда",
+	time.Thursday:  "четверг",
+	time.Friday:    "пятница",
+	time.Saturday:  "суббота",
+	time.Sunday:    "воскресенье",
+}
+
+// describeRecurrence возвращает описание правила повторения для пользователя
+func describeRecurrence(rule string) string {
+	r, err := expense.ParseRecurrenceRule(rule)
+	if err != nil {
+		return rule
+	}
+
+	var text string
+	switch r.Freq {
+	case expense.FreqDaily:
+		text = "каждый день"
+		if r.Interval > 1 {
+			text = fmt.Sprintf("каждые %d дн.", r.Interval)
+		}
+	case expense.FreqWeekly:
+		text = "каждую неделю"
+		if r.Interval > 1 {
+			text = fmt.Sprintf("каждые %d нед.", r.Interval)
+		}
+		if r.HasByDay {
+			text += ", " + weekdayNames[r.ByDay]
+		}
+	case expense.FreqMonthly:
+		text = "каждый месяц"
+		if r.Interval > 1 {
+			text = fmt.Sprintf("каждые %d мес.", r.Interval)
+		}
+		if r.ByMonthDay != 0 {
+			text += fmt.Sprintf(", %d числа", r.ByMonthDay)
+		}
+	}
+	return text
+}
+
+// sendEditMenu отправляет меню редактирования расхода
+func (b *Bot) sendEditMenu(ctx context.Context, chatID int64, entry *service.ExpenseEntryDTO) {
+	summary := fmt.Sprintf("✏️ Редактирование расхода:\nДата: %s\nСумма: %s\nКатегория: %s\nПримечание: %s",
+		entry.Date.Format("02.01.2006"),
+		currency.Format(entry.Amount, entry.Currency),
+		entry.Category,
+		entry.Note,
+	)
+	keyboard := tu.InlineKeyboard(
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("Сумма").WithCallbackData("edit_amount"),
+			tu.InlineKeyboardButton("Дата").WithCallbackData("edit_date"),
+		),
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("Категория").WithCallbackData("edit_category"),
+			tu.InlineKeyboardButton("Примечание").WithCallbackData("edit_note"),
+		),
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("🗑 Удалить").WithCallbackData("edit_delete"),
+			tu.InlineKeyboardButton("✅ Готово").WithCallbackData("edit_done"),
+		),
+	)
+
+	b.SendMessageWithKeyboard(ctx, chatID, summary, keyboard)
+}
+
+// maxExpenseButtons ограничивает число кнопок трат в одном сообщении
+const maxExpenseButtons = 50
+
+// expenseCategory возвращает категорию траты, для распределенной траты - все части с суммами
+func expenseCategory(exp *service.ExpenseDTO) string {
+	if len(exp.Splits) == 0 {
+		return exp.CategoryIcon + " " + exp.Category
+	}
+	parts := make([]string, 0, len(exp.Splits))
+	for _, split := range exp.Splits {
+		parts = append(parts, fmt.Sprintf("%s %s %s", split.CategoryIcon, split.Category, currency.Format(split.Amount, exp.Currency)))
+	}
+	return "✂️ " + strings.Join(parts, " + ")
+}
+
+// expenseKeyboard создает кнопки для открытия трат на редактирование.
+// Если трат больше maxExpenseButtons, показываются последние
+func expenseKeyboard(expenses []*service.ExpenseDTO) *telego.InlineKeyboardMarkup {
+	if len(expenses) > maxExpenseButtons {
+		expenses = expenses[len(expenses)-maxExpenseButtons:]
+	}
+	keyboard := tu.InlineKeyboard()
+	for _, exp := range expenses {
+		text := fmt.Sprintf("✏️ %s: %s %s %s", exp.Date.Format("02.01"), currency.Format(exp.Amount, exp.Currency), exp.CategoryIcon, exp.Category)
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(text).WithCallbackData("exp_"+exp.ID),
+		))
+	}
+	return keyboard
 }
@@ -2,12 +2,25 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	domainAccount "github.com/SobolevTim/finance_bot/internal/domain/account"
+	domainBudget "github.com/SobolevTim/finance_bot/internal/domain/budget"
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/SobolevTim/finance_bot/internal/domain/group"
+	domainUser "github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/SobolevTim/finance_bot/internal/pkg/calc"
+	"github.com/SobolevTim/finance_bot/internal/pkg/chart"
+	"github.com/SobolevTim/finance_bot/internal/pkg/clock"
 	"github.com/SobolevTim/finance_bot/internal/service"
 	"github.com/mymmrac/telego"
 	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/shopspring/decimal"
 )
 
 // handlersCmd обработка команд
@@ -17,28 +30,85 @@ import (
 // /cancel - отмена операции
 // /help - получение справки
 // /setbudget - установка бюджета
-func (b *Bot) handlersCmd(update telego.Update) {
+// /limit - установка лимита категории
+// /income [сумма источник] - запись дохода
+// /accounts - счета и балансы
+// /account <тип> <название> [баланс] - новый счет
+// /transfer <сумма> <со счета> > <на счет> - перевод между счетами
+// /goal [new|add|delete ...] - цели накоплений
+// /notifications - настройки уведомлений
+// /alerts [пороги] - пороги предупреждений о тратах бюджета
+// /timezone [пояс] - часовой пояс
+// /budgetperiod <период> - период бюджета: месяц с дня N или две недели
+// /rollover <on|off> - перенос остатка бюджета в следующий период
+// /categories - управление категориями
+// /alias <слово> <категория> - псевдоним категории для быстрой записи
+// /stats - диаграммы трат по категориям, дням и месяцам
+// /find <запрос> - поиск трат по описанию, категории, сумме и дате
+// /export - выгрузка трат в CSV или XLSX
+// /members - участники общего бюджета группы
+// /role @username <admin|member> - роль участника группы
+func (b *Bot) handlersCmd(ctx context.Context, update telego.Update) {
 	b.logger.Debug("Получена команда", "command", update.Message.Text, "tgID", update.Message.Chat.ID)
-	switch update.Message.Text {
+	command, args, _ := strings.Cut(strings.TrimSpace(update.Message.Text), " ")
+	// В группах команды приходят с именем бота: "/month@finance_bot"
+	command, _, _ = strings.Cut(command, "@")
+	switch command {
 	case "/start":
-		b.handlersStart(update)
+		b.handlersStart(ctx, update)
 	case "/cancel":
-		b.handlersCancel(update)
+		b.handlersCancel(ctx, update)
 	case "/help":
-		b.SendMessage(update.Message.Chat.ID, "Доступные команды:\n/start - регистрация\n/cancel - отмена операции\n/setbudget - установка бюджета\n/getbudget - получение бюджета\n/expense - просмотр расходов\n/add - добавление расхода")
+		b.SendMessage(ctx, update.Message.Chat.ID, "Доступные команды:\n/start - регистрация\n/cancel - отмена операции\n/setbudget - установка бюджета\n/getbudget - бюджет и прошлые периоды\n/budgetperiod - период бюджета\n/rollover - перенос остатка\n/limit - лимит по категории\n/income - запись дохода\n/accounts - счета и балансы\n/account - новый счет\n/transfer - перевод между счетами\n/goal - цели накоплений\n/notifications - настройки уведомлений\n/alerts - пороги предупреждений о бюджете\n/timezone - часовой пояс\n/categories - мои категории\n/alias - псевдоним категории\n/expense - просмотр расходов\n/stats - диаграммы расходов\n/find - поиск расходов\n/add - добавление расхода\n/export - выгрузка расходов в файл\n/members - участники группы\n/role - роль участника группы\n\nБыстрая запись: просто напишите \"350 кофе\" или \"25.03 500 еда\"\nИмпорт выписки: отправьте CSV-файл из банковского приложения\nКассовый чек: отправьте фото QR-кода или строку из него")
 	case "/setbudget":
-		b.handlersSetBudget(update)
+		b.handlersSetBudget(ctx, update)
 	case "/getbudget":
-		b.handlersGetBudget(update)
+		b.handlersGetBudget(ctx, update)
+	case "/limit":
+		b.handlersLimit(ctx, update)
+	case "/income":
+		b.handleIncomeCommand(ctx, update.Message.Chat.ID, update.Message.From, args)
+	case "/accounts":
+		b.handleAccountsCommand(ctx, update.Message.Chat.ID)
+	case "/account":
+		b.handleAccountCommand(ctx, update.Message.Chat.ID, args)
+	case "/transfer":
+		b.handleTransferCommand(ctx, update.Message.Chat.ID, args)
+	case "/goal":
+		b.handleGoalCommand(ctx, update, args)
+	case "/notifications":
+		b.handleNotificationsCommand(ctx, update.Message.Chat.ID)
+	case "/budgetperiod":
+		b.handleBudgetPeriodCommand(ctx, update, args)
+	case "/rollover":
+		b.handleRolloverCommand(ctx, update, args)
+	case "/timezone":
+		b.handleTimezoneCommand(ctx, update.Message.Chat.ID, args)
+	case "/alerts":
+		b.handleAlertsCommand(ctx, update, args)
+	case "/categories":
+		b.handleCategoriesCommand(ctx, update.Message.Chat.ID)
+	case "/alias":
+		b.handleAliasCommand(ctx, update.Message.Chat.ID, args)
 	case "/expense":
-		b.handleExpenseCommand(update.Message.Chat.ID, 0)
+		b.handleExpenseCommand(ctx, update.Message.Chat.ID, 0)
 	case "/month":
-		b.handleMonthCommand(update.Message.Chat.ID)
+		b.handleMonthCommand(ctx, update.Message.Chat.ID)
+	case "/stats":
+		b.handleStatsCommand(ctx, update.Message.Chat.ID)
+	case "/find":
+		b.handleFindCommand(ctx, update.Message.Chat.ID, args)
 	case "/add":
-		b.StartAddExpense(update.Message.Chat.ID)
+		b.StartAddExpense(ctx, update.Message.Chat.ID)
+	case "/export":
+		b.handleExportCommand(ctx, update.Message.Chat.ID)
+	case "/members":
+		b.handleMembersCommand(ctx, update.Message.Chat.ID)
+	case "/role":
+		b.handleRoleCommand(ctx, update.Message.Chat.ID, update.Message.From, args)
 	default:
 		b.logger.Debug("Неизвестная команда", "command", update.Message.Text)
-		b.SendMessage(update.Message.Chat.ID, "Неизвестная команда")
+		b.SendMessage(ctx, update.Message.Chat.ID, "Неизвестная команда")
 	}
 }
 
@@ -46,10 +116,10 @@ func (b *Bot) handlersCmd(update telego.Update) {
 //
 // При получении команды регистрирует пользователя в базе данных
 // и отправляет сообщение с приветствием и бюджетом
-func (b *Bot) handlersStart(update telego.Update) {
+func (b *Bot) handlersStart(ctx context.Context, update telego.Update) {
 	b.logger.Debug("Обработка команды start", "tgID", update.Message.Chat.ID)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	userName := update.Message.From.Username
@@ -66,114 +136,739 @@ func (b *Bot) handlersStart(update telego.Update) {
 
 	if err != nil {
 		b.logger.Error("Ошибка регистрации пользователя", "error", err)
-		b.SendErrorMessage(update.Message.Chat.ID, "Ошибка регистрации пользователя, попробуйте еще раз")
+		b.SendErrorMessage(ctx, update.Message.Chat.ID, "Ошибка регистрации пользователя, попробуйте еще раз")
 		return
 	}
 
+	// В группе отправитель становится участником общего бюджета, первый - владельцем
+	if service.IsGroupChat(update.Message.Chat.ID) {
+		member, err := b.Service.JoinGroup(ctx, update.Message.Chat.ID, authorFrom(update.Message.From))
+		if err != nil {
+			b.logger.Error("Ошибка добавления участника группы", "error", err)
+			b.SendErrorMessage(ctx, update.Message.Chat.ID, "Ошибка регистрации участника группы, попробуйте еще раз")
+			return
+		}
+		b.SendMessage(ctx, update.Message.Chat.ID, fmt.Sprintf("👥 %s, вы участник общего бюджета группы (%s)", member.DisplayName(), group.RoleTitle(member.Role)))
+	}
+
 	// Получение бюджета пользователя
 	budget, err := b.Service.GetCurrentBudget(ctx, user.ID)
 	if err != nil {
 		b.logger.Error("Ошибка получения бюджета", "error", err)
-		b.SendErrorMessage(update.Message.Chat.ID, "Ошибка получения бюджета, попробуйте еще раз")
+		b.SendErrorMessage(ctx, update.Message.Chat.ID, "Ошибка получения бюджета, попробуйте еще раз")
 		return
 	}
 
 	if budget == nil {
 		b.logger.Debug("Бюджет не найден", "userID", user.ID)
-		b.SendMessage(update.Message.Chat.ID, "💰Бюджет на месяц еще не установлен!\nНапишите мне сумму, которые вы закладываете на месяц")
+		b.SendMessage(ctx, update.Message.Chat.ID, "💰Бюджет на месяц еще не установлен!\nНапишите мне сумму, которые вы закладываете на месяц")
 		err := b.Service.SetStatus(ctx, update.Message.Chat.ID, StatusBudget)
 		if err != nil {
 			b.logger.Error("Ошибка установки статуса", "error", err)
-			b.SendErrorMessage(update.Message.Chat.ID, "Что-то пошло не так. Попробуйте еще раз чуть позже")
+			b.SendErrorMessage(ctx, update.Message.Chat.ID, "Что-то пошло не так. Попробуйте еще раз чуть позже")
 		}
 		return
 	}
 	// Формирование сообщения
-	text := fmt.Sprintf("Привет, %s!\nЯ бот для ведения бюджета.\nВаш бюджет на месяц %.2f", user.UserName, budget.Amount.InexactFloat64())
+	text := fmt.Sprintf("Привет, %s!\nЯ бот для ведения бюджета.\nВаш бюджет на период %s", user.UserName, currency.Format(budget.Total(), budget.Currency))
 
 	// Отправка сообщения
-	b.SendMessage(update.Message.Chat.ID, text)
+	b.SendMessage(ctx, update.Message.Chat.ID, text)
 }
 
 // handlersCancel обработка команды cancel
 //
 // При получении команды отменяет текущую операцию
 // и отправляет сообщение об отмене
-func (b *Bot) handlersCancel(update telego.Update) {
+func (b *Bot) handlersCancel(ctx context.Context, update telego.Update) {
 	b.logger.Debug("Обработка команды cancel", "tgID", update.Message.Chat.ID)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	err := b.Service.SetStatus(ctx, update.Message.Chat.ID, "")
 	if err != nil {
 		b.logger.Error("Ошибка обновления статуса", "error", err)
-		b.SendErrorMessage(update.Message.Chat.ID, "Произошла ошибка. Попробуйте еще раз")
+		b.SendErrorMessage(ctx, update.Message.Chat.ID, "Произошла ошибка. Попробуйте еще раз")
 		return
 	}
 
 	text := "Операция отменена"
-	b.SendMessage(update.Message.Chat.ID, text)
+	b.SendMessage(ctx, update.Message.Chat.ID, text)
 }
 
 // handlersSetBudget обработка команды установки бюджета
 //
 // При получении команды устанавливает статус пользователя в StatusBudget
 // и отправляет сообщение с просьбой указать бюджет
-func (b *Bot) handlersSetBudget(update telego.Update) {
+func (b *Bot) handlersSetBudget(ctx context.Context, update telego.Update) {
 	b.logger.Debug("Обработка команды setbudget", "tgID", update.Message.Chat.ID)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
+	if !b.checkBudgetAccess(ctx, update.Message.Chat.ID, update.Message.From) {
+		return
+	}
 	err := b.Service.SetStatus(ctx, update.Message.Chat.ID, StatusBudget)
 	if err != nil {
 		b.logger.Error("Ошибка обновления статуса", "error", err)
-		b.SendErrorMessage(update.Message.Chat.ID, "Произошла ошибка. Попробуйте еще раз")
+		b.SendErrorMessage(ctx, update.Message.Chat.ID, "Произошла ошибка. Попробуйте еще раз")
 		return
 	}
 
-	text := "Укажите ваш бюджет на месяц"
-	b.SendMessage(update.Message.Chat.ID, text)
+	text := "Укажите ваш бюджет на месяц\nИли напишите \"доход\", чтобы бюджет был равен доходам за месяц"
+	b.SendMessage(ctx, update.Message.Chat.ID, text)
 }
 
+// budgetHistoryPeriods - сколько прошлых периодов бюджета показывает /getbudget
+const budgetHistoryPeriods = 6
+
 // handlersGetBudget обработка команды получения бюджета
 //
-// При получении команды отправляет сообщение с текущим бюджетом пользователя
+// При получении команды отправляет сообщение с текущим бюджетом пользователя,
+// его настройками и тратами за прошлые периоды.
 // Если бюджет не установлен, отправляет сообщение об этом
-func (b *Bot) handlersGetBudget(update telego.Update) {
-	b.logger.Debug("Обработка команды getbudget", "tgID", update.Message.Chat.ID)
+func (b *Bot) handlersGetBudget(ctx context.Context, update telego.Update) {
+	chatID := update.Message.Chat.ID
+	b.logger.Debug("Обработка команды getbudget", "tgID", chatID)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	user, err := b.Service.GetUserByTelegramID(ctx, update.Message.Chat.ID)
+	budget, err := b.Service.GetBudgetByTgID(ctx, chatID)
 	if err != nil {
-		b.logger.Error("Ошибка получения пользователя", "error", err)
-		b.SendErrorMessage(update.Message.Chat.ID, "Произошла ошибка. Попробуйте еще раз")
+		b.logger.Error("Ошибка получения бюджета", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 		return
 	}
 
-	budget, err := b.Service.GetCurrentBudget(ctx, user.ID)
+	if budget == nil {
+		b.SendMessage(ctx, chatID, "Бюджет на месяц еще не установлен")
+		return
+	}
+
+	text := fmt.Sprintf("Ваш бюджет на период %s - %s: %s",
+		budget.StartDate.Format("02.01.2006"), budget.EndDate.Format("02.01.2006"),
+		currency.Format(budget.Total(), budget.Currency))
+	if budget.FromIncome {
+		text += " (по доходам)"
+	}
+	if !budget.CarryOver.IsZero() {
+		text += "\n" + formatCarryOver(budget.CarryOver, budget.Currency)
+	}
+	text += "\nПериод: " + budget.PeriodTitle()
+	if budget.Rollover {
+		text += "\nПеренос остатка: включен"
+	} else {
+		text += "\nПеренос остатка: выключен"
+	}
+
+	history, err := b.Service.GetBudgetHistory(ctx, chatID, budgetHistoryPeriods+1)
 	if err != nil {
-		b.logger.Error("Ошибка получения бюджета", "error", err)
-		b.SendErrorMessage(update.Message.Chat.ID, "Произошла ошибка. Попробуйте еще раз")
+		b.logger.Error("Ошибка получения истории бюджетов", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 		return
 	}
+	past := make([]*service.BudgetPeriodDTO, 0, len(history))
+	for _, p := range history {
+		if !p.Current {
+			past = append(past, p)
+		}
+	}
+	if len(past) > 0 {
+		text += "\n\nПрошлые периоды:"
+		for _, p := range past {
+			mark := "✅"
+			if p.Spent.GreaterThan(p.Total) {
+				mark = "❗️"
+			}
+			text += fmt.Sprintf("\n%s %s - %s: %s из %s", mark,
+				p.StartDate.Format("02.01"), p.EndDate.Format("02.01.2006"),
+				currency.Format(p.Spent, p.Currency), currency.Format(p.Total, p.Currency))
+		}
+	}
+	text += "\n\nНастройки: /budgetperiod - период, /rollover - перенос остатка"
+	b.SendMessage(ctx, chatID, text)
+}
+
+// handlersLimit обработка команды установки лимита категории
+//
+// При получении команды отправляет список категорий для выбора.
+// Лимит можно установить только при установленном бюджете
+func (b *Bot) handlersLimit(ctx context.Context, update telego.Update) {
+	chatID := update.Message.Chat.ID
+	b.logger.Debug("Обработка команды limit", "tgID", chatID)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
 
+	if !b.checkBudgetAccess(ctx, chatID, update.Message.From) {
+		return
+	}
+
+	budget, err := b.Service.GetBudgetByTgID(ctx, chatID)
+	if err != nil {
+		b.logger.Error("Ошибка получения бюджета", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
 	if budget == nil {
-		b.SendMessage(update.Message.Chat.ID, "Бюджет на месяц еще не установлен")
+		b.SendMessage(ctx, chatID, "Бюджет на месяц еще не установлен. Воспользуйтесь командой /setbudget")
+		return
+	}
+
+	userCategories, err := b.Service.GetUserCategories(ctx, chatID)
+	if err != nil {
+		b.logger.Error("Ошибка получения категорий", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз.")
+		return
+	}
+
+	// Создаем кнопки для категорий с текущими лимитами
+	keyboards := tu.InlineKeyboard()
+	for _, cat := range userCategories {
+		text := cat.Icon + " " + cat.Name
+		if limit, ok := budget.Categories[cat.ID]; ok {
+			text += fmt.Sprintf(" (%s)", currency.Format(limit, budget.Currency))
+		}
+		keyboards.InlineKeyboard = append(keyboards.InlineKeyboard, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(text).WithCallbackData(StatusLimit+cat.Name),
+		))
+	}
+
+	b.SendMessageWithKeyboard(ctx, chatID, "Выберите категорию для установки лимита:", keyboards)
+}
+
+// handleCategoriesCommand обрабатывает команду /categories
+//
+// Отправляет список базовых и пользовательских категорий.
+// Пользовательские категории можно переименовать, сменить иконку или удалить
+func (b *Bot) handleCategoriesCommand(ctx context.Context, chatID int64) {
+	b.logger.Debug("Обработка команды categories", "tgID", chatID)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	userCategories, err := b.Service.GetUserCategories(ctx, chatID)
+	if err != nil {
+		b.logger.Error("Ошибка получения категорий", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз.")
+		return
+	}
+
+	text := "📂 Базовые категории:\n"
+	keyboards := tu.InlineKeyboard()
+	own := 0
+	for _, cat := range userCategories {
+		if cat.IsDefault {
+			text += cat.Icon + " " + cat.Name + "\n"
+			continue
+		}
+		own++
+		keyboards.InlineKeyboard = append(keyboards.InlineKeyboard, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(cat.Icon+" "+cat.Name).WithCallbackData("cat_menu_"+cat.Name),
+		))
+	}
+	if own > 0 {
+		text += "\n🗂 Ваши категории - нажмите, чтобы изменить:"
+	} else {
+		text += "\nСвоих категорий пока нет"
+	}
+	keyboards.InlineKeyboard = append(keyboards.InlineKeyboard, tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("➕ Новая категория").WithCallbackData("cat_new"),
+	))
+
+	b.SendMessageWithKeyboard(ctx, chatID, text, keyboards)
+}
+
+// handleAliasCommand обрабатывает команду /alias <слово> <категория>
+//
+// Сохраняет слово как псевдоним категории для быстрой записи трат
+func (b *Bot) handleAliasCommand(ctx context.Context, chatID int64, args string) {
+	b.logger.Debug("Обработка команды alias", "tgID", chatID, "args", args)
+
+	alias, category, ok := strings.Cut(strings.TrimSpace(args), " ")
+	if !ok || strings.TrimSpace(category) == "" {
+		b.SendMessage(ctx, chatID, "Использование: /alias <слово> <категория>\nНапример: /alias шаверма Еда")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	c, err := b.Service.SetCategoryAlias(ctx, chatID, alias, category)
+	if err != nil {
+		b.logger.Error("Ошибка сохранения псевдонима категории", "error", err)
+		b.sendCategoryError(ctx, chatID, err)
+		return
+	}
+
+	b.SendMessage(ctx, chatID, fmt.Sprintf("✅ Теперь \"%s\" записывается в категорию %s %s", alias, c.Icon, c.Name))
+}
+
+// handleIncomeCommand обрабатывает команду /income
+//
+// "/income 50000 зарплата" записывает доход сразу,
+// без аргументов - запрашивает сумму и источник дохода
+func (b *Bot) handleIncomeCommand(ctx context.Context, chatID int64, from *telego.User, args string) {
+	b.logger.Debug("Обработка команды income", "tgID", chatID, "args", args)
+
+	if strings.TrimSpace(args) != "" {
+		b.addIncome(ctx, chatID, from, args)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if err := b.Service.SetStatus(ctx, chatID, StatusIncome); err != nil {
+		b.logger.Error("Ошибка обновления статуса", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+	b.SendMessage(ctx, chatID, "Укажите сумму и источник дохода\nНапример: 50000 зарплата или 300$ фриланс вчера")
+}
+
+// handleAccountsCommand обрабатывает команду /accounts
+//
+// Отправляет список счетов с текущими балансами
+func (b *Bot) handleAccountsCommand(ctx context.Context, chatID int64) {
+	b.logger.Debug("Обработка команды accounts", "tgID", chatID)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	accounts, err := b.Service.GetAccounts(ctx, chatID)
+	if err != nil {
+		b.logger.Error("Ошибка получения счетов", "error", err)
+		b.sendAccountError(ctx, chatID, err)
+		return
+	}
+	if len(accounts) == 0 {
+		b.SendMessage(ctx, chatID, "Счетов пока нет. Добавьте счет командой /account <тип> <название> [баланс]\nНапример: /account карта Тинькофф 15000")
 		return
 	}
 
-	text := fmt.Sprintf("Ваш бюджет на месяц %.2f", budget.Amount.InexactFloat64())
-	b.SendMessage(update.Message.Chat.ID, text)
+	text := "💰 Счета:\n"
+	for _, a := range accounts {
+		text += fmt.Sprintf("%s (%s): %s\n", a.Name, domainAccount.TypeTitle(a.Type), currency.Format(a.Balance, a.Currency))
+	}
+	text += "\nПеревод между счетами: /transfer <сумма> <со счета> > <на счет>"
+	b.SendMessage(ctx, chatID, text)
+}
+
+// handleAccountCommand обрабатывает команду /account
+//
+// Ожидается формат "/account <тип> <название> [баланс]", например "/account карта Тинькофф 15000".
+// Название может состоять из нескольких слов, баланс - число с необязательной валютой
+func (b *Bot) handleAccountCommand(ctx context.Context, chatID int64, args string) {
+	b.logger.Debug("Обработка команды account", "tgID", chatID, "args", args)
+
+	fields := strings.Fields(args)
+	if len(fields) < 2 {
+		b.SendMessage(ctx, chatID, "Использование: /account <тип> <название> [баланс]\nТипы: наличные, карта, кредитка, вклад\nНапример: /account карта Тинькофф 15000")
+		return
+	}
+
+	// Название - слова до первого числа, баланс - остаток строки
+	name, balance := fields[1:], []string(nil)
+	for i, f := range name {
+		if _, err := strconv.ParseFloat(f, 64); err == nil {
+			name, balance = fields[1:i+1], fields[i+1:]
+			break
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	a, err := b.Service.CreateAccount(ctx, chatID, fields[0], strings.Join(name, " "), strings.Join(balance, " "))
+	if err != nil {
+		b.logger.Error("Ошибка создания счета", "error", err)
+		b.sendAccountError(ctx, chatID, err)
+		return
+	}
+	b.SendMessage(ctx, chatID, fmt.Sprintf("✅ Счет %s (%s) добавлен, баланс: %s", a.Name, domainAccount.TypeTitle(a.Type), currency.Format(a.Balance, a.Currency)))
+}
+
+// handleTransferCommand обрабатывает команду /transfer
+//
+// Ожидается формат "/transfer <сумма> <со счета> > <на счет>".
+// Если названия счетов из одного слова, разделитель можно не указывать: "/transfer 5000 Наличные Сбер"
+func (b *Bot) handleTransferCommand(ctx context.Context, chatID int64, args string) {
+	b.logger.Debug("Обработка команды transfer", "tgID", chatID, "args", args)
+
+	usage := "Использование: /transfer <сумма> <со счета> > <на счет>\nНапример: /transfer 5000 Наличные > Тинькофф"
+	amountText, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	amount, err := calc.Calculate(amountText)
+	if err != nil || !amount.IsPositive() {
+		b.SendMessage(ctx, chatID, usage)
+		return
+	}
+	from, to, ok := splitTransferAccounts(rest)
+	if !ok {
+		b.SendMessage(ctx, chatID, usage)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	t, err := b.Service.TransferBetweenAccounts(ctx, chatID, amount, from, to)
+	if err != nil {
+		b.logger.Error("Ошибка перевода между счетами", "error", err)
+		b.sendAccountError(ctx, chatID, err)
+		return
+	}
+
+	text := fmt.Sprintf("✅ Перевод %s: %s → %s", currency.Format(t.Amount, t.FromCurrency), t.From, t.To)
+	if t.FromCurrency != t.ToCurrency {
+		text += fmt.Sprintf(" (зачислено %s)", currency.Format(t.ToAmount, t.ToCurrency))
+	}
+	b.SendMessage(ctx, chatID, text)
+}
+
+// goalUsage - справка по команде /goal
+const goalUsage = "Использование:\n/goal - цели и прогресс\n/goal new <сумма> <название> <срок> - новая цель, срок ДД.ММ.ГГГГ или ММ.ГГГГ\n/goal add <сумма> <название> - взнос в цель\n/goal delete <название> - удалить цель\nНапример: /goal new 150000 Отпуск 06.2027"
+
+// handleGoalCommand обрабатывает команду /goal
+//
+// Без аргументов отправляет список целей, подкоманды new, add и delete создают цель,
+// записывают взнос и удаляют цель. Создавать и удалять цели группы могут только администраторы
+func (b *Bot) handleGoalCommand(ctx context.Context, update telego.Update, args string) {
+	chatID := update.Message.Chat.ID
+	b.logger.Debug("Обработка команды goal", "tgID", chatID, "args", args)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	action, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	switch strings.ToLower(action) {
+	case "":
+		b.sendGoals(ctx, chatID)
+	case "new":
+		amount, name, ok := splitGoalAmount(rest)
+		fields := strings.Fields(name)
+		if !ok || len(fields) < 2 {
+			b.SendMessage(ctx, chatID, goalUsage)
+			return
+		}
+		if !b.checkBudgetAccess(ctx, chatID, update.Message.From) {
+			return
+		}
+		deadline := fields[len(fields)-1]
+		g, err := b.Service.CreateGoal(ctx, chatID, amount, strings.Join(fields[:len(fields)-1], " "), deadline)
+		if err != nil {
+			b.logger.Error("Ошибка создания цели", "error", err)
+			b.sendGoalError(ctx, chatID, err)
+			return
+		}
+		b.SendMessage(ctx, chatID, fmt.Sprintf("🎯 Цель %s добавлена: %s до %s", g.Name, currency.Format(g.Target, g.Currency), g.Deadline.Format("02.01.2006")))
+	case "add":
+		amount, name, ok := splitGoalAmount(rest)
+		if !ok || strings.TrimSpace(name) == "" {
+			b.SendMessage(ctx, chatID, goalUsage)
+			return
+		}
+		g, err := b.Service.ContributeToGoal(ctx, chatID, authorFrom(update.Message.From), amount, name)
+		if err != nil {
+			b.logger.Error("Ошибка взноса в цель", "error", err)
+			b.sendGoalError(ctx, chatID, err)
+			return
+		}
+		text := fmt.Sprintf("✅ Взнос в цель %s записан\n%s / %s\n%s", g.Name,
+			currency.Format(g.Saved, g.Currency),
+			currency.Format(g.Target, g.Currency),
+			progressBar(g.Progress()))
+		if g.IsReached() {
+			text += "\n🎉 Цель достигнута!"
+		}
+		b.SendMessage(ctx, chatID, text)
+	case "delete":
+		if strings.TrimSpace(rest) == "" {
+			b.SendMessage(ctx, chatID, goalUsage)
+			return
+		}
+		if !b.checkBudgetAccess(ctx, chatID, update.Message.From) {
+			return
+		}
+		g, err := b.Service.DeleteGoal(ctx, chatID, rest)
+		if err != nil {
+			b.logger.Error("Ошибка удаления цели", "error", err)
+			b.sendGoalError(ctx, chatID, err)
+			return
+		}
+		b.SendMessage(ctx, chatID, fmt.Sprintf("🗑 Цель %s удалена", g.Name))
+	default:
+		b.SendMessage(ctx, chatID, goalUsage)
+	}
+}
+
+// sendGoals отправляет список целей с прогрессом и рекомендуемыми взносами
+func (b *Bot) sendGoals(ctx context.Context, chatID int64) {
+	overview, err := b.Service.GetGoals(ctx, chatID)
+	if err != nil {
+		b.logger.Error("Ошибка получения целей", "error", err)
+		b.sendGoalError(ctx, chatID, err)
+		return
+	}
+	if len(overview.Goals) == 0 {
+		b.SendMessage(ctx, chatID, "Целей пока нет. Добавьте цель командой /goal new <сумма> <название> <срок>\nНапример: /goal new 150000 Отпуск 06.2027")
+		return
+	}
+	b.SendMessage(ctx, chatID, "🎯 Цели:\n"+formatGoals(overview))
+}
+
+// splitGoalAmount отделяет сумму от названия цели: "2000 USD Отпуск" -> "2000 USD", "Отпуск".
+// Валюта может идти отдельным словом после суммы
+func splitGoalAmount(text string) (amount, rest string, ok bool) {
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		return "", "", false
+	}
+	if value, _ := currency.SplitAmount(fields[0]); !isNumber(value) {
+		return "", "", false
+	}
+	amount, fields = fields[0], fields[1:]
+	if _, err := currency.Normalize(fields[0]); err == nil && len(fields) > 1 {
+		amount, fields = amount+" "+fields[0], fields[1:]
+	}
+	return amount, strings.Join(fields, " "), true
+}
+
+// isNumber проверяет, что текст - число: "150000", "99.90", "99,90"
+func isNumber(text string) bool {
+	_, err := strconv.ParseFloat(strings.Replace(text, ",", ".", 1), 64)
+	return err == nil
+}
+
+// splitTransferAccounts разделяет названия счетов перевода по "->", "→" или ">".
+// Без разделителя ожидаются ровно два слова
+func splitTransferAccounts(text string) (from, to string, ok bool) {
+	for _, sep := range []string{"->", "→", ">"} {
+		if from, to, ok = strings.Cut(text, sep); ok {
+			from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+			return from, to, from != "" && to != ""
+		}
+	}
+	fields := strings.Fields(text)
+	if len(fields) != 2 {
+		return "", "", false
+	}
+	return fields[0], fields[1], true
+}
+
+// handleNotificationsCommand обрабатывает команду /notifications
+//
+// Отправляет меню включения и выключения уведомлений
+func (b *Bot) handleNotificationsCommand(ctx context.Context, chatID int64) {
+	b.logger.Debug("Обработка команды notifications", "tgID", chatID)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	settings, err := b.Service.GetNotificationSettings(ctx, chatID)
+	if err != nil {
+		b.logger.Error("Ошибка получения настроек уведомлений", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+	b.sendNotificationsMenu(ctx, chatID, settings)
+}
+
+// handleAlertsCommand обрабатывает команду /alerts
+//
+// Без аргументов показывает текущие пороги предупреждений,
+// с аргументами "50 80 100" - устанавливает новые
+func (b *Bot) handleAlertsCommand(ctx context.Context, update telego.Update, args string) {
+	chatID := update.Message.Chat.ID
+	b.logger.Debug("Обработка команды alerts", "tgID", chatID, "args", args)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	args = strings.TrimSpace(args)
+	if args == "" {
+		thresholds, err := b.Service.GetBudgetThresholds(ctx, chatID)
+		if err != nil {
+			b.logger.Error("Ошибка получения порогов бюджета", "error", err)
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+			return
+		}
+		b.SendMessage(ctx, chatID, fmt.Sprintf("Предупреждения о тратах приходят при %s бюджета и лимитов категорий, а также при превышении.\n\nИзменить пороги: /alerts 50 80 100", formatThresholds(thresholds)))
+		return
+	}
+
+	if !b.checkBudgetAccess(ctx, chatID, update.Message.From) {
+		return
+	}
+	thresholds, err := b.Service.SetBudgetThresholds(ctx, chatID, args)
+	if err != nil {
+		if errors.Is(err, domainBudget.ErrInvalidThreshold) {
+			b.SendErrorMessage(ctx, chatID, "Пороги - это проценты от 1 до 100 через пробел, например: /alerts 50 80 100")
+			return
+		}
+		b.logger.Error("Ошибка установки порогов бюджета", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+	b.SendMessage(ctx, chatID, fmt.Sprintf("✅ Предупреждения будут приходить при %s бюджета", formatThresholds(thresholds)))
+}
+
+// handleBudgetPeriodCommand обрабатывает команду /budgetperiod
+//
+// Аргументы: "10" или "месяц 10" - месяц с 10 числа, "месяц" - календарный месяц, "2 недели".
+// Текущий бюджет продлевается до конца нового периода, следующие создаются с новым периодом
+func (b *Bot) handleBudgetPeriodCommand(ctx context.Context, update telego.Update, args string) {
+	chatID := update.Message.Chat.ID
+	b.logger.Debug("Обработка команды budgetperiod", "tgID", chatID, "args", args)
+
+	if strings.TrimSpace(args) == "" {
+		b.SendMessage(ctx, chatID, "Укажите период бюджета:\n/budgetperiod месяц - календарный месяц\n/budgetperiod 10 - месяц с 10 числа, например с дня зарплаты\n/budgetperiod 2 недели - каждые две недели")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if !b.checkBudgetAccess(ctx, chatID, update.Message.From) {
+		return
+	}
+	budget, err := b.Service.SetBudgetPeriod(ctx, chatID, args)
+	switch {
+	case errors.Is(err, domainBudget.ErrInvalidPeriod):
+		b.SendErrorMessage(ctx, chatID, "Неизвестный период. Примеры: /budgetperiod 10, /budgetperiod 2 недели")
+		return
+	case errors.Is(err, domainBudget.ErrInvalidStartDay):
+		b.SendErrorMessage(ctx, chatID, fmt.Sprintf("День начала периода должен быть от 1 до %d", domainBudget.MaxStartDay))
+		return
+	case errors.Is(err, domainBudget.ErrBudgetNotFound):
+		b.SendMessage(ctx, chatID, "Бюджет еще не установлен. Воспользуйтесь командой /setbudget")
+		return
+	case err != nil:
+		b.logger.Error("Ошибка установки периода бюджета", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+	b.SendMessage(ctx, chatID, fmt.Sprintf("✅ Период бюджета: %s\nТекущий период: %s - %s",
+		budget.PeriodTitle(), budget.StartDate.Format("02.01.2006"), budget.EndDate.Format("02.01.2006")))
+}
+
+// handleRolloverCommand обрабатывает команду /rollover
+//
+// "on" включает перенос остатка или перерасхода текущего периода в следующий, "off" - выключает
+func (b *Bot) handleRolloverCommand(ctx context.Context, update telego.Update, args string) {
+	chatID := update.Message.Chat.ID
+	b.logger.Debug("Обработка команды rollover", "tgID", chatID, "args", args)
+
+	var enabled bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "on", "вкл", "да":
+		enabled = true
+	case "off", "выкл", "нет":
+		enabled = false
+	default:
+		b.SendMessage(ctx, chatID, "Перенос остатка: неизрасходованные деньги или перерасход переходят в следующий период бюджета.\n/rollover on - включить\n/rollover off - выключить")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if !b.checkBudgetAccess(ctx, chatID, update.Message.From) {
+		return
+	}
+	_, err := b.Service.SetBudgetRollover(ctx, chatID, enabled)
+	if errors.Is(err, domainBudget.ErrBudgetNotFound) {
+		b.SendMessage(ctx, chatID, "Бюджет еще не установлен. Воспользуйтесь командой /setbudget")
+		return
+	}
+	if err != nil {
+		b.logger.Error("Ошибка установки переноса остатка", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+	if enabled {
+		b.SendMessage(ctx, chatID, "✅ Остаток текущего периода перейдет в следующий")
+	} else {
+		b.SendMessage(ctx, chatID, "✅ Перенос остатка выключен")
+	}
+}
+
+// handleTimezoneCommand обрабатывает команду /timezone
+//
+// Без аргументов показывает текущий часовой пояс и дату пользователя,
+// с аргументом "UTC+10" или "Asia/Vladivostok" - меняет его
+func (b *Bot) handleTimezoneCommand(ctx context.Context, chatID int64, args string) {
+	b.logger.Debug("Обработка команды timezone", "tgID", chatID, "args", args)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	args = strings.TrimSpace(args)
+	if args == "" {
+		tz, err := b.Service.GetTimezone(ctx, chatID)
+		if err != nil {
+			b.logger.Error("Ошибка получения часового пояса", "error", err)
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+			return
+		}
+		b.SendMessage(ctx, chatID, fmt.Sprintf("🕒 Ваш часовой пояс: %s\n\nИзменить: /timezone UTC+10 или /timezone Asia/Vladivostok", tz))
+		return
+	}
+
+	tz, err := b.Service.SetTimezone(ctx, chatID, args)
+	if err != nil {
+		if errors.Is(err, domainUser.ErrInvalidTimezoneFormat) {
+			b.SendErrorMessage(ctx, chatID, "Неизвестный часовой пояс. Укажите смещение, например UTC+3 или UTC-5:30, или название, например Europe/Moscow")
+			return
+		}
+		b.logger.Error("Ошибка установки часового пояса", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+	today, err := b.Service.Today(ctx, chatID)
+	if err != nil {
+		b.logger.Error("Ошибка получения даты пользователя", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+	b.SendMessage(ctx, chatID, fmt.Sprintf("✅ Часовой пояс изменен: %s\nСегодня у вас %s", tz, today.Format("02.01.2006")))
+}
+
+// formatThresholds форматирует пороги как "50%, 80%, 100%"
+func formatThresholds(thresholds []int) string {
+	parts := make([]string, len(thresholds))
+	for i, t := range thresholds {
+		parts[i] = fmt.Sprintf("%d%%", t)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// handleExportCommand обрабатывает команду /export
+//
+// Предлагает выбрать период выгрузки трат
+func (b *Bot) handleExportCommand(ctx context.Context, chatID int64) {
+	b.logger.Debug("Обработка команды export", "tgID", chatID)
+
+	keyboard := tu.InlineKeyboard(
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("Текущий месяц").WithCallbackData("export_p_month"),
+			tu.InlineKeyboardButton("Прошлый месяц").WithCallbackData("export_p_prev"),
+		),
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("Указать период").WithCallbackData("export_p_range"),
+			tu.InlineKeyboardButton("Все время").WithCallbackData("export_p_all"),
+		),
+	)
+	b.SendMessageWithKeyboard(ctx, chatID, "📤 Выберите период для выгрузки расходов:", keyboard)
 }
 
 // StartAddExpense инициирует процесс записи расхода.
-func (b *Bot) StartAddExpense(chatID int64) {
+func (b *Bot) StartAddExpense(ctx context.Context, chatID int64) {
 	// Создаем новое состояние записи
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 	b.Service.SetExpenseStatus(ctx, chatID, &service.ExpenseEntryDTO{
 		Step: "date",
@@ -193,59 +888,261 @@ func (b *Bot) StartAddExpense(chatID int64) {
 // handleMonthCommand обрабатывает команду /month
 //
 // Отправляет статистику расходов за текущий месяц
-func (b *Bot) handleMonthCommand(chatID int64) {
+func (b *Bot) handleMonthCommand(ctx context.Context, chatID int64) {
 	b.logger.Debug("Обработка команды month", "tgID", chatID)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	// Получение статистики расходов за текущий месяц
 	expenses, sumExp, err := b.Service.GetExpensesByMonth(ctx, chatID)
-	if err != nil {
+	if err != nil && !errors.Is(err, expense.ErrorExpenseNotFound) {
 		b.logger.Error("Ошибка получения расходов за месяц", "error", err)
-		b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+
+	// Доходы за текущий месяц
+	_, sumInc, err := b.Service.GetIncomeByMonth(ctx, chatID)
+	if err != nil {
+		b.logger.Error("Ошибка получения доходов за месяц", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 		return
 	}
 
 	user, err := b.Service.GetUserByTelegramID(ctx, chatID)
 	if err != nil {
 		b.logger.Error("Ошибка получения пользователя", "error", err)
-		b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 		return
 	}
 
 	budget, err := b.Service.GetCurrentBudget(ctx, user.ID)
 	if err != nil {
 		b.logger.Error("Ошибка получения бюджета", "error", err)
-		b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 		return
 	}
 
 	if budget == nil {
-		b.SendMessage(chatID, "Бюджет на месяц еще не установлен")
+		b.SendMessage(ctx, chatID, "Бюджет на месяц еще не установлен")
 		return
 	}
-	userBudget := budget.Amount.InexactFloat64()
+	userBudget := budget.Total()
 
 	// Формирование сообщения
-	text := fmt.Sprintf("🙈 Расходы за месяц:\n")
-	text += fmt.Sprintf("Всего потрачено: %.2f\n", sumExp)
-	text += fmt.Sprintf("Бюджет на месяц: %.2f\n", userBudget)
-	text += fmt.Sprintf("Осталось: %.2f\n", userBudget-sumExp)
-	daysLeft := time.Now().AddDate(0, 0, 1).Day() - time.Now().Day()
-	text += fmt.Sprintf("Среднее на день осталось: %.2f\n", (userBudget-sumExp)/float64(daysLeft))
-	startDate := time.Date(time.Now().Year(), time.Now().Month(), 1, 0, 0, 0, 0, time.UTC)
-	endDate := startDate.AddDate(0, 1, -1)
-	text += fmt.Sprintf("Изнаначальное среднее: %.2f\n", userBudget/float64(endDate.Day()))
+	text := fmt.Sprintf("🙈 Расходы за период %s - %s:\n", budget.StartDate.Format("02.01"), budget.EndDate.Format("02.01"))
+	text += fmt.Sprintf("Всего потрачено: %s\n", currency.Format(sumExp, budget.Currency))
+	text += fmt.Sprintf("Бюджет на период: %s\n", currency.Format(userBudget, budget.Currency))
+	if !budget.CarryOver.IsZero() {
+		text += formatCarryOver(budget.CarryOver, budget.Currency) + "\n"
+	}
+	text += fmt.Sprintf("Осталось: %s\n", currency.Format(userBudget.Sub(sumExp), budget.Currency))
+	// Дни до конца периода по часовому поясу пользователя, включая сегодняшний
+	today := clock.Date(b.Service.Now(), user.Location())
+	daysLeft := int(budget.EndDate.Sub(today).Hours()/24) + 1
+	if daysLeft < 1 {
+		daysLeft = 1
+	}
+	periodDays := int(budget.EndDate.Sub(budget.StartDate).Hours()/24) + 1
+	text += fmt.Sprintf("Среднее на день осталось: %s\n", currency.Format(userBudget.Sub(sumExp).Div(decimal.NewFromInt(int64(daysLeft))), budget.Currency))
+	text += fmt.Sprintf("Изнаначальное среднее: %s\n", currency.Format(userBudget.Div(decimal.NewFromInt(int64(periodDays))), budget.Currency))
 
-	// Отправка сообщения
-	b.SendMessage(chatID, text)
+	// Доходы, расходы и накопления
+	text += fmt.Sprintf("\n💵 Доходы: %s\n", currency.Format(sumInc, budget.Currency))
+	text += fmt.Sprintf("💸 Расходы: %s\n", currency.Format(sumExp, budget.Currency))
+	text += fmt.Sprintf("🏦 Накопления: %s\n", currency.Format(sumInc.Sub(sumExp), budget.Currency))
+
+	// Лимиты по категориям
+	limits, err := b.Service.GetCategoryLimits(ctx, budget, expenses)
+	if err != nil {
+		b.logger.Error("Ошибка получения лимитов категорий", "error", err)
+	}
+	if len(limits) > 0 {
+		text += "\n📊 Лимиты по категориям:\n"
+		for _, l := range limits {
+			text += fmt.Sprintf("%s %s: %s / %s", l.CategoryIcon, l.Category, currency.Format(l.Spent, budget.Currency), currency.Format(l.Limit, budget.Currency))
+			if errors.Is(l.Err, domainBudget.ErrCategoryLimitExceeded) {
+				text += fmt.Sprintf(" ⚠️ превышен на %s", currency.Format(l.Spent.Sub(l.Limit), budget.Currency))
+			}
+			text += "\n"
+		}
+	}
+
+	// Прогресс целей накоплений
+	goals, err := b.Service.GetGoals(ctx, chatID)
+	if err != nil {
+		b.logger.Error("Ошибка получения целей", "error", err)
+	} else if len(goals.Goals) > 0 {
+		text += "\n🎯 Цели:\n" + formatGoals(goals)
+	}
+
+	// Траты по участникам общего бюджета
+	if service.IsGroupChat(chatID) {
+		spending, err := b.Service.GetMemberSpending(ctx, chatID, expenses)
+		if err != nil {
+			b.logger.Error("Ошибка получения трат участников", "error", err)
+		}
+		if len(spending) > 0 {
+			text += "\n👥 По участникам:\n"
+			for _, m := range spending {
+				text += fmt.Sprintf("%s: %s (%d)\n", m.Member, currency.Format(m.Amount, budget.Currency), m.Count)
+			}
+		}
+	}
+
+	// Отправка сообщения с кнопками диаграмм
+	b.SendMessageWithKeyboard(ctx, chatID, text, statsKeyboard())
+
+	if len(expenses) == 0 {
+		return
+	}
 
-	// Отправка сообщения с детализацией расходов
+	// Отправка сообщения с детализацией расходов и кнопками редактирования
 	var message string
 	for _, exp := range expenses {
-		message += fmt.Sprintf("📅 %s: %.2f₽ - %s %s - %s", exp.Date.Format("02.01.2006"), exp.Amount, exp.CategoryIcon, exp.Category, exp.Description)
+		message += fmt.Sprintf("📅 %s: %s - %s - %s\n", exp.Date.Format("02.01.2006"), currency.Format(exp.Amount, exp.Currency), expenseCategory(exp), exp.Description)
+
+	}
+	b.SendMessageWithKeyboard(ctx, chatID, message, expenseKeyboard(expenses))
+}
+
+// handleStatsCommand обрабатывает команду /stats
+//
+// Отправляет диаграммы трат текущего периода по категориям и дням и трат по месяцам
+func (b *Bot) handleStatsCommand(ctx context.Context, chatID int64) {
+	b.logger.Debug("Обработка команды stats", "tgID", chatID)
+	b.sendStatsCharts(ctx, chatID, service.ChartCategories, service.ChartDaily, service.ChartTrend)
+}
+
+// sendStatsCharts отправляет диаграммы видов kinds с подписями
+func (b *Bot) sendStatsCharts(ctx context.Context, chatID int64, kinds ...string) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	stats, err := b.Service.GetStats(ctx, chatID)
+	if err != nil {
+		b.logger.Error("Ошибка получения статистики", "error", err)
+		if errors.Is(err, domainUser.ErrUserNotFound) {
+			b.SendErrorMessage(ctx, chatID, "Сначала зарегистрируйтесь командой /start")
+			return
+		}
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+
+	for _, kind := range kinds {
+		img, err := b.Service.RenderStatsChart(stats, kind)
+		if errors.Is(err, chart.ErrNoData) {
+			b.SendMessage(ctx, chatID, "Нет расходов для диаграммы")
+			continue
+		}
+		if err != nil {
+			b.logger.Error("Ошибка построения диаграммы", "kind", kind, "error", err)
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+			return
+		}
+		b.SendPhoto(ctx, chatID, kind+".png", img, statsCaption(stats, kind))
+	}
+}
+
+// findUsage - справка по команде /find
+const findUsage = "Использование: /find <запрос>\n" +
+	"Слова ищутся в описании трат, дополнительно можно указать:\n" +
+	"#категория - категория или псевдоним\n" +
+	">500, <1000, 500..1000 - сумма\n" +
+	"15.02.2026, 01.02.2026-15.02.2026, 02.2026, феврале - дата\n" +
+	"Например: /find аптека в феврале"
+
+// handleFindCommand обрабатывает команду /find
+//
+// Ищет траты по запросу и отправляет первую страницу результатов
+func (b *Bot) handleFindCommand(ctx context.Context, chatID int64, args string) {
+	b.logger.Debug("Обработка команды find", "tgID", chatID, "args", args)
+
+	if strings.TrimSpace(args) == "" {
+		b.SendMessage(ctx, chatID, findUsage)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := b.Service.SearchExpenses(ctx, chatID, args, 0)
+	if err != nil {
+		b.logger.Error("Ошибка поиска расходов", "error", err)
+		b.sendSearchError(ctx, chatID, err)
+		return
+	}
+	b.sendSearchResults(ctx, chatID, result)
+}
+
+// handleMembersCommand обрабатывает команду /members
+//
+// Отправляет список участников общего бюджета группы с ролями
+func (b *Bot) handleMembersCommand(ctx context.Context, chatID int64) {
+	b.logger.Debug("Обработка команды members", "tgID", chatID)
+
+	if !service.IsGroupChat(chatID) {
+		b.SendMessage(ctx, chatID, "Команда доступна только в групповом чате")
+		return
+	}
 
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	members, err := b.Service.GetGroupMembers(ctx, chatID)
+	if err != nil {
+		b.logger.Error("Ошибка получения участников группы", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+	if len(members) == 0 {
+		b.SendMessage(ctx, chatID, "В группе еще нет участников. Отправьте /start, чтобы вступить")
+		return
+	}
+
+	text := "👥 Участники общего бюджета:\n"
+	for _, m := range members {
+		text += fmt.Sprintf("%s - %s\n", m.DisplayName(), group.RoleTitle(m.Role))
+	}
+	text += "\nВладелец назначает роли командой /role @username admin|member"
+	b.SendMessage(ctx, chatID, text)
+}
+
+// handleRoleCommand обрабатывает команду /role
+//
+// Ожидается формат "/role @username <admin|member>". Роли меняет только владелец группы
+func (b *Bot) handleRoleCommand(ctx context.Context, chatID int64, from *telego.User, args string) {
+	b.logger.Debug("Обработка команды role", "tgID", chatID, "args", args)
+
+	if !service.IsGroupChat(chatID) {
+		b.SendMessage(ctx, chatID, "Команда доступна только в групповом чате")
+		return
+	}
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		b.SendMessage(ctx, chatID, "Использование: /role @username admin|member")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	member, err := b.Service.SetMemberRole(ctx, chatID, authorFrom(from), fields[0], fields[1])
+	switch {
+	case err == nil:
+		b.SendMessage(ctx, chatID, fmt.Sprintf("✅ %s теперь %s", member.DisplayName(), group.RoleTitle(member.Role)))
+	case errors.Is(err, group.ErrForbidden):
+		b.SendErrorMessage(ctx, chatID, "Менять роли может только владелец группы")
+	case errors.Is(err, group.ErrInvalidRole):
+		b.SendErrorMessage(ctx, chatID, "Неизвестная роль. Доступные роли: admin, member")
+	case errors.Is(err, group.ErrOwnerRole):
+		b.SendErrorMessage(ctx, chatID, "Роль владельца нельзя изменить или назначить")
+	case errors.Is(err, group.ErrMemberNotFound):
+		b.SendErrorMessage(ctx, chatID, fmt.Sprintf("Участник %s не найден. Участник должен хотя бы раз записать трату или отправить /start", fields[0]))
+	default:
+		b.logger.Error("Ошибка изменения роли участника", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 	}
-	b.SendMessage(chatID, message)
 }
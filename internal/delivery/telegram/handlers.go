@@ -2,11 +2,30 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"image"
+	"path"
+	"regexp"
 	"strings"
 	"time"
+	"unicode"
 
+	"github.com/SobolevTim/finance_bot/internal/domain/account"
+	domainBudget "github.com/SobolevTim/finance_bot/internal/domain/budget"
+	"github.com/SobolevTim/finance_bot/internal/domain/categories"
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/SobolevTim/finance_bot/internal/domain/goal"
+	"github.com/SobolevTim/finance_bot/internal/domain/group"
+	"github.com/SobolevTim/finance_bot/internal/domain/income"
+	"github.com/SobolevTim/finance_bot/internal/domain/status"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
 	"github.com/SobolevTim/finance_bot/internal/pkg/calc"
+	"github.com/SobolevTim/finance_bot/internal/pkg/qr"
+	"github.com/SobolevTim/finance_bot/internal/pkg/quickadd"
+	"github.com/SobolevTim/finance_bot/internal/pkg/receipt"
+	"github.com/SobolevTim/finance_bot/internal/pkg/statement"
 	"github.com/SobolevTim/finance_bot/internal/service"
 	"github.com/mymmrac/telego"
 	tu "github.com/mymmrac/telego/telegoutil"
@@ -14,88 +33,143 @@ import (
 
 // handlers обработка сообщений
 //
+// Обработка документов (импорт выписки);
+// Обработка фото и строк из QR-кода кассового чека;
 // Обработка команд;
 // Получение статуса;
 // Обработка статуса;
 // Обработка сообщения;
-func (b *Bot) handlers(update telego.Update) {
+func (b *Bot) handlers(ctx context.Context, update telego.Update) {
 	b.logger.Debug("Получено сообщение", "message", update.Message.Text, "tgID", update.Message.Chat.ID)
 
+	// Обработка документов
+	if update.Message.Document != nil {
+		b.handleDocument(ctx, update)
+		return
+	}
+	// Обработка фото кассового чека
+	if len(update.Message.Photo) > 0 {
+		b.handlePhoto(ctx, update)
+		return
+	}
+	// Строка из QR-кода чека начинает новую запись на любом шаге
+	if receipt.IsReceipt(update.Message.Text) {
+		b.handleReceiptText(ctx, update)
+		return
+	}
+
 	// Обработка команд
 	if strings.HasPrefix(update.Message.Text, "/") {
-		b.handlersCmd(update)
+		b.handlersCmd(ctx, update)
 		return
 	}
 	chatID := update.Message.Chat.ID
 
 	// Получение статуса
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	statusCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
-	status, err := b.Service.GetStatus(ctx, chatID)
+	status, err := b.Service.GetStatus(statusCtx, chatID)
 	if err != nil {
 		b.logger.Error("Ошибка получения статуса", "error", err)
-		b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 		return
 	}
 	// Обработка статуса
 	if status != "" {
-		b.handlerStatus(status, update)
+		b.handlerStatus(ctx, status, update)
 		return
 	}
 
 	// Получение статуса записи расхода
-	statusExpense, err := b.Service.GetExpenseStatus(ctx, chatID)
+	statusExpense, err := b.Service.GetExpenseStatus(statusCtx, chatID)
 	if err != nil {
 		b.logger.Error("Ошибка получения статуса записи расхода", "error", err)
-		b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 		return
 	}
 	// Обработка статуса записи расхода
 	if statusExpense != nil {
 		b.logger.Debug("Статус записи расхода получен", "tgID", chatID, "status", statusExpense.Step)
-		b.HandleAddExpenseText(chatID, update.Message.Text, statusExpense)
+		if statusExpense.ExpenseID != "" {
+			b.HandleEditExpenseText(ctx, chatID, update.Message.Text, statusExpense)
+			return
+		}
+		b.HandleAddExpenseText(ctx, chatID, update.Message.Text, statusExpense)
 		return
 	}
 
 	// Обработка сообщения
-	b.handlersMessage(update)
+	b.handlersMessage(ctx, update)
 
 }
 
 // handlerStatus обработка статуса
 //
 // Обработка статуса "budget" - установка бюджета
-func (b *Bot) handlerStatus(status string, update telego.Update) {
+// Обработка статуса "limit_<Категория>" - установка лимита категории
+// Обработка статуса "income" - запись дохода
+// Обработка статусов "category_*" - создание и изменение категорий
+func (b *Bot) handlerStatus(ctx context.Context, status string, update telego.Update) {
 	b.logger.Debug("Обработка статуса", "status", status, "tgID", update.Message.Chat.ID)
-	switch status {
-	case StatusBudget:
-		b.requestBudget(update)
+	switch {
+	case status == StatusBudget:
+		b.requestBudget(ctx, update)
+	case strings.HasPrefix(status, StatusLimit):
+		b.requestLimit(ctx, strings.TrimPrefix(status, StatusLimit), update)
+	case status == StatusIncome:
+		b.requestIncome(ctx, update)
+	case status == StatusCategoryNew:
+		b.requestCategoryNew(ctx, update)
+	case strings.HasPrefix(status, StatusCategoryRename):
+		b.requestCategoryRename(ctx, strings.TrimPrefix(status, StatusCategoryRename), update)
+	case strings.HasPrefix(status, StatusCategoryIcon):
+		b.requestCategoryIcon(ctx, strings.TrimPrefix(status, StatusCategoryIcon), update)
+	case status == StatusExportRange:
+		b.requestExportRange(ctx, update)
+	case status == StatusImportMapping:
+		b.requestImportMapping(ctx, update)
 	default:
 		b.logger.Debug("Неизвестный статус", "status", status)
-		b.SendErrorMessage(update.Message.Chat.ID, "Произошла ошибка. Воспользуйтесь командами:\n/start для начала работы\n/help для получения справки")
+		b.SendErrorMessage(ctx, update.Message.Chat.ID, "Произошла ошибка. Воспользуйтесь командами:\n/start для начала работы\n/help для получения справки")
 	}
 }
 
 // requestBudget запрос бюджета
 //
-// Запрос бюджета у пользователя и обновление в базе данных
+// Запрос бюджета у пользователя и обновление в базе данных.
+// Ответ "доход" устанавливает бюджет равным доходам за месяц
 // Отправка сообщения о результате
-func (b *Bot) requestBudget(update telego.Update) {
+func (b *Bot) requestBudget(ctx context.Context, update telego.Update) {
 	chatID := update.Message.Chat.ID
 	amount := update.Message.Text
 	b.logger.Debug("Запрос бюджета requestBudget", "tgID", chatID, "amount", amount)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	budget, err := b.Service.UpdateBudgetByTgID(ctx, chatID, amount)
+	if !b.checkBudgetAccess(ctx, chatID, update.Message.From) {
+		return
+	}
+
+	fromIncome := isIncomeBudget(amount)
+	var budget *domainBudget.Budget
+	var err error
+	if fromIncome {
+		budget, err = b.Service.SetBudgetFromIncome(ctx, chatID)
+	} else {
+		budget, err = b.Service.UpdateBudgetByTgID(ctx, chatID, amount)
+	}
+	if errors.Is(err, income.ErrNoIncome) {
+		b.SendErrorMessage(ctx, chatID, "В этом месяце еще нет доходов. Запишите доход командой /income")
+		return
+	}
 	if err != nil {
 		b.logger.Error("Ошибка обновления бюджета requestBudget", "error", err)
-		b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 		return
 	}
 	if budget == nil {
 		b.logger.Error("Ошибка обновления бюджета requestBudget", "error", "budget is nil")
-		b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 		return
 	}
 
@@ -103,27 +177,401 @@ func (b *Bot) requestBudget(update telego.Update) {
 	err = b.Service.SetStatus(ctx, chatID, "")
 	if err != nil {
 		b.logger.Error("Ошибка установки статуса", "error", err)
-		b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 		return
 	}
 
-	text := fmt.Sprintf("Бюджет на месяц установлен: %.2f", budget.Amount.InexactFloat64())
+	text := fmt.Sprintf("Бюджет на месяц установлен: %s", currency.Format(budget.Amount, budget.Currency))
+	if fromIncome {
+		text += "\nБюджет будет пересчитываться при каждой записи дохода"
+	}
 	b.logger.Debug("Бюджет установлен requestBudget", "tgID", chatID, "amount", budget.Amount.InexactFloat64())
-	b.SendMessage(chatID, text)
+	b.SendMessage(ctx, chatID, text)
+}
+
+// requestLimit запрос лимита категории
+//
+// Запрос лимита у пользователя и сохранение в текущем бюджете
+// Отправка сообщения о результате
+func (b *Bot) requestLimit(ctx context.Context, category string, update telego.Update) {
+	chatID := update.Message.Chat.ID
+	b.logger.Debug("Запрос лимита requestLimit", "tgID", chatID, "category", category, "amount", update.Message.Text)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if !b.checkBudgetAccess(ctx, chatID, update.Message.From) {
+		return
+	}
+	amount, err := calc.Calculate(update.Message.Text)
+	if err != nil {
+		b.SendErrorMessage(ctx, chatID, calcErrorText(update.Message.Text, err))
+		return
+	}
+	if amount.IsNegative() {
+		b.SendErrorMessage(ctx, chatID, "Неверная сумма лимита. Попробуйте еще раз.")
+		return
+	}
+
+	_, err = b.Service.SetCategoryLimit(ctx, chatID, category, calc.FormatNumber(amount))
+	if err != nil {
+		b.logger.Error("Ошибка установки лимита requestLimit", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+
+	// Сброс статуса
+	err = b.Service.SetStatus(ctx, chatID, "")
+	if err != nil {
+		b.logger.Error("Ошибка установки статуса", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+
+	if amount.IsZero() {
+		b.SendMessage(ctx, chatID, fmt.Sprintf("Лимит для категории %s удален", category))
+		return
+	}
+	b.SendMessage(ctx, chatID, fmt.Sprintf("Лимит для категории %s установлен: %s", category, amount.StringFixed(2)))
+}
+
+// isIncomeBudget проверяет, просит ли пользователь рассчитывать бюджет по доходам
+func isIncomeBudget(text string) bool {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "доход", "доходы", "income":
+		return true
+	}
+	return false
+}
+
+// requestIncome запрос дохода
+//
+// Записывает доход из ответа пользователя и сбрасывает статус
+func (b *Bot) requestIncome(ctx context.Context, update telego.Update) {
+	chatID := update.Message.Chat.ID
+	b.logger.Debug("Запрос дохода requestIncome", "tgID", chatID, "text", update.Message.Text)
+
+	if !b.addIncome(ctx, chatID, update.Message.From, update.Message.Text) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	b.resetStatus(ctx, chatID)
+}
+
+// addIncome записывает доход из текста "50000 зарплата" и сообщает о результате
+//
+// Возвращает false, если доход не записан
+func (b *Bot) addIncome(ctx context.Context, chatID int64, from *telego.User, text string) bool {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	inc, budget, err := b.Service.AddIncome(ctx, chatID, authorFrom(from), text)
+	switch {
+	case err == nil:
+	case errors.Is(err, quickadd.ErrNoAmount), errors.Is(err, income.ErrNonPositiveAmount):
+		b.SendErrorMessage(ctx, chatID, "Не удалось разобрать доход. Пример: 50000 зарплата")
+		return false
+	case errors.Is(err, currency.ErrUnknownCurrency):
+		b.SendErrorMessage(ctx, chatID, "Неизвестная валюта. Пример: 300$ фриланс")
+		return false
+	case errors.Is(err, currency.ErrRateNotFound):
+		b.SendErrorMessage(ctx, chatID, "Нет курса этой валюты к валюте бюджета")
+		return false
+	case errors.Is(err, user.ErrUserNotFound):
+		b.SendErrorMessage(ctx, chatID, "Сначала зарегистрируйтесь командой /start")
+		return false
+	default:
+		b.logger.Error("Ошибка записи дохода", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return false
+	}
+
+	message := fmt.Sprintf("✅ Доход записан: %s", currency.Format(inc.Amount, inc.Currency))
+	if inc.Source != "" {
+		message += fmt.Sprintf(" - %s", inc.Source)
+	}
+	message += fmt.Sprintf(" (%s)", inc.Date.Format("02.01.2006"))
+	if budget != nil {
+		message += fmt.Sprintf("\nБюджет на период: %s", currency.Format(budget.Total(), budget.Currency))
+	}
+	b.SendMessage(ctx, chatID, message)
+	return true
+}
+
+// requestCategoryNew запрос названия новой категории
+//
+// Название может начинаться с иконки: "🐶 Питомец"
+func (b *Bot) requestCategoryNew(ctx context.Context, update telego.Update) {
+	chatID := update.Message.Chat.ID
+	b.logger.Debug("Создание категории requestCategoryNew", "tgID", chatID, "text", update.Message.Text)
+
+	icon, name := splitCategoryInput(update.Message.Text)
+	if !b.checkCategoryNameLength(ctx, chatID, name) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c, err := b.Service.CreateCategory(ctx, chatID, name, icon)
+	if err != nil {
+		b.logger.Error("Ошибка создания категории requestCategoryNew", "error", err)
+		b.sendCategoryError(ctx, chatID, err)
+		return
+	}
+
+	b.resetStatus(ctx, chatID)
+	b.SendMessage(ctx, chatID, fmt.Sprintf("✅ Категория %s %s создана", c.Icon, c.Name))
+}
+
+// requestCategoryRename запрос нового названия категории
+func (b *Bot) requestCategoryRename(ctx context.Context, category string, update telego.Update) {
+	chatID := update.Message.Chat.ID
+	b.logger.Debug("Переименование категории requestCategoryRename", "tgID", chatID, "category", category, "text", update.Message.Text)
+
+	name := strings.TrimSpace(update.Message.Text)
+	if !b.checkCategoryNameLength(ctx, chatID, name) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c, err := b.Service.RenameCategory(ctx, chatID, category, name)
+	if err != nil {
+		b.logger.Error("Ошибка переименования категории requestCategoryRename", "error", err)
+		b.sendCategoryError(ctx, chatID, err)
+		return
+	}
+
+	b.resetStatus(ctx, chatID)
+	b.SendMessage(ctx, chatID, fmt.Sprintf("✅ Категория %s переименована в %s %s", category, c.Icon, c.Name))
+}
+
+// requestCategoryIcon запрос новой иконки категории
+func (b *Bot) requestCategoryIcon(ctx context.Context, category string, update telego.Update) {
+	chatID := update.Message.Chat.ID
+	b.logger.Debug("Смена иконки категории requestCategoryIcon", "tgID", chatID, "category", category, "text", update.Message.Text)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c, err := b.Service.SetCategoryIcon(ctx, chatID, category, strings.TrimSpace(update.Message.Text))
+	if err != nil {
+		b.logger.Error("Ошибка смены иконки категории requestCategoryIcon", "error", err)
+		b.sendCategoryError(ctx, chatID, err)
+		return
+	}
+
+	b.resetStatus(ctx, chatID)
+	b.SendMessage(ctx, chatID, fmt.Sprintf("✅ Иконка категории изменена: %s %s", c.Icon, c.Name))
+}
+
+// checkCategoryNameLength проверяет, что название категории поместится в callback_data кнопок
+func (b *Bot) checkCategoryNameLength(ctx context.Context, chatID int64, name string) bool {
+	if name == "" {
+		b.SendErrorMessage(ctx, chatID, "Название категории не может быть пустым. Попробуйте еще раз.")
+		return false
+	}
+	if len("add_category_"+name) > maxCallbackDataLength {
+		b.SendErrorMessage(ctx, chatID, "Слишком длинное название категории. Попробуйте короче.")
+		return false
+	}
+	return true
+}
+
+// sendCategoryError отправляет понятное пользователю сообщение об ошибке работы с категорией
+func (b *Bot) sendCategoryError(ctx context.Context, chatID int64, err error) {
+	switch {
+	case errors.Is(err, categories.ErrDuplicateName):
+		b.SendErrorMessage(ctx, chatID, "Категория с таким названием уже есть. Попробуйте другое.")
+	case errors.Is(err, categories.ErrNameTooLong), errors.Is(err, categories.ErrIconTooLong):
+		b.SendErrorMessage(ctx, chatID, "Слишком длинное значение. Попробуйте короче.")
+	case errors.Is(err, categories.ErrEmptyName):
+		b.SendErrorMessage(ctx, chatID, "Название категории не может быть пустым.")
+	case errors.Is(err, categories.ErrCategoryNotFound):
+		b.SendErrorMessage(ctx, chatID, "Категория не найдена.")
+	case errors.Is(err, categories.ErrUpdateDefaultCategory):
+		b.SendErrorMessage(ctx, chatID, "Базовую категорию изменить нельзя.")
+	case errors.Is(err, categories.ErrDeleteDefaultCategory):
+		b.SendErrorMessage(ctx, chatID, "Базовую категорию удалить нельзя.")
+	case errors.Is(err, categories.ErrCategoryInUse):
+		b.SendErrorMessage(ctx, chatID, "По категории уже есть расходы, удалить её нельзя.")
+	default:
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+	}
+}
+
+// calcErrorText возвращает сообщение об ошибке вычисления суммы с выделенным ошибочным фрагментом
+func calcErrorText(expression string, err error) string {
+	var calcErr *calc.Error
+	if !errors.As(err, &calcErr) {
+		return "Ошибка в вычислении суммы. Попробуйте еще раз."
+	}
+	return fmt.Sprintf("Ошибка в вычислении суммы: %s\n(%s)\nПопробуйте еще раз.", strings.TrimSpace(calc.Mark(expression, err)), calcErr.Err)
+}
+
+// sendAccountError отправляет понятное пользователю сообщение об ошибке работы со счетами
+func (b *Bot) sendAccountError(ctx context.Context, chatID int64, err error) {
+	switch {
+	case errors.Is(err, account.ErrAccountNotFound):
+		b.SendErrorMessage(ctx, chatID, "Счет не найден. Посмотреть счета: /accounts")
+	case errors.Is(err, account.ErrDuplicateName):
+		b.SendErrorMessage(ctx, chatID, "Счет с таким названием уже есть.")
+	case errors.Is(err, account.ErrEmptyName):
+		b.SendErrorMessage(ctx, chatID, "Название счета не может быть пустым.")
+	case errors.Is(err, account.ErrNameTooLong):
+		b.SendErrorMessage(ctx, chatID, fmt.Sprintf("Название счета не должно превышать %d символов.", account.MaxNameLength))
+	case errors.Is(err, account.ErrInvalidType):
+		b.SendErrorMessage(ctx, chatID, "Неизвестный тип счета. Доступные типы: наличные, карта, кредитка, вклад")
+	case errors.Is(err, account.ErrSameAccount):
+		b.SendErrorMessage(ctx, chatID, "Нельзя перевести деньги на тот же счет.")
+	case errors.Is(err, currency.ErrUnknownCurrency):
+		b.SendErrorMessage(ctx, chatID, "Неизвестная валюта.")
+	case errors.Is(err, currency.ErrRateNotFound):
+		b.SendErrorMessage(ctx, chatID, "Нет курса между валютами счетов.")
+	case errors.Is(err, user.ErrUserNotFound):
+		b.SendErrorMessage(ctx, chatID, "Сначала зарегистрируйтесь командой /start")
+	default:
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+	}
+}
+
+// sendGoalError отправляет понятное пользователю сообщение об ошибке работы с целями
+func (b *Bot) sendGoalError(ctx context.Context, chatID int64, err error) {
+	switch {
+	case errors.Is(err, goal.ErrGoalNotFound):
+		b.SendErrorMessage(ctx, chatID, "Цель не найдена. Посмотреть цели: /goal")
+	case errors.Is(err, goal.ErrDuplicateName):
+		b.SendErrorMessage(ctx, chatID, "Цель с таким названием уже есть.")
+	case errors.Is(err, goal.ErrEmptyName):
+		b.SendErrorMessage(ctx, chatID, "Название цели не может быть пустым.")
+	case errors.Is(err, goal.ErrNameTooLong):
+		b.SendErrorMessage(ctx, chatID, fmt.Sprintf("Название цели не должно превышать %d символов.", goal.MaxNameLength))
+	case errors.Is(err, goal.ErrNonPositiveAmount):
+		b.SendErrorMessage(ctx, chatID, "Сумма должна быть больше нуля.")
+	case errors.Is(err, goal.ErrInvalidDeadline):
+		b.SendErrorMessage(ctx, chatID, "Неверный срок. Укажите дату ДД.ММ.ГГГГ или месяц ММ.ГГГГ")
+	case errors.Is(err, goal.ErrDeadlinePassed):
+		b.SendErrorMessage(ctx, chatID, "Срок цели не может быть в прошлом.")
+	case errors.Is(err, currency.ErrUnknownCurrency):
+		b.SendErrorMessage(ctx, chatID, "Неизвестная валюта.")
+	case errors.Is(err, currency.ErrRateNotFound):
+		b.SendErrorMessage(ctx, chatID, "Нет курса между валютами взноса и цели.")
+	case errors.Is(err, user.ErrUserNotFound):
+		b.SendErrorMessage(ctx, chatID, "Сначала зарегистрируйтесь командой /start")
+	default:
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+	}
+}
+
+// sendSearchError отправляет понятное пользователю сообщение об ошибке поиска трат
+func (b *Bot) sendSearchError(ctx context.Context, chatID int64, err error) {
+	switch {
+	case errors.Is(err, expense.ErrEmptySearch):
+		b.SendErrorMessage(ctx, chatID, "Запрос поиска пуст или устарел.\n"+findUsage)
+	case errors.Is(err, categories.ErrCategoryNotFound):
+		b.SendErrorMessage(ctx, chatID, "Категория не найдена. Посмотреть категории: /categories")
+	case errors.Is(err, user.ErrUserNotFound):
+		b.SendErrorMessage(ctx, chatID, "Сначала зарегистрируйтесь командой /start")
+	default:
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+	}
+}
+
+// sendSplitError отправляет понятное пользователю сообщение об ошибке распределения траты по категориям
+func (b *Bot) sendSplitError(ctx context.Context, chatID int64, entry *service.ExpenseEntryDTO, err error) {
+	var calcErr *calc.Error
+	switch {
+	case errors.Is(err, expense.ErrSplitSumMismatch):
+		b.SendErrorMessage(ctx, chatID, fmt.Sprintf("Сумма частей должна быть равна сумме покупки %s. Попробуйте еще раз.", currency.Format(entry.Amount, entry.Currency)))
+	case errors.Is(err, expense.ErrSplitNonPositive):
+		b.SendErrorMessage(ctx, chatID, "Сумма каждой части должна быть больше нуля. Попробуйте еще раз.")
+	case errors.Is(err, expense.ErrSplitDuplicateCategory):
+		b.SendErrorMessage(ctx, chatID, "Категории частей не должны повторяться. Попробуйте еще раз.")
+	case errors.Is(err, expense.ErrSplitTooFew):
+		b.SendErrorMessage(ctx, chatID, "Укажите хотя бы две разные категории. Попробуйте еще раз.")
+	case errors.Is(err, categories.ErrCategoryNotFound):
+		b.SendErrorMessage(ctx, chatID, "Категория не найдена. Посмотреть категории: /categories")
+	case errors.Is(err, service.ErrSplitFormat):
+		b.SendErrorMessage(ctx, chatID, "Не удалось разобрать части. Укажите категорию и сумму через запятую, например: Хозтовары 500, Одежда 800")
+	case errors.As(err, &calcErr):
+		b.SendErrorMessage(ctx, chatID, fmt.Sprintf("Ошибка в вычислении суммы части: %s\nПопробуйте еще раз.", calcErr.Err))
+	case errors.Is(err, user.ErrUserNotFound):
+		b.SendErrorMessage(ctx, chatID, "Сначала зарегистрируйтесь командой /start")
+	default:
+		b.logger.Error("Ошибка распределения расхода", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+	}
+}
+
+// checkBudgetAccess проверяет, может ли пользователь from менять бюджет и лимиты чата
+//
+// Если прав недостаточно, сообщает об этом и возвращает false
+func (b *Bot) checkBudgetAccess(ctx context.Context, chatID int64, from *telego.User) bool {
+	err := b.Service.CheckBudgetAccess(ctx, chatID, authorFrom(from))
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, group.ErrForbidden) {
+		b.SendErrorMessage(ctx, chatID, "Менять бюджет и лимиты могут только владелец и администраторы группы")
+		return false
+	}
+	b.logger.Error("Ошибка проверки прав участника", "error", err)
+	b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+	return false
+}
+
+// authorFrom возвращает автора действия по отправителю сообщения или нажатия кнопки
+func authorFrom(from *telego.User) service.AuthorDTO {
+	if from == nil {
+		return service.AuthorDTO{}
+	}
+	return service.AuthorDTO{
+		TelegramID: from.ID,
+		UserName:   from.Username,
+		FirstName:  from.FirstName,
+		LastName:   from.LastName,
+	}
+}
+
+// resetStatus сбрасывает статус пользователя
+func (b *Bot) resetStatus(ctx context.Context, chatID int64) {
+	if err := b.Service.SetStatus(ctx, chatID, ""); err != nil {
+		b.logger.Error("Ошибка сброса статуса", "error", err)
+	}
+}
+
+// splitCategoryInput отделяет иконку от названия категории.
+// Первое слово считается иконкой, если в нем нет букв и цифр
+func splitCategoryInput(text string) (icon, name string) {
+	text = strings.TrimSpace(text)
+	parts := strings.SplitN(text, " ", 2)
+	if len(parts) < 2 {
+		return "", text
+	}
+	for _, r := range parts[0] {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return "", text
+		}
+	}
+	return parts[0], strings.TrimSpace(parts[1])
 }
 
 // HandleAddExpenseText обрабатывает текстовые сообщения, поступающие на разных шагах диалога.
-func (b *Bot) HandleAddExpenseText(chatID int64, text string, entry *service.ExpenseEntryDTO) {
+func (b *Bot) HandleAddExpenseText(ctx context.Context, chatID int64, text string, entry *service.ExpenseEntryDTO) {
 	b.logger.Debug("Обработка текстового сообщения в HandleAddExpenseText", "tgID", chatID, "text", text, "entry", entry)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	switch entry.Step {
 	case "date_input":
 		t, err := time.Parse("02.01.2006", text)
 		if err != nil {
-			b.SendErrorMessage(chatID, "Неверный формат даты. Попробуйте еще раз.")
+			b.SendErrorMessage(ctx, chatID, "Неверный формат даты. Попробуйте еще раз.")
 			return
 		}
 		entry.Date = t
@@ -131,57 +579,373 @@ func (b *Bot) HandleAddExpenseText(chatID int64, text string, entry *service.Exp
 		err = b.Service.SetExpenseStatus(ctx, chatID, entry)
 		if err != nil {
 			b.logger.Error("Ошибка установки статуса записи расхода", "error", err)
-			b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 			return
 		}
-		b.sendAmountPrompt(chatID)
+		b.sendAmountPrompt(ctx, chatID)
 	case "amount":
-		amount, err := calc.Calculate(text)
+		amountText, code := currency.SplitAmount(text)
+		amount, err := calc.Calculate(amountText)
 		if err != nil {
-			b.SendErrorMessage(chatID, "Ошибка в вычислении суммы. Попробуйте еще раз.")
+			b.SendErrorMessage(ctx, chatID, calcErrorText(amountText, err))
 			return
 		}
+		if code == "" {
+			// Без указания валюты трата записывается в валюте бюджета
+			code, err = b.Service.GetUserCurrency(ctx, chatID)
+			if err != nil {
+				b.logger.Error("Ошибка получения валюты пользователя", "error", err)
+				b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+				return
+			}
+		}
 		entry.Amount = amount
+		entry.Currency = code
 		entry.Step = "category"
 		err = b.Service.SetExpenseStatus(ctx, chatID, entry)
 		if err != nil {
 			b.logger.Error("Ошибка установки статуса записи расхода", "error", err)
-			b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 			return
 		}
-		// Показываем кнопки для выбора категории.
-
-		defaultCategory, err := b.Service.GetDefaultCategories(ctx)
+		b.sendCategoryPrompt(ctx, chatID, "Выберите категорию расхода:")
+	case "note_input":
+		entry.Note = text
+		entry.Step = "recurring"
+		err := b.Service.SetExpenseStatus(ctx, chatID, entry)
 		if err != nil {
-			b.logger.Error("Ошибка получения категорий", "error", err)
-			b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз.")
+			b.logger.Error("Ошибка установки статуса записи расхода", "error", err)
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 			return
 		}
-
-		// Создаем кнопки для категорий
-		keyboards := tu.InlineKeyboard()
-		for _, cat := range defaultCategory {
-			keyboards.InlineKeyboard = append(keyboards.InlineKeyboard, tu.InlineKeyboardRow(
-				tu.InlineKeyboardButton(cat.Icon+" "+cat.Name).WithCallbackData("add_category_"+cat.Name),
-			))
+		b.sendRecurrencePrompt(ctx, chatID, entry)
+	case "split":
+		if err := b.Service.PlanSplit(ctx, chatID, entry, text); err != nil {
+			b.sendSplitError(ctx, chatID, entry, err)
+			return
 		}
-
-		b.SendMessageWithKeyboard(chatID, "Выберите категорию расхода:", keyboards)
-	case "note_input":
-		entry.Note = text
 		entry.Step = "confirm"
-		err := b.Service.SetExpenseStatus(ctx, chatID, entry)
-		if err != nil {
+		if err := b.Service.SetExpenseStatus(ctx, chatID, entry); err != nil {
 			b.logger.Error("Ошибка установки статуса записи расхода", "error", err)
-			b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+			return
+		}
+		b.sendConfirmation(ctx, chatID, entry)
+	}
+}
+
+// HandleEditExpenseText обрабатывает новые значения при редактировании расхода.
+func (b *Bot) HandleEditExpenseText(ctx context.Context, chatID int64, text string, entry *service.ExpenseEntryDTO) {
+	b.logger.Debug("Обработка текстового сообщения в HandleEditExpenseText", "tgID", chatID, "text", text, "entry", entry)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	switch entry.Step {
+	case "edit_amount":
+		amountText, code := currency.SplitAmount(text)
+		amount, err := calc.Calculate(amountText)
+		if err != nil {
+			b.SendErrorMessage(ctx, chatID, calcErrorText(amountText, err))
+			return
+		}
+		if !amount.IsPositive() {
+			b.SendErrorMessage(ctx, chatID, "Ошибка в вычислении суммы. Попробуйте еще раз.")
+			return
+		}
+		entry.Amount = amount
+		if code != "" {
+			entry.Currency = code
+		}
+	case "edit_date":
+		t, err := time.Parse("02.01.2006", text)
+		if err != nil {
+			b.SendErrorMessage(ctx, chatID, "Неверный формат даты. Попробуйте еще раз.")
 			return
 		}
-		b.sendConfirmation(chatID, entry)
+		entry.Date = t
+	case "edit_note":
+		entry.Note = strings.TrimSpace(text)
+		if entry.Note == "-" {
+			entry.Note = ""
+		}
+	default:
+		b.SendMessage(ctx, chatID, "Выберите действие кнопками или завершите редактирование")
+		return
 	}
+
+	b.saveEditedExpense(ctx, chatID, entry)
 }
 
-func (b *Bot) handlersMessage(update telego.Update) {
-	b.logger.Debug("Обработка общих сообщений", "tgID", update.Message.Chat.ID)
-	// TODO обработка сообщений
-	// b.SendErrorMessage(update.Message.Chat.ID, "Произошла ошибка. Воспользуйтесь командами:\n/start для начала работы\n/help для получения справки")
+// requestExportRange запрос периода выгрузки
+//
+// Ожидается период в формате "ДД.ММ.ГГГГ-ДД.ММ.ГГГГ".
+// После разбора предлагает выбрать формат файла
+func (b *Bot) requestExportRange(ctx context.Context, update telego.Update) {
+	chatID := update.Message.Chat.ID
+	text := update.Message.Text
+	b.logger.Debug("Запрос периода выгрузки requestExportRange", "tgID", chatID, "text", text)
+
+	start, end, err := parseExportRange(text)
+	if err != nil {
+		b.logger.Debug("Неверный период выгрузки", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Неверный формат периода. Пример: 01.01.2025-31.03.2025")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if err := b.Service.SetStatus(ctx, chatID, ""); err != nil {
+		b.logger.Error("Ошибка установки статуса", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+	b.sendExportFormatPrompt(ctx, chatID, start.Format(exportDateLayout)+"_"+end.Format(exportDateLayout))
+}
+
+// parseExportRange разбирает период вида "ДД.ММ.ГГГГ-ДД.ММ.ГГГГ"
+func parseExportRange(text string) (time.Time, time.Time, error) {
+	from, to, ok := strings.Cut(strings.ReplaceAll(text, " ", ""), "-")
+	if !ok {
+		return time.Time{}, time.Time{}, fmt.Errorf("period separator not found in %q", text)
+	}
+	start, err := time.Parse("02.01.2006", from)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := time.Parse("02.01.2006", to)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("period end %s is before start %s", to, from)
+	}
+	return start, end, nil
+}
+
+// handleDocument обработка документов
+//
+// CSV-файл считается банковской выпиской: бот сохраняет его и показывает предпросмотр импорта
+func (b *Bot) handleDocument(ctx context.Context, update telego.Update) {
+	chatID := update.Message.Chat.ID
+	doc := update.Message.Document
+	b.logger.Debug("Получен документ", "tgID", chatID, "file", doc.FileName, "mime", doc.MimeType, "size", doc.FileSize)
+
+	if !strings.EqualFold(path.Ext(doc.FileName), ".csv") {
+		b.SendMessage(ctx, chatID, "Для импорта выписки отправьте файл в формате CSV")
+		return
+	}
+	if doc.FileSize > statement.MaxFileSize {
+		b.sendImportError(ctx, chatID, statement.ErrFileTooLarge)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	file, err := b.Client.GetFile(ctx, &telego.GetFileParams{FileID: doc.FileID})
+	if err != nil {
+		b.logger.Error("Ошибка получения файла", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Не удалось загрузить файл. Попробуйте еще раз")
+		return
+	}
+	data, err := tu.DownloadFile(b.Client.FileDownloadURL(file.FilePath))
+	if err != nil {
+		b.logger.Error("Ошибка загрузки файла", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Не удалось загрузить файл. Попробуйте еще раз")
+		return
+	}
+
+	preview, err := b.Service.StartImport(ctx, chatID, doc.FileName, data)
+	if err != nil {
+		b.logger.Error("Ошибка разбора выписки", "error", err)
+		b.sendImportError(ctx, chatID, err)
+		return
+	}
+	b.sendImportPreview(ctx, chatID, preview)
+}
+
+// handlePhoto распознает QR-код кассового чека на фото и начинает запись траты по нему
+func (b *Bot) handlePhoto(ctx context.Context, update telego.Update) {
+	chatID := update.Message.Chat.ID
+	// Фото приходит в нескольких размерах, последний - самый крупный
+	photo := update.Message.Photo[len(update.Message.Photo)-1]
+	b.logger.Debug("Получено фото", "tgID", chatID, "width", photo.Width, "height", photo.Height, "size", photo.FileSize)
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	file, err := b.Client.GetFile(ctx, &telego.GetFileParams{FileID: photo.FileID})
+	if err != nil {
+		b.logger.Error("Ошибка получения файла", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Не удалось загрузить фото. Попробуйте еще раз")
+		return
+	}
+	data, err := tu.DownloadFile(b.Client.FileDownloadURL(file.FilePath))
+	if err != nil {
+		b.logger.Error("Ошибка загрузки файла", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Не удалось загрузить фото. Попробуйте еще раз")
+		return
+	}
+
+	entry, err := b.Service.ScanReceipt(ctx, chatID, data)
+	if err != nil {
+		b.sendReceiptError(ctx, chatID, err)
+		return
+	}
+	b.sendReceiptEntry(ctx, chatID, entry)
+}
+
+// handleReceiptText начинает запись траты по строке из QR-кода кассового чека
+func (b *Bot) handleReceiptText(ctx context.Context, update telego.Update) {
+	chatID := update.Message.Chat.ID
+	b.logger.Debug("Получена строка чека", "tgID", chatID, "text", update.Message.Text)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	entry, err := b.Service.StartReceiptExpense(ctx, chatID, update.Message.Text)
+	if err != nil {
+		b.sendReceiptError(ctx, chatID, err)
+		return
+	}
+	b.sendReceiptEntry(ctx, chatID, entry)
+}
+
+// sendReceiptEntry показывает дату и сумму чека и предлагает выбрать категорию
+func (b *Bot) sendReceiptEntry(ctx context.Context, chatID int64, entry *service.ExpenseEntryDTO) {
+	text := fmt.Sprintf("🧾 Чек от %s на %s\nВыберите категорию расхода:",
+		entry.Date.Format("02.01.2006"), currency.Format(entry.Amount, entry.Currency))
+	b.sendCategoryPrompt(ctx, chatID, text)
+}
+
+// sendReceiptError отправляет понятное пользователю сообщение об ошибке записи чека
+func (b *Bot) sendReceiptError(ctx context.Context, chatID int64, err error) {
+	switch {
+	case errors.Is(err, qr.ErrNotFound):
+		b.SendErrorMessage(ctx, chatID, "QR-код на фото не найден. Сфотографируйте код крупнее и ровнее или отправьте строку из QR-кода текстом")
+	case errors.Is(err, qr.ErrUnreadable), errors.Is(err, qr.ErrUnsupported):
+		b.SendErrorMessage(ctx, chatID, "Не удалось прочитать QR-код. Сфотографируйте его еще раз при хорошем освещении")
+	case errors.Is(err, receipt.ErrNotReceipt):
+		b.SendErrorMessage(ctx, chatID, "Это не QR-код кассового чека")
+	case errors.Is(err, receipt.ErrInvalidReceipt):
+		b.SendErrorMessage(ctx, chatID, "В QR-коде чека ошибка: не удалось разобрать дату, сумму или номер документа")
+	case errors.Is(err, receipt.ErrNotPurchase):
+		b.SendErrorMessage(ctx, chatID, "Это чек возврата или расхода, он не записывается как трата")
+	case errors.Is(err, expense.ErrDuplicateReceipt):
+		b.SendErrorMessage(ctx, chatID, "Этот чек уже записан. Посмотреть расходы: /expense")
+	case errors.Is(err, image.ErrFormat):
+		b.SendErrorMessage(ctx, chatID, "Не удалось открыть фото. Отправьте его как фото, а не файлом")
+	case errors.Is(err, user.ErrUserNotFound):
+		b.SendErrorMessage(ctx, chatID, "Сначала зарегистрируйтесь командой /start")
+	default:
+		b.logger.Error("Ошибка записи чека", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+	}
+}
+
+// requestImportMapping запрос колонок выписки
+//
+// Ожидаются номера колонок даты, суммы и описания, например "1 3 5"
+func (b *Bot) requestImportMapping(ctx context.Context, update telego.Update) {
+	chatID := update.Message.Chat.ID
+	b.logger.Debug("Запрос колонок выписки requestImportMapping", "tgID", chatID, "text", update.Message.Text)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	preview, err := b.Service.SetImportMapping(ctx, chatID, update.Message.Text)
+	if err != nil {
+		b.logger.Debug("Ошибка сопоставления колонок", "error", err)
+		if errors.Is(err, status.ErrImportNotFound) {
+			b.resetStatus(ctx, chatID)
+		}
+		b.sendImportError(ctx, chatID, err)
+		return
+	}
+
+	b.resetStatus(ctx, chatID)
+	b.sendImportPreview(ctx, chatID, preview)
+}
+
+// sendImportError отправляет сообщение об ошибке импорта выписки
+func (b *Bot) sendImportError(ctx context.Context, chatID int64, err error) {
+	switch {
+	case errors.Is(err, status.ErrImportNotFound):
+		b.SendErrorMessage(ctx, chatID, "Выписка не найдена или устарела. Отправьте файл еще раз")
+	case errors.Is(err, statement.ErrFileTooLarge):
+		b.SendErrorMessage(ctx, chatID, "Файл слишком большой. Максимальный размер - 1 МБ")
+	case errors.Is(err, statement.ErrEmptyFile):
+		b.SendErrorMessage(ctx, chatID, "Файл пустой или не похож на CSV")
+	case errors.Is(err, statement.ErrInvalidMapping):
+		b.SendErrorMessage(ctx, chatID, "Неверные номера колонок. Пример: 1 3 5 (дата, сумма, описание)")
+	case errors.Is(err, statement.ErrNoRows):
+		b.SendErrorMessage(ctx, chatID, "В выбранных колонках не найдено ни одной траты. Укажите другие колонки")
+	case errors.Is(err, user.ErrUserNotFound):
+		b.SendErrorMessage(ctx, chatID, "Сначала зарегистрируйтесь командой /start")
+	default:
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+	}
+}
+
+// quickAddText возвращает текст быстрой записи траты из сообщения.
+//
+// В личном чате разбирается все сообщение. В группе число в обычной переписке
+// ("встретимся в 7") не означает трату, поэтому разбираются только обращения к боту:
+// ответ на его сообщение или упоминание @бота, которое удаляется из текста
+func (b *Bot) quickAddText(msg *telego.Message) (string, bool) {
+	if msg.Chat.Type == telego.ChatTypePrivate {
+		return msg.Text, true
+	}
+	if msg.ReplyToMessage != nil && msg.ReplyToMessage.From != nil && msg.ReplyToMessage.From.ID == b.id {
+		return msg.Text, true
+	}
+	if b.username == "" {
+		return "", false
+	}
+	mention := regexp.MustCompile(`(?i)@` + regexp.QuoteMeta(b.username) + `\b`)
+	if !mention.MatchString(msg.Text) {
+		return "", false
+	}
+	return strings.TrimSpace(mention.ReplaceAllString(msg.Text, "")), true
+}
+
+// handlersMessage обработка общих сообщений
+//
+// Сообщение вне диалогов разбирается как быстрая запись траты: "350 кофе",
+// "1200+300 такси вчера", "25.03 500 еда". Трата записывается после подтверждения
+func (b *Bot) handlersMessage(ctx context.Context, update telego.Update) {
+	chatID := update.Message.Chat.ID
+	b.logger.Debug("Обработка общих сообщений", "tgID", chatID)
+
+	text, ok := b.quickAddText(update.Message)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	entry, err := b.Service.ParseQuickExpense(ctx, chatID, text)
+	if err != nil {
+		if errors.Is(err, quickadd.ErrNoAmount) {
+			// Обычная переписка, не похожая на трату
+			return
+		}
+		if errors.Is(err, user.ErrUserNotFound) {
+			b.SendErrorMessage(ctx, chatID, "Сначала зарегистрируйтесь командой /start")
+			return
+		}
+		b.logger.Debug("Ошибка разбора быстрой записи", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Не удалось разобрать трату. Пример: 350 кофе")
+		return
+	}
+
+	if err := b.Service.SetExpenseStatus(ctx, chatID, entry); err != nil {
+		b.logger.Error("Ошибка установки статуса записи расхода", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+	b.sendConfirmation(ctx, chatID, entry)
 }
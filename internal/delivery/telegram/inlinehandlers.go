@@ -2,19 +2,24 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/SobolevTim/finance_bot/internal/pkg/clock"
 	"github.com/SobolevTim/finance_bot/internal/service"
 	"github.com/mymmrac/telego"
 	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/shopspring/decimal"
 )
 
 const daysPerPage = 7
 
 // handleInline обрабатывает инлайн-кнопки
-func (b *Bot) inlinehandlers(update telego.Update) {
+func (b *Bot) inlinehandlers(ctx context.Context, update telego.Update) {
 	callbackData := update.CallbackQuery.Data
 	chatID := update.CallbackQuery.Message.GetChat().ID
 	b.logger.Debug("Получено инлайн-событие", "callbackData", callbackData, "tgID", chatID)
@@ -25,31 +30,294 @@ func (b *Bot) inlinehandlers(update telego.Update) {
 		if err != nil {
 			return
 		}
-		b.handleExpenseCommand(chatID, page)
+		b.handleExpenseCommand(ctx, chatID, page)
+	} else if strings.HasPrefix(callbackData, "find_page_") {
+		// Перелистывание результатов поиска. Ожидается формат "find_page_<номер>"
+		var page int
+		if _, err := fmt.Sscanf(callbackData, "find_page_%d", &page); err != nil {
+			return
+		}
+		b.handleFindPage(ctx, chatID, page)
+	} else if strings.HasPrefix(callbackData, "exp_day_") {
+		// Траты за день из обзора /expense. Ожидается формат "exp_day_<ГГГГ-ММ-ДД>"
+		day, err := time.Parse(time.DateOnly, strings.TrimPrefix(callbackData, "exp_day_"))
+		if err != nil {
+			return
+		}
+		b.handleExpenseDay(ctx, chatID, day)
+	} else if strings.HasPrefix(callbackData, "exp_") {
+		// Открытие траты для редактирования. Ожидается формат "exp_<ID>"
+		b.StartEditExpense(ctx, chatID, strings.TrimPrefix(callbackData, "exp_"))
+	} else if strings.HasPrefix(callbackData, "edit_") {
+		// Обработка inline-кнопок редактирования расхода.
+		b.HandleEditExpenseCallback(ctx, chatID, callbackData)
 	} else if strings.HasPrefix(callbackData, "add_") {
 		// Обработка inline-кнопок для записи расхода.
-		b.HandleAddExpenseCallback(chatID, callbackData)
+		b.HandleAddExpenseCallback(ctx, chatID, authorFrom(&update.CallbackQuery.From), callbackData)
+	} else if strings.HasPrefix(callbackData, StatusLimit) {
+		// Обработка выбора категории для установки лимита.
+		b.HandleLimitCallback(ctx, chatID, callbackData)
+	} else if strings.HasPrefix(callbackData, "cat_") {
+		// Обработка inline-кнопок управления категориями.
+		b.HandleCategoryCallback(ctx, chatID, callbackData)
+	} else if strings.HasPrefix(callbackData, "export_") {
+		// Обработка inline-кнопок выгрузки трат.
+		b.HandleExportCallback(ctx, chatID, callbackData)
+	} else if strings.HasPrefix(callbackData, "import_") {
+		// Обработка inline-кнопок импорта выписки.
+		b.HandleImportCallback(ctx, chatID, authorFrom(&update.CallbackQuery.From), callbackData)
+	} else if strings.HasPrefix(callbackData, "notify_") {
+		// Включение и выключение уведомлений.
+		b.HandleNotificationCallback(ctx, chatID, callbackData)
+	} else if strings.HasPrefix(callbackData, "stats_") {
+		// Диаграмма из кнопок под отчетами. Ожидается формат "stats_<categories|daily|trend>"
+		b.sendStatsCharts(ctx, chatID, strings.TrimPrefix(callbackData, "stats_"))
+	}
+}
+
+// HandleNotificationCallback включает или выключает уведомление.
+//
+// Ожидается формат "notify_<daily|weekly|monthly>"
+func (b *Bot) HandleNotificationCallback(ctx context.Context, chatID int64, callbackData string) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	settings, err := b.Service.ToggleNotification(ctx, chatID, strings.TrimPrefix(callbackData, "notify_"))
+	if err != nil {
+		b.logger.Error("Ошибка изменения настроек уведомлений", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+	b.sendNotificationsMenu(ctx, chatID, settings)
+}
+
+// HandleImportCallback обрабатывает inline-кнопки импорта выписки.
+//
+// Форматы: "import_confirm", "import_map", "import_cancel"
+//
+// author - пользователь, нажавший кнопку, в групповом чате записывается автором трат
+func (b *Bot) HandleImportCallback(ctx context.Context, chatID int64, author service.AuthorDTO, callbackData string) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	switch callbackData {
+	case "import_confirm":
+		result, err := b.Service.ConfirmImport(ctx, chatID, author)
+		if err != nil {
+			b.logger.Error("Ошибка импорта выписки", "error", err)
+			b.sendImportError(ctx, chatID, err)
+			return
+		}
+		b.SendMessage(ctx, chatID, fmt.Sprintf("✅ Импортировано трат: %d\nУже были записаны: %d\nПропущено строк: %d", result.Imported, result.Duplicates, result.Skipped))
+	case "import_map":
+		preview, err := b.Service.GetImportPreview(ctx, chatID)
+		if err != nil {
+			b.logger.Error("Ошибка получения выписки", "error", err)
+			b.sendImportError(ctx, chatID, err)
+			return
+		}
+		b.sendImportMappingPrompt(ctx, chatID, preview)
+	case "import_cancel":
+		if err := b.Service.CancelImport(ctx, chatID); err != nil {
+			b.logger.Error("Ошибка отмены импорта", "error", err)
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+			return
+		}
+		b.resetStatus(ctx, chatID)
+		b.SendMessage(ctx, chatID, "Импорт отменен")
+	}
+}
+
+// exportDateLayout - формат дат периода в callback_data выгрузки
+const exportDateLayout = "20060102"
+
+// HandleExportCallback обрабатывает inline-кнопки выгрузки трат.
+//
+// Форматы: "export_p_<month|prev|range|all>" - выбор периода,
+// "export_f_<csv|xlsx>_<ГГГГММДД>_<ГГГГММДД>" или "export_f_<csv|xlsx>_all" - выбор формата
+func (b *Bot) HandleExportCallback(ctx context.Context, chatID int64, callbackData string) {
+	switch {
+	case callbackData == "export_p_month", callbackData == "export_p_prev":
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+		today, err := b.Service.Today(ctx, chatID)
+		if err != nil {
+			b.logger.Error("Ошибка получения даты пользователя", "error", err)
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+			return
+		}
+		monthStart, monthEnd := clock.Month(today)
+		if callbackData == "export_p_prev" {
+			monthStart, monthEnd = clock.Month(monthStart.AddDate(0, 0, -1))
+		}
+		b.sendExportFormatPrompt(ctx, chatID, monthStart.Format(exportDateLayout)+"_"+monthEnd.Format(exportDateLayout))
+	case callbackData == "export_p_all":
+		b.sendExportFormatPrompt(ctx, chatID, "all")
+	case callbackData == "export_p_range":
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+		if err := b.Service.SetStatus(ctx, chatID, StatusExportRange); err != nil {
+			b.logger.Error("Ошибка установки статуса", "error", err)
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+			return
+		}
+		b.sendTextPrompt(ctx, chatID, "Введите период в формате ДД.ММ.ГГГГ-ДД.ММ.ГГГГ, например: 01.01.2025-31.03.2025")
+	case strings.HasPrefix(callbackData, "export_f_"):
+		format, period, ok := strings.Cut(strings.TrimPrefix(callbackData, "export_f_"), "_")
+		if !ok {
+			return
+		}
+		b.sendExport(ctx, chatID, format, period)
+	}
+}
+
+// sendExport формирует файл выгрузки за период и отправляет его документом
+//
+// period - "all" или "<ГГГГММДД>_<ГГГГММДД>"
+func (b *Bot) sendExport(ctx context.Context, chatID int64, format, period string) {
+	b.logger.Debug("Выгрузка трат", "tgID", chatID, "format", format, "period", period)
+
+	// Выгрузка за все время может быть объемной
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	var start, end time.Time
+	if period == "all" {
+		today, err := b.Service.Today(ctx, chatID)
+		if err != nil {
+			b.logger.Error("Ошибка получения даты пользователя", "error", err)
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+			return
+		}
+		end = today
+	} else {
+		from, to, ok := strings.Cut(period, "_")
+		if !ok {
+			return
+		}
+		var err error
+		if start, err = time.Parse(exportDateLayout, from); err != nil {
+			return
+		}
+		if end, err = time.Parse(exportDateLayout, to); err != nil {
+			return
+		}
+	}
+
+	name, data, err := b.Service.ExportExpenses(ctx, chatID, start, end, format)
+	if err != nil {
+		if errors.Is(err, expense.ErrorExpenseNotFound) {
+			b.SendMessage(ctx, chatID, "За выбранный период расходов нет")
+			return
+		}
+		b.logger.Error("Ошибка выгрузки трат", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+
+	b.SendDocument(ctx, chatID, name, data)
+}
+
+// HandleCategoryCallback обрабатывает inline-кнопки управления категориями.
+//
+// Форматы: "cat_new", "cat_menu_<Category>", "cat_rename_<Category>",
+// "cat_icon_<Category>", "cat_delete_<Category>"
+func (b *Bot) HandleCategoryCallback(ctx context.Context, chatID int64, callbackData string) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var status, prompt string
+	switch {
+	case callbackData == "cat_new":
+		status = StatusCategoryNew
+		prompt = "Введите название новой категории. Можно начать с иконки, например: 🐶 Питомец"
+	case strings.HasPrefix(callbackData, "cat_menu_"):
+		cat := strings.TrimPrefix(callbackData, "cat_menu_")
+		keyboard := tu.InlineKeyboard(
+			tu.InlineKeyboardRow(
+				tu.InlineKeyboardButton("✏️ Переименовать").WithCallbackData("cat_rename_"+cat),
+				tu.InlineKeyboardButton("🎨 Иконка").WithCallbackData("cat_icon_"+cat),
+			),
+			tu.InlineKeyboardRow(
+				tu.InlineKeyboardButton("🗑 Удалить").WithCallbackData("cat_delete_"+cat),
+			),
+		)
+		b.SendMessageWithKeyboard(ctx, chatID, fmt.Sprintf("Категория %s:", cat), keyboard)
+		return
+	case strings.HasPrefix(callbackData, "cat_rename_"):
+		cat := strings.TrimPrefix(callbackData, "cat_rename_")
+		status = StatusCategoryRename + cat
+		prompt = fmt.Sprintf("Введите новое название для категории %s:", cat)
+	case strings.HasPrefix(callbackData, "cat_icon_"):
+		cat := strings.TrimPrefix(callbackData, "cat_icon_")
+		status = StatusCategoryIcon + cat
+		prompt = fmt.Sprintf("Отправьте эмодзи для категории %s:", cat)
+	case strings.HasPrefix(callbackData, "cat_delete_"):
+		cat := strings.TrimPrefix(callbackData, "cat_delete_")
+		if err := b.Service.DeleteCategory(ctx, chatID, cat); err != nil {
+			b.logger.Error("Ошибка удаления категории", "error", err)
+			b.sendCategoryError(ctx, chatID, err)
+			return
+		}
+		b.SendMessage(ctx, chatID, fmt.Sprintf("🗑 Категория %s удалена", cat))
+		return
+	default:
+		return
 	}
+
+	if err := b.Service.SetStatus(ctx, chatID, status); err != nil {
+		b.logger.Error("Ошибка установки статуса", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+	b.sendTextPrompt(ctx, chatID, prompt)
+}
+
+// HandleLimitCallback обрабатывает выбор категории для установки лимита.
+// Ожидается формат "limit_<Category>"
+func (b *Bot) HandleLimitCallback(ctx context.Context, chatID int64, callbackData string) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	cat := strings.TrimPrefix(callbackData, StatusLimit)
+	err := b.Service.SetStatus(ctx, chatID, callbackData)
+	if err != nil {
+		b.logger.Error("Ошибка установки статуса", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+	b.sendTextPrompt(ctx, chatID, fmt.Sprintf("Введите месячный лимит для категории %s (0 - удалить лимит):", cat))
 }
 
 // handleExpenseCommand обрабатывает команду /expense
-func (b *Bot) handleExpenseCommand(chatID int64, page int) {
-	now := time.Now()
-	weekday := int(now.Weekday())
+func (b *Bot) handleExpenseCommand(ctx context.Context, chatID int64, page int) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	today, err := b.Service.Today(ctx, chatID)
+	if err != nil {
+		b.SendErrorMessage(ctx, chatID, "Ошибка при получении данных о расходах")
+		return
+	}
+	weekday := int(today.Weekday())
 	if weekday == 0 {
 		weekday = 7 // Воскресенье -> 7
 	}
 
 	// Вычисляем начало недели (понедельник) и сдвигаем на нужное количество недель
-	startOfWeek := now.AddDate(0, 0, -weekday+1-(page*7)).Truncate(24 * time.Hour)
+	startOfWeek := today.AddDate(0, 0, -weekday+1-(page*7))
 	endOfWeek := startOfWeek.AddDate(0, 0, 6)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
 	expenses, err := b.Service.GetExpenses(ctx, chatID, startOfWeek, endOfWeek)
 	if err != nil {
-		b.SendErrorMessage(chatID, "Ошибка при получении данных о расходах")
+		b.SendErrorMessage(ctx, chatID, "Ошибка при получении данных о расходах")
+		return
+	}
+
+	// Суммы по дням уже переведены в валюту бюджета
+	cur, err := b.Service.GetUserCurrency(ctx, chatID)
+	if err != nil {
+		b.SendErrorMessage(ctx, chatID, "Ошибка при получении данных о расходах")
 		return
 	}
 
@@ -57,86 +325,286 @@ func (b *Bot) handleExpenseCommand(chatID int64, page int) {
 
 	message := fmt.Sprintf(
 		"*Обзор расходов за неделю (%s - %s):*\n"+
-			"- Общая сумма: %.2f₽\n"+
-			"- Средний расход: %.2f₽\n"+
-			"- Макс. расход: %.2f₽ (%s)\n\n",
+			"- Общая сумма: %s\n"+
+			"- Средний расход: %s\n"+
+			"- Макс. расход: %s (%s)\n\n",
 		startOfWeek.Format("02.01.2006"), endOfWeek.Format("02.01.2006"),
-		totalSum, avgExpense, maxExpense, maxDate.Format("02.01.2006"),
+		currency.Format(totalSum, cur), currency.Format(avgExpense, cur), currency.Format(maxExpense, cur), maxDate.Format("02.01.2006"),
 	)
 
+	// Инлайн-кнопки для просмотра трат за день
+	inlineKeyboard := tu.InlineKeyboard()
 	for _, exp := range expenses {
-		message += fmt.Sprintf("📅 %s: %.2f₽\n", exp.Date.Format("02.01.2006"), exp.Amount)
+		message += fmt.Sprintf("📅 %s: %s\n", exp.Date.Format("02.01.2006"), currency.Format(exp.Amount, cur))
+		inlineKeyboard.InlineKeyboard = append(inlineKeyboard.InlineKeyboard, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(fmt.Sprintf("📅 %s: %s", exp.Date.Format("02.01"), currency.Format(exp.Amount, cur))).WithCallbackData("exp_day_"+exp.Date.Format(time.DateOnly)),
+		))
 	}
 
 	// Инлайн-кнопки для переключения недель
-	inlineKeyboard := tu.InlineKeyboard(
-		tu.InlineKeyboardRow(
-			tu.InlineKeyboardButton("⬅ Пред. неделя").WithCallbackData(fmt.Sprintf("expenses_page_%d", page+1)),
-			tu.InlineKeyboardButton("След. неделя ➡").WithCallbackData(fmt.Sprintf("expenses_page_%d", page-1)),
-		),
-	)
+	inlineKeyboard.InlineKeyboard = append(inlineKeyboard.InlineKeyboard, tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("⬅ Пред. неделя").WithCallbackData(fmt.Sprintf("expenses_page_%d", page+1)),
+		tu.InlineKeyboardButton("След. неделя ➡").WithCallbackData(fmt.Sprintf("expenses_page_%d", page-1)),
+	), statsKeyboardRow())
+
+	b.SendMessageWithKeyboard(ctx, chatID, message, inlineKeyboard)
+}
 
-	b.SendMessageWithKeyboard(chatID, message, inlineKeyboard)
+// handleFindPage отправляет страницу page результатов последнего поиска
+func (b *Bot) handleFindPage(ctx context.Context, chatID int64, page int) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := b.Service.SearchExpensesPage(ctx, chatID, page)
+	if err != nil {
+		b.logger.Error("Ошибка поиска расходов", "error", err)
+		b.sendSearchError(ctx, chatID, err)
+		return
+	}
+	b.sendSearchResults(ctx, chatID, result)
+}
+
+// handleExpenseDay отправляет траты за день с кнопками редактирования
+func (b *Bot) handleExpenseDay(ctx context.Context, chatID int64, day time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	expenses, err := b.Service.GetExpensesByPeriod(ctx, chatID, day, day)
+	if err != nil {
+		b.logger.Error("Ошибка получения трат за день", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Ошибка при получении данных о расходах")
+		return
+	}
+	if len(expenses) == 0 {
+		b.SendMessage(ctx, chatID, fmt.Sprintf("За %s расходов нет", day.Format("02.01.2006")))
+		return
+	}
+
+	message := fmt.Sprintf("Расходы за %s - нажмите, чтобы изменить:", day.Format("02.01.2006"))
+	b.SendMessageWithKeyboard(ctx, chatID, message, expenseKeyboard(expenses))
 }
 
 // calculateSummary вычисляет сводку расходов
-func calculateSummary(expenses []*service.ExpenseDTO) (total float64, avg float64, max float64, maxDate time.Time) {
+func calculateSummary(expenses []*service.ExpenseDTO) (total decimal.Decimal, avg decimal.Decimal, max decimal.Decimal, maxDate time.Time) {
 	if len(expenses) == 0 {
-		return 0, 0, 0, time.Time{}
+		return decimal.Zero, decimal.Zero, decimal.Zero, time.Time{}
 	}
 	for _, exp := range expenses {
-		total += exp.Amount
-		if exp.Amount > max {
+		total = total.Add(exp.Amount)
+		if exp.Amount.GreaterThan(max) {
 			max = exp.Amount
 			maxDate = exp.Date
 		}
 	}
-	avg = total / float64(len(expenses))
+	avg = total.Div(decimal.NewFromInt(int64(len(expenses))))
 	return total, avg, max, maxDate
 }
 
+// StartEditExpense открывает трату для редактирования.
+//
+// Состояние редактирования хранится в том же ExpenseEntry, что и запись нового расхода
+func (b *Bot) StartEditExpense(ctx context.Context, chatID int64, expenseID string) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	exp, err := b.Service.GetExpense(ctx, chatID, expenseID)
+	if err != nil {
+		b.logger.Error("Ошибка получения траты", "error", err)
+		b.sendExpenseError(ctx, chatID, err)
+		return
+	}
+
+	entry := &service.ExpenseEntryDTO{
+		ExpenseID: exp.ID,
+		Date:      exp.Date,
+		Amount:    exp.Amount,
+		Currency:  exp.Currency,
+		Category:  exp.Category,
+		Note:      exp.Description,
+		Step:      "edit",
+	}
+	if err := b.Service.SetExpenseStatus(ctx, chatID, entry); err != nil {
+		b.logger.Error("Ошибка установки статуса редактирования расхода", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+	b.sendEditMenu(ctx, chatID, entry)
+}
+
+// HandleEditExpenseCallback обрабатывает inline-кнопки редактирования расхода.
+func (b *Bot) HandleEditExpenseCallback(ctx context.Context, chatID int64, callbackData string) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	entry, err := b.Service.GetExpenseStatus(ctx, chatID)
+	if err != nil || entry == nil || entry.ExpenseID == "" {
+		b.logger.Error("Ошибка получения статуса редактирования расхода", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Редактирование устарело. Откройте расход заново")
+		return
+	}
+
+	switch {
+	case callbackData == "edit_amount":
+		entry.Step = "edit_amount"
+		b.setEditStep(ctx, chatID, entry, "Введите новую сумму (можно использовать математическое выражение):")
+	case callbackData == "edit_date":
+		entry.Step = "edit_date"
+		b.setEditStep(ctx, chatID, entry, "Введите новую дату в формате ДД.ММ.ГГГГ:")
+	case callbackData == "edit_note":
+		entry.Step = "edit_note"
+		b.setEditStep(ctx, chatID, entry, "Введите новое примечание (\"-\" - удалить примечание):")
+	case callbackData == "edit_category":
+		entry.Step = "edit_category"
+		if err := b.Service.SetExpenseStatus(ctx, chatID, entry); err != nil {
+			b.logger.Error("Ошибка установки статуса редактирования расхода", "error", err)
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+			return
+		}
+		userCategories, err := b.Service.GetUserCategories(ctx, chatID)
+		if err != nil {
+			b.logger.Error("Ошибка получения категорий", "error", err)
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз.")
+			return
+		}
+		keyboards := tu.InlineKeyboard()
+		for _, cat := range userCategories {
+			keyboards.InlineKeyboard = append(keyboards.InlineKeyboard, tu.InlineKeyboardRow(
+				tu.InlineKeyboardButton(cat.Icon+" "+cat.Name).WithCallbackData("edit_cat_"+cat.Name),
+			))
+		}
+		b.SendMessageWithKeyboard(ctx, chatID, "Выберите новую категорию:", keyboards)
+	case strings.HasPrefix(callbackData, "edit_cat_"):
+		entry.Category = strings.TrimPrefix(callbackData, "edit_cat_")
+		b.saveEditedExpense(ctx, chatID, entry)
+	case callbackData == "edit_delete":
+		keyboard := tu.InlineKeyboard(
+			tu.InlineKeyboardRow(
+				tu.InlineKeyboardButton("🗑 Да, удалить").WithCallbackData("edit_delete_confirm"),
+				tu.InlineKeyboardButton("Отмена").WithCallbackData("edit_back"),
+			),
+		)
+		b.SendMessageWithKeyboard(ctx, chatID, fmt.Sprintf("Удалить расход %s (%s) от %s?", currency.Format(entry.Amount, entry.Currency), entry.Category, entry.Date.Format("02.01.2006")), keyboard)
+	case callbackData == "edit_delete_confirm":
+		if err := b.Service.DeleteExpense(ctx, chatID, entry.ExpenseID); err != nil {
+			b.logger.Error("Ошибка удаления расхода", "error", err)
+			b.sendExpenseError(ctx, chatID, err)
+			return
+		}
+		b.Service.DeleteStatus(ctx, chatID)
+		b.SendMessage(ctx, chatID, "🗑 Расход удален")
+	case callbackData == "edit_back":
+		entry.Step = "edit"
+		if err := b.Service.SetExpenseStatus(ctx, chatID, entry); err != nil {
+			b.logger.Error("Ошибка установки статуса редактирования расхода", "error", err)
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+			return
+		}
+		b.sendEditMenu(ctx, chatID, entry)
+	case callbackData == "edit_done":
+		b.Service.DeleteStatus(ctx, chatID)
+		b.SendMessage(ctx, chatID, "✅ Редактирование завершено")
+	}
+}
+
+// setEditStep сохраняет шаг редактирования и запрашивает новое значение
+func (b *Bot) setEditStep(ctx context.Context, chatID int64, entry *service.ExpenseEntryDTO, prompt string) {
+	if err := b.Service.SetExpenseStatus(ctx, chatID, entry); err != nil {
+		b.logger.Error("Ошибка установки статуса редактирования расхода", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+	b.sendTextPrompt(ctx, chatID, prompt)
+}
+
+// saveEditedExpense сохраняет изменения траты и возвращает пользователя в меню редактирования
+func (b *Bot) saveEditedExpense(ctx context.Context, chatID int64, entry *service.ExpenseEntryDTO) {
+	err := b.Service.UpdateExpense(ctx, chatID, entry.ExpenseID, entry.Amount, entry.Currency, entry.Date, entry.Category, entry.Note)
+	if err != nil {
+		b.logger.Error("Ошибка обновления расхода", "error", err)
+		b.sendExpenseError(ctx, chatID, err)
+		return
+	}
+
+	entry.Step = "edit"
+	if err := b.Service.SetExpenseStatus(ctx, chatID, entry); err != nil {
+		b.logger.Error("Ошибка установки статуса редактирования расхода", "error", err)
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+		return
+	}
+	b.SendMessage(ctx, chatID, "✅ Расход обновлен")
+	b.sendEditMenu(ctx, chatID, entry)
+}
+
+// sendExpenseError отправляет понятное пользователю сообщение об ошибке работы с тратой
+func (b *Bot) sendExpenseError(ctx context.Context, chatID int64, err error) {
+	if errors.Is(err, expense.ErrorExpenseNotFound) {
+		b.SendErrorMessage(ctx, chatID, "Расход не найден")
+		return
+	}
+	if errors.Is(err, currency.ErrRateNotFound) {
+		b.SendErrorMessage(ctx, chatID, "Нет курса этой валюты к валюте бюджета или счета")
+		return
+	}
+	b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+}
+
 // HandleAddExpenseCallback обрабатывает inline-кнопки для записи расхода.
-func (b *Bot) HandleAddExpenseCallback(chatID int64, callbackData string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//
+// author - пользователь, нажавший кнопку, в групповом чате записывается автором траты
+func (b *Bot) HandleAddExpenseCallback(ctx context.Context, chatID int64, author service.AuthorDTO, callbackData string) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 	entry, err := b.Service.GetExpenseStatus(ctx, chatID)
 	if err != nil || entry == nil {
 		b.logger.Error("Ошибка получения статуса записи расхода", "error", err)
-		b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+		b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 		return
 	}
 
 	switch callbackData {
 	case "add_date_today":
-		entry.Date = time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 0, 0, 0, 0, time.UTC)
+		today, err := b.Service.Today(ctx, chatID)
+		if err != nil {
+			b.logger.Error("Ошибка получения даты пользователя", "error", err)
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+			return
+		}
+		entry.Date = today
 		entry.Step = "amount"
-		err := b.Service.SetExpenseStatus(ctx, chatID, entry)
+		err = b.Service.SetExpenseStatus(ctx, chatID, entry)
 		if err != nil {
 			b.logger.Error("Ошибка установки статуса записи расхода", "error", err)
-			b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 			return
 		}
-		b.sendAmountPrompt(chatID)
+		b.sendAmountPrompt(ctx, chatID)
 	case "add_date_custom":
 		// Просим ввести дату текстом.
 		entry.Step = "date_input"
 		err := b.Service.SetExpenseStatus(ctx, chatID, entry)
 		if err != nil {
 			b.logger.Error("Ошибка установки статуса записи расхода", "error", err)
-			b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+			b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 			return
 		}
-		b.sendTextPrompt(chatID, "Введите дату в формате ДД.ММ.ГГГГ (например, 23.03.2025):")
+		b.sendTextPrompt(ctx, chatID, "Введите дату в формате ДД.ММ.ГГГГ (например, 23.03.2025):")
 	default:
 		// Обработка выбора категории. Ожидается формат "add_category_<Category>"
 		if strings.HasPrefix(callbackData, "add_category_") {
 			cat := strings.TrimPrefix(callbackData, "add_category_")
 			entry.Category = cat
 			entry.Step = "note"
+			// Трата по чеку уже заполнена, после категории остается только подтвердить
+			if entry.Receipt != nil {
+				entry.Step = "confirm"
+			}
 			err := b.Service.SetExpenseStatus(ctx, chatID, entry)
 			if err != nil {
 				b.logger.Error("Ошибка установки статуса записи расхода", "error", err)
-				b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+				b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+				return
+			}
+			if entry.Receipt != nil {
+				b.sendConfirmation(ctx, chatID, entry)
 				return
 			}
 			// Предлагаем добавить примечание или пропустить
@@ -147,36 +615,119 @@ func (b *Bot) HandleAddExpenseCallback(chatID int64, callbackData string) {
 				),
 			)
 
-			b.SendMessageWithKeyboard(chatID, "Хотите добавить примечание?", keyboard)
+			b.SendMessageWithKeyboard(ctx, chatID, "Хотите добавить примечание?", keyboard)
 		} else if callbackData == "add_note" {
 			entry.Step = "note_input"
 			err := b.Service.SetExpenseStatus(ctx, chatID, entry)
 			if err != nil {
 				b.logger.Error("Ошибка установки статуса записи расхода", "error", err)
-				b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+				b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 				return
 			}
-			b.sendTextPrompt(chatID, "Введите примечание:")
+			b.sendTextPrompt(ctx, chatID, "Введите примечание:")
 		} else if callbackData == "add_skip_note" {
 			entry.Note = ""
-			entry.Step = "confirm"
+			entry.Step = "recurring"
 			err := b.Service.SetExpenseStatus(ctx, chatID, entry)
 			if err != nil {
 				b.logger.Error("Ошибка установки статуса записи расхода", "error", err)
-				b.SendErrorMessage(chatID, "Произошла ошибка. Попробуйте еще раз")
+				b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+				return
+			}
+			b.sendRecurrencePrompt(ctx, chatID, entry)
+		} else if strings.HasPrefix(callbackData, "add_recur_") {
+			// Выбор повторения. Ожидается формат "add_recur_<none|weekly|monthly>"
+			switch strings.TrimPrefix(callbackData, "add_recur_") {
+			case "weekly":
+				entry.Recurrence = expense.WeeklyRule(entry.Date.Weekday())
+			case "monthly":
+				entry.Recurrence = expense.MonthlyRule(entry.Date.Day())
+			default:
+				entry.Recurrence = ""
+			}
+			// Выбор счета, если у пользователя есть счета
+			accounts, err := b.Service.GetAccounts(ctx, chatID)
+			if err != nil {
+				b.logger.Error("Ошибка получения счетов", "error", err)
+				b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+				return
+			}
+			entry.Step = "confirm"
+			if len(accounts) > 0 {
+				entry.Step = "account"
+			}
+			if err := b.Service.SetExpenseStatus(ctx, chatID, entry); err != nil {
+				b.logger.Error("Ошибка установки статуса записи расхода", "error", err)
+				b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+				return
+			}
+			if len(accounts) > 0 {
+				b.sendAccountPrompt(ctx, chatID, accounts)
+				return
+			}
+			b.sendConfirmation(ctx, chatID, entry)
+		} else if strings.HasPrefix(callbackData, "add_account_") {
+			// Выбор счета. Ожидается формат "add_account_<ID>" или "add_account_none"
+			entry.AccountID, entry.Account = "", ""
+			if id := strings.TrimPrefix(callbackData, "add_account_"); id != "none" {
+				a, err := b.Service.GetAccount(ctx, chatID, id)
+				if err != nil {
+					b.logger.Error("Ошибка получения счета", "error", err)
+					b.SendErrorMessage(ctx, chatID, "Счет не найден. Попробуйте еще раз")
+					return
+				}
+				entry.AccountID, entry.Account = a.ID.String(), a.Name
+			}
+			entry.Step = "confirm"
+			if err := b.Service.SetExpenseStatus(ctx, chatID, entry); err != nil {
+				b.logger.Error("Ошибка установки статуса записи расхода", "error", err)
+				b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
 				return
 			}
-			b.sendConfirmation(chatID, entry)
+			b.sendConfirmation(ctx, chatID, entry)
 		} else if callbackData == "add_confirm" {
 			// Подтверждение записи расхода
-			if err := b.Service.AddExpense(ctx, chatID, entry.Amount, entry.Date, entry.Category, entry.Note); err != nil {
-				b.SendErrorMessage(chatID, "Ошибка записи расхода.")
+			alerts, err := b.Service.AddExpense(ctx, chatID, author, entry.Amount, entry.Currency, entry.Date, entry.Category, entry.Note, entry.Recurrence, entry.AccountID, entry.Splits, entry.Receipt)
+			if errors.Is(err, service.ErrBudgetAlerts) {
+				// Расход записан, не удалась только проверка порогов
+				b.logger.Error("Ошибка проверки порогов бюджета", "error", err)
+				err = nil
+			}
+			if err != nil {
+				b.logger.Error("Ошибка записи расхода", "error", err)
+				if errors.Is(err, currency.ErrRateNotFound) {
+					b.SendErrorMessage(ctx, chatID, fmt.Sprintf("Нет курса %s к валюте бюджета или счета. Расход не записан.", entry.Currency))
+				} else if errors.Is(err, expense.ErrDuplicateReceipt) {
+					b.SendErrorMessage(ctx, chatID, "Этот чек уже записан. Расход не записан.")
+				} else {
+					b.SendErrorMessage(ctx, chatID, "Ошибка записи расхода.")
+				}
 			} else {
-				b.SendMessage(chatID, "✅ Расход записан!")
+				b.SendMessage(ctx, chatID, "✅ Расход записан!")
+				b.sendBudgetAlerts(ctx, chatID, alerts)
 			}
 			b.Service.DeleteStatus(ctx, chatID)
+		} else if callbackData == "add_split" {
+			// Распределение траты по категориям
+			entry.Step = "split"
+			if err := b.Service.SetExpenseStatus(ctx, chatID, entry); err != nil {
+				b.logger.Error("Ошибка установки статуса записи расхода", "error", err)
+				b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+				return
+			}
+			b.sendTextPrompt(ctx, chatID, fmt.Sprintf("Введите части покупки через запятую: категория и сумма, например: Хозтовары 500, Одежда 800.\n"+
+				"Остаток останется в категории %s.", entry.Category))
+		} else if callbackData == "add_split_reset" {
+			entry.Splits = nil
+			entry.Step = "confirm"
+			if err := b.Service.SetExpenseStatus(ctx, chatID, entry); err != nil {
+				b.logger.Error("Ошибка установки статуса записи расхода", "error", err)
+				b.SendErrorMessage(ctx, chatID, "Произошла ошибка. Попробуйте еще раз")
+				return
+			}
+			b.sendConfirmation(ctx, chatID, entry)
 		} else if callbackData == "add_cancel" {
-			b.SendErrorMessage(chatID, "Запись отменена.")
+			b.SendErrorMessage(ctx, chatID, "Запись отменена.")
 			b.Service.DeleteStatus(ctx, chatID)
 		}
 	}
@@ -0,0 +1,157 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/SobolevTim/finance_bot/internal/domain/notification"
+	"github.com/SobolevTim/finance_bot/internal/service"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/shopspring/decimal"
+)
+
+// ProcessRecurringExpenses создает наступившие повторяющиеся расходы
+// и уведомляет пользователей о каждой записи
+//
+// Задача для планировщика, см. scheduler.Job
+func (b *Bot) ProcessRecurringExpenses(ctx context.Context) error {
+	created, err := b.Service.ProcessRecurringExpenses(ctx, b.Service.Now())
+	// Уведомляем о созданных записях даже при ошибке на одной из трат
+	for _, e := range created {
+		text := fmt.Sprintf("🔁 Записан повторяющийся расход (%s):\n📅 %s: %s - %s %s",
+			describeRecurrence(e.Recurrence),
+			e.Date.Format("02.01.2006"),
+			currency.Format(e.Amount, e.Currency),
+			e.CategoryIcon,
+			e.Category,
+		)
+		if e.Description != "" {
+			text += " - " + e.Description
+		}
+		b.SendMessage(ctx, e.TelegramID, text)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка обработки повторяющихся расходов: %w", err)
+	}
+	b.logger.Debug("Повторяющиеся расходы обработаны", "created", len(created))
+	return nil
+}
+
+// SendNotifications отправляет запланированные уведомления:
+// вечернее напоминание, обзор недели и итоги месяца
+//
+// Задача для планировщика, см. scheduler.Job
+func (b *Bot) SendNotifications(ctx context.Context) error {
+	notifications, err := b.Service.ProcessNotifications(ctx, b.Service.Now())
+	// Отправляем собранные уведомления даже при ошибке у одного из пользователей
+	for _, n := range notifications {
+		switch n.Kind {
+		case notification.KindDailyReminder:
+			b.SendMessage(ctx, n.TelegramID, "📝 Сегодня вы еще не записали ни одного расхода.\nНапишите, например, \"350 кофе\" или воспользуйтесь /add")
+		case notification.KindWeeklyDigest:
+			b.sendWeeklyDigest(ctx, n)
+		case notification.KindMonthlyReport:
+			b.sendMonthlyReport(ctx, n)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка обработки уведомлений: %w", err)
+	}
+	b.logger.Debug("Уведомления обработаны", "sent", len(notifications))
+	return nil
+}
+
+// RollBudgets создает бюджеты новых периодов по шаблону закончившихся
+// и сообщает пользователям о начале периода
+//
+// Задача для планировщика, см. scheduler.Job
+func (b *Bot) RollBudgets(ctx context.Context) error {
+	started, err := b.Service.RollBudgets(ctx, b.Service.Now())
+	// Сообщаем о созданных бюджетах даже при ошибке у одного из пользователей
+	for _, p := range started {
+		text := fmt.Sprintf("📅 Начался новый период бюджета %s - %s\nБюджет: %s",
+			p.StartDate.Format("02.01.2006"), p.EndDate.Format("02.01.2006"), currency.Format(p.Total, p.Currency))
+		if !p.CarryOver.IsZero() {
+			text += "\n" + formatCarryOver(p.CarryOver, p.Currency)
+		}
+		b.SendMessage(ctx, p.TelegramID, text)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка создания бюджетов новых периодов: %w", err)
+	}
+	b.logger.Debug("Бюджеты новых периодов созданы", "count", len(started))
+	return nil
+}
+
+// formatCarryOver описывает перенесенный из прошлого периода остаток или перерасход
+func formatCarryOver(amount decimal.Decimal, cur string) string {
+	if amount.IsNegative() {
+		return "Перерасход прошлого периода: " + currency.Format(amount.Neg(), cur)
+	}
+	return "Перенесено с прошлого периода: " + currency.Format(amount, cur)
+}
+
+// sendWeeklyDigest отправляет обзор трат за прошлую неделю в формате /expense
+func (b *Bot) sendWeeklyDigest(ctx context.Context, n *service.NotificationDTO) {
+	totalSum, avgExpense, maxExpense, maxDate := calculateSummary(n.Days)
+
+	message := fmt.Sprintf("📆 *Обзор расходов за неделю (%s - %s):*\n", n.StartDate.Format("02.01.2006"), n.EndDate.Format("02.01.2006"))
+	if len(n.Days) == 0 {
+		b.SendMessage(ctx, n.TelegramID, message+"Расходов не было")
+		return
+	}
+	message += fmt.Sprintf(
+		"- Общая сумма: %s\n"+
+			"- Средний расход: %s\n"+
+			"- Макс. расход: %s (%s)\n\n",
+		currency.Format(totalSum, n.Currency), currency.Format(avgExpense, n.Currency), currency.Format(maxExpense, n.Currency), maxDate.Format("02.01.2006"),
+	)
+	for _, exp := range n.Days {
+		message += fmt.Sprintf("📅 %s: %s\n", exp.Date.Format("02.01.2006"), currency.Format(exp.Amount, n.Currency))
+	}
+
+	// Прошлая неделя в /expense - первая страница назад
+	keyboard := tu.InlineKeyboard(tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("Подробнее").WithCallbackData("expenses_page_1"),
+	))
+	b.SendMessageWithKeyboard(ctx, n.TelegramID, message, keyboard)
+}
+
+// sendMonthlyReport отправляет итоги прошлого месяца
+func (b *Bot) sendMonthlyReport(ctx context.Context, n *service.NotificationDTO) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📊 Итоги месяца (%s - %s):\n", n.StartDate.Format("02.01.2006"), n.EndDate.Format("02.01.2006"))
+	fmt.Fprintf(&sb, "💵 Доходы: %s\n", currency.Format(n.Income, n.Currency))
+	fmt.Fprintf(&sb, "💸 Расходы: %s\n", currency.Format(n.Spent, n.Currency))
+	fmt.Fprintf(&sb, "🏦 Накопления: %s\n", currency.Format(n.Income.Sub(n.Spent), n.Currency))
+	if len(n.Categories) > 0 {
+		sb.WriteString("\nБольше всего потрачено:\n")
+		for _, c := range n.Categories {
+			fmt.Fprintf(&sb, "%s %s: %s\n", c.CategoryIcon, c.Category, currency.Format(c.Amount, n.Currency))
+		}
+	}
+	b.SendMessage(ctx, n.TelegramID, sb.String())
+}
+
+// ReportDispatcherStats пишет в лог метрики очереди обновлений.
+// Задача для планировщика
+func (b *Bot) ReportDispatcherStats(ctx context.Context) error {
+	d := b.dispatcher.Load()
+	if d == nil {
+		return nil
+	}
+	stats := d.Stats()
+	b.logger.Info("Очередь обновлений",
+		"workers", stats.Workers,
+		"queued", stats.Queued,
+		"maxQueue", stats.MaxQueue,
+		"queueSize", stats.QueueSize,
+		"inFlight", stats.InFlight,
+		"processed", stats.Processed,
+		"dropped", stats.Dropped,
+		"panics", stats.Panics,
+	)
+	return nil
+}
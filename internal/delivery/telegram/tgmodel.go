@@ -2,4 +2,17 @@ package telegram
 
 var (
 	StatusBudget = "budget" // Статус установки бюджета "budget"
+	StatusLimit  = "limit_" // Префикс статуса установки лимита категории "limit_<Категория>"
+	StatusIncome = "income" // Статус записи дохода "income"
+
+	StatusCategoryNew    = "category_new"     // Статус создания категории "category_new"
+	StatusCategoryRename = "category_rename_" // Префикс статуса переименования категории "category_rename_<Категория>"
+	StatusCategoryIcon   = "category_icon_"   // Префикс статуса смены иконки категории "category_icon_<Категория>"
+
+	StatusExportRange   = "export_range"   // Статус ввода периода выгрузки "export_range"
+	StatusImportMapping = "import_mapping" // Статус ввода колонок выписки "import_mapping"
+)
+
+const (
+	maxCallbackDataLength = 64 // Ограничение Telegram на длину callback_data в байтах
 )
@@ -0,0 +1,130 @@
+package account
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	MaxNameLength        = 100
+	ErrAccountNotFound   = errors.New("account not found")
+	ErrEmptyName         = errors.New("account name cannot be empty")
+	ErrNameTooLong       = errors.New("account name exceeds maximum length")
+	ErrDuplicateName     = errors.New("account with this name already exists")
+	ErrInvalidType       = errors.New("invalid account type")
+	ErrSameAccount       = errors.New("cannot transfer to the same account")
+	ErrNonPositiveAmount = errors.New("transfer amount must be positive")
+)
+
+// Типы счетов
+const (
+	TypeCash    = "cash"    // Наличные
+	TypeDebit   = "debit"   // Дебетовая карта
+	TypeCredit  = "credit"  // Кредитная карта, баланс может быть отрицательным
+	TypeSavings = "savings" // Накопительный счет
+)
+
+// typeAliases - названия типов, которые можно указать в команде
+var typeAliases = map[string]string{
+	"cash":          TypeCash,
+	"наличные":      TypeCash,
+	"нал":           TypeCash,
+	"debit":         TypeDebit,
+	"дебет":         TypeDebit,
+	"дебетовая":     TypeDebit,
+	"карта":         TypeDebit,
+	"credit":        TypeCredit,
+	"кредит":        TypeCredit,
+	"кредитка":      TypeCredit,
+	"кредитная":     TypeCredit,
+	"savings":       TypeSavings,
+	"накопления":    TypeSavings,
+	"накопительный": TypeSavings,
+	"вклад":         TypeSavings,
+}
+
+// typeTitles - названия типов для сообщений
+var typeTitles = map[string]string{
+	TypeCash:    "💵 наличные",
+	TypeDebit:   "💳 дебетовая карта",
+	TypeCredit:  "💳 кредитная карта",
+	TypeSavings: "🏦 накопительный счет",
+}
+
+// Account - счет пользователя: наличные, карта или накопительный счет
+type Account struct {
+	ID        uuid.UUID       // ID счета
+	UserID    uuid.UUID       // ID пользователя
+	Name      string          // Название счета: "Тинькофф", "Кошелек"
+	Type      string          // Тип счета
+	Currency  string          // Валюта счета (ISO 4217)
+	Balance   decimal.Decimal // Текущий баланс в валюте счета
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// New создает новый счет с валидацией
+func New(userID uuid.UUID, name, accountType string, balance decimal.Decimal) (*Account, error) {
+	name = strings.TrimSpace(name)
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	if _, ok := typeTitles[accountType]; !ok {
+		return nil, ErrInvalidType
+	}
+
+	return &Account{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Type:      accountType,
+		Currency:  currency.Default,
+		Balance:   balance,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}, nil
+}
+
+// SetCurrency устанавливает валюту счета.
+// Принимает код, символ или название валюты: "USD", "$", "евро"
+func (a *Account) SetCurrency(code string) error {
+	code, err := currency.Normalize(code)
+	if err != nil {
+		return err
+	}
+	a.Currency = code
+	return nil
+}
+
+// ParseType возвращает тип счета по названию из команды: "карта", "наличные", "вклад"...
+func ParseType(text string) (string, error) {
+	t, ok := typeAliases[strings.ToLower(strings.TrimSpace(text))]
+	if !ok {
+		return "", ErrInvalidType
+	}
+	return t, nil
+}
+
+// TypeTitle возвращает название типа счета для сообщений
+func TypeTitle(accountType string) string {
+	if title, ok := typeTitles[accountType]; ok {
+		return title
+	}
+	return accountType
+}
+
+func validateName(name string) error {
+	if name == "" {
+		return ErrEmptyName
+	}
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return ErrNameTooLong
+	}
+	return nil
+}
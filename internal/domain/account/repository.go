@@ -0,0 +1,17 @@
+package account
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Repository определяет методы для работы со счетами и переводами
+type Repository interface {
+	AccountCreate(ctx context.Context, account *Account) error
+	AccountGet(ctx context.Context, id uuid.UUID) (*Account, error)
+	AccountsGetByUser(ctx context.Context, userID uuid.UUID) ([]*Account, error)
+	AccountAdjustBalance(ctx context.Context, id uuid.UUID, delta decimal.Decimal) error
+	TransferCreate(ctx context.Context, transfer *Transfer) error
+}
@@ -0,0 +1,48 @@
+package account
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Transfer - перевод между счетами пользователя.
+//
+// Перевод меняет балансы счетов, но не считается тратой
+type Transfer struct {
+	ID        uuid.UUID       // ID перевода
+	UserID    uuid.UUID       // ID пользователя
+	FromID    uuid.UUID       // Счет списания
+	ToID      uuid.UUID       // Счет зачисления
+	Amount    decimal.Decimal // Сумма списания в валюте счета списания
+	ToAmount  decimal.Decimal // Сумма зачисления в валюте счета зачисления
+	Date      time.Time       // Дата перевода
+	CreatedAt time.Time
+}
+
+// NewTransfer создает перевод со счета from на счет to.
+//
+// toAmount - сумма в валюте счета to, для счетов в одной валюте равна amount
+func NewTransfer(from, to *Account, amount, toAmount decimal.Decimal, date time.Time) (*Transfer, error) {
+	if from.ID == to.ID {
+		return nil, ErrSameAccount
+	}
+	if from.UserID != to.UserID {
+		return nil, ErrAccountNotFound
+	}
+	if !amount.IsPositive() || !toAmount.IsPositive() {
+		return nil, ErrNonPositiveAmount
+	}
+
+	return &Transfer{
+		ID:        uuid.New(),
+		UserID:    from.UserID,
+		FromID:    from.ID,
+		ToID:      to.ID,
+		Amount:    amount,
+		ToAmount:  toAmount,
+		Date:      date,
+		CreatedAt: time.Now(),
+	}, nil
+}
@@ -14,9 +14,12 @@ var (
 	ErrCategoryNotFound      = errors.New("category not found in budget")
 	ErrCategoryLimitExceeded = errors.New("category limit exceeded")
 	ErrUserNotFound          = errors.New("user not found")
+	ErrBudgetNotFound        = errors.New("budget not found")
 )
 
-// Budget представляет собой месячный бюджет пользователя
+// Budget представляет собой бюджет пользователя на период.
+//
+// Бюджет последнего периода служит шаблоном для следующего, см. Next
 type Budget struct {
 	ID         uuid.UUID
 	UserID     uuid.UUID
@@ -24,6 +27,11 @@ type Budget struct {
 	Currency   string
 	StartDate  time.Time
 	EndDate    time.Time
+	FromIncome bool            // Сумма бюджета равна доходам за месяц
+	Period     string          // Период: PeriodMonthly или PeriodBiweekly
+	StartDay   int             // День месяца, с которого начинается месячный период
+	Rollover   bool            // Переносить остаток или перерасход в следующий период
+	CarryOver  decimal.Decimal // Остаток, перенесенный из прошлого периода, отрицательный - перерасход
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 	Categories map[uuid.UUID]decimal.Decimal // Лимиты по категориям
@@ -46,12 +54,19 @@ func New(userID uuid.UUID, amount decimal.Decimal, currency string, startDate, e
 		Currency:   currency,
 		StartDate:  startDate,
 		EndDate:    endDate,
+		Period:     PeriodMonthly,
+		StartDay:   1,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 		Categories: make(map[uuid.UUID]decimal.Decimal),
 	}, nil
 }
 
+// Total возвращает сумму бюджета с учетом перенесенного остатка
+func (b *Budget) Total() decimal.Decimal {
+	return b.Amount.Add(b.CarryOver)
+}
+
 // IsActive проверяет, активен ли бюджет на текущий момент
 func (b *Budget) IsActive(now time.Time) bool {
 	return !now.Before(b.StartDate) && !now.After(b.EndDate)
@@ -65,3 +80,23 @@ func (b *Budget) AddCategory(categoryID uuid.UUID, limit decimal.Decimal) error
 	b.Categories[categoryID] = limit
 	return nil
 }
+
+// RemoveCategory удаляет лимит для категории
+func (b *Budget) RemoveCategory(categoryID uuid.UUID) {
+	delete(b.Categories, categoryID)
+}
+
+// CheckCategoryLimit сравнивает сумму трат по категории с её лимитом
+//
+// Возвращает ErrCategoryNotFound, если лимит для категории не задан,
+// и ErrCategoryLimitExceeded, если траты превысили лимит
+func (b *Budget) CheckCategoryLimit(categoryID uuid.UUID, spent decimal.Decimal) error {
+	limit, ok := b.Categories[categoryID]
+	if !ok {
+		return ErrCategoryNotFound
+	}
+	if spent.GreaterThan(limit) {
+		return ErrCategoryLimitExceeded
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package budget
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	PeriodMonthly  = "monthly"  // Месяц, начиная с дня StartDay
+	PeriodBiweekly = "biweekly" // Две недели
+)
+
+// biweeklyDays - длина двухнедельного периода в днях
+const biweeklyDays = 14
+
+// MaxStartDay - последний день, с которого может начинаться месячный период,
+// чтобы он был в каждом месяце
+const MaxStartDay = 28
+
+var (
+	ErrInvalidPeriod   = errors.New("unknown budget period")
+	ErrInvalidStartDay = errors.New("start day must be from 1 to 28")
+)
+
+// ParsePeriod разбирает период бюджета: "месяц", "10" или "месяц 10" - месяц с 10 числа,
+// "2 недели" - две недели.
+// Возвращает период и день начала месячного периода
+func ParsePeriod(text string) (string, int, error) {
+	fields := strings.Fields(strings.ToLower(text))
+	switch strings.Join(fields, "") {
+	case "":
+		return "", 0, ErrInvalidPeriod
+	case "2недели", "двенедели", "2w", "biweekly":
+		return PeriodBiweekly, 1, nil
+	case "месяц", "month", "monthly":
+		return PeriodMonthly, 1, nil
+	}
+
+	if len(fields) == 2 && (fields[0] == "месяц" || fields[0] == "month" || fields[0] == "monthly") {
+		fields = fields[1:]
+	}
+	if len(fields) != 1 {
+		return "", 0, ErrInvalidPeriod
+	}
+	day, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return "", 0, ErrInvalidPeriod
+	}
+	if day < 1 || day > MaxStartDay {
+		return "", 0, ErrInvalidStartDay
+	}
+	return PeriodMonthly, day, nil
+}
+
+// SetPeriod меняет период бюджета.
+//
+// Начало текущего бюджета сохраняется, а конец переносится на конец нового периода,
+// в который входит дата today. Так между бюджетами не остается пропусков
+func (b *Budget) SetPeriod(period string, startDay int, today time.Time) error {
+	switch period {
+	case PeriodMonthly:
+		if startDay < 1 || startDay > MaxStartDay {
+			return ErrInvalidStartDay
+		}
+	case PeriodBiweekly:
+		startDay = 1
+	default:
+		return ErrInvalidPeriod
+	}
+
+	b.Period = period
+	b.StartDay = startDay
+	if today.Before(b.StartDate) {
+		today = b.StartDate
+	}
+	b.EndDate = b.periodEndAfter(b.StartDate, today)
+	return nil
+}
+
+// Next создает бюджет следующего периода по шаблону текущего.
+//
+// spent - траты за текущий период в валюте бюджета. Если включен перенос,
+// остаток или перерасход переходит в следующий период. now - время создания бюджета
+func (b *Budget) Next(spent decimal.Decimal, now time.Time) *Budget {
+	start := b.EndDate.AddDate(0, 0, 1)
+	next := &Budget{
+		ID:         uuid.New(),
+		UserID:     b.UserID,
+		Amount:     b.Amount,
+		Currency:   b.Currency,
+		StartDate:  start,
+		FromIncome: b.FromIncome,
+		Period:     b.Period,
+		StartDay:   b.StartDay,
+		Rollover:   b.Rollover,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Categories: make(map[uuid.UUID]decimal.Decimal, len(b.Categories)),
+	}
+	for id, limit := range b.Categories {
+		next.Categories[id] = limit
+	}
+	next.EndDate = next.periodEndAfter(start, start)
+	if b.Rollover {
+		next.CarryOver = b.Total().Sub(spent)
+	}
+	return next
+}
+
+// PeriodTitle возвращает описание периода бюджета
+func (b *Budget) PeriodTitle() string {
+	if b.Period == PeriodBiweekly {
+		return "каждые 2 недели"
+	}
+	if b.StartDay <= 1 {
+		return "календарный месяц"
+	}
+	return "месяц с " + strconv.Itoa(b.StartDay) + " числа"
+}
+
+// periodEndAfter возвращает конец периода, в который входит дата date.
+// Конец всегда позже start, иначе бюджет из одного дня нарушил бы ограничение end_date > start_date
+func (b *Budget) periodEndAfter(start, date time.Time) time.Time {
+	end := b.periodEnd(start, date)
+	if !end.After(start) {
+		end = b.periodEnd(start, end.AddDate(0, 0, 1))
+	}
+	return end
+}
+
+// periodEnd возвращает последний день периода, в который входит дата date.
+// Двухнедельные периоды отсчитываются от anchor
+func (b *Budget) periodEnd(anchor, date time.Time) time.Time {
+	if b.Period == PeriodBiweekly {
+		days := int(date.Sub(anchor).Hours() / 24)
+		if days < 0 {
+			days = 0
+		}
+		return anchor.AddDate(0, 0, (days/biweeklyDays+1)*biweeklyDays-1)
+	}
+
+	day := b.StartDay
+	if day < 1 {
+		day = 1
+	}
+	start := time.Date(date.Year(), date.Month(), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() < day {
+		start = start.AddDate(0, -1, 0)
+	}
+	return start.AddDate(0, 1, -1)
+}
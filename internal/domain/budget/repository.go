@@ -2,16 +2,25 @@ package budget
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 // Repository определяет методы для работы с бюджетами
 type Repository interface {
 	BudgetCreate(ctx context.Context, budget *Budget) error
 	BudgetGetByID(ctx context.Context, id uuid.UUID) (*Budget, error)
-	BudgetGetByTgID(ctx context.Context, tgID string) (*Budget, error)
-	BudgetGetCurrent(ctx context.Context, userID uuid.UUID) (*Budget, error)
+	BudgetGetByTgID(ctx context.Context, tgID string, date time.Time) (*Budget, error)
+	BudgetGetCurrent(ctx context.Context, userID uuid.UUID, date time.Time) (*Budget, error)
 	BudgetUpdate(ctx context.Context, budget *Budget) error
 	BudgetDelete(ctx context.Context, id uuid.UUID) error
+	BudgetSetCategoryLimit(ctx context.Context, budgetID, categoryID uuid.UUID, limit decimal.Decimal) error
+	BudgetDeleteCategoryLimit(ctx context.Context, budgetID, categoryID uuid.UUID) error
+	BudgetAlertAdd(ctx context.Context, budgetID, categoryID uuid.UUID, threshold int) (bool, error)
+	BudgetCreateNext(ctx context.Context, budget *Budget) (bool, error)
+	BudgetGetLatest(ctx context.Context, userID uuid.UUID) (*Budget, error)
+	BudgetsGetEnded(ctx context.Context, date time.Time) ([]*Budget, error)
+	BudgetsGetHistory(ctx context.Context, userID uuid.UUID, date time.Time, limit int) ([]*Budget, error)
 }
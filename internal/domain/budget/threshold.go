@@ -0,0 +1,73 @@
+package budget
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidThreshold - порог вне диапазона 1..100
+var ErrInvalidThreshold = errors.New("threshold must be a percent from 1 to 100")
+
+// ThresholdOver - порог перерасхода: траты больше лимита.
+// Отслеживается всегда, независимо от настроек пользователя
+const ThresholdOver = 101
+
+// DefaultThresholds - пороги предупреждений в процентах по умолчанию
+var DefaultThresholds = []int{50, 80, 100}
+
+// ParseThresholds разбирает пороги предупреждений из строки вида "50 80 100" или "50%, 90%".
+// Возвращает пороги по возрастанию без повторов
+func ParseThresholds(text string) ([]int, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ' ' || r == ',' || r == ';'
+	})
+	if len(fields) == 0 {
+		return nil, ErrInvalidThreshold
+	}
+
+	seen := make(map[int]bool, len(fields))
+	thresholds := make([]int, 0, len(fields))
+	for _, f := range fields {
+		pct, err := strconv.Atoi(strings.TrimSuffix(f, "%"))
+		if err != nil || pct < 1 || pct > 100 {
+			return nil, ErrInvalidThreshold
+		}
+		if !seen[pct] {
+			seen[pct] = true
+			thresholds = append(thresholds, pct)
+		}
+	}
+	sort.Ints(thresholds)
+	return thresholds, nil
+}
+
+// CrossedThresholds возвращает пороги, которые пересекли траты spent при лимите limit.
+//
+// Порог pct пересечен, если spent >= limit * pct%; ThresholdOver - если spent > limit.
+// Результат по возрастанию, ThresholdOver - последний
+func CrossedThresholds(spent, limit decimal.Decimal, thresholds []int) []int {
+	if !limit.IsPositive() {
+		return nil
+	}
+	crossed := make([]int, 0, len(thresholds)+1)
+	for _, pct := range thresholds {
+		if spent.Mul(decimal.NewFromInt(100)).GreaterThanOrEqual(limit.Mul(decimal.NewFromInt(int64(pct)))) {
+			crossed = append(crossed, pct)
+		}
+	}
+	if spent.GreaterThan(limit) {
+		crossed = append(crossed, ThresholdOver)
+	}
+	return crossed
+}
+
+// CategoryLimit возвращает лимит категории и признак, что он задан
+func (b *Budget) CategoryLimit(categoryID uuid.UUID) (decimal.Decimal, bool) {
+	limit, ok := b.Categories[categoryID]
+	return limit, ok
+}
@@ -0,0 +1,72 @@
+package categories
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+var ErrEmptyAlias = errors.New("category alias cannot be empty")
+
+// minPrefixLength - минимальная длина ключевого слова для совпадения по началу слова:
+// "кофе" совпадает с "кофейня", а "газ" - только с "газ"
+const minPrefixLength = 4
+
+// Keywords - ключевые слова базовых категорий для быстрой записи трат
+var Keywords = map[string][]string{
+	"Еда":         {"еда", "кофе", "обед", "ужин", "завтрак", "продукты", "магазин", "кафе", "ресторан", "пицца", "перекус", "шаурма", "доставка", "столовая"},
+	"Транспорт":   {"такси", "метро", "автобус", "бензин", "проезд", "парковка", "электричка", "трамвай", "каршеринг", "заправка"},
+	"Коммуналка":  {"коммуналка", "жкх", "свет", "газ", "вода", "квартплата", "интернет", "электричество", "связь"},
+	"Здоровье":    {"аптека", "лекарства", "врач", "стоматолог", "анализы", "таблетки", "клиника"},
+	"Одежда":      {"одежда", "обувь", "куртка", "джинсы", "кроссовки", "футболка", "платье"},
+	"Развлечения": {"кино", "театр", "концерт", "бар", "игры", "подписка", "музей"},
+	"Спорт":       {"спорт", "зал", "фитнес", "бассейн", "тренировка", "абонемент"},
+	"Подарки":     {"подарок", "подарки", "цветы"},
+	"Прочее":      {"прочее"},
+}
+
+// NormalizeAlias приводит псевдоним категории к виду для хранения и сравнения
+func NormalizeAlias(alias string) string {
+	return strings.ToLower(strings.TrimSpace(alias))
+}
+
+// MatchWord ищет категорию по слову.
+//
+// Приоритет: псевдоним пользователя, название категории, ключевое слово.
+// aliases - псевдонимы пользователя (NormalizeAlias(слово) -> ID категории).
+// Возвращает nil, если совпадений нет
+func MatchWord(word string, list []*Categories, aliases map[string]uuid.UUID) *Categories {
+	word = NormalizeAlias(word)
+	if word == "" {
+		return nil
+	}
+
+	if id, ok := aliases[word]; ok {
+		for _, c := range list {
+			if c.ID == id {
+				return c
+			}
+		}
+	}
+
+	for _, c := range list {
+		if strings.ToLower(c.Name) == word {
+			return c
+		}
+	}
+
+	for _, c := range list {
+		if !c.IsDefault {
+			continue
+		}
+		for _, kw := range Keywords[c.Name] {
+			if word == kw || (utf8.RuneCountInString(kw) >= minPrefixLength && strings.HasPrefix(word, kw)) {
+				return c
+			}
+		}
+	}
+
+	return nil
+}
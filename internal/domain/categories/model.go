@@ -9,8 +9,10 @@ import (
 
 var (
 	MaxNameLength            = 100
+	MaxIconLength            = 50
 	DefaultIcon              = "📁"
 	ErrNameTooLong           = errors.New("category name exceeds maximum length")
+	ErrIconTooLong           = errors.New("category icon exceeds maximum length")
 	ErrCategoryNotFound      = errors.New("category not found")
 	ErrEmptyName             = errors.New("category name cannot be empty")
 	ErrDuplicateName         = errors.New("category with this name already exists")
@@ -46,6 +48,39 @@ func New(userID uuid.UUID, name string, isDefault bool) (*Categories, error) {
 	}, nil
 }
 
+// Rename переименовывает пользовательскую категорию
+func (c *Categories) Rename(name string) error {
+	if c.IsDefault {
+		return ErrUpdateDefaultCategory
+	}
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	c.Name = name
+	c.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// SetIcon меняет иконку пользовательской категории
+//
+// Пустая иконка заменяется на DefaultIcon
+func (c *Categories) SetIcon(icon string) error {
+	if c.IsDefault {
+		return ErrUpdateDefaultCategory
+	}
+	if icon == "" {
+		icon = DefaultIcon
+	}
+	if len(icon) > MaxIconLength {
+		return ErrIconTooLong
+	}
+
+	c.Icon = icon
+	c.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
 func validateName(name string) error {
 	if name == "" {
 		return ErrEmptyName
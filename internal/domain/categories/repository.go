@@ -13,4 +13,10 @@ type Repository interface {
 	CategoriesGetForUser(ctx context.Context, userID uuid.UUID) ([]*Categories, error)
 	CategoriesGetDefaults(ctx context.Context) ([]*Categories, error)
 	CategoriesGetDefaultsByName(ctx context.Context, name string) (*Categories, error)
+	CategoriesGetByName(ctx context.Context, userID uuid.UUID, name string) (*Categories, error)
+	CategoriesUpdate(ctx context.Context, category *Categories) error
+	CategoriesDelete(ctx context.Context, id uuid.UUID) error
+	CategoriesIsUsed(ctx context.Context, id uuid.UUID) (bool, error)
+	CategoriesAliasSet(ctx context.Context, userID, categoryID uuid.UUID, alias string) error
+	CategoriesAliasGetForUser(ctx context.Context, userID uuid.UUID) (map[string]uuid.UUID, error)
 }
@@ -0,0 +1,163 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/shopspring/decimal"
+)
+
+// Default - валюта по умолчанию для бюджетов и трат
+const Default = "RUB"
+
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrRateNotFound    = errors.New("exchange rate not found")
+)
+
+// symbols - поддерживаемые валюты ISO 4217 и их символы
+var symbols = map[string]string{
+	"RUB": "₽",
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"CNY": "¥",
+	"JPY": "JP¥",
+	"KZT": "₸",
+	"BYN": "Br",
+	"UAH": "₴",
+	"TRY": "₺",
+	"GEL": "₾",
+	"AMD": "֏",
+	"AED": "AED",
+	"THB": "฿",
+}
+
+// minorDigits - валюты, у которых дробная часть суммы не из двух знаков. Иены не делятся на сены
+var minorDigits = map[string]int32{
+	"JPY": 0,
+}
+
+// aliases - символы и названия валют, которые пользователи пишут вместо кода
+var aliases = map[string]string{
+	"₽":     "RUB",
+	"р":     "RUB",
+	"руб":   "RUB",
+	"$":     "USD",
+	"долл":  "USD",
+	"€":     "EUR",
+	"евро":  "EUR",
+	"£":     "GBP",
+	"¥":     "CNY",
+	"юань":  "CNY",
+	"₸":     "KZT",
+	"тенге": "KZT",
+	"₴":     "UAH",
+	"₺":     "TRY",
+	"лир":   "TRY",
+	"₾":     "GEL",
+	"лари":  "GEL",
+	"֏":     "AMD",
+	"драм":  "AMD",
+	"฿":     "THB",
+	"бат":   "THB",
+}
+
+// Normalize приводит код, символ или название валюты к коду ISO 4217: "usd", "$" -> "USD"
+func Normalize(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if code, ok := aliases[strings.ToLower(strings.TrimSuffix(s, "."))]; ok {
+		return code, nil
+	}
+	code := strings.ToUpper(s)
+	if _, ok := symbols[code]; ok {
+		return code, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownCurrency, s)
+}
+
+// IsCurrency проверяет, что слово обозначает поддерживаемую валюту
+func IsCurrency(s string) bool {
+	_, err := Normalize(s)
+	return err == nil
+}
+
+// Symbol возвращает символ валюты или ее код, если символа нет
+func Symbol(code string) string {
+	if s, ok := symbols[code]; ok {
+		return s
+	}
+	return code
+}
+
+// Decimals возвращает число знаков после запятой в суммах валюты: 2, у иены 0
+func Decimals(code string) int32 {
+	if d, ok := minorDigits[code]; ok {
+		return d
+	}
+	return 2
+}
+
+// Round округляет сумму до наименьшей единицы валюты: копеек, центов или целых иен.
+// Половина округляется от нуля: 0.005₽ -> 0.01₽
+func Round(amount decimal.Decimal, code string) decimal.Decimal {
+	if code == "" {
+		code = Default
+	}
+	return amount.Round(Decimals(code))
+}
+
+// Format форматирует сумму с символом валюты: "350.00₽", "20.00$", "350JP¥"
+func Format(amount decimal.Decimal, code string) string {
+	if code == "" {
+		code = Default
+	}
+	return amount.StringFixed(Decimals(code)) + Symbol(code)
+}
+
+// SplitAmount отделяет валюту от суммы: "20 USD", "20usd", "$20", "20$" -> "20", "USD".
+//
+// Если валюта не указана, возвращает текст без изменений и пустой код
+func SplitAmount(text string) (string, string) {
+	text = strings.TrimSpace(text)
+
+	// Валюта отдельным словом: "20 USD", "USD 20"
+	if fields := strings.Fields(text); len(fields) > 1 {
+		if code, err := Normalize(fields[len(fields)-1]); err == nil {
+			return strings.Join(fields[:len(fields)-1], " "), code
+		}
+		if code, err := Normalize(fields[0]); err == nil {
+			return strings.Join(fields[1:], " "), code
+		}
+	}
+
+	// Валюта слитно с числом: "20usd", "20$", "$20"
+	end := strings.LastIndexFunc(text, isAmountRune)
+	if end >= 0 && end < len(text)-1 {
+		_, size := firstRune(text[end:])
+		if code, err := Normalize(text[end+size:]); err == nil {
+			return strings.TrimSpace(text[:end+size]), code
+		}
+	}
+	start := strings.IndexFunc(text, isAmountRune)
+	if start > 0 {
+		if code, err := Normalize(text[:start]); err == nil {
+			return strings.TrimSpace(text[start:]), code
+		}
+	}
+	return text, ""
+}
+
+// isAmountRune - символы суммы и выражения
+func isAmountRune(r rune) bool {
+	return unicode.IsDigit(r) || strings.ContainsRune("+-*/()^.,%", r)
+}
+
+func firstRune(s string) (rune, int) {
+	for _, r := range s {
+		return r, len(string(r))
+	}
+	return 0, 0
+}
@@ -0,0 +1,54 @@
+package currency
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Rates - курсы валют на дату относительно базовой валюты:
+// Values["USD"] - сколько единиц Base стоит один доллар
+type Rates struct {
+	Base   string
+	Date   time.Time
+	Values map[string]decimal.Decimal
+}
+
+// Rate возвращает курс from -> to: сколько единиц to стоит одна единица from.
+// Курс между двумя небазовыми валютами считается через базовую
+func (r *Rates) Rate(from, to string) (decimal.Decimal, error) {
+	fromRate, ok := r.value(from)
+	if !ok {
+		return decimal.Zero, ErrRateNotFound
+	}
+	toRate, ok := r.value(to)
+	if !ok {
+		return decimal.Zero, ErrRateNotFound
+	}
+	return fromRate.DivRound(toRate, 8), nil
+}
+
+func (r *Rates) value(code string) (decimal.Decimal, bool) {
+	if code == r.Base {
+		return decimal.NewFromInt(1), true
+	}
+	v, ok := r.Values[code]
+	if !ok || !v.IsPositive() {
+		return decimal.Zero, false
+	}
+	return v, true
+}
+
+// Convert переводит сумму из валюты from в валюту to по курсу на дату date
+// и округляет результат до наименьшей единицы валюты to
+func Convert(ctx context.Context, p RateProvider, amount decimal.Decimal, from, to string, date time.Time) (decimal.Decimal, error) {
+	if from == to || amount.IsZero() {
+		return amount, nil
+	}
+	rate, err := p.ExchangeRate(ctx, from, to, date)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return Round(amount.Mul(rate), to), nil
+}
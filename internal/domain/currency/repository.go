@@ -0,0 +1,15 @@
+package currency
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// RateProvider - источник курсов валют
+type RateProvider interface {
+	// ExchangeRate возвращает курс from -> to на дату date: сколько единиц to стоит одна единица from.
+	// Используется последний известный курс не позже date, иначе ErrRateNotFound
+	ExchangeRate(ctx context.Context, from, to string, date time.Time) (decimal.Decimal, error)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 )
@@ -18,31 +19,79 @@ type Expense struct {
 	UserID         uuid.UUID       // ID пользователя
 	CategoryID     uuid.UUID       // ID категории
 	Ammount        decimal.Decimal // Сумма траты
+	Currency       string          // Валюта траты (ISO 4217)
 	Date           time.Time       // Дата траты
 	IsRecurring    bool            // Повторяющаяся траты
 	RecurrenceRule string          // Правило повторения
 	Description    string          // Описание траты
-	//CardID 	   uuid.UUID 	   // TODO: добавить поле для карты
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ParentID       uuid.UUID       // ID повторяющейся траты, из которой создана эта (uuid.Nil, если нет)
+	AuthorID       uuid.UUID       // ID пользователя, записавшего трату (uuid.Nil, если неизвестен)
+	AccountID      uuid.UUID       // ID счета, с которого оплачена трата (uuid.Nil, если не указан)
+	Splits         []*Split        // Распределение по категориям, пустое - вся трата в CategoryID
+	Receipt        *Receipt        // Кассовый чек, по которому записана трата (nil, если записана вручную)
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// BalanceChange - изменение баланса счета, которое записывается в одной транзакции с тратой
+type BalanceChange struct {
+	AccountID uuid.UUID       // ID счета
+	Delta     decimal.Decimal // Изменение баланса в валюте счета
 }
 
 func NewExpences(userID, categoryID uuid.UUID, amount decimal.Decimal, date time.Time, isRecurring bool, recurrenceRule, description string) (*Expense, error) {
 	if isRecurring == true && recurrenceRule == "" {
 		return nil, ErrEmptyRecurrenceRule
 	}
+	if isRecurring {
+		if _, err := ParseRecurrenceRule(recurrenceRule); err != nil {
+			return nil, err
+		}
+	}
 
 	return &Expense{
 		ID:             uuid.New(), // Генерация нового ID
 		UserID:         userID,
 		CategoryID:     categoryID,
 		Ammount:        amount,
+		Currency:       currency.Default,
 		Date:           date,
 		IsRecurring:    isRecurring,
 		RecurrenceRule: recurrenceRule,
 		Description:    description,
-		//CardID:         cardID, // TODO: добавить поле для карты
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		AuthorID:       userID,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
 	}, nil
 }
+
+// SetCurrency устанавливает валюту траты и округляет сумму до наименьшей единицы валюты.
+// Принимает код, символ или название валюты: "USD", "$", "евро"
+func (e *Expense) SetCurrency(code string) error {
+	code, err := currency.Normalize(code)
+	if err != nil {
+		return err
+	}
+	e.Currency = code
+	e.Ammount = currency.Round(e.Ammount, code)
+	return nil
+}
+
+// NewOccurrence создает очередное вхождение повторяющейся траты на дату date
+func (e *Expense) NewOccurrence(date time.Time) *Expense {
+	return &Expense{
+		ID:          uuid.New(),
+		UserID:      e.UserID,
+		CategoryID:  e.CategoryID,
+		Ammount:     e.Ammount,
+		Currency:    e.Currency,
+		Date:        date,
+		Description: e.Description,
+		ParentID:    e.ID,
+		AuthorID:    e.AuthorID,
+		AccountID:   e.AccountID,
+		Splits:      e.Splits,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
@@ -0,0 +1,14 @@
+package expense
+
+import "errors"
+
+// ErrDuplicateReceipt - трата по этому чеку уже записана
+var ErrDuplicateReceipt = errors.New("receipt already recorded")
+
+// Receipt - фискальные реквизиты кассового чека, по которому записана трата.
+// По ним один и тот же чек не записывается дважды
+type Receipt struct {
+	FN string // Номер фискального накопителя
+	FD string // Номер фискального документа
+	FP string // Фискальный признак документа
+}
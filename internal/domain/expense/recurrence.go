@@ -0,0 +1,156 @@
+package expense
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRecurrenceRule = errors.New("invalid recurrence rule")
+
+// Частота повторения в формате RRULE (RFC 5545)
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// RecurrenceRule - правило повторения траты, подмножество RRULE:
+//
+//	FREQ=MONTHLY;BYMONTHDAY=5  - каждый месяц 5 числа
+//	FREQ=WEEKLY;BYDAY=MO       - каждую неделю в понедельник
+//	FREQ=DAILY;INTERVAL=2      - через день
+type RecurrenceRule struct {
+	Freq       string       // DAILY, WEEKLY или MONTHLY
+	Interval   int          // Интервал повторения, по умолчанию 1
+	ByMonthDay int          // День месяца для MONTHLY, 0 - день исходной траты
+	ByDay      time.Weekday // День недели для WEEKLY
+	HasByDay   bool         // Задан ли BYDAY
+}
+
+// ParseRecurrenceRule разбирает правило повторения вида "FREQ=MONTHLY;BYMONTHDAY=5"
+func ParseRecurrenceRule(rule string) (*RecurrenceRule, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(strings.ToUpper(rule)), "RRULE:")
+	if rule == "" {
+		return nil, ErrEmptyRecurrenceRule
+	}
+
+	r := &RecurrenceRule{Interval: 1}
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRecurrenceRule, part)
+		}
+		switch key {
+		case "FREQ":
+			if value != FreqDaily && value != FreqWeekly && value != FreqMonthly {
+				return nil, fmt.Errorf("%w: unsupported FREQ %q", ErrInvalidRecurrenceRule, value)
+			}
+			r.Freq = value
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: bad INTERVAL %q", ErrInvalidRecurrenceRule, value)
+			}
+			r.Interval = n
+		case "BYMONTHDAY":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 31 {
+				return nil, fmt.Errorf("%w: bad BYMONTHDAY %q", ErrInvalidRecurrenceRule, value)
+			}
+			r.ByMonthDay = n
+		case "BYDAY":
+			d, ok := weekdays[value]
+			if !ok {
+				return nil, fmt.Errorf("%w: bad BYDAY %q", ErrInvalidRecurrenceRule, value)
+			}
+			r.ByDay = d
+			r.HasByDay = true
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %q", ErrInvalidRecurrenceRule, key)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRecurrenceRule)
+	}
+	if r.ByMonthDay != 0 && r.Freq != FreqMonthly {
+		return nil, fmt.Errorf("%w: BYMONTHDAY requires FREQ=MONTHLY", ErrInvalidRecurrenceRule)
+	}
+	if r.HasByDay && r.Freq != FreqWeekly {
+		return nil, fmt.Errorf("%w: BYDAY requires FREQ=WEEKLY", ErrInvalidRecurrenceRule)
+	}
+
+	return r, nil
+}
+
+// MonthlyRule возвращает правило "каждый месяц в день day"
+func MonthlyRule(day int) string {
+	return fmt.Sprintf("FREQ=%s;BYMONTHDAY=%d", FreqMonthly, day)
+}
+
+// WeeklyRule возвращает правило "каждую неделю в день weekday"
+func WeeklyRule(weekday time.Weekday) string {
+	for code, d := range weekdays {
+		if d == weekday {
+			return fmt.Sprintf("FREQ=%s;BYDAY=%s", FreqWeekly, code)
+		}
+	}
+	return ""
+}
+
+// Next возвращает первое вхождение строго после after.
+//
+// after - предыдущее вхождение (или дата исходной траты),
+// время суток и часовой пояс берутся из него
+func (r *RecurrenceRule) Next(after time.Time) time.Time {
+	switch r.Freq {
+	case FreqDaily:
+		return after.AddDate(0, 0, r.Interval)
+	case FreqWeekly:
+		if !r.HasByDay {
+			return after.AddDate(0, 0, 7*r.Interval)
+		}
+		next := after.AddDate(0, 0, 1)
+		for next.Weekday() != r.ByDay {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next.AddDate(0, 0, 7*(r.Interval-1))
+	case FreqMonthly:
+		day := r.ByMonthDay
+		if day == 0 {
+			day = after.Day()
+		}
+		next := monthDay(after, 0, day)
+		if !next.After(after) {
+			next = monthDay(after, r.Interval, day)
+		}
+		return next
+	}
+	return after
+}
+
+// monthDay возвращает день day месяца, отстоящего от ref на months месяцев.
+// Если в месяце меньше дней, берется последний день месяца
+func monthDay(ref time.Time, months, day int) time.Time {
+	first := time.Date(ref.Year(), ref.Month()+time.Month(months), 1, ref.Hour(), ref.Minute(), ref.Second(), ref.Nanosecond(), ref.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
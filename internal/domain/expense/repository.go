@@ -8,13 +8,19 @@ import (
 )
 
 type Repository interface {
-	CreateExpens(ctx context.Context, expense *Expense) error
-	UpdateExpens(ctx context.Context, expense *Expense) error
-	DeleteExpens(ctx context.Context, id uuid.UUID) error
+	CreateExpens(ctx context.Context, expense *Expense, balance []BalanceChange) error
+	CreateExpensesBatch(ctx context.Context, expenses []*Expense) error
+	UpdateExpens(ctx context.Context, expense *Expense, balance []BalanceChange) error
+	DeleteExpens(ctx context.Context, id uuid.UUID, balance []BalanceChange) error
 	GetExpenses(ctx context.Context, id uuid.UUID) (*Expense, error)
 	GetExpensesByUserID(ctx context.Context, userID uuid.UUID) ([]*Expense, error)
 	GetExpensesByDate(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) ([]*Expense, error)
 	GetExpensesByDateForDay(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) ([]*Expense, error)
 	GetExpensesByTelegramID(ctx context.Context, telegramID int64) ([]*Expense, error)
 	GetExpensesByTelegramIDAndDate(ctx context.Context, telegramID int64, date string) ([]*Expense, error)
+	GetRecurringExpenses(ctx context.Context) ([]*Expense, error)
+	GetLastOccurrence(ctx context.Context, parentID uuid.UUID) (time.Time, error)
+	CreateOccurrence(ctx context.Context, expense *Expense, balance []BalanceChange) (bool, error)
+	SearchExpenses(ctx context.Context, userID uuid.UUID, filter *SearchFilter) ([]*Expense, int, error)
+	ReceiptExists(ctx context.Context, userID uuid.UUID, receipt *Receipt) (bool, error)
 }
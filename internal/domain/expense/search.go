@@ -0,0 +1,181 @@
+package expense
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var ErrEmptySearch = errors.New("search query is empty")
+
+// SearchPageSize - число трат на странице результатов поиска
+const SearchPageSize = 10
+
+// SearchFilter - условия поиска трат. Пустые поля не ограничивают поиск
+type SearchFilter struct {
+	Text       string              // Слова для полнотекстового поиска по описанию
+	Category   string              // Название или псевдоним категории из запроса
+	CategoryID uuid.UUID           // ID категории, uuid.Nil - любая
+	MinAmount  decimal.NullDecimal // Минимальная сумма в валюте траты
+	MaxAmount  decimal.NullDecimal // Максимальная сумма в валюте траты
+	From       time.Time           // Начало периода включительно
+	To         time.Time           // Конец периода включительно
+	Limit      int                 // Размер страницы
+	Offset     int                 // Сколько трат пропустить
+}
+
+// monthForms - формы названий месяцев: "февраль", "февраля", "феврале"
+var monthForms = map[string]time.Month{}
+
+func init() {
+	stems := []string{"январ", "феврал", "март", "апрел", "ма", "июн", "июл", "август", "сентябр", "октябр", "ноябр", "декабр"}
+	for i, stem := range stems {
+		month := time.Month(i + 1)
+		switch month {
+		case time.March, time.August:
+			monthForms[stem] = month
+			monthForms[stem+"а"] = month
+			monthForms[stem+"е"] = month
+		case time.May:
+			monthForms["май"] = month
+			monthForms["мая"] = month
+			monthForms["мае"] = month
+		default:
+			monthForms[stem+"ь"] = month
+			monthForms[stem+"я"] = month
+			monthForms[stem+"е"] = month
+		}
+	}
+}
+
+// ParseSearchQuery разбирает запрос поиска трат.
+//
+// Поддерживаемые условия, в любом порядке:
+//   - "#категория" - категория или ее псевдоним, "_" заменяется пробелом;
+//   - ">500", "<1000", "500..1000" - диапазон сумм;
+//   - "15.02.2026", "01.02.2026-15.02.2026", "02.2026" - день, период или месяц;
+//   - "февраль", "в феврале 2026" - месяц; без года - последний такой месяц не позже today.
+//
+// Остальные слова ищутся в описании траты
+func ParseSearchQuery(text string, today time.Time) (*SearchFilter, error) {
+	f := &SearchFilter{Limit: SearchPageSize}
+	words := make([]string, 0)
+	fields := strings.Fields(text)
+	for i := 0; i < len(fields); i++ {
+		word := fields[i]
+		lower := strings.ToLower(word)
+		switch {
+		case strings.HasPrefix(word, "#") && len(word) > 1:
+			f.Category = strings.ReplaceAll(word[1:], "_", " ")
+		case parseAmountBound(f, word):
+		case parseDateRange(f, word):
+		default:
+			month, ok := monthForms[lower]
+			if !ok {
+				words = append(words, word)
+				continue
+			}
+			year := today.Year()
+			if month > today.Month() {
+				year--
+			}
+			if i+1 < len(fields) {
+				if y, err := strconv.Atoi(fields[i+1]); err == nil && y >= 2000 && y <= 2100 {
+					year = y
+					i++
+				}
+			}
+			// Предлог перед месяцем не ищется в описании: "в феврале", "за март"
+			if n := len(words); n > 0 && isMonthPreposition(words[n-1]) {
+				words = words[:n-1]
+			}
+			f.From = time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+			f.To = f.From.AddDate(0, 1, -1)
+		}
+	}
+	f.Text = strings.Join(words, " ")
+
+	if f.Text == "" && f.Category == "" && !f.MinAmount.Valid && !f.MaxAmount.Valid && f.From.IsZero() && f.To.IsZero() {
+		return nil, ErrEmptySearch
+	}
+	return f, nil
+}
+
+// isMonthPreposition сообщает, что слово - предлог перед названием месяца
+func isMonthPreposition(word string) bool {
+	switch strings.ToLower(word) {
+	case "в", "во", "за", "с":
+		return true
+	}
+	return false
+}
+
+// parseAmountBound разбирает границы суммы ">500", "<1000", "500..1000"
+func parseAmountBound(f *SearchFilter, word string) bool {
+	word = strings.ReplaceAll(word, ",", ".")
+	switch {
+	case strings.HasPrefix(word, ">"):
+		v, err := decimal.NewFromString(strings.TrimPrefix(strings.TrimPrefix(word, ">"), "="))
+		if err != nil {
+			return false
+		}
+		f.MinAmount = decimal.NewNullDecimal(v)
+		return true
+	case strings.HasPrefix(word, "<"):
+		v, err := decimal.NewFromString(strings.TrimPrefix(strings.TrimPrefix(word, "<"), "="))
+		if err != nil {
+			return false
+		}
+		f.MaxAmount = decimal.NewNullDecimal(v)
+		return true
+	}
+	from, to, ok := strings.Cut(word, "..")
+	if !ok {
+		return false
+	}
+	min, err := decimal.NewFromString(from)
+	if err != nil {
+		return false
+	}
+	max, err := decimal.NewFromString(to)
+	if err != nil {
+		return false
+	}
+	if min.GreaterThan(max) {
+		min, max = max, min
+	}
+	f.MinAmount, f.MaxAmount = decimal.NewNullDecimal(min), decimal.NewNullDecimal(max)
+	return true
+}
+
+// parseDateRange разбирает день "15.02.2026", период "01.02.2026-15.02.2026" или месяц "02.2026"
+func parseDateRange(f *SearchFilter, word string) bool {
+	if from, to, ok := strings.Cut(word, "-"); ok {
+		start, err := time.Parse("02.01.2006", from)
+		if err != nil {
+			return false
+		}
+		end, err := time.Parse("02.01.2006", to)
+		if err != nil {
+			return false
+		}
+		if start.After(end) {
+			start, end = end, start
+		}
+		f.From, f.To = start, end
+		return true
+	}
+	if day, err := time.Parse("02.01.2006", word); err == nil {
+		f.From, f.To = day, day
+		return true
+	}
+	if month, err := time.Parse("01.2006", word); err == nil {
+		f.From, f.To = month, month.AddDate(0, 1, -1)
+		return true
+	}
+	return false
+}
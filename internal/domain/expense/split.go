@@ -0,0 +1,81 @@
+package expense
+
+import (
+	"errors"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrSplitTooFew            = errors.New("split needs at least two categories")
+	ErrSplitNonPositive       = errors.New("split amount must be positive")
+	ErrSplitDuplicateCategory = errors.New("split categories must be different")
+	ErrSplitSumMismatch       = errors.New("split amounts must add up to the expense amount")
+)
+
+// Split - часть траты, отнесенная к категории: одна покупка в супермаркете
+// может делиться на еду, хозтовары и одежду
+type Split struct {
+	CategoryID uuid.UUID       // ID категории
+	Amount     decimal.Decimal // Сумма части в валюте траты
+}
+
+// SetSplits распределяет трату по категориям.
+//
+// Частей должно быть не меньше двух, категории не повторяются, а сумма частей,
+// округленных до наименьшей единицы валюты, равна сумме траты.
+// Категорией траты становится категория самой крупной части.
+// Пустой splits отменяет распределение
+func (e *Expense) SetSplits(splits []*Split) error {
+	if len(splits) == 0 {
+		e.Splits = nil
+		return nil
+	}
+	if len(splits) < 2 {
+		return ErrSplitTooFew
+	}
+
+	seen := make(map[uuid.UUID]bool, len(splits))
+	rounded := make([]*Split, 0, len(splits))
+	sum := decimal.Zero
+	largest := 0
+	for i, s := range splits {
+		amount := currency.Round(s.Amount, e.Currency)
+		if !amount.IsPositive() {
+			return ErrSplitNonPositive
+		}
+		if seen[s.CategoryID] {
+			return ErrSplitDuplicateCategory
+		}
+		seen[s.CategoryID] = true
+		rounded = append(rounded, &Split{CategoryID: s.CategoryID, Amount: amount})
+		sum = sum.Add(amount)
+		if amount.GreaterThan(rounded[largest].Amount) {
+			largest = i
+		}
+	}
+	if !sum.Equal(e.Ammount) {
+		return ErrSplitSumMismatch
+	}
+
+	e.Splits = rounded
+	e.CategoryID = rounded[largest].CategoryID
+	return nil
+}
+
+// IsSplit проверяет, распределена ли трата по нескольким категориям
+func (e *Expense) IsSplit() bool {
+	return len(e.Splits) > 0
+}
+
+// Allocations возвращает суммы траты по категориям: части распределенной траты
+// или одну часть на всю сумму в категории траты.
+// Отчеты и лимиты по категориям считаются по этим частям
+func (e *Expense) Allocations() []*Split {
+	if e.IsSplit() {
+		return e.Splits
+	}
+	return []*Split{{CategoryID: e.CategoryID, Amount: e.Ammount}}
+}
@@ -0,0 +1,165 @@
+package goal
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	MaxNameLength        = 100
+	ErrGoalNotFound      = errors.New("goal not found")
+	ErrEmptyName         = errors.New("goal name cannot be empty")
+	ErrNameTooLong       = errors.New("goal name exceeds maximum length")
+	ErrDuplicateName     = errors.New("goal with this name already exists")
+	ErrNonPositiveAmount = errors.New("goal amount must be positive")
+	ErrDeadlinePassed    = errors.New("goal deadline must not be in the past")
+	ErrInvalidDeadline   = errors.New("invalid goal deadline format")
+)
+
+// Goal - цель накоплений: сумма, которую пользователь хочет накопить к сроку
+type Goal struct {
+	ID        uuid.UUID       // ID цели
+	UserID    uuid.UUID       // ID пользователя
+	Name      string          // Название цели: "Отпуск", "Машина"
+	Target    decimal.Decimal // Сумма, которую нужно накопить
+	Saved     decimal.Decimal // Накоплено - сумма всех взносов
+	Currency  string          // Валюта цели (ISO 4217)
+	Deadline  time.Time       // Срок, к которому нужно накопить
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// New создает новую цель с валидацией
+//
+// today - сегодняшняя дата пользователя, срок не может быть раньше нее
+func New(userID uuid.UUID, name string, target decimal.Decimal, deadline, today time.Time) (*Goal, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrEmptyName
+	}
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return nil, ErrNameTooLong
+	}
+	if !target.IsPositive() {
+		return nil, ErrNonPositiveAmount
+	}
+	if deadline.Before(today) {
+		return nil, ErrDeadlinePassed
+	}
+
+	return &Goal{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Target:    target,
+		Saved:     decimal.Zero,
+		Currency:  currency.Default,
+		Deadline:  deadline,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}, nil
+}
+
+// ParseDeadline разбирает срок цели: "31.12.2026" или "12.2026".
+// Если указан только месяц, срок - его последний день
+func ParseDeadline(text string) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if d, err := time.Parse("02.01.2006", text); err == nil {
+		return d, nil
+	}
+	if m, err := time.Parse("01.2006", text); err == nil {
+		return m.AddDate(0, 1, -1), nil
+	}
+	return time.Time{}, ErrInvalidDeadline
+}
+
+// SetCurrency устанавливает валюту цели и округляет сумму цели до наименьшей единицы валюты.
+// Принимает код, символ или название валюты: "USD", "$", "евро"
+func (g *Goal) SetCurrency(code string) error {
+	code, err := currency.Normalize(code)
+	if err != nil {
+		return err
+	}
+	g.Currency = code
+	g.Target = currency.Round(g.Target, code)
+	return nil
+}
+
+// Remaining возвращает сумму, которую еще нужно накопить
+func (g *Goal) Remaining() decimal.Decimal {
+	if g.Saved.GreaterThanOrEqual(g.Target) {
+		return decimal.Zero
+	}
+	return g.Target.Sub(g.Saved)
+}
+
+// Progress возвращает долю накопленного от 0 до 1
+func (g *Goal) Progress() float64 {
+	if !g.Target.IsPositive() {
+		return 0
+	}
+	p := g.Saved.Div(g.Target).InexactFloat64()
+	if p < 0 {
+		return 0
+	}
+	if p > 1 {
+		return 1
+	}
+	return p
+}
+
+// IsReached сообщает, накоплена ли сумма цели
+func (g *Goal) IsReached() bool {
+	return g.Saved.GreaterThanOrEqual(g.Target)
+}
+
+// MonthsLeft возвращает число месяцев для взносов до срока, включая текущий.
+// Если срок прошел, возвращает 0
+func (g *Goal) MonthsLeft(today time.Time) int {
+	if g.Deadline.Before(today) {
+		return 0
+	}
+	return (g.Deadline.Year()-today.Year())*12 + int(g.Deadline.Month()-today.Month()) + 1
+}
+
+// MonthlyContribution возвращает ежемесячный взнос, нужный, чтобы накопить остаток к сроку.
+// Если срок прошел, нужно внести весь остаток сразу
+func (g *Goal) MonthlyContribution(today time.Time) decimal.Decimal {
+	months := g.MonthsLeft(today)
+	if months < 1 {
+		months = 1
+	}
+	// Округление вверх до наименьшей единицы валюты, чтобы сумма взносов покрывала остаток
+	return g.Remaining().Div(decimal.NewFromInt(int64(months))).RoundUp(currency.Decimals(g.Currency))
+}
+
+// Contribution - взнос в цель
+type Contribution struct {
+	ID        uuid.UUID       // ID взноса
+	GoalID    uuid.UUID       // ID цели
+	AuthorID  uuid.UUID       // ID пользователя, сделавшего взнос (uuid.Nil, если неизвестен)
+	Amount    decimal.Decimal // Сумма в валюте цели
+	Date      time.Time       // Дата взноса
+	CreatedAt time.Time
+}
+
+// NewContribution создает взнос в цель g
+func NewContribution(g *Goal, amount decimal.Decimal, date time.Time) (*Contribution, error) {
+	if !amount.IsPositive() {
+		return nil, ErrNonPositiveAmount
+	}
+	return &Contribution{
+		ID:        uuid.New(),
+		GoalID:    g.ID,
+		AuthorID:  g.UserID,
+		Amount:    amount,
+		Date:      date,
+		CreatedAt: time.Now(),
+	}, nil
+}
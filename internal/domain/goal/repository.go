@@ -0,0 +1,15 @@
+package goal
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Repository определяет методы для работы с целями накоплений
+type Repository interface {
+	GoalCreate(ctx context.Context, goal *Goal) error
+	GoalsGetByUser(ctx context.Context, userID uuid.UUID) ([]*Goal, error)
+	GoalDelete(ctx context.Context, id uuid.UUID) error
+	GoalContribute(ctx context.Context, contribution *Contribution) error
+}
@@ -0,0 +1,114 @@
+package group
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMemberNotFound = errors.New("group member not found")
+	ErrInvalidRole    = errors.New("invalid member role")
+	ErrForbidden      = errors.New("not enough rights")
+	ErrOwnerRole      = errors.New("owner role cannot be changed")
+)
+
+// Роли участников группы
+const (
+	RoleOwner  = "owner"  // Создатель общего бюджета, управляет ролями
+	RoleAdmin  = "admin"  // Может менять бюджет и лимиты
+	RoleMember = "member" // Может записывать траты
+)
+
+// roleAliases - названия ролей, которые можно указать в команде
+var roleAliases = map[string]string{
+	"admin":         RoleAdmin,
+	"админ":         RoleAdmin,
+	"администратор": RoleAdmin,
+	"member":        RoleMember,
+	"участник":      RoleMember,
+}
+
+// roleTitles - названия ролей для сообщений
+var roleTitles = map[string]string{
+	RoleOwner:  "владелец",
+	RoleAdmin:  "администратор",
+	RoleMember: "участник",
+}
+
+// Member - участник общего бюджета группового чата.
+//
+// Общий бюджет группы хранится у пользователя с ID группового чата (GroupID),
+// участники - обычные пользователи Telegram (UserID)
+type Member struct {
+	GroupID   uuid.UUID // ID пользователя группового чата
+	UserID    uuid.UUID // ID пользователя-участника
+	Role      string    // Роль участника
+	UserName  string    // Имя пользователя в Telegram, заполняется при чтении
+	FirstName string    // Имя, заполняется при чтении
+	CreatedAt time.Time
+}
+
+// NewMember создает участника группы с валидацией роли
+func NewMember(groupID, userID uuid.UUID, role string) (*Member, error) {
+	if _, ok := roleTitles[role]; !ok {
+		return nil, ErrInvalidRole
+	}
+	return &Member{
+		GroupID:   groupID,
+		UserID:    userID,
+		Role:      role,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// ParseRole возвращает роль по названию из команды: "admin", "админ", "участник"...
+//
+// Роль владельца назначить нельзя
+func ParseRole(text string) (string, error) {
+	role, ok := roleAliases[strings.ToLower(strings.TrimSpace(text))]
+	if !ok {
+		return "", ErrInvalidRole
+	}
+	return role, nil
+}
+
+// RoleTitle возвращает название роли для сообщений
+func RoleTitle(role string) string {
+	if title, ok := roleTitles[role]; ok {
+		return title
+	}
+	return role
+}
+
+// SetRole меняет роль участника. Роль владельца не меняется и не назначается
+func (m *Member) SetRole(role string) error {
+	if m.Role == RoleOwner || role == RoleOwner {
+		return ErrOwnerRole
+	}
+	if _, ok := roleTitles[role]; !ok {
+		return ErrInvalidRole
+	}
+	m.Role = role
+	return nil
+}
+
+// CanManageBudget сообщает, может ли участник менять бюджет и лимиты группы
+func (m *Member) CanManageBudget() bool {
+	return m.Role == RoleOwner || m.Role == RoleAdmin
+}
+
+// CanManageMembers сообщает, может ли участник менять роли других участников
+func (m *Member) CanManageMembers() bool {
+	return m.Role == RoleOwner
+}
+
+// DisplayName возвращает имя участника для сообщений
+func (m *Member) DisplayName() string {
+	if m.UserName != "" {
+		return "@" + m.UserName
+	}
+	return m.FirstName
+}
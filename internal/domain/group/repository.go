@@ -0,0 +1,15 @@
+package group
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Repository определяет методы для работы с участниками групп
+type Repository interface {
+	MemberAdd(ctx context.Context, member *Member) error
+	MemberGet(ctx context.Context, groupID, userID uuid.UUID) (*Member, error)
+	MembersGetByGroup(ctx context.Context, groupID uuid.UUID) ([]*Member, error)
+	MemberUpdateRole(ctx context.Context, member *Member) error
+}
@@ -0,0 +1,60 @@
+package income
+
+import (
+	"errors"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrNonPositiveAmount = errors.New("income amount must be positive")
+	ErrIncomeNotFound    = errors.New("income not found")
+	ErrNoIncome          = errors.New("no income for the period")
+)
+
+// Income - поступление денег в бюджет пользователя
+type Income struct {
+	ID        uuid.UUID       // ID дохода
+	UserID    uuid.UUID       // ID пользователя
+	AuthorID  uuid.UUID       // ID пользователя, записавшего доход (uuid.Nil, если неизвестен)
+	Amount    decimal.Decimal // Сумма дохода
+	Currency  string          // Валюта дохода (ISO 4217)
+	Date      time.Time       // Дата поступления
+	Source    string          // Источник дохода: "зарплата", "фриланс"
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// New создает новый доход с валидацией
+func New(userID uuid.UUID, amount decimal.Decimal, date time.Time, source string) (*Income, error) {
+	if !amount.IsPositive() {
+		return nil, ErrNonPositiveAmount
+	}
+
+	return &Income{
+		ID:        uuid.New(),
+		UserID:    userID,
+		AuthorID:  userID,
+		Amount:    amount,
+		Currency:  currency.Default,
+		Date:      date,
+		Source:    source,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}, nil
+}
+
+// SetCurrency устанавливает валюту дохода и округляет сумму до наименьшей единицы валюты.
+// Принимает код, символ или название валюты: "USD", "$", "евро"
+func (i *Income) SetCurrency(code string) error {
+	code, err := currency.Normalize(code)
+	if err != nil {
+		return err
+	}
+	i.Currency = code
+	i.Amount = currency.Round(i.Amount, code)
+	return nil
+}
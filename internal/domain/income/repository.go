@@ -0,0 +1,15 @@
+package income
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Repository определяет методы для работы с доходами
+type Repository interface {
+	IncomeCreate(ctx context.Context, income *Income) error
+	IncomeDelete(ctx context.Context, id uuid.UUID) error
+	IncomeGetByDate(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) ([]*Income, error)
+}
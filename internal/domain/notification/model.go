@@ -0,0 +1,138 @@
+package notification
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrUnknownKind = errors.New("unknown notification kind")
+
+// Виды уведомлений
+const (
+	KindDailyReminder = "daily"   // Вечернее напоминание, если за день нет трат
+	KindWeeklyDigest  = "weekly"  // Обзор трат за прошлую неделю по понедельникам
+	KindMonthlyReport = "monthly" // Итоги прошлого месяца первого числа
+)
+
+// Kinds - все виды уведомлений в порядке показа в настройках
+var Kinds = []string{KindDailyReminder, KindWeeklyDigest, KindMonthlyReport}
+
+// Время отправки уведомлений по часовому поясу пользователя
+const (
+	ReminderHour = 21 // Вечернее напоминание
+	DigestHour   = 10 // Недельный обзор и итоги месяца
+)
+
+// kindTitles - названия уведомлений для меню настроек
+var kindTitles = map[string]string{
+	KindDailyReminder: "Вечернее напоминание",
+	KindWeeklyDigest:  "Обзор недели",
+	KindMonthlyReport: "Итоги месяца",
+}
+
+// Settings - настройки уведомлений пользователя.
+//
+// Все уведомления выключены по умолчанию и включаются пользователем.
+// Даты последней отправки хранятся в часовом поясе пользователя и
+// защищают от повторной отправки при каждом запуске планировщика
+type Settings struct {
+	UserID        uuid.UUID // ID пользователя
+	DailyReminder bool      // Вечернее напоминание
+	WeeklyDigest  bool      // Обзор недели
+	MonthlyReport bool      // Итоги месяца
+	ReminderSent  time.Time // День последнего напоминания, нулевое - не отправлялось
+	DigestSent    time.Time // День последнего обзора недели
+	ReportSent    time.Time // День последних итогов месяца
+	Thresholds    []int     // Пороги предупреждений о тратах в процентах, пустой - budget.DefaultThresholds
+	TelegramID    string    // ID чата пользователя, заполняется при чтении
+	Timezone      string    // Часовой пояс пользователя, заполняется при чтении
+	UpdatedAt     time.Time
+}
+
+// New создает настройки с выключенными уведомлениями
+func New(userID uuid.UUID) *Settings {
+	return &Settings{
+		UserID:    userID,
+		UpdatedAt: time.Now(),
+	}
+}
+
+// Enabled проверяет, включено ли уведомление kind
+func (s *Settings) Enabled(kind string) bool {
+	switch kind {
+	case KindDailyReminder:
+		return s.DailyReminder
+	case KindWeeklyDigest:
+		return s.WeeklyDigest
+	case KindMonthlyReport:
+		return s.MonthlyReport
+	}
+	return false
+}
+
+// Toggle включает или выключает уведомление kind
+func (s *Settings) Toggle(kind string) error {
+	switch kind {
+	case KindDailyReminder:
+		s.DailyReminder = !s.DailyReminder
+	case KindWeeklyDigest:
+		s.WeeklyDigest = !s.WeeklyDigest
+	case KindMonthlyReport:
+		s.MonthlyReport = !s.MonthlyReport
+	default:
+		return ErrUnknownKind
+	}
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// Due проверяет, пора ли отправить уведомление kind.
+//
+// local - текущее время в часовом поясе пользователя.
+// Напоминание отправляется каждый день после ReminderHour, обзор недели - в понедельник,
+// итоги месяца - первого числа после DigestHour. Каждое уведомление - не чаще раза в день
+func (s *Settings) Due(kind string, local time.Time) bool {
+	if !s.Enabled(kind) {
+		return false
+	}
+	today := Day(local)
+	switch kind {
+	case KindDailyReminder:
+		return local.Hour() >= ReminderHour && s.ReminderSent.Before(today)
+	case KindWeeklyDigest:
+		return local.Weekday() == time.Monday && local.Hour() >= DigestHour && s.DigestSent.Before(today)
+	case KindMonthlyReport:
+		return local.Day() == 1 && local.Hour() >= DigestHour && s.ReportSent.Before(today)
+	}
+	return false
+}
+
+// MarkSent запоминает, что уведомление kind отправлено в день local
+func (s *Settings) MarkSent(kind string, local time.Time) {
+	today := Day(local)
+	switch kind {
+	case KindDailyReminder:
+		s.ReminderSent = today
+	case KindWeeklyDigest:
+		s.DigestSent = today
+	case KindMonthlyReport:
+		s.ReportSent = today
+	}
+	s.UpdatedAt = time.Now()
+}
+
+// KindTitle возвращает название уведомления для меню настроек
+func KindTitle(kind string) string {
+	if title, ok := kindTitles[kind]; ok {
+		return title
+	}
+	return kind
+}
+
+// Day возвращает календарный день t в виде полуночи UTC.
+// Так же хранятся даты трат и даты в колонках DATE
+func Day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
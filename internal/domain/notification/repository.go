@@ -0,0 +1,14 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Repository определяет методы для работы с настройками уведомлений
+type Repository interface {
+	NotificationGet(ctx context.Context, userID uuid.UUID) (*Settings, error)
+	NotificationSave(ctx context.Context, settings *Settings) error
+	NotificationsGetEnabled(ctx context.Context) ([]*Settings, error)
+}
@@ -8,4 +8,9 @@ type Repository interface {
 	SetExpenseStatus(ctx context.Context, ChatID string, ExpenseEntry *ExpenseEntry) error
 	GetExpenseStatus(ctx context.Context, ChatID string) (*ExpenseEntry, error)
 	Delete(ctx context.Context, tgID string) error
+	SetImportSession(ctx context.Context, ChatID string, session *ImportSession) error
+	GetImportSession(ctx context.Context, ChatID string) (*ImportSession, error)
+	DeleteImportSession(ctx context.Context, ChatID string) error
+	SetSearchQuery(ctx context.Context, ChatID string, query string) error
+	GetSearchQuery(ctx context.Context, ChatID string) (string, error)
 }
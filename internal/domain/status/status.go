@@ -3,17 +3,47 @@ package status
 import (
 	"errors"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 var (
 	ErrEmptyTelegramID = errors.New("empty telegram id")
 	ErrEmptyStatus     = errors.New("empty status")
+	ErrImportNotFound  = errors.New("import session not found")
 )
 
 type ExpenseEntry struct {
-	Date     time.Time
-	Amount   float64
+	ExpenseID  string // ID редактируемой траты, пустой при записи новой
+	Date       time.Time
+	Amount     decimal.Decimal // Хранится в JSON строкой без потери точности, старые записи с числом тоже читаются
+	Currency   string          // Валюта траты, пустая - валюта бюджета
+	Category   string
+	Note       string
+	Recurrence string        // Правило повторения, пустое для разовой траты
+	Splits     []SplitEntry  // Распределение по категориям, пустое - вся трата в Category
+	Receipt    *ReceiptEntry // Кассовый чек, с которого заполнена трата
+	Step       string        // Текущий шаг: "date", "date_input", "amount", "category", "note", "note_input", "recurring", "confirm", "split", "edit", "edit_amount", "edit_date", "edit_note", "edit_category"
+}
+
+// SplitEntry - часть траты, распределенной по категориям
+type SplitEntry struct {
 	Category string
-	Note     string
-	Step     string // Текущий шаг: "date", "date_input", "amount", "category", "note", "note_input", "confirm"
+	Amount   decimal.Decimal
+}
+
+// ReceiptEntry - фискальные реквизиты чека, с которого заполнена трата
+type ReceiptEntry struct {
+	FN string
+	FD string
+	FP string
+}
+
+// ImportSession - загруженная выписка, ожидающая подтверждения импорта
+type ImportSession struct {
+	FileName    string
+	Data        []byte // Содержимое CSV-файла
+	Date        int    // Номер колонки с датой, -1 - не задана
+	Amount      int    // Номер колонки с суммой, -1 - не задана
+	Description int    // Номер колонки с описанием, -1 - не задана
 }
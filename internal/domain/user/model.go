@@ -3,6 +3,8 @@ package user
 import (
 	"errors"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,7 +12,8 @@ import (
 
 var (
 	telegramIDRegex = regexp.MustCompile(`^-?[0-9]+$`) // ID 1234567890 && -1234567890
-	timezoneRegex   = regexp.MustCompile(`^UTC[+-]\d{1,2}$`)
+	// Смещение от UTC: UTC+3, UTC-5, UTC+5:30
+	offsetRegex = regexp.MustCompile(`^(?:UTC|GMT)(?:([+-])(\d{1,2})(?::(\d{2}))?)?$`)
 )
 
 var (
@@ -21,7 +24,7 @@ var (
 	ErrInvalidTelegramIDFormat = errors.New("invalid telegram ID format")
 	ErrDuplicateTelegramID     = errors.New("duplicate telegram ID")
 	ErrDuplicateUserName       = errors.New("duplicate username")
-	ErrInvalidTimezoneFormat   = errors.New("timezone must be in UTC±XX format")
+	ErrInvalidTimezoneFormat   = errors.New("timezone must be in UTC±XX format or an IANA name")
 )
 
 // User представляет сущность пользователя системы
@@ -81,13 +84,71 @@ func (u *User) UpdateNames(userName, firstName, lastName string) error {
 	return nil
 }
 
-// UpdateTimezone обновляет временную зону пользователя
+// UpdateTimezone обновляет временную зону пользователя.
+//
+// Принимает смещение "UTC+3", "UTC+5:30" или имя IANA "Europe/Moscow"
 func (u *User) UpdateTimezone(tz string) error {
-	if !timezoneRegex.MatchString(tz) {
-		return ErrInvalidTimezoneFormat
+	name, _, err := LoadLocation(tz)
+	if err != nil {
+		return err
 	}
 
-	u.Timezone = tz
+	u.Timezone = name
 	u.UpdatedAt = time.Now().UTC()
 	return nil
 }
+
+// Location возвращает часовой пояс пользователя
+func (u *User) Location() *time.Location {
+	return ParseLocation(u.Timezone)
+}
+
+// ParseLocation возвращает часовой пояс по строке вида "UTC+3" или "Europe/Moscow".
+// Если часовой пояс неизвестен, используется UTC
+func ParseLocation(tz string) *time.Location {
+	_, loc, err := LoadLocation(tz)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// LoadLocation разбирает часовой пояс: смещение "UTC+3", "UTC-5", "UTC+5:30" или имя IANA "Asia/Vladivostok".
+//
+// Возвращает имя в каноническом виде для хранения и сам часовой пояс
+func LoadLocation(tz string) (string, *time.Location, error) {
+	tz = strings.TrimSpace(tz)
+	if m := offsetRegex.FindStringSubmatch(strings.ToUpper(tz)); m != nil {
+		if m[1] == "" {
+			return "UTC", time.UTC, nil
+		}
+		hours, _ := strconv.Atoi(m[2])
+		minutes := 0
+		if m[3] != "" {
+			minutes, _ = strconv.Atoi(m[3])
+		}
+		if hours > 14 || minutes > 59 {
+			return "", nil, ErrInvalidTimezoneFormat
+		}
+
+		name := "UTC" + m[1] + strconv.Itoa(hours)
+		if minutes != 0 {
+			name += ":" + m[3]
+		}
+		offset := hours*3600 + minutes*60
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return name, time.FixedZone(name, offset), nil
+	}
+
+	// Имена IANA содержат "/", а time.LoadLocation принимает и пути к файлам
+	if !strings.Contains(tz, "/") || strings.Contains(tz, "..") || strings.HasPrefix(tz, "/") {
+		return "", nil, ErrInvalidTimezoneFormat
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return "", nil, ErrInvalidTimezoneFormat
+	}
+	return loc.String(), loc, nil
+}
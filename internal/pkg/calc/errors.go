@@ -0,0 +1,59 @@
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrEmpty            = errors.New("пустое выражение")
+	ErrUnexpectedToken  = errors.New("неожиданный символ в выражении")
+	ErrUnexpectedEnd    = errors.New("выражение оборвано")
+	ErrUnbalancedParens = errors.New("несоответствие скобок")
+	ErrDivisionByZero   = errors.New("деление на ноль")
+	ErrInvalidPercent   = errors.New("процент допустим только после + или -")
+	ErrUnknownFunction  = errors.New("неизвестная функция")
+	ErrUnknownVariable  = errors.New("неизвестная переменная")
+	ErrArgumentCount    = errors.New("неверное число аргументов функции")
+	ErrInvalidResult    = errors.New("ошибка в вычислениях")
+)
+
+// Error - ошибка разбора или вычисления с позицией фрагмента в выражении
+type Error struct {
+	Err error // Причина, одна из Err*
+	Pos int   // Позиция фрагмента в символах (рунах) от начала выражения
+	Len int   // Длина фрагмента в символах, 0 - конец выражения
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (позиция %d)", e.Err, e.Pos+1)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Mark выделяет в выражении фрагмент, на который указывает ошибка err: "100+[*]5".
+// Если err не *Error, возвращает выражение без изменений
+func Mark(expression string, err error) string {
+	var e *Error
+	if !errors.As(err, &e) {
+		return expression
+	}
+	runes := []rune(expression)
+	pos := min(max(e.Pos, 0), len(runes))
+	end := min(pos+e.Len, len(runes))
+
+	var sb strings.Builder
+	sb.WriteString(string(runes[:pos]))
+	sb.WriteString("[")
+	sb.WriteString(string(runes[pos:end]))
+	sb.WriteString("]")
+	sb.WriteString(string(runes[end:]))
+	return sb.String()
+}
+
+func errorAt(err error, t token) *Error {
+	return &Error{Err: err, Pos: t.pos, Len: t.len}
+}
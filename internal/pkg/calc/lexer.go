@@ -0,0 +1,167 @@
+package calc
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/shopspring/decimal"
+)
+
+type tokenKind int
+
+const (
+	tokEOF     tokenKind = iota
+	tokNumber            // Число
+	tokOp                // Оператор: + - * / ^
+	tokPercent           // %
+	tokLParen            // (
+	tokRParen            // )
+	tokComma             // Разделитель аргументов функции: , или ;
+	tokIdent             // Имя функции или переменной
+)
+
+type token struct {
+	kind  tokenKind
+	text  string          // Оператор или имя
+	value decimal.Decimal // Значение числа
+	pos   int             // Позиция в рунах
+	len   int             // Длина в рунах
+}
+
+// multipliers - знаки множителя между числами: "3x150", "3х150", "3×150"
+var multipliers = map[string]bool{"x": true, "х": true, "×": true}
+
+// units - единицы количества после числа, которые не влияют на результат: "150*3шт"
+var units = map[string]bool{"шт": true, "штук": true, "штуки": true, "штука": true, "pcs": true, "pc": true}
+
+// tokenize разбивает выражение на токены.
+//
+// Запятая между цифрами - десятичный разделитель, кроме аргументов функций:
+// там дробная часть отделяется точкой, а запятая разделяет аргументы.
+// Пробел перед группой из трех цифр - разделитель разрядов: "1 000".
+// "x" после числа или скобки - умножение, единицы количества ("шт") пропускаются
+func tokenize(expression string) ([]token, error) {
+	runes := []rune(strings.ToLower(expression))
+	tokens := make([]token, 0, len(runes))
+	calls := make([]bool, 0) // Для каждой открытой скобки: скобка вызова функции
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.' || r == ',' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]) && !inCall(calls):
+			t, next, err := readNumber(runes, i, inCall(calls))
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t)
+			i = next
+		case strings.ContainsRune("+-*/^", r) || r == '×' && endsValue(tokens):
+			tokens = append(tokens, token{kind: tokOp, text: string(r), pos: i, len: 1})
+			if r == '×' {
+				tokens[len(tokens)-1].text = "*"
+			}
+			i++
+		case r == '%':
+			tokens = append(tokens, token{kind: tokPercent, text: "%", pos: i, len: 1})
+			i++
+		case r == '(':
+			calls = append(calls, len(tokens) > 0 && tokens[len(tokens)-1].kind == tokIdent)
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i, len: 1})
+			i++
+		case r == ')':
+			if len(calls) > 0 {
+				calls = calls[:len(calls)-1]
+			}
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i, len: 1})
+			i++
+		case r == ',' || r == ';':
+			tokens = append(tokens, token{kind: tokComma, text: string(r), pos: i, len: 1})
+			i++
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) && !multipliers[string(runes[start:i])] || runes[i] == '_') {
+				i++
+			}
+			name := string(runes[start:i])
+			switch {
+			case multipliers[name] && endsValue(tokens):
+				tokens = append(tokens, token{kind: tokOp, text: "*", pos: start, len: i - start})
+			case units[name] && endsValue(tokens):
+				// "шт." - точка сокращения тоже пропускается
+				if i < len(runes) && runes[i] == '.' {
+					i++
+				}
+			default:
+				tokens = append(tokens, token{kind: tokIdent, text: name, pos: start, len: i - start})
+			}
+		default:
+			return nil, &Error{Err: ErrUnexpectedToken, Pos: i, Len: 1}
+		}
+	}
+	tokens = append(tokens, token{kind: tokEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+// readNumber читает число, начиная с позиции start. Возвращает токен и позицию после числа
+func readNumber(runes []rune, start int, call bool) (token, int, error) {
+	var sb strings.Builder
+	i := start
+	fraction := false
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsDigit(r):
+			sb.WriteRune(r)
+		case (r == '.' || r == ',' && !call) && !fraction:
+			fraction = true
+			sb.WriteRune('.')
+		case (r == '.' || r == ',' && !call) && fraction:
+			// Вторая дробная часть: "1.2.3"
+			return token{}, 0, &Error{Err: ErrUnexpectedToken, Pos: i, Len: 1}
+		case r == ' ' && !fraction && isThousands(runes, i+1):
+			// Разделитель разрядов: "1 000"
+		default:
+			return numberToken(sb.String(), start, i)
+		}
+		i++
+	}
+	return numberToken(sb.String(), start, i)
+}
+
+func numberToken(text string, start, end int) (token, int, error) {
+	v, err := decimal.NewFromString(text)
+	if err != nil {
+		return token{}, 0, &Error{Err: ErrUnexpectedToken, Pos: start, Len: end - start}
+	}
+	return token{kind: tokNumber, text: text, value: v, pos: start, len: end - start}, end, nil
+}
+
+// isThousands проверяет, что с позиции i идут ровно три цифры
+func isThousands(runes []rune, i int) bool {
+	if i+3 > len(runes) {
+		return false
+	}
+	for _, r := range runes[i : i+3] {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return i+3 == len(runes) || !unicode.IsDigit(runes[i+3]) && runes[i+3] != '.' && runes[i+3] != ','
+}
+
+// endsValue сообщает, что последний токен завершает операнд: число, ")" или "%"
+func endsValue(tokens []token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	switch tokens[len(tokens)-1].kind {
+	case tokNumber, tokRParen, tokPercent:
+		return true
+	}
+	return false
+}
+
+func inCall(calls []bool) bool {
+	return len(calls) > 0 && calls[len(calls)-1]
+}
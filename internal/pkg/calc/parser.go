@@ -0,0 +1,296 @@
+package calc
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// parser - разбор выражения рекурсивным спуском.
+//
+// Грамматика:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/") unary | unary }   // без знака - неявное умножение: 3(200)
+//	unary   = ("+" | "-") unary | power
+//	power   = postfix [ "^" unary ]
+//	postfix = primary [ "%" ]
+//	primary = number | "(" expr ")" | name "(" expr { "," expr } ")" | name
+//
+// Процент допустим только у слагаемого после + или - и считается от всей суммы слева: 100+10% = 110
+type parser struct {
+	tokens []token
+	pos    int
+	vars   map[string]decimal.Decimal
+}
+
+var hundred = decimal.NewFromInt(100)
+
+// maxExponent - наибольшая по модулю степень, чтобы 10^100000 не считалось вечно
+const maxExponent = 1000
+
+// operand - значение с отметкой процента: токен "%" или nil
+type operand struct {
+	value   decimal.Decimal
+	percent *token
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// parse разбирает и вычисляет выражение целиком
+func (p *parser) parse() (decimal.Decimal, error) {
+	if p.peek().kind == tokEOF {
+		return decimal.Zero, errorAt(ErrEmpty, p.peek())
+	}
+	v, err := p.expr()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	switch t := p.peek(); t.kind {
+	case tokEOF:
+	case tokRParen:
+		return decimal.Zero, errorAt(ErrUnbalancedParens, t)
+	default:
+		return decimal.Zero, errorAt(ErrUnexpectedToken, t)
+	}
+	return v, nil
+}
+
+func (p *parser) expr() (decimal.Decimal, error) {
+	left, err := p.term()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if left.percent != nil {
+		return decimal.Zero, errorAt(ErrInvalidPercent, *left.percent)
+	}
+	result := left.value
+	for {
+		t := p.peek()
+		if t.kind != tokOp || t.text != "+" && t.text != "-" {
+			return result, nil
+		}
+		p.next()
+		right, err := p.term()
+		if err != nil {
+			return decimal.Zero, err
+		}
+		v := right.value
+		if right.percent != nil {
+			v = result.Mul(right.value).Div(hundred)
+		}
+		if t.text == "+" {
+			result = result.Add(v)
+		} else {
+			result = result.Sub(v)
+		}
+	}
+}
+
+func (p *parser) term() (operand, error) {
+	left, err := p.unary()
+	if err != nil {
+		return operand{}, err
+	}
+	factors := 1
+	for {
+		t := p.peek()
+		op := ""
+		switch {
+		case t.kind == tokOp && (t.text == "*" || t.text == "/"):
+			op = t.text
+			p.next()
+		case t.kind == tokNumber || t.kind == tokLParen || t.kind == tokIdent:
+			op = "*" // Неявное умножение
+		default:
+			if left.percent != nil && factors > 1 {
+				return operand{}, errorAt(ErrInvalidPercent, *left.percent)
+			}
+			return left, nil
+		}
+
+		right, err := p.unary()
+		if err != nil {
+			return operand{}, err
+		}
+		if right.percent != nil {
+			return operand{}, errorAt(ErrInvalidPercent, *right.percent)
+		}
+		if left.percent != nil {
+			return operand{}, errorAt(ErrInvalidPercent, *left.percent)
+		}
+		factors++
+		if op == "/" {
+			if right.value.IsZero() {
+				return operand{}, errorAt(ErrDivisionByZero, t)
+			}
+			left.value = left.value.Div(right.value)
+		} else {
+			left.value = left.value.Mul(right.value)
+		}
+	}
+}
+
+func (p *parser) unary() (operand, error) {
+	if t := p.peek(); t.kind == tokOp && (t.text == "-" || t.text == "+") {
+		p.next()
+		v, err := p.unary()
+		if err != nil {
+			return operand{}, err
+		}
+		if t.text == "-" {
+			v.value = v.value.Neg()
+		}
+		return v, nil
+	}
+	return p.power()
+}
+
+func (p *parser) power() (operand, error) {
+	base, err := p.postfix()
+	if err != nil {
+		return operand{}, err
+	}
+	t := p.peek()
+	if t.kind != tokOp || t.text != "^" {
+		return base, nil
+	}
+	if base.percent != nil {
+		return operand{}, errorAt(ErrInvalidPercent, *base.percent)
+	}
+	p.next()
+	exp, err := p.unary() // ^ правоассоциативен: 2^2^2 = 2^4
+	if err != nil {
+		return operand{}, err
+	}
+	if exp.percent != nil {
+		return operand{}, errorAt(ErrInvalidPercent, *exp.percent)
+	}
+	if exp.value.Abs().GreaterThan(decimal.NewFromInt(maxExponent)) {
+		return operand{}, errorAt(ErrInvalidResult, t)
+	}
+	v, err := base.value.PowWithPrecision(exp.value, int32(decimal.DivisionPrecision))
+	if err != nil {
+		return operand{}, errorAt(ErrInvalidResult, t)
+	}
+	return operand{value: v}, nil
+}
+
+func (p *parser) postfix() (operand, error) {
+	v, err := p.primary()
+	if err != nil {
+		return operand{}, err
+	}
+	if t := p.peek(); t.kind == tokPercent {
+		p.next()
+		return operand{value: v, percent: &t}, nil
+	}
+	return operand{value: v}, nil
+}
+
+func (p *parser) primary() (decimal.Decimal, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return t.value, nil
+	case tokLParen:
+		v, err := p.expr()
+		if err != nil {
+			return decimal.Zero, err
+		}
+		return v, p.closeParen()
+	case tokIdent:
+		if p.peek().kind == tokLParen {
+			return p.call(t)
+		}
+		v, ok := p.vars[t.text]
+		if !ok {
+			return decimal.Zero, errorAt(ErrUnknownVariable, t)
+		}
+		return v, nil
+	case tokEOF:
+		return decimal.Zero, errorAt(ErrUnexpectedEnd, t)
+	default:
+		return decimal.Zero, errorAt(ErrUnexpectedToken, t)
+	}
+}
+
+// closeParen пропускает закрывающую скобку. Незакрытые скобки в конце выражения допускаются: "((1+1)"
+func (p *parser) closeParen() error {
+	switch t := p.peek(); t.kind {
+	case tokRParen:
+		p.next()
+		return nil
+	case tokEOF:
+		return nil
+	default:
+		return errorAt(ErrUnexpectedToken, t)
+	}
+}
+
+// call разбирает аргументы функции name и вычисляет ее
+func (p *parser) call(name token) (decimal.Decimal, error) {
+	f, ok := functions[name.text]
+	if !ok {
+		return decimal.Zero, errorAt(ErrUnknownFunction, name)
+	}
+	p.next() // (
+
+	args := make([]decimal.Decimal, 0, 2)
+	if p.peek().kind != tokRParen {
+		for {
+			v, err := p.expr()
+			if err != nil {
+				return decimal.Zero, err
+			}
+			args = append(args, v)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if err := p.closeParen(); err != nil {
+		return decimal.Zero, err
+	}
+	if len(args) < f.minArgs || f.maxArgs >= 0 && len(args) > f.maxArgs {
+		return decimal.Zero, errorAt(ErrArgumentCount, name)
+	}
+	return f.eval(args), nil
+}
+
+// function - встроенная функция выражения
+type function struct {
+	minArgs int // Минимальное число аргументов
+	maxArgs int // Максимальное число аргументов, -1 - не ограничено
+	eval    func(args []decimal.Decimal) decimal.Decimal
+}
+
+// functions - встроенные функции
+var functions = map[string]function{
+	// round(x) - до целого, round(x, n) - до n знаков после запятой
+	"round": {1, 2, func(args []decimal.Decimal) decimal.Decimal {
+		if len(args) == 1 {
+			return args[0].Round(0)
+		}
+		places := args[1].Round(0)
+		places = decimal.Min(decimal.Max(places, decimal.NewFromInt(-maxPlaces)), decimal.NewFromInt(maxPlaces))
+		return args[0].Round(int32(places.IntPart()))
+	}},
+	"min":   {1, -1, func(args []decimal.Decimal) decimal.Decimal { return decimal.Min(args[0], args[1:]...) }},
+	"max":   {1, -1, func(args []decimal.Decimal) decimal.Decimal { return decimal.Max(args[0], args[1:]...) }},
+	"abs":   {1, 1, func(args []decimal.Decimal) decimal.Decimal { return args[0].Abs() }},
+	"floor": {1, 1, func(args []decimal.Decimal) decimal.Decimal { return args[0].Floor() }},
+	"ceil":  {1, 1, func(args []decimal.Decimal) decimal.Decimal { return args[0].Ceil() }},
+}
+
+// maxPlaces ограничивает число знаков в round(x, n)
+const maxPlaces = 20
@@ -0,0 +1,86 @@
+package chart
+
+import (
+	"image"
+)
+
+// Размеры столбчатых и линейных диаграмм
+const (
+	plotWidth    = 800
+	plotHeight   = 450
+	marginLeft   = 80
+	marginRight  = 20
+	marginTop    = 30
+	marginBottom = 40
+)
+
+// Bars рисует столбчатую диаграмму values с подписями labels под столбцами.
+//
+// Если limit больше нуля, рисуется пунктирная линия лимита, а столбцы выше нее закрашиваются красным.
+// Подписи выводятся не чаще, чем помещаются под осью
+func Bars(values []float64, labels []string, limit float64) ([]byte, error) {
+	top, err := maxValue(values)
+	if err != nil {
+		return nil, err
+	}
+	top = max(top, limit) * 1.1
+
+	img := newCanvas(plotWidth, plotHeight)
+	area := plotArea()
+	drawAxes(img, area, top)
+
+	slot := float64(area.Dx()) / float64(len(values))
+	gap := int(slot * 0.2)
+	step := labelStep(labels, int(slot))
+	for i, v := range values {
+		x0 := area.Min.X + int(float64(i)*slot)
+		x1 := area.Min.X + int(float64(i+1)*slot)
+		if v > 0 {
+			c := barColor
+			if limit > 0 && v > limit {
+				c = overColor
+			}
+			fillRect(img, x0+gap/2, scaleY(area, v, top), max(x1-gap/2, x0+gap/2+1), area.Max.Y, c)
+		}
+		if i < len(labels) && i%step == 0 {
+			drawText(img, (x0+x1)/2-textWidth(labels[i])/2, area.Max.Y+10, labels[i], textColor)
+		}
+	}
+
+	if limit > 0 {
+		y := scaleY(area, limit, top)
+		dashedLine(img, area.Min.X, area.Max.X, y, limitColor)
+		drawText(img, marginLeft-10-textWidth(Compact(limit)), y-textHeight/2, Compact(limit), limitColor)
+	}
+	return encode(img)
+}
+
+// plotArea возвращает область построения внутри полей
+func plotArea() image.Rectangle {
+	return image.Rect(marginLeft, marginTop, plotWidth-marginRight, plotHeight-marginBottom)
+}
+
+// drawAxes рисует оси и подписи нуля и максимума шкалы
+func drawAxes(img *image.RGBA, area image.Rectangle, top float64) {
+	fillRect(img, area.Min.X-2, area.Min.Y, area.Min.X, area.Max.Y, axisColor)
+	fillRect(img, area.Min.X-2, area.Max.Y, area.Max.X, area.Max.Y+2, axisColor)
+	drawText(img, marginLeft-10-textWidth("0"), area.Max.Y-textHeight/2, "0", textColor)
+	drawText(img, marginLeft-10-textWidth(Compact(top)), area.Min.Y-textHeight/2, Compact(top), textColor)
+}
+
+// scaleY переводит значение v в координату Y области построения
+func scaleY(area image.Rectangle, v, top float64) int {
+	return area.Max.Y - int(v/top*float64(area.Dy()))
+}
+
+// labelStep возвращает шаг подписей, при котором они не перекрываются
+func labelStep(labels []string, slot int) int {
+	widest := 0
+	for _, l := range labels {
+		widest = max(widest, textWidth(l))
+	}
+	if slot <= 0 {
+		return 1
+	}
+	return max(1, (widest+10+slot-1)/slot)
+}
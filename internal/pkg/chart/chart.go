@@ -0,0 +1,132 @@
+// Package chart рисует PNG-диаграммы для отчетов без внешних зависимостей.
+//
+// Подписи категорий на изображениях не выводятся: цвета палитры совпадают
+// с эмодзи Marks, по которым легенда строится в тексте сообщения
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrNoData - нет данных для диаграммы: пустой список или все значения нулевые
+var ErrNoData = errors.New("no data for chart")
+
+// Palette - цвета секторов и столбцов. Последний цвет - для объединенного "Прочее"
+var Palette = []color.RGBA{
+	{231, 76, 60, 255},  // красный
+	{243, 156, 18, 255}, // оранжевый
+	{241, 196, 15, 255}, // желтый
+	{46, 204, 113, 255}, // зеленый
+	{52, 152, 219, 255}, // синий
+	{155, 89, 182, 255}, // фиолетовый
+	{160, 110, 70, 255}, // коричневый
+	{90, 90, 90, 255},   // серый
+}
+
+// Marks - эмодзи для легенды, по одному на цвет Palette
+var Marks = []string{"🟥", "🟧", "🟨", "🟩", "🟦", "🟪", "🟫", "⬛"}
+
+var (
+	background = color.RGBA{255, 255, 255, 255}
+	axisColor  = color.RGBA{180, 180, 180, 255}
+	textColor  = color.RGBA{60, 60, 60, 255}
+	barColor   = Palette[4]
+	overColor  = Palette[0]
+	limitColor = Palette[1]
+)
+
+// newCanvas создает изображение с белым фоном
+func newCanvas(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
+	return img
+}
+
+// encode кодирует изображение в PNG
+func encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fillRect закрашивает прямоугольник [x0, x1) x [y0, y1)
+func fillRect(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	draw.Draw(img, image.Rect(x0, y0, x1, y1), &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+// dashedLine рисует горизонтальную пунктирную линию толщиной 2 пикселя
+func dashedLine(img *image.RGBA, x0, x1, y int, c color.RGBA) {
+	for x := x0; x < x1; x += 12 {
+		fillRect(img, x, y-1, min(x+8, x1), y+1, c)
+	}
+}
+
+// thickLine рисует отрезок толщиной width пикселей
+func thickLine(img *image.RGBA, x0, y0, x1, y1, width int, c color.RGBA) {
+	steps := max(abs(x1-x0), abs(y1-y0))
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		x := x0 + int(math.Round(float64(x1-x0)*t))
+		y := y0 + int(math.Round(float64(y1-y0)*t))
+		dot(img, x, y, width/2, c)
+	}
+}
+
+// dot рисует закрашенный круг радиуса r
+func dot(img *image.RGBA, cx, cy, r int, c color.RGBA) {
+	for y := -r; y <= r; y++ {
+		for x := -r; x <= r; x++ {
+			if x*x+y*y <= r*r {
+				img.SetRGBA(cx+x, cy+y, c)
+			}
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// maxValue возвращает наибольшее значение; ErrNoData, если все значения не больше нуля
+func maxValue(values []float64) (float64, error) {
+	var m float64
+	for _, v := range values {
+		m = math.Max(m, v)
+	}
+	if m <= 0 {
+		return 0, ErrNoData
+	}
+	return m, nil
+}
+
+// Compact форматирует число для подписи оси: 950, 12.5k, 1.2M
+func Compact(v float64) string {
+	switch {
+	case math.Abs(v) >= 1e6:
+		return trimZero(strconv.FormatFloat(v/1e6, 'f', 1, 64)) + "M"
+	case math.Abs(v) >= 1e3:
+		return trimZero(strconv.FormatFloat(v/1e3, 'f', 1, 64)) + "k"
+	default:
+		return strconv.FormatFloat(math.Round(v), 'f', 0, 64)
+	}
+}
+
+func trimZero(s string) string {
+	return strings.TrimSuffix(s, ".0")
+}
@@ -0,0 +1,57 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+)
+
+// glyphs - растровый шрифт 3x5 для подписей осей: цифры, точка, минус, процент, k и M.
+// Каждая строка - три бита, старший бит - левый пиксель
+var glyphs = map[rune][5]uint8{
+	'0': {7, 5, 5, 5, 7},
+	'1': {2, 6, 2, 2, 7},
+	'2': {7, 1, 7, 4, 7},
+	'3': {7, 1, 7, 1, 7},
+	'4': {5, 5, 7, 1, 1},
+	'5': {7, 4, 7, 1, 7},
+	'6': {7, 4, 7, 5, 7},
+	'7': {7, 1, 1, 1, 1},
+	'8': {7, 5, 7, 5, 7},
+	'9': {7, 5, 7, 1, 7},
+	'.': {0, 0, 0, 0, 2},
+	'-': {0, 0, 7, 0, 0},
+	'%': {5, 1, 2, 4, 5},
+	'k': {4, 5, 6, 5, 5},
+	'M': {5, 7, 7, 5, 5},
+	' ': {0, 0, 0, 0, 0},
+}
+
+// fontScale - размер пикселя шрифта
+const fontScale = 3
+
+// textWidth возвращает ширину подписи в пикселях
+func textWidth(text string) int {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+	return n*4*fontScale - fontScale
+}
+
+// textHeight - высота подписи в пикселях
+const textHeight = 5 * fontScale
+
+// drawText выводит подпись с левым верхним углом в (x, y). Неизвестные символы пропускаются
+func drawText(img *image.RGBA, x, y int, text string, c color.RGBA) {
+	for _, r := range text {
+		g := glyphs[r]
+		for row, bits := range g {
+			for col := 0; col < 3; col++ {
+				if bits&(4>>col) != 0 {
+					fillRect(img, x+col*fontScale, y+row*fontScale, x+(col+1)*fontScale, y+(row+1)*fontScale, c)
+				}
+			}
+		}
+		x += 4 * fontScale
+	}
+}
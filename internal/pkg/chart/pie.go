@@ -0,0 +1,56 @@
+package chart
+
+import "math"
+
+// pieSize - ширина и высота круговой диаграммы
+const pieSize = 500
+
+// Pie рисует кольцевую диаграмму долей values.
+// Сектор i закрашивается цветом Palette[i], поэтому значений не должно быть больше len(Palette)
+func Pie(values []float64) ([]byte, error) {
+	var total float64
+	for _, v := range values {
+		if v > 0 {
+			total += v
+		}
+	}
+	if total <= 0 {
+		return nil, ErrNoData
+	}
+
+	// Границы секторов по часовой стрелке от верхней точки, в долях круга
+	bounds := make([]float64, len(values))
+	var acc float64
+	for i, v := range values {
+		acc += math.Max(v, 0) / total
+		bounds[i] = acc
+	}
+
+	img := newCanvas(pieSize, pieSize)
+	center := float64(pieSize) / 2
+	outer, inner := center-20, (center-20)*0.5
+	for y := 0; y < pieSize; y++ {
+		for x := 0; x < pieSize; x++ {
+			dx, dy := float64(x)+0.5-center, float64(y)+0.5-center
+			r := math.Hypot(dx, dy)
+			if r > outer || r < inner {
+				continue
+			}
+			angle := math.Atan2(dx, -dy) / (2 * math.Pi)
+			if angle < 0 {
+				angle++
+			}
+			for i, b := range bounds {
+				if angle < b || i == len(bounds)-1 {
+					img.SetRGBA(x, y, Palette[i%len(Palette)])
+					break
+				}
+			}
+		}
+	}
+
+	// Общая сумма в центре кольца
+	label := Compact(total)
+	drawText(img, pieSize/2-textWidth(label)/2, pieSize/2-textHeight/2, label, textColor)
+	return encode(img)
+}
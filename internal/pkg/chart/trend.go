@@ -0,0 +1,39 @@
+package chart
+
+// Trend рисует линию изменения values с подписями labels под точками и значениями над ними
+func Trend(values []float64, labels []string) ([]byte, error) {
+	top, err := maxValue(values)
+	if err != nil {
+		return nil, err
+	}
+	top *= 1.2
+
+	img := newCanvas(plotWidth, plotHeight)
+	area := plotArea()
+	drawAxes(img, area, top)
+
+	slot := float64(area.Dx()) / float64(len(values))
+	xs := make([]int, len(values))
+	ys := make([]int, len(values))
+	for i, v := range values {
+		xs[i] = area.Min.X + int((float64(i)+0.5)*slot)
+		ys[i] = scaleY(area, max(v, 0), top)
+	}
+	for i := 1; i < len(values); i++ {
+		thickLine(img, xs[i-1], ys[i-1], xs[i], ys[i], 4, barColor)
+	}
+
+	step := labelStep(labels, int(slot))
+	for i, v := range values {
+		dot(img, xs[i], ys[i], 6, barColor)
+		// Подложка, чтобы линия не перечеркивала подпись значения
+		label := Compact(v)
+		x, y := xs[i]-textWidth(label)/2, ys[i]-textHeight-12
+		fillRect(img, x-2, y-2, x+textWidth(label)+2, y+textHeight+2, background)
+		drawText(img, x, y, label, textColor)
+		if i < len(labels) && i%step == 0 {
+			drawText(img, xs[i]-textWidth(labels[i])/2, area.Max.Y+10, labels[i], textColor)
+		}
+	}
+	return encode(img)
+}
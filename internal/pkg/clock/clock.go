@@ -0,0 +1,43 @@
+package clock
+
+import "time"
+
+// Clock - источник текущего времени.
+//
+// Сервис получает время только через Clock, поэтому в тестах его можно подменить на Fixed
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// System возвращает системные часы
+func System() Clock {
+	return systemClock{}
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// Fixed возвращает часы, всегда показывающие время t
+func Fixed(t time.Time) Clock {
+	return fixedClock(t)
+}
+
+// Date возвращает календарную дату момента t в часовом поясе loc.
+//
+// Даты хранятся как полночь UTC, поэтому результат не зависит от часового пояса
+// сервера: 08:00 по UTC+10 - это уже сегодня, хотя в UTC еще вчера
+func Date(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Month возвращает первый и последний день месяца, в который входит дата date
+func Month(date time.Time) (start, end time.Time) {
+	start = time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, -1)
+}
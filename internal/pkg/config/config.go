@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"regexp"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -12,16 +14,31 @@ type Config struct {
 		Env  string `mapstructure:"env" validate:"required"`  // default, production, development
 		Name string `mapstructure:"name" validate:"required"` // finance_bot
 	}
-	TG    TGConfig       `mapstructure:"tg"`    // TGConfig - структура конфигурации Telegram
-	DB    DatabaseConfig `mapstructure:"db"`    // DatabaseConfig - структура конфигурации базы данных
-	Redis RedisConfig    `mapstructure:"redis"` // RedisConfig - структура конфигурации Redis
+	TG        TGConfig        `mapstructure:"tg"`        // TGConfig - структура конфигурации Telegram
+	DB        DatabaseConfig  `mapstructure:"db"`        // DatabaseConfig - структура конфигурации базы данных
+	Redis     RedisConfig     `mapstructure:"redis"`     // RedisConfig - структура конфигурации Redis
+	Scheduler SchedulerConfig `mapstructure:"scheduler"` // SchedulerConfig - структура конфигурации планировщика
+	Currency  CurrencyConfig  `mapstructure:"currency"`  // CurrencyConfig - структура конфигурации валют
 }
 
 // TGConfig - структура конфигурации Telegram
 type TGConfig struct {
-	Token       string `mapstructure:"token"`        // Токен бота
-	TypePolling string `mapstructure:"type_polling"` // Тип опроса бота
-	Debug       bool   `mapstructure:"debug"`        // Режим отладки
+	Token           string        `mapstructure:"token"`            // Токен бота
+	TypePolling     string        `mapstructure:"type_polling"`     // Тип опроса бота: longpolling или webhook
+	Debug           bool          `mapstructure:"debug"`            // Режим отладки
+	APIURL          string        `mapstructure:"api_url"`          // Адрес Bot API, пустой - api.telegram.org
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"` // Время на обработку полученных обновлений при завершении работы
+	Workers         int           `mapstructure:"workers"`          // Количество параллельных обработчиков обновлений
+	QueueSize       int           `mapstructure:"queue_size"`       // Емкость очереди одного обработчика
+	Webhook         WebhookConfig `mapstructure:"webhook"`          // WebhookConfig - структура конфигурации webhook
+}
+
+// WebhookConfig - структура конфигурации webhook
+type WebhookConfig struct {
+	URL         string `mapstructure:"url"`          // Публичный URL, на который Telegram отправляет обновления
+	Listen      string `mapstructure:"listen"`       // Адрес HTTP-сервера, например :8080
+	Path        string `mapstructure:"path"`         // Путь, по которому HTTP-сервер принимает обновления
+	SecretToken string `mapstructure:"secret_token"` // Секрет из заголовка X-Telegram-Bot-Api-Secret-Token
 }
 
 // DatabaseConfig - структура конфигурации базы данных
@@ -41,6 +58,24 @@ type RedisConfig struct {
 	Timeout  int    `mapstructure:"timeout"`                  // Таймаут подключения
 }
 
+// SchedulerConfig - структура конфигурации планировщика
+type SchedulerConfig struct {
+	RecurringInterval time.Duration `mapstructure:"recurring_interval"` // Интервал обработки повторяющихся расходов
+	StatsInterval     time.Duration `mapstructure:"stats_interval"`     // Интервал записи метрик очереди обновлений в лог
+	NotifyInterval    time.Duration `mapstructure:"notify_interval"`    // Интервал проверки запланированных уведомлений
+	BudgetInterval    time.Duration `mapstructure:"budget_interval"`    // Интервал создания бюджетов новых периодов
+}
+
+// CurrencyConfig - структура конфигурации валют
+type CurrencyConfig struct {
+	Default     string `mapstructure:"default"`      // Валюта бюджета по умолчанию
+	RatesSource string `mapstructure:"rates_source"` // Источник курсов: file или db
+	RatesFile   string `mapstructure:"rates_file"`   // Путь к JSON-файлу курсов для источника file
+}
+
+// secretTokenRe - допустимый формат секрета webhook по документации Bot API
+var secretTokenRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
 // LoadConfig загружает конфигурацию с приоритетом:
 //
 // 1. Переменные окружения
@@ -56,16 +91,34 @@ func LoadConfig(path string) (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("не удалось привязать переменную окружения к ключу в конфиге: %w", err)
 	}
+	if err := viper.BindEnv("tg.webhook.url", "TG_WEBHOOK_URL"); err != nil {
+		return nil, fmt.Errorf("не удалось привязать переменную окружения к ключу в конфиге: %w", err)
+	}
+	if err := viper.BindEnv("tg.webhook.secret_token", "TG_WEBHOOK_SECRET"); err != nil {
+		return nil, fmt.Errorf("не удалось привязать переменную окружения к ключу в конфиге: %w", err)
+	}
 
 	// Значения по умолчанию
 	viper.SetDefault("app.env", "default")
 	viper.SetDefault("app.name", "finance_bot")
 	viper.SetDefault("tg.debug", false)
 	viper.SetDefault("tg.type_polling", "longpolling")
+	viper.SetDefault("tg.shutdown_timeout", "10s")
+	viper.SetDefault("tg.workers", 8)
+	viper.SetDefault("tg.queue_size", 100)
+	viper.SetDefault("tg.webhook.listen", ":8080")
+	viper.SetDefault("tg.webhook.path", "/webhook")
 	viper.SetDefault("db.max_conns", 10)
 	viper.SetDefault("db.idle_conns", 5)
 	viper.SetDefault("db.timeout", 30)
 	viper.SetDefault("redis.timeout", 30)
+	viper.SetDefault("scheduler.recurring_interval", "10m")
+	viper.SetDefault("scheduler.stats_interval", "1m")
+	viper.SetDefault("scheduler.notify_interval", "5m")
+	viper.SetDefault("scheduler.budget_interval", "15m")
+	viper.SetDefault("currency.default", "RUB")
+	viper.SetDefault("currency.rates_source", "file")
+	viper.SetDefault("currency.rates_file", "internal/pkg/config/rates.json")
 
 	// Получаем окружение (из ENV или default)
 	env := viper.GetString("app.env")
@@ -107,6 +160,32 @@ func (c *Config) Validate() error {
 	if c.TG.Token == "" {
 		return fmt.Errorf("telegram.token не может быть пустым")
 	}
+	if c.TG.TypePolling != "longpolling" && c.TG.TypePolling != "webhook" {
+		return fmt.Errorf("tg.type_polling должен быть longpolling или webhook")
+	}
+	if c.TG.ShutdownTimeout <= 0 {
+		return fmt.Errorf("tg.shutdown_timeout не может быть меньше или равно 0")
+	}
+	if c.TG.Workers <= 0 {
+		return fmt.Errorf("tg.workers не может быть меньше или равно 0")
+	}
+	if c.TG.QueueSize <= 0 {
+		return fmt.Errorf("tg.queue_size не может быть меньше или равно 0")
+	}
+	if c.TG.TypePolling == "webhook" {
+		if c.TG.Webhook.URL == "" {
+			return fmt.Errorf("tg.webhook.url не может быть пустым")
+		}
+		if c.TG.Webhook.Listen == "" {
+			return fmt.Errorf("tg.webhook.listen не может быть пустым")
+		}
+		if c.TG.Webhook.Path == "" || c.TG.Webhook.Path[0] != '/' {
+			return fmt.Errorf("tg.webhook.path должен начинаться с /")
+		}
+		if !secretTokenRe.MatchString(c.TG.Webhook.SecretToken) {
+			return fmt.Errorf("tg.webhook.secret_token должен содержать от 1 до 256 символов A-Z, a-z, 0-9, _ и -")
+		}
+	}
 	if c.DB.URL == "" {
 		return fmt.Errorf("db.url не может быть пустым")
 	}
@@ -134,6 +213,27 @@ func (c *Config) Validate() error {
 	if c.Redis.Timeout <= 0 {
 		return fmt.Errorf("redis.timeout не может быть меньше или равно 0")
 	}
+	if c.Scheduler.RecurringInterval <= 0 {
+		return fmt.Errorf("scheduler.recurring_interval не может быть меньше или равно 0")
+	}
+	if c.Scheduler.StatsInterval <= 0 {
+		return fmt.Errorf("scheduler.stats_interval не может быть меньше или равно 0")
+	}
+	if c.Scheduler.NotifyInterval <= 0 {
+		return fmt.Errorf("scheduler.notify_interval не может быть меньше или равно 0")
+	}
+	if c.Scheduler.BudgetInterval <= 0 {
+		return fmt.Errorf("scheduler.budget_interval не может быть меньше или равно 0")
+	}
+	if len(c.Currency.Default) != 3 {
+		return fmt.Errorf("currency.default должен быть кодом валюты ISO 4217")
+	}
+	if c.Currency.RatesSource != "file" && c.Currency.RatesSource != "db" {
+		return fmt.Errorf("currency.rates_source должен быть file или db")
+	}
+	if c.Currency.RatesSource == "file" && c.Currency.RatesFile == "" {
+		return fmt.Errorf("currency.rates_file не может быть пустым")
+	}
 
 	return nil
 }
@@ -0,0 +1,167 @@
+// Package dispatcher выполняет задачи параллельно, сохраняя порядок задач с одинаковым ключом
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClosed возвращается при отправке задачи в закрытый диспетчер
+var ErrClosed = errors.New("dispatcher is closed")
+
+// Task задача диспетчера
+type Task func(ctx context.Context)
+
+type job struct {
+	ctx  context.Context
+	key  int64
+	task Task
+}
+
+// Stats - метрики диспетчера
+type Stats struct {
+	Workers   int   // Количество обработчиков
+	Queued    int   // Задач в очередях
+	MaxQueue  int   // Длина самой загруженной очереди
+	QueueSize int   // Емкость очереди одного обработчика
+	InFlight  int64 // Выполняемых задач
+	Processed int64 // Выполнено задач
+	Dropped   int64 // Отброшено задач с отмененным контекстом
+	Panics    int64 // Задач, завершившихся паникой
+}
+
+// Dispatcher распределяет задачи между обработчиками по ключу.
+//
+// Задачи с одинаковым ключом (ID чата) попадают в одну очередь и выполняются
+// строго по порядку, задачи с разными ключами - параллельно. Очереди ограничены:
+// при переполнении Dispatch ожидает освобождения места
+type Dispatcher struct {
+	queues []chan job
+	logger *slog.Logger
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+
+	inFlight  atomic.Int64
+	processed atomic.Int64
+	dropped   atomic.Int64
+	panics    atomic.Int64
+}
+
+// New создает диспетчер и запускает обработчики
+//
+// workers - количество обработчиков
+// queueSize - емкость очереди одного обработчика
+// logger - логгер
+func New(workers, queueSize int, logger *slog.Logger) *Dispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	d := &Dispatcher{
+		queues: make([]chan job, workers),
+		logger: logger,
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan job, queueSize)
+		d.wg.Add(1)
+		go d.run(d.queues[i])
+	}
+	return d
+}
+
+// Dispatch ставит задачу в очередь ключа key.
+//
+// Задача выполняется с контекстом ctx. Если очередь заполнена, Dispatch ожидает
+// освобождения места или отмены ctx
+func (d *Dispatcher) Dispatch(ctx context.Context, key int64, task Task) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return ErrClosed
+	}
+
+	queue := d.queues[uint64(key)%uint64(len(d.queues))]
+	j := job{ctx: ctx, key: key, task: task}
+	select {
+	case queue <- j:
+		return nil
+	default:
+	}
+
+	d.logger.Warn("Очередь обработчика заполнена", "key", key, "queueSize", cap(queue))
+	select {
+	case queue <- j:
+		return nil
+	case <-ctx.Done():
+		d.dropped.Add(1)
+		return ctx.Err()
+	}
+}
+
+// Close перестает принимать задачи и ожидает выполнения уже поставленных
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, queue := range d.queues {
+			close(queue)
+		}
+	}
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+// Stats возвращает текущие метрики диспетчера
+func (d *Dispatcher) Stats() Stats {
+	s := Stats{
+		Workers:   len(d.queues),
+		QueueSize: cap(d.queues[0]),
+		InFlight:  d.inFlight.Load(),
+		Processed: d.processed.Load(),
+		Dropped:   d.dropped.Load(),
+		Panics:    d.panics.Load(),
+	}
+	for _, queue := range d.queues {
+		n := len(queue)
+		s.Queued += n
+		if n > s.MaxQueue {
+			s.MaxQueue = n
+		}
+	}
+	return s
+}
+
+func (d *Dispatcher) run(queue <-chan job) {
+	defer d.wg.Done()
+	for j := range queue {
+		if j.ctx.Err() != nil {
+			d.dropped.Add(1)
+			d.logger.Warn("Задача отброшена: контекст отменен", "key", j.key)
+			continue
+		}
+		d.exec(j)
+	}
+}
+
+// exec выполняет задачу, перехватывая панику
+func (d *Dispatcher) exec(j job) {
+	d.inFlight.Add(1)
+	now := time.Now()
+	defer func() {
+		d.inFlight.Add(-1)
+		d.processed.Add(1)
+		if r := recover(); r != nil {
+			d.panics.Add(1)
+			d.logger.Error("Паника при обработке задачи", "key", j.key, "panic", r, "duration", time.Since(now))
+		}
+	}()
+	j.task(j.ctx)
+}
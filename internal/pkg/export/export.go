@@ -0,0 +1,96 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/shopspring/decimal"
+)
+
+// Форматы выгрузки
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Row - строка выгрузки трат
+type Row struct {
+	Date        time.Time
+	Category    string
+	Amount      decimal.Decimal
+	Currency    string
+	BaseAmount  decimal.Decimal // Сумма в валюте итогов
+	Description string
+}
+
+// SummaryRow - итог по категории
+type SummaryRow struct {
+	Category string
+	Total    decimal.Decimal // Сумма в валюте итогов
+	Share    float64         // Доля от всех трат, %
+}
+
+var header = []string{"Дата", "Категория", "Сумма", "Валюта", "Описание"}
+
+// utf8BOM помогает Excel распознать кодировку CSV
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// WriteCSV записывает траты в CSV с BOM и заголовком
+func WriteCSV(w io.Writer, rows []Row) error {
+	if _, err := w.Write(utf8BOM); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		record := []string{
+			r.Date.Format("02.01.2006"),
+			r.Category,
+			formatAmount(r.Amount, r.Currency),
+			r.Currency,
+			r.Description,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Summarize считает итоги по категориям в валюте итогов (BaseAmount), отсортированные по убыванию суммы
+func Summarize(rows []Row) []SummaryRow {
+	totals := make(map[string]decimal.Decimal)
+	sum := decimal.Zero
+	for _, r := range rows {
+		totals[r.Category] = totals[r.Category].Add(r.BaseAmount)
+		sum = sum.Add(r.BaseAmount)
+	}
+
+	summary := make([]SummaryRow, 0, len(totals))
+	for category, total := range totals {
+		var share float64
+		if sum.IsPositive() {
+			share = total.Div(sum).Mul(decimal.NewFromInt(100)).InexactFloat64()
+		}
+		summary = append(summary, SummaryRow{Category: category, Total: total, Share: share})
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		if summary[i].Total.Equal(summary[j].Total) {
+			return summary[i].Category < summary[j].Category
+		}
+		return summary[i].Total.GreaterThan(summary[j].Total)
+	})
+	return summary
+}
+
+// formatAmount форматирует сумму с числом знаков после запятой, принятым для валюты: "350.00", "350" для иен
+func formatAmount(amount decimal.Decimal, code string) string {
+	return amount.StringFixed(currency.Decimals(code))
+}
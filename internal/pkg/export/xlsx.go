@@ -0,0 +1,191 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Стили ячеек из styles.xml
+const (
+	styleDefault = 0
+	styleHeader  = 1 // Жирный шрифт
+	styleAmount  = 2 // Число с двумя знаками после запятой
+)
+
+// cell - ячейка листа: строка или число
+type cell struct {
+	text   string
+	number decimal.Decimal
+	isNum  bool
+	style  int
+}
+
+func textCell(s string, style int) cell            { return cell{text: s, style: style} }
+func numberCell(n decimal.Decimal, style int) cell { return cell{number: n, isNum: true, style: style} }
+func headerRow(names ...string) []cell {
+	row := make([]cell, 0, len(names))
+	for _, n := range names {
+		row = append(row, textCell(n, styleHeader))
+	}
+	return row
+}
+
+// WriteXLSX записывает траты в книгу XLSX с двумя листами:
+// "Расходы" со всеми тратами и "Итоги" с суммами по категориям в валюте baseCurrency.
+//
+// Книга собирается без внешних библиотек: минимальный набор частей SpreadsheetML в zip-архиве
+func WriteXLSX(w io.Writer, rows []Row, baseCurrency string) error {
+	expenses := [][]cell{headerRow(header...)}
+	total := decimal.Zero
+	for _, r := range rows {
+		expenses = append(expenses, []cell{
+			textCell(r.Date.Format("02.01.2006"), styleDefault),
+			textCell(r.Category, styleDefault),
+			numberCell(r.Amount, styleAmount),
+			textCell(r.Currency, styleDefault),
+			textCell(r.Description, styleDefault),
+		})
+		total = total.Add(r.BaseAmount)
+	}
+
+	summary := [][]cell{headerRow("Категория", "Сумма, "+baseCurrency, "Доля, %")}
+	for _, s := range Summarize(rows) {
+		summary = append(summary, []cell{
+			textCell(s.Category, styleDefault),
+			numberCell(s.Total, styleAmount),
+			numberCell(decimal.NewFromFloat(s.Share).Round(2), styleAmount),
+		})
+	}
+	summary = append(summary, []cell{
+		textCell("Итого", styleHeader),
+		numberCell(total, styleAmount),
+		numberCell(decimal.NewFromInt(100), styleAmount),
+	})
+
+	sheets := []struct {
+		name string
+		rows [][]cell
+	}{
+		{name: "Расходы", rows: expenses},
+		{name: "Итоги", rows: summary},
+	}
+
+	zw := zip.NewWriter(w)
+	parts := map[string]string{
+		"[Content_Types].xml":        contentTypes(len(sheets)),
+		"_rels/.rels":                rootRels,
+		"xl/_rels/workbook.xml.rels": workbookRels(len(sheets)),
+		"xl/styles.xml":              styles,
+	}
+	var workbook strings.Builder
+	workbook.WriteString(xml.Header)
+	workbook.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, s := range sheets {
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(s.name), i+1, i+1)
+		parts[fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)] = sheetXML(s.rows)
+	}
+	workbook.WriteString(`</sheets></workbook>`)
+	parts["xl/workbook.xml"] = workbook.String()
+
+	// Порядок частей фиксирован, чтобы одинаковые данные давали одинаковый файл
+	order := []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"}
+	for i := range sheets {
+		order = append(order, fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+	}
+	for _, name := range order {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, parts[name]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// sheetXML формирует лист с ячейками inlineStr, чтобы не вести sharedStrings
+func sheetXML(rows [][]cell) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, c := range row {
+			ref := columnName(j) + strconv.Itoa(i+1)
+			if c.isNum {
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, c.style, c.number.String())
+				continue
+			}
+			fmt.Fprintf(&b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, c.style, escape(c.text))
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+// columnName возвращает буквенное имя колонки: 0 -> A, 25 -> Z, 26 -> AA
+func columnName(i int) string {
+	name := ""
+	for i >= 0 {
+		name = string(rune('A'+i%26)) + name
+		i = i/26 - 1
+	}
+	return name
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func contentTypes(sheets int) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func workbookRels(sheets int) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, sheets+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const styles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`</styleSheet>`
@@ -0,0 +1,206 @@
+package qr
+
+import "image"
+
+// bitMatrix - черно-белое изображение или сетка модулей кода, true - черный
+type bitMatrix struct {
+	width, height int
+	bits          []bool
+}
+
+func newBitMatrix(width, height int) *bitMatrix {
+	return &bitMatrix{width: width, height: height, bits: make([]bool, width*height)}
+}
+
+// get возвращает цвет точки. Точки за пределами изображения считаются белыми
+func (m *bitMatrix) get(x, y int) bool {
+	if x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return false
+	}
+	return m.bits[y*m.width+x]
+}
+
+func (m *bitMatrix) set(x, y int, v bool) {
+	m.bits[y*m.width+x] = v
+}
+
+// setRegion закрашивает прямоугольник черным
+func (m *bitMatrix) setRegion(left, top, width, height int) {
+	for y := top; y < top+height; y++ {
+		for x := left; x < left+width; x++ {
+			m.set(x, y, true)
+		}
+	}
+}
+
+// transpose отражает матрицу относительно главной диагонали
+func (m *bitMatrix) transpose() *bitMatrix {
+	t := newBitMatrix(m.height, m.width)
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			t.set(y, x, m.get(x, y))
+		}
+	}
+	return t
+}
+
+// grayImage - яркость точек изображения 0-255
+type grayImage struct {
+	width, height int
+	pix           []uint8
+}
+
+// luminance переводит изображение в оттенки серого
+func luminance(img image.Image) *grayImage {
+	b := img.Bounds()
+	g := &grayImage{width: b.Dx(), height: b.Dy(), pix: make([]uint8, b.Dx()*b.Dy())}
+	if gray, ok := img.(*image.Gray); ok {
+		for y := 0; y < g.height; y++ {
+			copy(g.pix[y*g.width:(y+1)*g.width], gray.Pix[y*gray.Stride:])
+		}
+		return g
+	}
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			r, gr, bl, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			// Цвета уже умножены на прозрачность, прозрачные точки считаются белыми
+			l := (299*r+587*gr+114*bl)/1000 + 0xffff - a
+			g.pix[y*g.width+x] = uint8(l >> 8)
+		}
+	}
+	return g
+}
+
+const (
+	// blockSize - сторона блока локальной бинаризации
+	blockSize = 8
+	// minDynamicRange - разброс яркости блока, ниже которого блок считается однотонным
+	minDynamicRange = 24
+	// minHybridSize - меньшие изображения бинаризуются по общему порогу
+	minHybridSize = 5 * blockSize
+)
+
+// hybridBinarize бинаризует изображение по средней яркости окрестности 5x5 блоков.
+// Так код остается читаемым при тенях и неравномерном освещении фото
+func hybridBinarize(g *grayImage) *bitMatrix {
+	if g.width < minHybridSize || g.height < minHybridSize {
+		return globalBinarize(g)
+	}
+	subW := (g.width + blockSize - 1) / blockSize
+	subH := (g.height + blockSize - 1) / blockSize
+	black := blockBlackPoints(g, subW, subH)
+
+	m := newBitMatrix(g.width, g.height)
+	for by := 0; by < subH; by++ {
+		top := min(by*blockSize, g.height-blockSize)
+		cy := clamp(by, 2, subH-3)
+		for bx := 0; bx < subW; bx++ {
+			left := min(bx*blockSize, g.width-blockSize)
+			cx := clamp(bx, 2, subW-3)
+			sum := 0
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					sum += black[clamp(cy+dy, 0, subH-1)*subW+clamp(cx+dx, 0, subW-1)]
+				}
+			}
+			threshold := sum / 25
+			for y := top; y < top+blockSize; y++ {
+				for x := left; x < left+blockSize; x++ {
+					m.set(x, y, int(g.pix[y*g.width+x]) <= threshold)
+				}
+			}
+		}
+	}
+	return m
+}
+
+// blockBlackPoints вычисляет порог черного для каждого блока.
+//
+// Однотонный блок получает половину минимальной яркости, то есть считается белым,
+// если только соседние блоки не показывают, что он лежит внутри темной области
+func blockBlackPoints(g *grayImage, subW, subH int) []int {
+	black := make([]int, subW*subH)
+	for by := 0; by < subH; by++ {
+		top := min(by*blockSize, g.height-blockSize)
+		for bx := 0; bx < subW; bx++ {
+			left := min(bx*blockSize, g.width-blockSize)
+			sum, lo, hi := 0, 255, 0
+			for y := top; y < top+blockSize; y++ {
+				for _, p := range g.pix[y*g.width+left : y*g.width+left+blockSize] {
+					v := int(p)
+					sum += v
+					lo, hi = min(lo, v), max(hi, v)
+				}
+			}
+
+			avg := sum / (blockSize * blockSize)
+			if hi-lo <= minDynamicRange {
+				avg = lo / 2
+				if by > 0 && bx > 0 {
+					// Среднее соседей сверху и слева
+					neighbors := (black[(by-1)*subW+bx] + 2*black[by*subW+bx-1] + black[(by-1)*subW+bx-1]) / 4
+					if lo < neighbors {
+						avg = neighbors
+					}
+				}
+			}
+			black[by*subW+bx] = avg
+		}
+	}
+	return black
+}
+
+// globalBinarize бинаризует изображение по порогу, найденному по гистограмме яркости:
+// порог ставится во впадине между пиками темных и светлых точек
+func globalBinarize(g *grayImage) *bitMatrix {
+	var hist [32]int
+	for _, p := range g.pix {
+		hist[p>>3]++
+	}
+
+	// Самый высокий пик
+	first := 0
+	for i, c := range hist {
+		if c > hist[first] {
+			first = i
+		}
+	}
+	// Второй пик - с учетом расстояния до первого
+	second, secondScore := 0, 0
+	for i, c := range hist {
+		d := i - first
+		if score := c * d * d; score > secondScore {
+			second, secondScore = i, score
+		}
+	}
+	if first > second {
+		first, second = second, first
+	}
+
+	// Впадина между пиками, ближе к светлому
+	valley, valleyScore := second-1, -1
+	for i := second - 1; i > first; i-- {
+		d := i - first
+		score := d * d * (second - i) * (hist[first] - hist[i])
+		if score > valleyScore {
+			valley, valleyScore = i, score
+		}
+	}
+	threshold := valley << 3
+
+	m := newBitMatrix(g.width, g.height)
+	for i, p := range g.pix {
+		m.bits[i] = int(p) < threshold
+	}
+	return m
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,198 @@
+package qr
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Режимы сегментов данных
+const (
+	modeTerminator   = 0x0
+	modeNumeric      = 0x1
+	modeAlphanumeric = 0x2
+	modeStructured   = 0x3
+	modeByte         = 0x4
+	modeFNC1First    = 0x5
+	modeECI          = 0x7
+	modeKanji        = 0x8
+	modeFNC1Second   = 0x9
+	modeHanzi        = 0xd
+)
+
+// alphanumericTable - символы буквенно-цифрового режима
+const alphanumericTable = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// bitReader читает поток данных по битам от старшего к младшему
+type bitReader struct {
+	data []byte
+	pos  int // Номер следующего бита
+}
+
+func (r *bitReader) available() int {
+	return len(r.data)*8 - r.pos
+}
+
+// read читает n бит. Возвращает false, если бит не хватает
+func (r *bitReader) read(n int) (int, bool) {
+	if n > r.available() {
+		return 0, false
+	}
+	v := 0
+	for i := 0; i < n; i++ {
+		v <<= 1
+		if r.data[r.pos/8]&(0x80>>(r.pos%8)) != 0 {
+			v |= 1
+		}
+		r.pos++
+	}
+	return v, true
+}
+
+// countBits возвращает длину поля количества символов для режима и версии
+func countBits(mode, version int) int {
+	var bits [3]int
+	switch mode {
+	case modeNumeric:
+		bits = [3]int{10, 12, 14}
+	case modeAlphanumeric:
+		bits = [3]int{9, 11, 13}
+	case modeByte:
+		bits = [3]int{8, 16, 16}
+	default:
+		bits = [3]int{8, 10, 12}
+	}
+	switch {
+	case version <= 9:
+		return bits[0]
+	case version <= 26:
+		return bits[1]
+	default:
+		return bits[2]
+	}
+}
+
+// decodeData разбирает сегменты потока данных.
+// Байтовые сегменты в UTF-8 сохраняются как есть, остальные читаются как ISO-8859-1
+func decodeData(data []byte, version int) (string, error) {
+	r := &bitReader{data: data}
+	var sb strings.Builder
+	for r.available() >= 4 {
+		mode, _ := r.read(4)
+		switch mode {
+		case modeTerminator:
+			return sb.String(), nil
+		case modeFNC1First, modeFNC1Second:
+			// Признак GS1 не влияет на текст
+			if mode == modeFNC1Second {
+				if _, ok := r.read(8); !ok {
+					return "", ErrUnreadable
+				}
+			}
+		case modeStructured:
+			// Номер части и четность составного кода
+			if _, ok := r.read(16); !ok {
+				return "", ErrUnreadable
+			}
+		case modeECI:
+			if !skipECI(r) {
+				return "", ErrUnreadable
+			}
+		case modeNumeric, modeAlphanumeric, modeByte:
+			count, ok := r.read(countBits(mode, version))
+			if !ok {
+				return "", ErrUnreadable
+			}
+			switch mode {
+			case modeNumeric:
+				ok = decodeNumeric(r, count, &sb)
+			case modeAlphanumeric:
+				ok = decodeAlphanumeric(r, count, &sb)
+			default:
+				ok = decodeBytes(r, count, &sb)
+			}
+			if !ok {
+				return "", ErrUnreadable
+			}
+		case modeKanji, modeHanzi:
+			return "", ErrUnsupported
+		default:
+			return "", ErrUnreadable
+		}
+	}
+	return sb.String(), nil
+}
+
+// skipECI пропускает номер набора символов: 1, 2 или 3 байта в зависимости от старших бит
+func skipECI(r *bitReader) bool {
+	first, ok := r.read(8)
+	if !ok {
+		return false
+	}
+	switch {
+	case first&0x80 == 0:
+		return true
+	case first&0xc0 == 0x80:
+		_, ok = r.read(8)
+	case first&0xe0 == 0xc0:
+		_, ok = r.read(16)
+	default:
+		return false
+	}
+	return ok
+}
+
+// decodeNumeric читает цифры группами по три в 10 битах, остаток - в 7 или 4 битах
+func decodeNumeric(r *bitReader, count int, sb *strings.Builder) bool {
+	for count > 0 {
+		digits, bits := min(count, 3), [4]int{0, 4, 7, 10}[min(count, 3)]
+		v, ok := r.read(bits)
+		if !ok || v >= [4]int{1, 10, 100, 1000}[digits] {
+			return false
+		}
+		for d := digits - 1; d >= 0; d-- {
+			sb.WriteByte(byte('0' + v/[3]int{1, 10, 100}[d]%10))
+		}
+		count -= digits
+	}
+	return true
+}
+
+// decodeAlphanumeric читает пары символов в 11 битах, последний нечетный символ - в 6 битах
+func decodeAlphanumeric(r *bitReader, count int, sb *strings.Builder) bool {
+	for ; count > 1; count -= 2 {
+		v, ok := r.read(11)
+		if !ok || v >= 45*45 {
+			return false
+		}
+		sb.WriteByte(alphanumericTable[v/45])
+		sb.WriteByte(alphanumericTable[v%45])
+	}
+	if count == 1 {
+		v, ok := r.read(6)
+		if !ok || v >= 45 {
+			return false
+		}
+		sb.WriteByte(alphanumericTable[v])
+	}
+	return true
+}
+
+// decodeBytes читает count байт
+func decodeBytes(r *bitReader, count int, sb *strings.Builder) bool {
+	if count*8 > r.available() {
+		return false
+	}
+	buf := make([]byte, count)
+	for i := range buf {
+		v, _ := r.read(8)
+		buf[i] = byte(v)
+	}
+	if utf8.Valid(buf) {
+		sb.Write(buf)
+		return true
+	}
+	for _, c := range buf {
+		sb.WriteRune(rune(c))
+	}
+	return true
+}
@@ -0,0 +1,213 @@
+package qr
+
+// decodeGrid декодирует сетку модулей. Если код не читается, сетка
+// пробуется в зеркальном отражении: так выглядит код, снятый через стекло
+func decodeGrid(grid *bitMatrix) (string, error) {
+	text, err := decodeModules(grid)
+	if err == nil {
+		return text, nil
+	}
+	if mirrored, e := decodeModules(grid.transpose()); e == nil {
+		return mirrored, nil
+	}
+	return "", err
+}
+
+// decodeModules читает формат и версию, снимает маску, исправляет ошибки
+// и разбирает поток данных
+func decodeModules(grid *bitMatrix) (string, error) {
+	// Без формата и версии найденные узоры, скорее всего, не были кодом
+	format, ok := readFormat(grid)
+	if !ok {
+		return "", ErrNotFound
+	}
+	version, ok := readVersion(grid)
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	blocks := versionBlocks[version-1][format.level]
+	codewords := readCodewords(grid, version, format.mask, blocks.totalCodewords())
+	if codewords == nil {
+		return "", ErrUnreadable
+	}
+	data, ok := correctBlocks(codewords, blocks)
+	if !ok {
+		return "", ErrUnreadable
+	}
+	return decodeData(data, version)
+}
+
+// readFormat читает обе копии информации о формате: вокруг верхнего левого
+// поискового узора и разнесенную между верхним правым и нижним левым
+func readFormat(grid *bitMatrix) (formatInfo, bool) {
+	dimension := grid.width
+	bits1 := 0
+	copyBit := func(bits *int, x, y int) {
+		*bits <<= 1
+		if grid.get(x, y) {
+			*bits |= 1
+		}
+	}
+	for x := 0; x < 6; x++ {
+		copyBit(&bits1, x, 8)
+	}
+	copyBit(&bits1, 7, 8)
+	copyBit(&bits1, 8, 8)
+	copyBit(&bits1, 8, 7)
+	for y := 5; y >= 0; y-- {
+		copyBit(&bits1, 8, y)
+	}
+
+	bits2 := 0
+	for y := dimension - 1; y >= dimension-7; y-- {
+		copyBit(&bits2, 8, y)
+	}
+	for x := dimension - 8; x < dimension; x++ {
+		copyBit(&bits2, x, 8)
+	}
+	return decodeFormat(bits1, bits2)
+}
+
+// readVersion определяет версию по размеру, а для версий 7-40 - по информации
+// о версии у верхнего правого или нижнего левого поискового узора
+func readVersion(grid *bitMatrix) (int, bool) {
+	dimension := grid.width
+	provisional := (dimension - 17) / 4
+	if provisional <= 6 {
+		return provisional, provisional >= 1
+	}
+
+	bits := 0
+	for y := 5; y >= 0; y-- {
+		for x := dimension - 9; x >= dimension-11; x-- {
+			bits <<= 1
+			if grid.get(x, y) {
+				bits |= 1
+			}
+		}
+	}
+	if v, ok := decodeVersion(bits); ok && dimensionForVersion(v) == dimension {
+		return v, true
+	}
+
+	bits = 0
+	for x := 5; x >= 0; x-- {
+		for y := dimension - 9; y >= dimension-11; y-- {
+			bits <<= 1
+			if grid.get(x, y) {
+				bits |= 1
+			}
+		}
+	}
+	if v, ok := decodeVersion(bits); ok && dimensionForVersion(v) == dimension {
+		return v, true
+	}
+	return 0, false
+}
+
+// masked проверяет условие маски для модуля в строке i и столбце j
+func masked(mask, i, j int) bool {
+	switch mask {
+	case 0:
+		return (i+j)%2 == 0
+	case 1:
+		return i%2 == 0
+	case 2:
+		return j%3 == 0
+	case 3:
+		return (i+j)%3 == 0
+	case 4:
+		return (i/2+j/3)%2 == 0
+	case 5:
+		return (i*j)%2+(i*j)%3 == 0
+	case 6:
+		return ((i*j)%2+(i*j)%3)%2 == 0
+	default:
+		return ((i+j)%2+(i*j)%3)%2 == 0
+	}
+}
+
+// readCodewords читает кодовые слова змейкой по парам столбцов снизу вверх
+// и обратно, пропуская служебные узоры и снимая маску
+func readCodewords(grid *bitMatrix, version, mask, total int) []byte {
+	dimension := grid.width
+	function := functionPattern(version)
+	result := make([]byte, 0, total)
+	var current byte
+	bitsRead := 0
+	up := true
+	for x := dimension - 1; x > 0; x -= 2 {
+		// Вертикальная полоса синхронизации занимает целый столбец
+		if x == 6 {
+			x--
+		}
+		for count := 0; count < dimension; count++ {
+			y := count
+			if up {
+				y = dimension - 1 - count
+			}
+			for col := 0; col < 2; col++ {
+				if function.get(x-col, y) {
+					continue
+				}
+				current <<= 1
+				if grid.get(x-col, y) != masked(mask, y, x-col) {
+					current |= 1
+				}
+				bitsRead++
+				if bitsRead == 8 {
+					result = append(result, current)
+					current, bitsRead = 0, 0
+				}
+			}
+		}
+		up = !up
+	}
+	if len(result) < total {
+		return nil
+	}
+	return result[:total]
+}
+
+// correctBlocks разбирает чередующиеся кодовые слова по блокам, исправляет ошибки
+// в каждом блоке и возвращает байты данных по порядку
+func correctBlocks(codewords []byte, blocks ecBlocks) ([]byte, bool) {
+	var sizes []int
+	for _, g := range blocks.groups {
+		for i := 0; i < g.count; i++ {
+			sizes = append(sizes, g.data)
+		}
+	}
+	data := make([][]byte, len(sizes))
+	ec := make([][]byte, len(sizes))
+
+	// Сначала идут байты данных: i-й байт каждого блока по очереди.
+	// Блоки второй группы длиннее на байт
+	offset := 0
+	longest := sizes[len(sizes)-1]
+	for i := 0; i < longest; i++ {
+		for b, size := range sizes {
+			if i < size {
+				data[b] = append(data[b], codewords[offset])
+				offset++
+			}
+		}
+	}
+	for i := 0; i < blocks.ecPerBlock; i++ {
+		for b := range sizes {
+			ec[b] = append(ec[b], codewords[offset])
+			offset++
+		}
+	}
+
+	var result []byte
+	for b := range sizes {
+		block := append(data[b], ec[b]...)
+		if !correctErrors(block, blocks.ecPerBlock) {
+			return nil, false
+		}
+		result = append(result, block[:sizes[b]]...)
+	}
+	return result, true
+}
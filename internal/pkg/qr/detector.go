@@ -0,0 +1,396 @@
+package qr
+
+import "math"
+
+// detect вычисляет размер кода по трем поисковым узорам, уточняет перспективу
+// по выравнивающему узору и считывает сетку модулей
+func detect(image *bitMatrix, t finderTriple) (*bitMatrix, error) {
+	moduleSize := (moduleSizeOneWay(image, t.topLeft, t.topRight) + moduleSizeOneWay(image, t.topLeft, t.bottomLeft)) / 2
+	if moduleSize < 1 || math.IsNaN(moduleSize) {
+		return nil, ErrNotFound
+	}
+	dimension, ok := computeDimension(t, moduleSize)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	// Выравнивающий узор ищется около нижнего правого угла, где его ожидает
+	// аффинное преобразование по трем поисковым узорам
+	var alignment *finderPattern
+	if version := (dimension - 17) / 4; version > 1 {
+		between := float64(dimension - 7)
+		correction := 1 - 3/between
+		bottomRightX := t.topRight.x - t.topLeft.x + t.bottomLeft.x
+		bottomRightY := t.topRight.y - t.topLeft.y + t.bottomLeft.y
+		estX := int(t.topLeft.x + correction*(bottomRightX-t.topLeft.x))
+		estY := int(t.topLeft.y + correction*(bottomRightY-t.topLeft.y))
+		for allowance := 4; allowance <= 16 && alignment == nil; allowance <<= 1 {
+			alignment = findAlignmentInRegion(image, moduleSize, estX, estY, allowance)
+		}
+	}
+
+	transform := gridTransform(t, alignment, dimension)
+	return sampleGrid(image, transform, dimension)
+}
+
+// moduleSizeOneWay оценивает размер модуля по черно-белым полосам поисковых узоров,
+// измеренным вдоль линии между центрами from и to
+func moduleSizeOneWay(image *bitMatrix, from, to *finderPattern) float64 {
+	est1 := runBothWays(image, int(from.x), int(from.y), int(to.x), int(to.y))
+	est2 := runBothWays(image, int(to.x), int(to.y), int(from.x), int(from.y))
+	switch {
+	case math.IsNaN(est1):
+		return est2 / 7
+	case math.IsNaN(est2):
+		return est1 / 7
+	}
+	return (est1 + est2) / 14
+}
+
+// runBothWays измеряет поисковый узор вдоль линии в обе стороны от центра.
+// Узор занимает 7 модулей, поэтому результат - семь размеров модуля
+func runBothWays(image *bitMatrix, fromX, fromY, toX, toY int) float64 {
+	result := blackWhiteBlackRun(image, fromX, fromY, toX, toY)
+
+	// Продолжение линии в обратную сторону, не выходя за изображение
+	scale := 1.0
+	otherX := fromX - (toX - fromX)
+	if otherX < 0 {
+		scale = float64(fromX) / float64(fromX-otherX)
+		otherX = 0
+	} else if otherX >= image.width {
+		scale = float64(image.width-1-fromX) / float64(otherX-fromX)
+		otherX = image.width - 1
+	}
+	otherY := int(float64(fromY) - float64(toY-fromY)*scale)
+
+	scale = 1.0
+	if otherY < 0 {
+		scale = float64(fromY) / float64(fromY-otherY)
+		otherY = 0
+	} else if otherY >= image.height {
+		scale = float64(image.height-1-fromY) / float64(otherY-fromY)
+		otherY = image.height - 1
+	}
+	otherX = int(float64(fromX) + float64(otherX-fromX)*scale)
+
+	result += blackWhiteBlackRun(image, fromX, fromY, otherX, otherY)
+	// Центральная точка посчитана дважды
+	return result - 1
+}
+
+// blackWhiteBlackRun проходит по линии алгоритмом Брезенхэма от центра узора
+// и возвращает расстояние до конца внешней черной рамки
+func blackWhiteBlackRun(image *bitMatrix, fromX, fromY, toX, toY int) float64 {
+	steep := abs(toY-fromY) > abs(toX-fromX)
+	if steep {
+		fromX, fromY = fromY, fromX
+		toX, toY = toY, toX
+	}
+
+	dx, dy := abs(toX-fromX), abs(toY-fromY)
+	errAcc := -dx / 2
+	xStep, yStep := 1, 1
+	if fromX > toX {
+		xStep = -1
+	}
+	if fromY > toY {
+		yStep = -1
+	}
+
+	state := 0
+	xLimit := toX + xStep
+	for x, y := fromX, fromY; x != xLimit; x += xStep {
+		realX, realY := x, y
+		if steep {
+			realX, realY = y, x
+		}
+		// Смена цвета: черный центр -> белая полоса -> черная рамка
+		if (state == 1) == image.get(realX, realY) {
+			if state == 2 {
+				return math.Hypot(float64(x-fromX), float64(y-fromY))
+			}
+			state++
+		}
+		errAcc += dy
+		if errAcc > 0 {
+			if y == toY {
+				break
+			}
+			y += yStep
+			errAcc -= dx
+		}
+	}
+	// Рамка доходит до конца линии
+	if state == 2 {
+		return math.Hypot(float64(toX+xStep-fromX), float64(toY-fromY))
+	}
+	return math.NaN()
+}
+
+// computeDimension вычисляет сторону кода в модулях по расстояниям между узорами.
+// Сторона кода всегда равна 4k+1
+func computeDimension(t finderTriple, moduleSize float64) (int, bool) {
+	tltr := int(math.Round(math.Sqrt(squaredDistance(t.topLeft, t.topRight)) / moduleSize))
+	tlbl := int(math.Round(math.Sqrt(squaredDistance(t.topLeft, t.bottomLeft)) / moduleSize))
+	dimension := (tltr+tlbl)/2 + 7
+	switch dimension & 3 {
+	case 0:
+		dimension++
+	case 2:
+		dimension--
+	case 3:
+		return 0, false
+	}
+	return dimension, dimension >= 21 && dimension <= maxModules
+}
+
+// findAlignmentInRegion ищет выравнивающий узор в квадрате со стороной 2*allowance модулей
+// вокруг предполагаемого центра
+func findAlignmentInRegion(image *bitMatrix, moduleSize float64, estX, estY, allowance int) *finderPattern {
+	a := int(float64(allowance) * moduleSize)
+	left := max(0, estX-a)
+	right := min(image.width-1, estX+a)
+	top := max(0, estY-a)
+	bottom := min(image.height-1, estY+a)
+	if float64(right-left) < moduleSize*3 || float64(bottom-top) < moduleSize*3 {
+		return nil
+	}
+	f := &alignmentFinder{image: image, moduleSize: moduleSize}
+	return f.find(left, top, right-left, bottom-top)
+}
+
+// alignmentFinder ищет выравнивающий узор 5x5 модулей по полосам 1:1:1 вокруг его черного центра
+type alignmentFinder struct {
+	image      *bitMatrix
+	moduleSize float64
+	centers    []*finderPattern
+}
+
+// find просматривает строки области от середины к краям. Возвращает узор, найденный дважды,
+// а если таких нет - первый найденный
+func (f *alignmentFinder) find(startX, startY, width, height int) *finderPattern {
+	maxX := startX + width
+	middleY := startY + height/2
+	for gen := 0; gen < height; gen++ {
+		y := middleY + (gen+1)/2
+		if gen&1 == 1 {
+			y = middleY - (gen+1)/2
+		}
+
+		var state [3]int
+		x := startX
+		// Начало строки до первой черной точки пропускается: длина той белой полосы неизвестна
+		for x < maxX && !f.image.get(x, y) {
+			x++
+		}
+		cur := 0
+		for ; x < maxX; x++ {
+			if !f.image.get(x, y) {
+				if cur == 1 {
+					cur++
+				}
+				state[cur]++
+				continue
+			}
+			switch cur {
+			case 1:
+				state[1]++
+			case 2:
+				if f.foundPatternCross(state) {
+					if p := f.handlePossibleCenter(state, y, x); p != nil {
+						return p
+					}
+				}
+				state, cur = [3]int{state[2], 1, 0}, 1
+			default:
+				cur++
+				state[cur]++
+			}
+		}
+		if f.foundPatternCross(state) {
+			if p := f.handlePossibleCenter(state, y, maxX); p != nil {
+				return p
+			}
+		}
+	}
+	if len(f.centers) > 0 {
+		return f.centers[0]
+	}
+	return nil
+}
+
+func (f *alignmentFinder) foundPatternCross(state [3]int) bool {
+	variance := f.moduleSize / 2
+	for _, c := range state {
+		if math.Abs(f.moduleSize-float64(c)) >= variance {
+			return false
+		}
+	}
+	return true
+}
+
+func alignmentCenterFromEnd(state [3]int, end int) float64 {
+	return float64(end-state[2]) - float64(state[1])/2
+}
+
+// handlePossibleCenter проверяет узор по столбцу и возвращает его, если он уже встречался
+func (f *alignmentFinder) handlePossibleCenter(state [3]int, row, end int) *finderPattern {
+	total := state[0] + state[1] + state[2]
+	centerX := alignmentCenterFromEnd(state, end)
+	centerY, ok := f.crossCheckVertical(row, int(centerX), 2*state[1], total)
+	if !ok {
+		return nil
+	}
+	size := float64(total) / 3
+	for _, c := range f.centers {
+		if c.aboutEquals(size, centerX, centerY) {
+			return &finderPattern{x: (c.x + centerX) / 2, y: (c.y + centerY) / 2, size: (c.size + size) / 2}
+		}
+	}
+	f.centers = append(f.centers, &finderPattern{x: centerX, y: centerY, size: size})
+	return nil
+}
+
+func (f *alignmentFinder) crossCheckVertical(startY, x, maxCount, total int) (float64, bool) {
+	var state [3]int
+	y := startY
+	for ; y >= 0 && f.image.get(x, y) && state[1] <= maxCount; y-- {
+		state[1]++
+	}
+	if y < 0 || state[1] > maxCount {
+		return 0, false
+	}
+	for ; y >= 0 && !f.image.get(x, y) && state[0] <= maxCount; y-- {
+		state[0]++
+	}
+	if state[0] > maxCount {
+		return 0, false
+	}
+
+	y = startY + 1
+	for ; y < f.image.height && f.image.get(x, y) && state[1] <= maxCount; y++ {
+		state[1]++
+	}
+	if y == f.image.height || state[1] > maxCount {
+		return 0, false
+	}
+	for ; y < f.image.height && !f.image.get(x, y) && state[2] <= maxCount; y++ {
+		state[2]++
+	}
+	if state[2] > maxCount {
+		return 0, false
+	}
+
+	sum := state[0] + state[1] + state[2]
+	if 5*abs(sum-total) >= 2*total || !f.foundPatternCross(state) {
+		return 0, false
+	}
+	return alignmentCenterFromEnd(state, y), true
+}
+
+// gridTransform строит перспективное преобразование из координат модулей в точки изображения.
+// Без выравнивающего узора четвертый угол достраивается до параллелограмма
+func gridTransform(t finderTriple, alignment *finderPattern, dimension int) *perspective {
+	dimMinusThree := float64(dimension) - 3.5
+	bottomRightX := t.topRight.x - t.topLeft.x + t.bottomLeft.x
+	bottomRightY := t.topRight.y - t.topLeft.y + t.bottomLeft.y
+	sourceBottomRight := dimMinusThree
+	if alignment != nil {
+		bottomRightX, bottomRightY = alignment.x, alignment.y
+		sourceBottomRight = dimMinusThree - 3
+	}
+	return quadrilateralToQuadrilateral(
+		[8]float64{3.5, 3.5, dimMinusThree, 3.5, sourceBottomRight, sourceBottomRight, 3.5, dimMinusThree},
+		[8]float64{t.topLeft.x, t.topLeft.y, t.topRight.x, t.topRight.y, bottomRightX, bottomRightY, t.bottomLeft.x, t.bottomLeft.y},
+	)
+}
+
+// sampleGrid считывает цвет центра каждого модуля. Центры, немного вышедшие
+// за край изображения, прижимаются к краю
+func sampleGrid(image *bitMatrix, transform *perspective, dimension int) (*bitMatrix, error) {
+	grid := newBitMatrix(dimension, dimension)
+	for y := 0; y < dimension; y++ {
+		for x := 0; x < dimension; x++ {
+			px, py := transform.apply(float64(x)+0.5, float64(y)+0.5)
+			ix, iy := int(px), int(py)
+			if ix < -1 || iy < -1 || ix > image.width || iy > image.height {
+				return nil, ErrNotFound
+			}
+			grid.set(x, y, image.get(clamp(ix, 0, image.width-1), clamp(iy, 0, image.height-1)))
+		}
+	}
+	return grid, nil
+}
+
+// perspective - матрица перспективного преобразования плоскости 3x3
+type perspective struct {
+	a11, a12, a13, a21, a22, a23, a31, a32, a33 float64
+}
+
+// quadrilateralToQuadrilateral строит преобразование, переводящее четырехугольник from в to.
+// Вершины заданы парами x, y по порядку обхода
+func quadrilateralToQuadrilateral(from, to [8]float64) *perspective {
+	return squareToQuadrilateral(to).times(squareToQuadrilateral(from).adjoint())
+}
+
+// squareToQuadrilateral переводит единичный квадрат в четырехугольник q
+func squareToQuadrilateral(q [8]float64) *perspective {
+	x0, y0, x1, y1, x2, y2, x3, y3 := q[0], q[1], q[2], q[3], q[4], q[5], q[6], q[7]
+	dx3 := x0 - x1 + x2 - x3
+	dy3 := y0 - y1 + y2 - y3
+	if dx3 == 0 && dy3 == 0 {
+		// Параллелограмм - аффинное преобразование
+		return &perspective{
+			a11: x1 - x0, a21: x2 - x1, a31: x0,
+			a12: y1 - y0, a22: y2 - y1, a32: y0,
+			a33: 1,
+		}
+	}
+	dx1, dx2 := x1-x2, x3-x2
+	dy1, dy2 := y1-y2, y3-y2
+	denominator := dx1*dy2 - dx2*dy1
+	a13 := (dx3*dy2 - dx2*dy3) / denominator
+	a23 := (dx1*dy3 - dx3*dy1) / denominator
+	return &perspective{
+		a11: x1 - x0 + a13*x1, a21: x3 - x0 + a23*x3, a31: x0,
+		a12: y1 - y0 + a13*y1, a22: y3 - y0 + a23*y3, a32: y0,
+		a13: a13, a23: a23, a33: 1,
+	}
+}
+
+// adjoint возвращает присоединенную матрицу: для преобразования она работает как обратная
+func (p *perspective) adjoint() *perspective {
+	return &perspective{
+		a11: p.a22*p.a33 - p.a23*p.a32,
+		a21: p.a23*p.a31 - p.a21*p.a33,
+		a31: p.a21*p.a32 - p.a22*p.a31,
+		a12: p.a13*p.a32 - p.a12*p.a33,
+		a22: p.a11*p.a33 - p.a13*p.a31,
+		a32: p.a12*p.a31 - p.a11*p.a32,
+		a13: p.a12*p.a23 - p.a13*p.a22,
+		a23: p.a13*p.a21 - p.a11*p.a23,
+		a33: p.a11*p.a22 - p.a12*p.a21,
+	}
+}
+
+// times возвращает композицию: сначала o, затем p
+func (p *perspective) times(o *perspective) *perspective {
+	return &perspective{
+		a11: p.a11*o.a11 + p.a21*o.a12 + p.a31*o.a13,
+		a21: p.a11*o.a21 + p.a21*o.a22 + p.a31*o.a23,
+		a31: p.a11*o.a31 + p.a21*o.a32 + p.a31*o.a33,
+		a12: p.a12*o.a11 + p.a22*o.a12 + p.a32*o.a13,
+		a22: p.a12*o.a21 + p.a22*o.a22 + p.a32*o.a23,
+		a32: p.a12*o.a31 + p.a22*o.a32 + p.a32*o.a33,
+		a13: p.a13*o.a11 + p.a23*o.a12 + p.a33*o.a13,
+		a23: p.a13*o.a21 + p.a23*o.a22 + p.a33*o.a23,
+		a33: p.a13*o.a31 + p.a23*o.a32 + p.a33*o.a33,
+	}
+}
+
+// apply переводит точку (x, y)
+func (p *perspective) apply(x, y float64) (float64, float64) {
+	d := p.a13*x + p.a23*y + p.a33
+	return (p.a11*x + p.a21*y + p.a31) / d, (p.a12*x + p.a22*y + p.a32) / d
+}
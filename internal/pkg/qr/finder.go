@@ -0,0 +1,293 @@
+package qr
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// maxModules - сторона самого большого кода (версия 40) без учета тихой зоны
+	maxModules = 177
+	// minSkip - шаг просмотра строк изображения при поиске поисковых узоров
+	minSkip = 3
+	// maxSizeRatio - во сколько раз размеры модулей трех узоров одного кода могут различаться
+	maxSizeRatio = 1.4
+)
+
+// finderPattern - найденный поисковый узор: квадрат 7x7 модулей в углу кода
+type finderPattern struct {
+	x, y  float64 // Центр
+	size  float64 // Размер модуля в точках
+	count int     // Сколько раз узор найден при просмотре строк
+}
+
+// finderTriple - три поисковых узора одного кода
+type finderTriple struct {
+	bottomLeft, topLeft, topRight *finderPattern
+}
+
+// finderFinder ищет поисковые узоры по соотношению полос 1:1:3:1:1
+type finderFinder struct {
+	image   *bitMatrix
+	centers []*finderPattern
+}
+
+// findFinderPatterns возвращает наборы из трех поисковых узоров,
+// упорядоченные по тому, насколько они похожи на углы одного кода
+func findFinderPatterns(image *bitMatrix) []finderTriple {
+	f := &finderFinder{image: image}
+	skip := max(3*image.height/(4*maxModules), minSkip)
+	for y := skip - 1; y < image.height; y += skip {
+		var state [5]int
+		cur := 0
+		for x := 0; x < image.width; x++ {
+			if image.get(x, y) {
+				// Черная точка после белой полосы начинает новую полосу
+				if cur&1 == 1 {
+					cur++
+				}
+				state[cur]++
+				continue
+			}
+			if cur&1 == 1 {
+				state[cur]++
+				continue
+			}
+			if cur < 4 {
+				cur++
+				state[cur]++
+				continue
+			}
+			// Пять полос собраны
+			if foundPatternCross(state) && f.handlePossibleCenter(state, y, x) {
+				state, cur = [5]int{}, 0
+				continue
+			}
+			state, cur = [5]int{state[2], state[3], state[4], 1, 0}, 3
+		}
+		if foundPatternCross(state) {
+			f.handlePossibleCenter(state, y, image.width)
+		}
+	}
+	return f.triples()
+}
+
+// foundPatternCross проверяет соотношение полос 1:1:3:1:1 с допуском в половину модуля
+func foundPatternCross(state [5]int) bool {
+	total := 0
+	for _, c := range state {
+		if c == 0 {
+			return false
+		}
+		total += c
+	}
+	if total < 7 {
+		return false
+	}
+	size := float64(total) / 7
+	variance := size / 2
+	return math.Abs(size-float64(state[0])) < variance &&
+		math.Abs(size-float64(state[1])) < variance &&
+		math.Abs(3*size-float64(state[2])) < 3*variance &&
+		math.Abs(size-float64(state[3])) < variance &&
+		math.Abs(size-float64(state[4])) < variance
+}
+
+// centerFromEnd возвращает центр узора по концу последней полосы
+func centerFromEnd(state [5]int, end int) float64 {
+	return float64(end-state[4]-state[3]) - float64(state[2])/2
+}
+
+// handlePossibleCenter проверяет узор в столбце через предполагаемый центр
+// и уточняет центр по строке. Подтвержденный узор объединяется с уже найденным рядом
+func (f *finderFinder) handlePossibleCenter(state [5]int, row, end int) bool {
+	total := 0
+	for _, c := range state {
+		total += c
+	}
+	centerX := centerFromEnd(state, end)
+	centerY, ok := f.crossCheck(int(centerX), row, state[2], total, true)
+	if !ok {
+		return false
+	}
+	centerX, ok = f.crossCheck(int(centerX), int(centerY), state[2], total, false)
+	if !ok {
+		return false
+	}
+
+	size := float64(total) / 7
+	for _, c := range f.centers {
+		if c.aboutEquals(size, centerX, centerY) {
+			n := float64(c.count)
+			c.x = (n*c.x + centerX) / (n + 1)
+			c.y = (n*c.y + centerY) / (n + 1)
+			c.size = (n*c.size + size) / (n + 1)
+			c.count++
+			return true
+		}
+	}
+	f.centers = append(f.centers, &finderPattern{x: centerX, y: centerY, size: size, count: 1})
+	return true
+}
+
+// crossCheck считает полосы узора от точки (x, y) по столбцу (vertical) или по строке
+// и возвращает уточненную координату центра вдоль этого направления.
+// maxCount ограничивает длину внешних полос, total - длина узора в исходной строке
+func (f *finderFinder) crossCheck(x, y, maxCount, total int, vertical bool) (float64, bool) {
+	get := func(i int) bool { return f.image.get(x, i) }
+	start, limit := y, f.image.height
+	if !vertical {
+		get = func(i int) bool { return f.image.get(i, y) }
+		start, limit = x, f.image.width
+	}
+
+	var state [5]int
+	i := start
+	for ; i >= 0 && get(i); i-- {
+		state[2]++
+	}
+	if i < 0 {
+		return 0, false
+	}
+	for ; i >= 0 && !get(i) && state[1] <= maxCount; i-- {
+		state[1]++
+	}
+	if i < 0 || state[1] > maxCount {
+		return 0, false
+	}
+	for ; i >= 0 && get(i) && state[0] <= maxCount; i-- {
+		state[0]++
+	}
+	if state[0] > maxCount {
+		return 0, false
+	}
+
+	i = start + 1
+	for ; i < limit && get(i); i++ {
+		state[2]++
+	}
+	if i == limit {
+		return 0, false
+	}
+	for ; i < limit && !get(i) && state[3] < maxCount; i++ {
+		state[3]++
+	}
+	if i == limit || state[3] >= maxCount {
+		return 0, false
+	}
+	for ; i < limit && get(i) && state[4] < maxCount; i++ {
+		state[4]++
+	}
+	if state[4] >= maxCount {
+		return 0, false
+	}
+
+	// Длина узора не должна сильно отличаться от длины в исходной строке
+	sum := state[0] + state[1] + state[2] + state[3] + state[4]
+	if 5*abs(sum-total) >= 2*total || !foundPatternCross(state) {
+		return 0, false
+	}
+	return centerFromEnd(state, i), true
+}
+
+// aboutEquals проверяет, что узор размера size с центром (x, y) - это уже найденный узор p
+func (p *finderPattern) aboutEquals(size, x, y float64) bool {
+	if math.Abs(y-p.y) > size || math.Abs(x-p.x) > size {
+		return false
+	}
+	diff := math.Abs(size - p.size)
+	return diff <= 1 || diff <= p.size
+}
+
+// triples перебирает тройки узоров с близкими размерами модулей и сортирует их
+// по отклонению от равнобедренного прямоугольного треугольника
+func (f *finderFinder) triples() []finderTriple {
+	centers := f.centers
+	// Узоры, найденные в нескольких строках, надежнее случайных совпадений
+	var confirmed []*finderPattern
+	for _, c := range centers {
+		if c.count >= 2 {
+			confirmed = append(confirmed, c)
+		}
+	}
+	if len(confirmed) >= 3 {
+		centers = confirmed
+	}
+	if len(centers) < 3 {
+		return nil
+	}
+	sort.Slice(centers, func(i, j int) bool { return centers[i].size < centers[j].size })
+
+	type scored struct {
+		triple finderTriple
+		score  float64
+	}
+	var list []scored
+	for i := 0; i < len(centers)-2; i++ {
+		for j := i + 1; j < len(centers)-1; j++ {
+			for k := j + 1; k < len(centers); k++ {
+				if centers[k].size > centers[i].size*maxSizeRatio {
+					break
+				}
+				sides := []float64{
+					squaredDistance(centers[i], centers[j]),
+					squaredDistance(centers[j], centers[k]),
+					squaredDistance(centers[i], centers[k]),
+				}
+				sort.Float64s(sides)
+				a, b, c := sides[0], sides[1], sides[2]
+
+				// Между центрами узоров от 14 (версия 1) до 170 (версия 40) модулей
+				size := (centers[i].size + centers[j].size + centers[k].size) / 3
+				if a < 10*10*size*size || c > 2*maxModules*maxModules*size*size {
+					continue
+				}
+				// Для равнобедренного прямоугольного треугольника c = 2a = 2b
+				score := (math.Abs(c-2*b) + math.Abs(c-2*a)) / c
+				list = append(list, scored{orderPatterns(centers[i], centers[j], centers[k]), score})
+			}
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].score < list[j].score })
+
+	triples := make([]finderTriple, 0, len(list))
+	for _, s := range list {
+		triples = append(triples, s.triple)
+	}
+	return triples
+}
+
+// orderPatterns определяет углы кода: верхний левый узор лежит напротив самой длинной стороны,
+// а нижний левый и верхний правый различаются по направлению обхода
+func orderPatterns(p0, p1, p2 *finderPattern) finderTriple {
+	d01 := squaredDistance(p0, p1)
+	d12 := squaredDistance(p1, p2)
+	d02 := squaredDistance(p0, p2)
+
+	var a, b, c *finderPattern
+	switch {
+	case d12 >= d01 && d12 >= d02:
+		a, b, c = p1, p0, p2
+	case d02 >= d12 && d02 >= d01:
+		a, b, c = p0, p1, p2
+	default:
+		a, b, c = p0, p2, p1
+	}
+	// Обход a -> b -> c должен идти по часовой стрелке в координатах изображения
+	if (c.x-b.x)*(a.y-b.y)-(c.y-b.y)*(a.x-b.x) < 0 {
+		a, c = c, a
+	}
+	return finderTriple{bottomLeft: a, topLeft: b, topRight: c}
+}
+
+func squaredDistance(a, b *finderPattern) float64 {
+	dx, dy := a.x-b.x, a.y-b.y
+	return dx*dx + dy*dy
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
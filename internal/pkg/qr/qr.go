@@ -0,0 +1,67 @@
+// Package qr распознает QR-коды на фотографиях без внешних зависимостей.
+//
+// Поддерживаются версии 1-40 и все уровни коррекции ошибок. Данные
+// в режиме кандзи не декодируются: в кассовых чеках и ссылках их нет
+package qr
+
+import (
+	"errors"
+	"image"
+)
+
+var (
+	ErrNotFound    = errors.New("qr code not found")
+	ErrUnreadable  = errors.New("qr code is damaged or unreadable")
+	ErrUnsupported = errors.New("qr code data mode is not supported")
+)
+
+// maxCandidates - сколько наборов поисковых узоров проверяется на одном изображении.
+// На фото чека текст и рисунки дают ложные узоры, поэтому первый набор не всегда верный
+const maxCandidates = 5
+
+// Decode находит на изображении QR-код и возвращает его содержимое.
+//
+// Код может быть повернут и снят под небольшим углом. Изображение сначала
+// бинаризуется по локальной яркости, а если код не найден - по общему порогу.
+// Возвращает ErrNotFound, если кода на изображении нет, и ErrUnreadable,
+// если код найден, но поврежден сильнее, чем позволяет коррекция ошибок
+func Decode(img image.Image) (string, error) {
+	lum := luminance(img)
+	err := ErrNotFound
+	for _, bits := range []*bitMatrix{hybridBinarize(lum), globalBinarize(lum)} {
+		text, e := decodeMatrix(bits)
+		if e == nil {
+			return text, nil
+		}
+		// Поврежденный код важнее для пользователя, чем ненайденный
+		if !errors.Is(e, ErrNotFound) {
+			err = e
+		}
+	}
+	return "", err
+}
+
+// decodeMatrix ищет код на бинаризованном изображении
+func decodeMatrix(image *bitMatrix) (string, error) {
+	candidates := findFinderPatterns(image)
+	if len(candidates) == 0 {
+		return "", ErrNotFound
+	}
+	if len(candidates) > maxCandidates {
+		candidates = candidates[:maxCandidates]
+	}
+
+	err := ErrNotFound
+	for _, c := range candidates {
+		grid, e := detect(image, c)
+		if e != nil {
+			continue
+		}
+		text, e := decodeGrid(grid)
+		if e == nil {
+			return text, nil
+		}
+		err = e
+	}
+	return "", err
+}
@@ -0,0 +1,150 @@
+package qr
+
+// Арифметика поля Галуа GF(256) с порождающим многочленом x^8+x^4+x^3+x^2+1
+var (
+	gfExp [512]byte
+	gfLog [256]int
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+gfLog[b]]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+255-gfLog[b]]
+}
+
+// gfPow возвращает a^n, n может быть отрицательным
+func gfPow(a byte, n int) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[((gfLog[a]*n)%255+255)%255]
+}
+
+// polyEval вычисляет многочлен с коэффициентами от младшего к старшему в точке x
+func polyEval(p []byte, x byte) byte {
+	var y byte
+	for i := len(p) - 1; i >= 0; i-- {
+		y = gfMul(y, x) ^ p[i]
+	}
+	return y
+}
+
+// correctErrors исправляет ошибки в блоке кода Рида-Соломона на месте.
+//
+// block - байты данных и ecCount байт коррекции, первый байт - старший коэффициент.
+// Исправляется до ecCount/2 ошибочных байт. Возвращает false, если ошибок больше
+func correctErrors(block []byte, ecCount int) bool {
+	n := len(block)
+	// Синдромы S_j = r(α^j): у кода QR корни порождающего многочлена α^0..α^(ecCount-1)
+	syndromes := make([]byte, ecCount)
+	clean := true
+	for j := range syndromes {
+		var s byte
+		x := gfExp[j]
+		for _, c := range block {
+			s = gfMul(s, x) ^ c
+		}
+		syndromes[j] = s
+		if s != 0 {
+			clean = false
+		}
+	}
+	if clean {
+		return true
+	}
+
+	// Многочлен локаторов ошибок - алгоритм Берлекэмпа-Мэсси
+	locator := []byte{1}
+	prev := []byte{1}
+	errorsCount, shift := 0, 1
+	var prevDelta byte = 1
+	for i := 0; i < ecCount; i++ {
+		delta := syndromes[i]
+		for j := 1; j <= errorsCount && j < len(locator); j++ {
+			delta ^= gfMul(locator[j], syndromes[i-j])
+		}
+		if delta == 0 {
+			shift++
+			continue
+		}
+		next := make([]byte, max(len(locator), len(prev)+shift))
+		copy(next, locator)
+		factor := gfDiv(delta, prevDelta)
+		for j, c := range prev {
+			next[j+shift] ^= gfMul(factor, c)
+		}
+		if 2*errorsCount <= i {
+			prev, prevDelta = locator, delta
+			errorsCount = i + 1 - errorsCount
+			shift = 1
+		} else {
+			shift++
+		}
+		locator = next
+	}
+	for len(locator) > 1 && locator[len(locator)-1] == 0 {
+		locator = locator[:len(locator)-1]
+	}
+	if errorsCount != len(locator)-1 || 2*errorsCount > ecCount {
+		return false
+	}
+
+	// Позиции ошибок - корни локатора (поиск Ченя): ошибка в степени k,
+	// если Λ(α^-k) = 0. Степень k соответствует байту n-1-k
+	var positions []int
+	for k := 0; k < n; k++ {
+		if polyEval(locator, gfPow(2, -k)) == 0 {
+			positions = append(positions, k)
+		}
+	}
+	if len(positions) != errorsCount {
+		return false
+	}
+
+	// Величины ошибок - алгоритм Форни: Ω = S·Λ mod x^ecCount, e = X·Ω(X^-1) / Λ'(X^-1)
+	omega := make([]byte, ecCount)
+	for i, s := range syndromes {
+		for j, l := range locator {
+			if i+j < ecCount {
+				omega[i+j] ^= gfMul(s, l)
+			}
+		}
+	}
+	derivative := make([]byte, len(locator))
+	for i := 1; i < len(locator); i += 2 {
+		derivative[i-1] = locator[i]
+	}
+	for _, k := range positions {
+		x := gfPow(2, k)
+		xInv := gfPow(2, -k)
+		d := polyEval(derivative, xInv)
+		if d == 0 {
+			return false
+		}
+		block[n-1-k] ^= gfMul(x, gfDiv(polyEval(omega, xInv), d))
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+package qr
+
+// ecLevel - уровень коррекции ошибок
+type ecLevel int
+
+const (
+	levelL ecLevel = iota // 7%
+	levelM                // 15%
+	levelQ                // 25%
+	levelH                // 30%
+)
+
+// levelFromBits - уровень по двум битам информации о формате
+var levelFromBits = [4]ecLevel{levelM, levelL, levelH, levelQ}
+
+// ecGroup - группа блоков с одинаковым числом байт данных
+type ecGroup struct {
+	count int // Количество блоков
+	data  int // Байт данных в блоке
+}
+
+// ecBlocks - разбиение кодовых слов версии на блоки коррекции ошибок
+type ecBlocks struct {
+	ecPerBlock int // Байт коррекции в каждом блоке
+	groups     []ecGroup
+}
+
+// totalCodewords возвращает общее число кодовых слов
+func (b ecBlocks) totalCodewords() int {
+	total := 0
+	for _, g := range b.groups {
+		total += g.count * (g.data + b.ecPerBlock)
+	}
+	return total
+}
+
+// versionBlocks - блоки коррекции для версий 1-40 и уровней L, M, Q, H (ГОСТ Р ИСО/МЭК 18004, таблица 9)
+var versionBlocks = [40][4]ecBlocks{
+	{{7, []ecGroup{{1, 19}}}, {10, []ecGroup{{1, 16}}}, {13, []ecGroup{{1, 13}}}, {17, []ecGroup{{1, 9}}}},                                                // 1
+	{{10, []ecGroup{{1, 34}}}, {16, []ecGroup{{1, 28}}}, {22, []ecGroup{{1, 22}}}, {28, []ecGroup{{1, 16}}}},                                              // 2
+	{{15, []ecGroup{{1, 55}}}, {26, []ecGroup{{1, 44}}}, {18, []ecGroup{{2, 17}}}, {22, []ecGroup{{2, 13}}}},                                              // 3
+	{{20, []ecGroup{{1, 80}}}, {18, []ecGroup{{2, 32}}}, {26, []ecGroup{{2, 24}}}, {16, []ecGroup{{4, 9}}}},                                               // 4
+	{{26, []ecGroup{{1, 108}}}, {24, []ecGroup{{2, 43}}}, {18, []ecGroup{{2, 15}, {2, 16}}}, {22, []ecGroup{{2, 11}, {2, 12}}}},                           // 5
+	{{18, []ecGroup{{2, 68}}}, {16, []ecGroup{{4, 27}}}, {24, []ecGroup{{4, 19}}}, {28, []ecGroup{{4, 15}}}},                                              // 6
+	{{20, []ecGroup{{2, 78}}}, {18, []ecGroup{{4, 31}}}, {18, []ecGroup{{2, 14}, {4, 15}}}, {26, []ecGroup{{4, 13}, {1, 14}}}},                            // 7
+	{{24, []ecGroup{{2, 97}}}, {22, []ecGroup{{2, 38}, {2, 39}}}, {22, []ecGroup{{4, 18}, {2, 19}}}, {26, []ecGroup{{4, 14}, {2, 15}}}},                   // 8
+	{{30, []ecGroup{{2, 116}}}, {22, []ecGroup{{3, 36}, {2, 37}}}, {20, []ecGroup{{4, 16}, {4, 17}}}, {24, []ecGroup{{4, 12}, {4, 13}}}},                  // 9
+	{{18, []ecGroup{{2, 68}, {2, 69}}}, {26, []ecGroup{{4, 43}, {1, 44}}}, {24, []ecGroup{{6, 19}, {2, 20}}}, {28, []ecGroup{{6, 15}, {2, 16}}}},          // 10
+	{{20, []ecGroup{{4, 81}}}, {30, []ecGroup{{1, 50}, {4, 51}}}, {28, []ecGroup{{4, 22}, {4, 23}}}, {24, []ecGroup{{3, 12}, {8, 13}}}},                   // 11
+	{{24, []ecGroup{{2, 92}, {2, 93}}}, {22, []ecGroup{{6, 36}, {2, 37}}}, {26, []ecGroup{{4, 20}, {6, 21}}}, {28, []ecGroup{{7, 14}, {4, 15}}}},          // 12
+	{{26, []ecGroup{{4, 107}}}, {22, []ecGroup{{8, 37}, {1, 38}}}, {24, []ecGroup{{8, 20}, {4, 21}}}, {22, []ecGroup{{12, 11}, {4, 12}}}},                 // 13
+	{{30, []ecGroup{{3, 115}, {1, 116}}}, {24, []ecGroup{{4, 40}, {5, 41}}}, {20, []ecGroup{{11, 16}, {5, 17}}}, {24, []ecGroup{{11, 12}, {5, 13}}}},      // 14
+	{{22, []ecGroup{{5, 87}, {1, 88}}}, {24, []ecGroup{{5, 41}, {5, 42}}}, {30, []ecGroup{{5, 24}, {7, 25}}}, {24, []ecGroup{{11, 12}, {7, 13}}}},         // 15
+	{{24, []ecGroup{{5, 98}, {1, 99}}}, {28, []ecGroup{{7, 45}, {3, 46}}}, {24, []ecGroup{{15, 19}, {2, 20}}}, {30, []ecGroup{{3, 15}, {13, 16}}}},        // 16
+	{{28, []ecGroup{{1, 107}, {5, 108}}}, {28, []ecGroup{{10, 46}, {1, 47}}}, {28, []ecGroup{{1, 22}, {15, 23}}}, {28, []ecGroup{{2, 14}, {17, 15}}}},     // 17
+	{{30, []ecGroup{{5, 120}, {1, 121}}}, {26, []ecGroup{{9, 43}, {4, 44}}}, {28, []ecGroup{{17, 22}, {1, 23}}}, {28, []ecGroup{{2, 14}, {19, 15}}}},      // 18
+	{{28, []ecGroup{{3, 113}, {4, 114}}}, {26, []ecGroup{{3, 44}, {11, 45}}}, {26, []ecGroup{{17, 21}, {4, 22}}}, {26, []ecGroup{{9, 13}, {16, 14}}}},     // 19
+	{{28, []ecGroup{{3, 107}, {5, 108}}}, {26, []ecGroup{{3, 41}, {13, 42}}}, {30, []ecGroup{{15, 24}, {5, 25}}}, {28, []ecGroup{{15, 15}, {10, 16}}}},    // 20
+	{{28, []ecGroup{{4, 116}, {4, 117}}}, {26, []ecGroup{{17, 42}}}, {28, []ecGroup{{17, 22}, {6, 23}}}, {30, []ecGroup{{19, 16}, {6, 17}}}},              // 21
+	{{28, []ecGroup{{2, 111}, {7, 112}}}, {28, []ecGroup{{17, 46}}}, {30, []ecGroup{{7, 24}, {16, 25}}}, {24, []ecGroup{{34, 13}}}},                       // 22
+	{{30, []ecGroup{{4, 121}, {5, 122}}}, {28, []ecGroup{{4, 47}, {14, 48}}}, {30, []ecGroup{{11, 24}, {14, 25}}}, {30, []ecGroup{{16, 15}, {14, 16}}}},   // 23
+	{{30, []ecGroup{{6, 117}, {4, 118}}}, {28, []ecGroup{{6, 45}, {14, 46}}}, {30, []ecGroup{{11, 24}, {16, 25}}}, {30, []ecGroup{{30, 16}, {2, 17}}}},    // 24
+	{{26, []ecGroup{{8, 106}, {4, 107}}}, {28, []ecGroup{{8, 47}, {13, 48}}}, {30, []ecGroup{{7, 24}, {22, 25}}}, {30, []ecGroup{{22, 15}, {13, 16}}}},    // 25
+	{{28, []ecGroup{{10, 114}, {2, 115}}}, {28, []ecGroup{{19, 46}, {4, 47}}}, {28, []ecGroup{{28, 22}, {6, 23}}}, {30, []ecGroup{{33, 16}, {4, 17}}}},    // 26
+	{{30, []ecGroup{{8, 122}, {4, 123}}}, {28, []ecGroup{{22, 45}, {3, 46}}}, {30, []ecGroup{{8, 23}, {26, 24}}}, {30, []ecGroup{{12, 15}, {28, 16}}}},    // 27
+	{{30, []ecGroup{{3, 117}, {10, 118}}}, {28, []ecGroup{{3, 45}, {23, 46}}}, {30, []ecGroup{{4, 24}, {31, 25}}}, {30, []ecGroup{{11, 15}, {31, 16}}}},   // 28
+	{{30, []ecGroup{{7, 116}, {7, 117}}}, {28, []ecGroup{{21, 45}, {7, 46}}}, {30, []ecGroup{{1, 23}, {37, 24}}}, {30, []ecGroup{{19, 15}, {26, 16}}}},    // 29
+	{{30, []ecGroup{{5, 115}, {10, 116}}}, {28, []ecGroup{{19, 47}, {10, 48}}}, {30, []ecGroup{{15, 24}, {25, 25}}}, {30, []ecGroup{{23, 15}, {25, 16}}}}, // 30
+	{{30, []ecGroup{{13, 115}, {3, 116}}}, {28, []ecGroup{{2, 46}, {29, 47}}}, {30, []ecGroup{{42, 24}, {1, 25}}}, {30, []ecGroup{{23, 15}, {28, 16}}}},   // 31
+	{{30, []ecGroup{{17, 115}}}, {28, []ecGroup{{10, 46}, {23, 47}}}, {30, []ecGroup{{10, 24}, {35, 25}}}, {30, []ecGroup{{19, 15}, {35, 16}}}},           // 32
+	{{30, []ecGroup{{17, 115}, {1, 116}}}, {28, []ecGroup{{14, 46}, {21, 47}}}, {30, []ecGroup{{29, 24}, {19, 25}}}, {30, []ecGroup{{11, 15}, {46, 16}}}}, // 33
+	{{30, []ecGroup{{13, 115}, {6, 116}}}, {28, []ecGroup{{14, 46}, {23, 47}}}, {30, []ecGroup{{44, 24}, {7, 25}}}, {30, []ecGroup{{59, 16}, {1, 17}}}},   // 34
+	{{30, []ecGroup{{12, 121}, {7, 122}}}, {28, []ecGroup{{12, 47}, {26, 48}}}, {30, []ecGroup{{39, 24}, {14, 25}}}, {30, []ecGroup{{22, 15}, {41, 16}}}}, // 35
+	{{30, []ecGroup{{6, 121}, {14, 122}}}, {28, []ecGroup{{6, 47}, {34, 48}}}, {30, []ecGroup{{46, 24}, {10, 25}}}, {30, []ecGroup{{2, 15}, {64, 16}}}},   // 36
+	{{30, []ecGroup{{17, 122}, {4, 123}}}, {28, []ecGroup{{29, 46}, {14, 47}}}, {30, []ecGroup{{49, 24}, {10, 25}}}, {30, []ecGroup{{24, 15}, {46, 16}}}}, // 37
+	{{30, []ecGroup{{4, 122}, {18, 123}}}, {28, []ecGroup{{13, 46}, {32, 47}}}, {30, []ecGroup{{48, 24}, {14, 25}}}, {30, []ecGroup{{42, 15}, {32, 16}}}}, // 38
+	{{30, []ecGroup{{20, 117}, {4, 118}}}, {28, []ecGroup{{40, 47}, {7, 48}}}, {30, []ecGroup{{43, 24}, {22, 25}}}, {30, []ecGroup{{10, 15}, {67, 16}}}},  // 39
+	{{30, []ecGroup{{19, 118}, {6, 119}}}, {28, []ecGroup{{18, 47}, {31, 48}}}, {30, []ecGroup{{34, 24}, {34, 25}}}, {30, []ecGroup{{20, 15}, {61, 16}}}}, // 40
+}
+
+const (
+	// formatMask - маска, накладываемая на информацию о формате
+	formatMask = 0x5412
+	// formatGenerator - порождающий многочлен кода БЧХ(15, 5) информации о формате
+	formatGenerator = 0x537
+	// versionGenerator - порождающий многочлен кода Голея (18, 6) информации о версии
+	versionGenerator = 0x1f25
+	// maxBitErrors - сколько ошибочных бит исправляется в информации о формате и версии
+	maxBitErrors = 3
+)
+
+// bchCode дописывает к данным остаток деления на порождающий многочлен
+func bchCode(data, generator, bits int) int {
+	degree := 0
+	for g := generator; g > 1; g >>= 1 {
+		degree++
+	}
+	value := data << degree
+	for i := bits - 1; i >= degree; i-- {
+		if value&(1<<i) != 0 {
+			value ^= generator << (i - degree)
+		}
+	}
+	return data<<degree | value
+}
+
+// hammingDistance - количество различающихся бит
+func hammingDistance(a, b int) int {
+	n := 0
+	for v := a ^ b; v != 0; v &= v - 1 {
+		n++
+	}
+	return n
+}
+
+// formatInfo - уровень коррекции и номер маски
+type formatInfo struct {
+	level ecLevel
+	mask  int
+}
+
+// decodeFormat находит формат, ближайший к одной из двух считанных копий.
+// Некоторые генераторы не накладывают маску на формат, поэтому проверяются оба варианта
+func decodeFormat(bits1, bits2 int) (formatInfo, bool) {
+	best, bestDistance := 0, maxBitErrors+1
+	for data := 0; data < 32; data++ {
+		code := bchCode(data, formatGenerator, 15)
+		for _, masked := range []int{code ^ formatMask, code} {
+			for _, bits := range []int{bits1, bits2} {
+				if d := hammingDistance(bits, masked); d < bestDistance {
+					best, bestDistance = data, d
+				}
+			}
+		}
+	}
+	if bestDistance > maxBitErrors {
+		return formatInfo{}, false
+	}
+	return formatInfo{level: levelFromBits[best>>3], mask: best & 7}, true
+}
+
+// decodeVersion находит версию 7-40, ближайшую к считанной информации о версии
+func decodeVersion(bits int) (int, bool) {
+	best, bestDistance := 0, maxBitErrors+1
+	for v := 7; v <= 40; v++ {
+		if d := hammingDistance(bits, bchCode(v, versionGenerator, 18)); d < bestDistance {
+			best, bestDistance = v, d
+		}
+	}
+	return best, bestDistance <= maxBitErrors
+}
+
+// dimensionForVersion возвращает сторону кода в модулях
+func dimensionForVersion(version int) int {
+	return 17 + 4*version
+}
+
+// alignmentCenters возвращает координаты центров выравнивающих узоров по одной оси
+func alignmentCenters(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := (version*4 + n*2 + 1) / (n*2 - 2) * 2
+	if version == 32 {
+		step = 26
+	}
+	centers := make([]int, n)
+	centers[0] = 6
+	for i, pos := n-1, dimensionForVersion(version)-7; i > 0; i, pos = i-1, pos-step {
+		centers[i] = pos
+	}
+	return centers
+}
+
+// functionPattern отмечает модули служебных узоров, в которых нет данных:
+// поисковые и выравнивающие узоры, полосы синхронизации, информацию о формате и версии
+func functionPattern(version int) *bitMatrix {
+	dimension := dimensionForVersion(version)
+	m := newBitMatrix(dimension, dimension)
+	m.setRegion(0, 0, 9, 9)
+	m.setRegion(dimension-8, 0, 8, 9)
+	m.setRegion(0, dimension-8, 9, 8)
+
+	centers := alignmentCenters(version)
+	last := len(centers) - 1
+	for i, cx := range centers {
+		for j, cy := range centers {
+			// Выравнивающие узоры не накладываются на поисковые
+			if (i == 0 && (j == 0 || j == last)) || (i == last && j == 0) {
+				continue
+			}
+			m.setRegion(cx-2, cy-2, 5, 5)
+		}
+	}
+
+	m.setRegion(6, 9, 1, dimension-17)
+	m.setRegion(9, 6, dimension-17, 1)
+	if version > 6 {
+		m.setRegion(dimension-11, 0, 3, 6)
+		m.setRegion(0, dimension-11, 6, 3)
+	}
+	return m
+}
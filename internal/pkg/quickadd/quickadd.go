@@ -0,0 +1,198 @@
+package quickadd
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/SobolevTim/finance_bot/internal/pkg/calc"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrNoAmount      = errors.New("сумма не найдена")
+	ErrInvalidAmount = errors.New("сумма должна быть больше нуля")
+)
+
+var dateRegex = regexp.MustCompile(`^(\d{1,2})\.(\d{1,2})(?:\.(\d{2}|\d{4}))?$`)
+
+// relativeDays - слова для относительных дат и сдвиг в днях
+var relativeDays = map[string]int{
+	"сегодня":   0,
+	"вчера":     -1,
+	"позавчера": -2,
+}
+
+// Result - разобранная быстрая запись траты
+type Result struct {
+	Amount   decimal.Decimal // Сумма, до копеек округляет вызывающий код по валюте
+	Currency string          // Валюта, если указана: "20 USD", "20$"
+	Date     time.Time       // Дата траты, по умолчанию сегодня
+	HasDate  bool            // Дата указана явно
+	Words    []string        // Остальные слова по порядку: категория и примечание
+}
+
+// Parse разбирает сообщение вида "350 кофе", "1200+300 такси вчера", "25.03 500 еда" или "20 USD кофе".
+//
+// Сообщение должно начинаться с суммы или даты, иначе возвращается ErrNoAmount -
+// так обычная переписка не принимается за трату.
+// Сумма может быть выражением и вычисляется через calc.Calculate.
+// now - текущее время, от него считаются относительные даты
+func Parse(text string, now time.Time) (*Result, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	groups := splitGroups(text)
+	if len(groups) == 0 || (!groups[0].numeric && !isDateWord(groups[0].text)) {
+		return nil, ErrNoAmount
+	}
+
+	numeric := 0
+	for _, g := range groups {
+		if g.numeric {
+			numeric++
+		}
+	}
+
+	res := &Result{Date: today}
+	amountFound := false
+	for _, g := range groups {
+		lower := strings.ToLower(g.text)
+		if shift, ok := relativeDays[lower]; ok && !res.HasDate {
+			res.Date = today.AddDate(0, 0, shift)
+			res.HasDate = true
+			continue
+		}
+		if !g.numeric {
+			// Валюта сразу после суммы: "20 USD"
+			if code, err := currency.Normalize(g.text); err == nil && amountFound && res.Currency == "" {
+				res.Currency = code
+				continue
+			}
+			res.Words = append(res.Words, g.text)
+			continue
+		}
+		// Число вида ДД.ММ считается датой, только если в сообщении есть еще и сумма
+		if !res.HasDate && (numeric > 1 || strings.Count(g.text, ".") == 2) {
+			if d, ok := parseDate(g.text, today); ok {
+				res.Date = d
+				res.HasDate = true
+				numeric--
+				continue
+			}
+		}
+		if amountFound {
+			res.Words = append(res.Words, g.text)
+			continue
+		}
+		amount, err := calc.Calculate(g.text)
+		if err != nil {
+			return nil, err
+		}
+		if !amount.IsPositive() {
+			return nil, ErrInvalidAmount
+		}
+		res.Amount = amount
+		amountFound = true
+	}
+
+	if !amountFound {
+		return nil, ErrNoAmount
+	}
+	return res, nil
+}
+
+type group struct {
+	text    string
+	numeric bool
+}
+
+// splitGroups разбивает текст на слова, склеивая соседние части выражения:
+// "1200 + 300 такси" -> ["1200+300", "такси"]
+func splitGroups(text string) []group {
+	var groups []group
+	// Валюта, написанная слитно с суммой, становится отдельным словом: "20$" -> "20", "$"
+	var fields []string
+	for _, field := range strings.Fields(text) {
+		if amount, code := currency.SplitAmount(field); code != "" && isExpression(amount) {
+			fields = append(fields, amount, code)
+			continue
+		}
+		fields = append(fields, field)
+	}
+
+	for _, field := range fields {
+		numeric := isExpression(field)
+		last := len(groups) - 1
+		if numeric && last >= 0 && groups[last].numeric && (startsWithOperator(field) || endsWithOperator(groups[last].text)) {
+			groups[last].text += field
+			continue
+		}
+		groups = append(groups, group{text: field, numeric: numeric})
+	}
+	return groups
+}
+
+// isExpression проверяет, что слово состоит только из символов выражения и содержит цифру.
+// Множитель тоже считается выражением: "3x150", "2шт"
+func isExpression(s string) bool {
+	if calc.IsExpression(s) {
+		return true
+	}
+	hasDigit := false
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		case strings.ContainsRune("+-*/()^.,%", r):
+		default:
+			return false
+		}
+	}
+	return hasDigit || strings.ContainsAny(s, "+-*/^")
+}
+
+func startsWithOperator(s string) bool {
+	return strings.ContainsAny(s[:1], "+-*/^%)")
+}
+
+func endsWithOperator(s string) bool {
+	return strings.ContainsAny(s[len(s)-1:], "+-*/^(")
+}
+
+func isDateWord(s string) bool {
+	_, ok := relativeDays[strings.ToLower(s)]
+	return ok
+}
+
+// parseDate разбирает дату ДД.ММ или ДД.ММ.ГГГГ.
+// Дата без года, которая еще не наступила, относится к прошлому году
+func parseDate(s string, today time.Time) (time.Time, bool) {
+	m := dateRegex.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, false
+	}
+	day, _ := strconv.Atoi(m[1])
+	month, _ := strconv.Atoi(m[2])
+	year := today.Year()
+	if m[3] != "" {
+		year, _ = strconv.Atoi(m[3])
+		if year < 100 {
+			year += 2000
+		}
+	}
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+
+	d := time.Date(year, time.Month(month), day, 0, 0, 0, 0, today.Location())
+	if d.Day() != day {
+		// 31.04 и подобные несуществующие даты
+		return time.Time{}, false
+	}
+	if m[3] == "" && d.After(today) {
+		d = d.AddDate(-1, 0, 0)
+	}
+	return d, true
+}
@@ -0,0 +1,128 @@
+// Package receipt разбирает строку из QR-кода кассового чека ФНС России:
+// "t=20240115T1830&s=1234.50&fn=9960440300123456&i=12345&fp=1234567890&n=1"
+package receipt
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrNotReceipt     = errors.New("not a fiscal receipt")
+	ErrInvalidReceipt = errors.New("invalid fiscal receipt")
+	ErrNotPurchase    = errors.New("receipt is not a purchase")
+)
+
+// Типы расчета (поле n)
+const (
+	OperationPurchase       = 1 // Приход
+	OperationPurchaseRefund = 2 // Возврат прихода
+	OperationExpense        = 3 // Расход
+	OperationExpenseRefund  = 4 // Возврат расхода
+)
+
+// Форматы даты и времени расчета: с секундами и без
+var timeLayouts = []string{"20060102T150405", "20060102T1504"}
+
+// Receipt - реквизиты кассового чека.
+//
+// Номер фискального накопителя, номер документа и фискальный признак
+// вместе однозначно определяют чек
+type Receipt struct {
+	Time      time.Time       // Дата и время расчета по времени магазина
+	Sum       decimal.Decimal // Сумма чека в рублях
+	FN        string          // Номер фискального накопителя
+	FD        string          // Номер фискального документа
+	FP        string          // Фискальный признак документа
+	Operation int             // Тип расчета, 0 - не указан
+}
+
+// IsReceipt проверяет, похож ли текст на строку из QR-кода чека:
+// есть дата расчета и номер фискального накопителя
+func IsReceipt(text string) bool {
+	values, err := params(text)
+	return err == nil && values.Has("t") && values.Has("fn")
+}
+
+// Parse разбирает строку из QR-кода чека.
+//
+// Возвращает ErrNotReceipt, если это не чек, ErrInvalidReceipt при ошибке
+// в реквизитах и ErrNotPurchase для чеков возврата и расхода
+func Parse(text string) (*Receipt, error) {
+	values, err := params(text)
+	if err != nil || !values.Has("t") || !values.Has("fn") {
+		return nil, ErrNotReceipt
+	}
+
+	r := &Receipt{
+		FN: values.Get("fn"),
+		FD: values.Get("i"),
+		FP: values.Get("fp"),
+	}
+	for _, id := range []string{r.FN, r.FD, r.FP} {
+		if !isDigits(id) {
+			return nil, ErrInvalidReceipt
+		}
+	}
+
+	for _, layout := range timeLayouts {
+		if r.Time, err = time.Parse(layout, strings.ToUpper(values.Get("t"))); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, ErrInvalidReceipt
+	}
+
+	r.Sum, err = decimal.NewFromString(strings.Replace(values.Get("s"), ",", ".", 1))
+	if err != nil || !r.Sum.IsPositive() {
+		return nil, ErrInvalidReceipt
+	}
+
+	switch n := values.Get("n"); n {
+	case "":
+	case "1", "2", "3", "4":
+		r.Operation = int(n[0] - '0')
+	default:
+		return nil, ErrInvalidReceipt
+	}
+	if r.Operation != 0 && r.Operation != OperationPurchase {
+		return nil, ErrNotPurchase
+	}
+	return r, nil
+}
+
+// params разбирает параметры строки, названия параметров приводятся к нижнему регистру.
+// Некоторые кассы печатают в QR-коде ссылку на проверку чека, тогда параметры идут после "?"
+func params(text string) (url.Values, error) {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexByte(text, '?'); i >= 0 {
+		text = text[i+1:]
+	}
+	values, err := url.ParseQuery(text)
+	if err != nil {
+		return nil, err
+	}
+	result := make(url.Values, len(values))
+	for k, v := range values {
+		result[strings.ToLower(k)] = v
+	}
+	return result, nil
+}
+
+// isDigits проверяет, что строка непустая и состоит из цифр
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
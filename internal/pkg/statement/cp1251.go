@@ -0,0 +1,30 @@
+package statement
+
+import "strings"
+
+// cp1251 - символы Windows-1251 в диапазоне 0x80-0xBF.
+// Диапазон 0xC0-0xFF соответствует А-я (U+0410-U+044F)
+var cp1251 = [64]rune{
+	'Ђ', 'Ѓ', '‚', 'ѓ', '„', '…', '†', '‡', '€', '‰', 'Љ', '‹', 'Њ', 'Ќ', 'Ћ', 'Џ',
+	'ђ', '‘', '’', '“', '”', '•', '–', '—', '�', '™', 'љ', '›', 'њ', 'ќ', 'ћ', 'џ',
+	' ', 'Ў', 'ў', 'Ј', '¤', 'Ґ', '¦', '§', 'Ё', '©', 'Є', '«', '¬', '­', '®', 'Ї',
+	'°', '±', 'І', 'і', 'ґ', 'µ', '¶', '·', 'ё', '№', 'є', '»', 'ј', 'Ѕ', 'ѕ', 'ї',
+}
+
+// decodeWindows1251 перекодирует текст из Windows-1251 в UTF-8.
+// Многие банки до сих пор выгружают выписки в этой кодировке
+func decodeWindows1251(data []byte) []byte {
+	var b strings.Builder
+	b.Grow(len(data) * 2)
+	for _, c := range data {
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case c >= 0xC0:
+			b.WriteRune(rune(c-0xC0) + 'А')
+		default:
+			b.WriteRune(cp1251[c-0x80])
+		}
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,266 @@
+// Package statement разбирает CSV-выписки банковских приложений
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxFileSize - максимальный размер файла выписки
+const MaxFileSize = 1 << 20
+
+var (
+	ErrFileTooLarge   = errors.New("statement file is too large")
+	ErrEmptyFile      = errors.New("statement file is empty")
+	ErrInvalidMapping = errors.New("invalid column mapping")
+	ErrNoRows         = errors.New("no expense rows in statement")
+)
+
+// Table - содержимое CSV-файла: заголовок и строки данных
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// Mapping - номера колонок (с нуля) с датой, суммой и описанием.
+// -1 означает, что колонка не задана
+type Mapping struct {
+	Date        int
+	Amount      int
+	Description int
+}
+
+// Row - разобранная строка выписки
+type Row struct {
+	Line        int // Номер строки в файле, начиная с 1
+	Date        time.Time
+	Amount      decimal.Decimal
+	Description string
+}
+
+// Result - результат разбора выписки
+type Result struct {
+	Rows    []Row
+	Skipped int // Строки с доходами, нулевыми суммами и ошибками разбора
+}
+
+// Заголовки колонок, по которым определяется сопоставление
+var (
+	dateHeaders        = []string{"дата операции", "дата", "date", "transaction date"}
+	amountHeaders      = []string{"сумма операции", "сумма платежа", "сумма", "amount", "sum"}
+	descriptionHeaders = []string{"описание", "назначение", "наименование", "комментарий", "description", "details", "memo"}
+)
+
+// Форматы дат, встречающиеся в выписках
+var dateLayouts = []string{
+	"02.01.2006",
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"02/01/2006",
+	"02.01.06",
+}
+
+// Read читает CSV-файл выписки.
+//
+// Кодировка (UTF-8 или Windows-1251) и разделитель (",", ";" или табуляция)
+// определяются автоматически. Первая строка считается заголовком
+func Read(data []byte) (*Table, error) {
+	if len(data) > MaxFileSize {
+		return nil, ErrFileTooLarge
+	}
+	data = bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+	if !utf8.Valid(data) {
+		data = decodeWindows1251(data)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, ErrEmptyFile
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = detectDelimiter(data)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, ErrEmptyFile
+	}
+	return &Table{Header: records[0], Rows: records[1:]}, nil
+}
+
+// DetectMapping определяет колонки по заголовку.
+// Возвращает false, если не удалось найти дату или сумму
+func DetectMapping(header []string) (Mapping, bool) {
+	m := Mapping{
+		Date:        findColumn(header, dateHeaders),
+		Amount:      findColumn(header, amountHeaders),
+		Description: findColumn(header, descriptionHeaders),
+	}
+	return m, m.Date >= 0 && m.Amount >= 0
+}
+
+// ParseMapping разбирает сопоставление колонок, введенное пользователем:
+// номера колонок даты, суммы и (необязательно) описания, начиная с 1, например "1 3 5"
+func ParseMapping(text string, columns int) (Mapping, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ' ' || r == ',' || r == ';'
+	})
+	if len(fields) < 2 || len(fields) > 3 {
+		return Mapping{}, fmt.Errorf("%w: expected 2 or 3 columns, got %d", ErrInvalidMapping, len(fields))
+	}
+
+	idx := []int{-1, -1, -1}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 1 || n > columns {
+			return Mapping{}, fmt.Errorf("%w: bad column %q", ErrInvalidMapping, f)
+		}
+		idx[i] = n - 1
+	}
+	m := Mapping{Date: idx[0], Amount: idx[1], Description: idx[2]}
+	if m.Date == m.Amount || (m.Description >= 0 && (m.Description == m.Date || m.Description == m.Amount)) {
+		return Mapping{}, fmt.Errorf("%w: columns must differ", ErrInvalidMapping)
+	}
+	return m, nil
+}
+
+// Parse разбирает строки таблицы по сопоставлению колонок.
+//
+// В выписках расходы обычно записаны отрицательными суммами, а поступления - положительными.
+// Если в файле есть отрицательные суммы, импортируются только они (по модулю),
+// иначе все суммы считаются расходами
+func Parse(t *Table, m Mapping) (*Result, error) {
+	if m.Date < 0 || m.Amount < 0 {
+		return nil, ErrInvalidMapping
+	}
+
+	rows := make([]Row, 0, len(t.Rows))
+	res := &Result{}
+	hasNegative := false
+	for i, record := range t.Rows {
+		if isBlank(record) {
+			continue
+		}
+		if m.Date >= len(record) || m.Amount >= len(record) {
+			res.Skipped++
+			continue
+		}
+		date, err := ParseDate(record[m.Date])
+		if err != nil {
+			res.Skipped++
+			continue
+		}
+		amount, err := ParseAmount(record[m.Amount])
+		if err != nil || amount.IsZero() {
+			res.Skipped++
+			continue
+		}
+		if amount.IsNegative() {
+			hasNegative = true
+		}
+		var description string
+		if m.Description >= 0 && m.Description < len(record) {
+			description = strings.Join(strings.Fields(record[m.Description]), " ")
+		}
+		// Строка 1 - заголовок
+		rows = append(rows, Row{Line: i + 2, Date: date, Amount: amount, Description: description})
+	}
+
+	for _, r := range rows {
+		if hasNegative {
+			if !r.Amount.IsNegative() {
+				res.Skipped++
+				continue
+			}
+			r.Amount = r.Amount.Neg()
+		}
+		res.Rows = append(res.Rows, r)
+	}
+
+	if len(res.Rows) == 0 {
+		return nil, ErrNoRows
+	}
+	return res, nil
+}
+
+// ParseDate разбирает дату в одном из форматов выписок
+func ParseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if d, err := time.Parse(layout, s); err == nil {
+			return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format %q", s)
+}
+
+// ParseAmount разбирает сумму вида "-1 234,56", "1234.56 RUB" или "−350"
+func ParseAmount(s string) (decimal.Decimal, error) {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9', r == '-', r == '.':
+			b.WriteRune(r)
+		case r == ',':
+			b.WriteRune('.')
+		case r == '−' || r == '–': // Минус и короткое тире из типографики
+			b.WriteRune('-')
+		}
+	}
+	return decimal.NewFromString(b.String())
+}
+
+// detectDelimiter выбирает разделитель, чаще всего встречающийся в первой строке
+func detectDelimiter(data []byte) rune {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	best, bestCount := ',', 0
+	for _, d := range []rune{';', '\t', ','} {
+		if n := bytes.Count(line, []byte(string(d))); n > bestCount {
+			best, bestCount = d, n
+		}
+	}
+	return best
+}
+
+func findColumn(header []string, names []string) int {
+	// Имена проверяются в порядке приоритета: "дата операции" важнее "даты списания"
+	for _, name := range names {
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				return i
+			}
+		}
+	}
+	for _, name := range names {
+		for i, h := range header {
+			if strings.Contains(strings.ToLower(h), name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isBlank(record []string) bool {
+	for _, f := range record {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+	return true
+}
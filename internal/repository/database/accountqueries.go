@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/account"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
+)
+
+// AccountCreate создает новый счет
+func (r *Repository) AccountCreate(ctx context.Context, a *account.Account) error {
+	r.Logger.Debug("Создание счета", "account", a)
+	query := `INSERT INTO accounts (id, user_id, name, type, currency, balance, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	now := time.Now()
+	_, err := r.DB.Exec(ctx, query, a.ID, a.UserID, a.Name, a.Type, a.Currency, a.Balance, a.CreatedAt, a.UpdatedAt)
+	if err != nil {
+		r.Logger.Debug("Не удалось создать счет", "error", err)
+		return err
+	}
+	r.Logger.Debug("Счет создан", "account", a, "duration", time.Since(now))
+	return nil
+}
+
+// AccountGet возвращает счет по ID
+// возвращает account.ErrAccountNotFound, если счета нет
+func (r *Repository) AccountGet(ctx context.Context, id uuid.UUID) (*account.Account, error) {
+	r.Logger.Debug("Получение счета", "id", id)
+	query := `SELECT id, user_id, name, type, currency, balance, created_at, updated_at
+		FROM accounts
+		WHERE id = $1`
+
+	now := time.Now()
+	a := &account.Account{}
+	err := r.DB.QueryRow(ctx, query, id).Scan(&a.ID, &a.UserID, &a.Name, &a.Type, &a.Currency, &a.Balance, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		r.Logger.Debug("Не удалось получить счет", "error", err)
+		if err.Error() == "no rows in result set" {
+			return nil, account.ErrAccountNotFound
+		}
+		return nil, err
+	}
+	r.Logger.Debug("Счет получен", "account", a, "duration", time.Since(now))
+	return a, nil
+}
+
+// AccountsGetByUser возвращает счета пользователя в порядке создания
+func (r *Repository) AccountsGetByUser(ctx context.Context, userID uuid.UUID) ([]*account.Account, error) {
+	r.Logger.Debug("Получение счетов пользователя", "userID", userID)
+	query := `SELECT id, user_id, name, type, currency, balance, created_at, updated_at
+		FROM accounts
+		WHERE user_id = $1
+		ORDER BY created_at ASC`
+
+	now := time.Now()
+	rows, err := r.DB.Query(ctx, query, userID)
+	if err != nil {
+		r.Logger.Debug("Не удалось получить счета", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := make([]*account.Account, 0)
+	for rows.Next() {
+		a := &account.Account{}
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Name, &a.Type, &a.Currency, &a.Balance, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			r.Logger.Debug("Не удалось получить счет", "error", err)
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	if rows.Err() != nil {
+		r.Logger.Debug("Ошибка перебора счетов", "error", rows.Err())
+		return nil, rows.Err()
+	}
+	r.Logger.Debug("Счета получены", "count", len(accounts), "duration", time.Since(now))
+	return accounts, nil
+}
+
+// AccountAdjustBalance изменяет баланс счета на delta
+// возвращает account.ErrAccountNotFound, если счета нет
+func (r *Repository) AccountAdjustBalance(ctx context.Context, id uuid.UUID, delta decimal.Decimal) error {
+	r.Logger.Debug("Изменение баланса счета", "id", id, "delta", delta)
+	now := time.Now()
+	if err := adjustBalance(ctx, r.DB, id, delta); err != nil {
+		r.Logger.Debug("Не удалось изменить баланс счета", "error", err)
+		return err
+	}
+	r.Logger.Debug("Баланс счета изменен", "id", id, "duration", time.Since(now))
+	return nil
+}
+
+// TransferCreate записывает перевод и меняет балансы обоих счетов в одной транзакции
+func (r *Repository) TransferCreate(ctx context.Context, t *account.Transfer) error {
+	r.Logger.Debug("Создание перевода", "transfer", t)
+	query := `INSERT INTO transfers (id, user_id, from_account_id, to_account_id, amount, to_amount, date, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	now := time.Now()
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		r.Logger.Debug("Не удалось начать транзакцию", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, query, t.ID, t.UserID, t.FromID, t.ToID, t.Amount, t.ToAmount, t.Date, t.CreatedAt); err != nil {
+		r.Logger.Debug("Не удалось создать перевод", "error", err)
+		return err
+	}
+	if err := adjustBalance(ctx, tx, t.FromID, t.Amount.Neg()); err != nil {
+		r.Logger.Debug("Не удалось списать со счета", "error", err)
+		return err
+	}
+	if err := adjustBalance(ctx, tx, t.ToID, t.ToAmount); err != nil {
+		r.Logger.Debug("Не удалось зачислить на счет", "error", err)
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.Logger.Debug("Не удалось зафиксировать транзакцию", "error", err)
+		return err
+	}
+	r.Logger.Debug("Перевод создан", "transfer", t, "duration", time.Since(now))
+	return nil
+}
+
+// execer - общий интерфейс пула соединений и транзакции
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// adjustBalance изменяет баланс счета на delta
+func adjustBalance(ctx context.Context, db execer, id uuid.UUID, delta decimal.Decimal) error {
+	query := `UPDATE accounts SET balance = balance + $2, updated_at = NOW() WHERE id = $1`
+	tag, err := db.Exec(ctx, query, id, delta)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return account.ErrAccountNotFound
+	}
+	return nil
+}
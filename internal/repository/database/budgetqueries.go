@@ -6,16 +6,22 @@ import (
 
 	"github.com/SobolevTim/finance_bot/internal/domain/budget"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
 )
 
+const budgetColumns = `b.id, b.user_id, b.amount, b.currency, b.start_date, b.end_date, b.from_income,
+	b.period, b.start_day, b.rollover, b.carry_over, b.created_at, b.updated_at`
+
 func (r *Repository) BudgetCreate(ctx context.Context, budget *budget.Budget) error {
 	r.Logger.Debug("Создание бюджета", "budget", budget)
 	query := `
-		INSERT INTO budgets (id, user_id, amount, currency, start_date, end_date, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO budgets (id, user_id, amount, currency, start_date, end_date, from_income, period, start_day, rollover, carry_over, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
 	now := time.Now()
-	_, err := r.DB.Exec(ctx, query, budget.ID, budget.UserID, budget.Amount, budget.Currency, budget.StartDate, budget.EndDate, budget.CreatedAt, budget.UpdatedAt)
+	_, err := r.DB.Exec(ctx, query, budget.ID, budget.UserID, budget.Amount, budget.Currency, budget.StartDate, budget.EndDate, budget.FromIncome,
+		budget.Period, budget.StartDay, budget.Rollover, budget.CarryOver, budget.CreatedAt, budget.UpdatedAt)
 	if err != nil {
 		r.Logger.Debug("Ошибка создания бюджета", "error", err)
 		return err
@@ -26,34 +32,32 @@ func (r *Repository) BudgetCreate(ctx context.Context, budget *budget.Budget) er
 
 func (r *Repository) BudgetGetByID(ctx context.Context, id uuid.UUID) (*budget.Budget, error) {
 	r.Logger.Debug("Получение бюджета по ID", "id", id)
-	query := `
-		SELECT id, user_id, amount, currency, start_date, end_date, created_at, updated_at
-		FROM budgets
-		WHERE id = $1
+	query := `SELECT ` + budgetColumns + `
+		FROM budgets b
+		WHERE b.id = $1
 	`
 	now := time.Now()
-	row := r.DB.QueryRow(ctx, query, id)
-	b := &budget.Budget{}
-	err := row.Scan(&b.ID, &b.UserID, &b.Amount, &b.Currency, &b.StartDate, &b.EndDate, &b.CreatedAt, &b.UpdatedAt)
+	b, err := scanBudget(r.DB.QueryRow(ctx, query, id))
 	if err != nil {
 		r.Logger.Debug("Ошибка получения бюджета", "error", err)
 		return nil, err
 	}
+	if err := r.budgetLoadCategories(ctx, b); err != nil {
+		return nil, err
+	}
 	r.Logger.Debug("Бюджет получен", "budget", b, "timeSinnce", time.Since(now))
 	return b, nil
 }
 
-func (r *Repository) BudgetGetCurrent(ctx context.Context, userID uuid.UUID) (*budget.Budget, error) {
-	r.Logger.Debug("Получение текущего бюджета", "userID", userID)
-	query := `
-		SELECT id, user_id, amount, currency, start_date, end_date, created_at, updated_at
-		FROM budgets
-		WHERE user_id = $1 AND start_date <= NOW() AND end_date >= NOW()
+// BudgetGetCurrent возвращает бюджет пользователя, действующий на дату date
+func (r *Repository) BudgetGetCurrent(ctx context.Context, userID uuid.UUID, date time.Time) (*budget.Budget, error) {
+	r.Logger.Debug("Получение текущего бюджета", "userID", userID, "date", date)
+	query := `SELECT ` + budgetColumns + `
+		FROM budgets b
+		WHERE b.user_id = $1 AND b.start_date <= $2 AND b.end_date >= $2
 	`
 	now := time.Now()
-	row := r.DB.QueryRow(ctx, query, userID)
-	b := &budget.Budget{}
-	err := row.Scan(&b.ID, &b.UserID, &b.Amount, &b.Currency, &b.StartDate, &b.EndDate, &b.CreatedAt, &b.UpdatedAt)
+	b, err := scanBudget(r.DB.QueryRow(ctx, query, userID, date))
 	if err != nil {
 		r.Logger.Debug("Ошибка получения текущего бюджета", "error", err)
 		if err.Error() == "no rows in result set" {
@@ -61,6 +65,9 @@ func (r *Repository) BudgetGetCurrent(ctx context.Context, userID uuid.UUID) (*b
 		}
 		return nil, err
 	}
+	if err := r.budgetLoadCategories(ctx, b); err != nil {
+		return nil, err
+	}
 	r.Logger.Debug("Текущий бюджет получен", "budget", b, "timeSinnce", time.Since(now))
 	return b, nil
 }
@@ -69,11 +76,13 @@ func (r *Repository) BudgetUpdate(ctx context.Context, budget *budget.Budget) er
 	r.Logger.Debug("Обновление бюджета", "budget", budget)
 	query := `
 		UPDATE budgets
-		SET amount = $2, currency = $3, start_date = $4, end_date = $5, updated_at = $6
+		SET amount = $2, currency = $3, start_date = $4, end_date = $5, from_income = $6,
+			period = $7, start_day = $8, rollover = $9, carry_over = $10, updated_at = $11
 		WHERE id = $1
 	`
 	now := time.Now()
-	_, err := r.DB.Exec(ctx, query, budget.ID, budget.Amount, budget.Currency, budget.StartDate, budget.EndDate, budget.UpdatedAt)
+	_, err := r.DB.Exec(ctx, query, budget.ID, budget.Amount, budget.Currency, budget.StartDate, budget.EndDate, budget.FromIncome,
+		budget.Period, budget.StartDay, budget.Rollover, budget.CarryOver, budget.UpdatedAt)
 	if err != nil {
 		r.Logger.Debug("Ошибка обновления бюджета", "error", err)
 		return err
@@ -98,18 +107,16 @@ func (r *Repository) BudgetDelete(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
-func (r *Repository) BudgetGetByTgID(ctx context.Context, tgID string) (*budget.Budget, error) {
-	r.Logger.Debug("Получение бюджета по telegramID", "telegramID", tgID)
-	query := `
-		SELECT b.id, b.user_id, b.amount, b.currency, b.start_date, b.end_date, b.created_at, b.updated_at
+// BudgetGetByTgID возвращает бюджет пользователя telegramID, действующий на дату date
+func (r *Repository) BudgetGetByTgID(ctx context.Context, tgID string, date time.Time) (*budget.Budget, error) {
+	r.Logger.Debug("Получение бюджета по telegramID", "telegramID", tgID, "date", date)
+	query := `SELECT ` + budgetColumns + `
 		FROM budgets b
 		JOIN users u ON b.user_id = u.id
-		WHERE u.telegram_id = $1 AND b.start_date <= NOW() AND b.end_date >= NOW()
+		WHERE u.telegram_id = $1 AND b.start_date <= $2 AND b.end_date >= $2
 	`
 	now := time.Now()
-	row := r.DB.QueryRow(ctx, query, tgID)
-	b := &budget.Budget{}
-	err := row.Scan(&b.ID, &b.UserID, &b.Amount, &b.Currency, &b.StartDate, &b.EndDate, &b.CreatedAt, &b.UpdatedAt)
+	b, err := scanBudget(r.DB.QueryRow(ctx, query, tgID, date))
 	if err != nil {
 		r.Logger.Debug("Ошибка получения бюджета по tgID", "error", err)
 		if err.Error() == "no rows in result set" {
@@ -117,6 +124,248 @@ func (r *Repository) BudgetGetByTgID(ctx context.Context, tgID string) (*budget.
 		}
 		return nil, err
 	}
+	if err := r.budgetLoadCategories(ctx, b); err != nil {
+		return nil, err
+	}
 	r.Logger.Debug("Бюджет получен", "budget", b, "timeSinnce", time.Since(now))
 	return b, nil
 }
+
+// BudgetSetCategoryLimit устанавливает лимит категории в бюджете
+func (r *Repository) BudgetSetCategoryLimit(ctx context.Context, budgetID, categoryID uuid.UUID, limit decimal.Decimal) error {
+	r.Logger.Debug("Установка лимита категории", "budgetID", budgetID, "categoryID", categoryID, "limit", limit)
+	query := `
+		INSERT INTO budget_categories (budget_id, category_id, limit_amount)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (budget_id, category_id) DO UPDATE SET limit_amount = EXCLUDED.limit_amount
+	`
+	now := time.Now()
+	_, err := r.DB.Exec(ctx, query, budgetID, categoryID, limit)
+	if err != nil {
+		r.Logger.Debug("Ошибка установки лимита категории", "error", err)
+		return err
+	}
+	r.Logger.Debug("Лимит категории установлен", "budgetID", budgetID, "categoryID", categoryID, "timeSinnce", time.Since(now))
+	return nil
+}
+
+// BudgetDeleteCategoryLimit удаляет лимит категории из бюджета
+func (r *Repository) BudgetDeleteCategoryLimit(ctx context.Context, budgetID, categoryID uuid.UUID) error {
+	r.Logger.Debug("Удаление лимита категории", "budgetID", budgetID, "categoryID", categoryID)
+	query := `
+		DELETE FROM budget_categories
+		WHERE budget_id = $1 AND category_id = $2
+	`
+	now := time.Now()
+	_, err := r.DB.Exec(ctx, query, budgetID, categoryID)
+	if err != nil {
+		r.Logger.Debug("Ошибка удаления лимита категории", "error", err)
+		return err
+	}
+	r.Logger.Debug("Лимит категории удален", "budgetID", budgetID, "categoryID", categoryID, "timeSinnce", time.Since(now))
+	return nil
+}
+
+// BudgetAlertAdd отмечает, что траты пересекли порог threshold бюджета или лимита категории
+// categoryID = uuid.Nil - бюджет целиком
+// возвращает false, если порог уже был отмечен
+func (r *Repository) BudgetAlertAdd(ctx context.Context, budgetID, categoryID uuid.UUID, threshold int) (bool, error) {
+	r.Logger.Debug("Отметка порога бюджета", "budgetID", budgetID, "categoryID", categoryID, "threshold", threshold)
+	query := `
+		INSERT INTO budget_alerts (budget_id, category_id, threshold)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (budget_id, category_id, threshold) DO NOTHING
+	`
+	now := time.Now()
+	tag, err := r.DB.Exec(ctx, query, budgetID, categoryID, threshold)
+	if err != nil {
+		r.Logger.Debug("Ошибка отметки порога бюджета", "error", err)
+		return false, err
+	}
+	r.Logger.Debug("Порог бюджета отмечен", "budgetID", budgetID, "created", tag.RowsAffected() > 0, "timeSinnce", time.Since(now))
+	return tag.RowsAffected() > 0, nil
+}
+
+// BudgetCreateNext создает бюджет следующего периода вместе с лимитами категорий.
+// Бюджет не создается, если у пользователя уже есть бюджет, заканчивающийся не раньше его начала.
+// Одновременный перенос того же периода из задачи и из обработчика чата пропускается
+// при конфликте с уникальностью периода пользователя.
+// Возвращает false, если бюджет не создан
+func (r *Repository) BudgetCreateNext(ctx context.Context, b *budget.Budget) (bool, error) {
+	r.Logger.Debug("Создание бюджета следующего периода", "budget", b)
+	query := `
+		INSERT INTO budgets (id, user_id, amount, currency, start_date, end_date, from_income, period, start_day, rollover, carry_over, created_at, updated_at)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+		WHERE NOT EXISTS (SELECT 1 FROM budgets WHERE user_id = $2 AND end_date >= $5)
+		ON CONFLICT DO NOTHING
+	`
+	limitQuery := `INSERT INTO budget_categories (budget_id, category_id, limit_amount) VALUES ($1, $2, $3)`
+
+	now := time.Now()
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		r.Logger.Debug("Не удалось начать транзакцию", "error", err)
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, b.ID, b.UserID, b.Amount, b.Currency, b.StartDate, b.EndDate, b.FromIncome,
+		b.Period, b.StartDay, b.Rollover, b.CarryOver, b.CreatedAt, b.UpdatedAt)
+	if err != nil {
+		r.Logger.Debug("Ошибка создания бюджета следующего периода", "error", err)
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		r.Logger.Debug("Бюджет периода уже существует", "userID", b.UserID, "start", b.StartDate)
+		return false, nil
+	}
+	for categoryID, limit := range b.Categories {
+		if _, err := tx.Exec(ctx, limitQuery, b.ID, categoryID, limit); err != nil {
+			r.Logger.Debug("Ошибка копирования лимита категории", "error", err)
+			return false, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.Logger.Debug("Не удалось зафиксировать транзакцию", "error", err)
+		return false, err
+	}
+	r.Logger.Debug("Бюджет следующего периода создан", "budget", b, "timeSinnce", time.Since(now))
+	return true, nil
+}
+
+// BudgetGetLatest возвращает последний по дате окончания бюджет пользователя
+// возвращает nil, если бюджетов нет
+func (r *Repository) BudgetGetLatest(ctx context.Context, userID uuid.UUID) (*budget.Budget, error) {
+	r.Logger.Debug("Получение последнего бюджета", "userID", userID)
+	query := `SELECT ` + budgetColumns + `
+		FROM budgets b
+		WHERE b.user_id = $1
+		ORDER BY b.end_date DESC
+		LIMIT 1
+	`
+	now := time.Now()
+	b, err := scanBudget(r.DB.QueryRow(ctx, query, userID))
+	if err != nil {
+		r.Logger.Debug("Ошибка получения последнего бюджета", "error", err)
+		if err.Error() == "no rows in result set" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := r.budgetLoadCategories(ctx, b); err != nil {
+		return nil, err
+	}
+	r.Logger.Debug("Последний бюджет получен", "budget", b, "timeSinnce", time.Since(now))
+	return b, nil
+}
+
+// BudgetsGetEnded возвращает последние бюджеты пользователей, закончившиеся раньше даты date.
+// Это бюджеты, по которым пора создать следующий период
+func (r *Repository) BudgetsGetEnded(ctx context.Context, date time.Time) ([]*budget.Budget, error) {
+	r.Logger.Debug("Получение закончившихся бюджетов", "date", date)
+	query := `SELECT * FROM (
+			SELECT DISTINCT ON (b.user_id) ` + budgetColumns + `
+			FROM budgets b
+			ORDER BY b.user_id, b.end_date DESC
+		) latest
+		WHERE latest.end_date < $1
+	`
+	now := time.Now()
+	list, err := r.queryBudgets(ctx, query, date)
+	if err != nil {
+		r.Logger.Debug("Ошибка получения закончившихся бюджетов", "error", err)
+		return nil, err
+	}
+	r.Logger.Debug("Закончившиеся бюджеты получены", "count", len(list), "timeSinnce", time.Since(now))
+	return list, nil
+}
+
+// BudgetsGetHistory возвращает до limit последних бюджетов пользователя, начавшихся не позже даты date,
+// от новых к старым
+func (r *Repository) BudgetsGetHistory(ctx context.Context, userID uuid.UUID, date time.Time, limit int) ([]*budget.Budget, error) {
+	r.Logger.Debug("Получение истории бюджетов", "userID", userID, "date", date, "limit", limit)
+	query := `SELECT ` + budgetColumns + `
+		FROM budgets b
+		WHERE b.user_id = $1 AND b.start_date <= $2
+		ORDER BY b.start_date DESC
+		LIMIT $3
+	`
+	now := time.Now()
+	list, err := r.queryBudgets(ctx, query, userID, date, limit)
+	if err != nil {
+		r.Logger.Debug("Ошибка получения истории бюджетов", "error", err)
+		return nil, err
+	}
+	r.Logger.Debug("История бюджетов получена", "count", len(list), "timeSinnce", time.Since(now))
+	return list, nil
+}
+
+// queryBudgets выполняет запрос с колонками budgetColumns и загружает лимиты категорий
+func (r *Repository) queryBudgets(ctx context.Context, query string, args ...any) ([]*budget.Budget, error) {
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*budget.Budget, 0)
+	for rows.Next() {
+		b, err := scanBudget(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		list = append(list, b)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	// Лимиты загружаются после закрытия rows: соединение занято до конца чтения
+	for _, b := range list {
+		if err := r.budgetLoadCategories(ctx, b); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// scanBudget читает бюджет из строки с колонками budgetColumns
+func scanBudget(row pgx.Row) (*budget.Budget, error) {
+	b := &budget.Budget{Categories: make(map[uuid.UUID]decimal.Decimal)}
+	err := row.Scan(&b.ID, &b.UserID, &b.Amount, &b.Currency, &b.StartDate, &b.EndDate, &b.FromIncome,
+		&b.Period, &b.StartDay, &b.Rollover, &b.CarryOver, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// budgetLoadCategories загружает лимиты категорий бюджета из budget_categories
+func (r *Repository) budgetLoadCategories(ctx context.Context, b *budget.Budget) error {
+	query := `
+		SELECT category_id, limit_amount
+		FROM budget_categories
+		WHERE budget_id = $1
+	`
+	rows, err := r.DB.Query(ctx, query, b.ID)
+	if err != nil {
+		r.Logger.Debug("Ошибка получения лимитов категорий", "error", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var categoryID uuid.UUID
+		var limit decimal.Decimal
+		if err := rows.Scan(&categoryID, &limit); err != nil {
+			r.Logger.Debug("Ошибка сканирования лимита категории", "error", err)
+			return err
+		}
+		b.Categories[categoryID] = limit
+	}
+	if rows.Err() != nil {
+		r.Logger.Debug("Ошибка перебора лимитов категорий", "error", rows.Err())
+		return rows.Err()
+	}
+	return nil
+}
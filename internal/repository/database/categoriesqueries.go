@@ -166,3 +166,130 @@ func (r *Repository) CategoriesGetDefaultsByName(ctx context.Context, name strin
 	r.Logger.Debug("Базовая категория получена", "category", c, "timeSinnce", time.Since(now))
 	return c, nil
 }
+
+// CategoriesGetByName возвращает базовую или пользовательскую категорию по имени
+func (r *Repository) CategoriesGetByName(ctx context.Context, userID uuid.UUID, name string) (*categories.Categories, error) {
+	r.Logger.Debug("Получение категории пользователя по имени", "userID", userID, "name", name)
+	query := `
+		SELECT id, user_id, name, is_default, icon, created_at, updated_at
+		FROM categories
+		WHERE name = $1 AND (is_default = true OR user_id = $2)
+		ORDER BY is_default ASC
+		LIMIT 1
+	`
+	now := time.Now()
+	row := r.DB.QueryRow(ctx, query, name, userID)
+	c := &categories.Categories{}
+	err := row.Scan(&c.ID, &c.UserID, &c.Name, &c.IsDefault, &c.Icon, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		r.Logger.Debug("Ошибка получения категории пользователя по имени", "error", err)
+		if err.Error() == "no rows in result set" {
+			return nil, categories.ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	r.Logger.Debug("Категория получена", "category", c, "timeSinnce", time.Since(now))
+	return c, nil
+}
+
+// CategoriesUpdate обновляет название и иконку категории
+func (r *Repository) CategoriesUpdate(ctx context.Context, category *categories.Categories) error {
+	r.Logger.Debug("Обновление категории", "category", category)
+	query := `
+		UPDATE categories
+		SET name = $2, icon = $3, updated_at = $4
+		WHERE id = $1
+	`
+	now := time.Now()
+	_, err := r.DB.Exec(ctx, query, category.ID, category.Name, category.Icon, category.UpdatedAt)
+	if err != nil {
+		r.Logger.Debug("Ошибка обновления категории", "error", err)
+		return err
+	}
+	r.Logger.Debug("Категория обновлена", "category", category, "timeSinnce", time.Since(now))
+	return nil
+}
+
+// CategoriesDelete удаляет категорию
+func (r *Repository) CategoriesDelete(ctx context.Context, id uuid.UUID) error {
+	r.Logger.Debug("Удаление категории", "id", id)
+	query := `
+		DELETE FROM categories
+		WHERE id = $1
+	`
+	now := time.Now()
+	_, err := r.DB.Exec(ctx, query, id)
+	if err != nil {
+		r.Logger.Debug("Ошибка удаления категории", "error", err)
+		return err
+	}
+	r.Logger.Debug("Категория удалена", "id", id, "timeSinnce", time.Since(now))
+	return nil
+}
+
+// CategoriesIsUsed проверяет, есть ли расходы в категории, в том числе части распределенных трат
+func (r *Repository) CategoriesIsUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.Logger.Debug("Проверка использования категории", "id", id)
+	query := `SELECT EXISTS (SELECT 1 FROM expenses WHERE category_id = $1) OR EXISTS (SELECT 1 FROM expense_splits WHERE category_id = $1)`
+	now := time.Now()
+	var used bool
+	err := r.DB.QueryRow(ctx, query, id).Scan(&used)
+	if err != nil {
+		r.Logger.Debug("Ошибка проверки использования категории", "error", err)
+		return false, err
+	}
+	r.Logger.Debug("Использование категории проверено", "id", id, "used", used, "timeSinnce", time.Since(now))
+	return used, nil
+}
+
+// CategoriesAliasSet сохраняет псевдоним категории пользователя
+func (r *Repository) CategoriesAliasSet(ctx context.Context, userID, categoryID uuid.UUID, alias string) error {
+	r.Logger.Debug("Сохранение псевдонима категории", "userID", userID, "categoryID", categoryID, "alias", alias)
+	query := `
+		INSERT INTO category_aliases (user_id, category_id, alias)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, alias) DO UPDATE SET category_id = EXCLUDED.category_id
+	`
+	now := time.Now()
+	_, err := r.DB.Exec(ctx, query, userID, categoryID, alias)
+	if err != nil {
+		r.Logger.Debug("Ошибка сохранения псевдонима категории", "error", err)
+		return err
+	}
+	r.Logger.Debug("Псевдоним категории сохранен", "alias", alias, "timeSinnce", time.Since(now))
+	return nil
+}
+
+// CategoriesAliasGetForUser возвращает псевдонимы категорий пользователя
+func (r *Repository) CategoriesAliasGetForUser(ctx context.Context, userID uuid.UUID) (map[string]uuid.UUID, error) {
+	r.Logger.Debug("Получение псевдонимов категорий", "userID", userID)
+	query := `
+		SELECT alias, category_id
+		FROM category_aliases
+		WHERE user_id = $1
+	`
+	now := time.Now()
+	rows, err := r.DB.Query(ctx, query, userID)
+	if err != nil {
+		r.Logger.Debug("Ошибка получения псевдонимов категорий", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make(map[string]uuid.UUID)
+	for rows.Next() {
+		var alias string
+		var categoryID uuid.UUID
+		if err := rows.Scan(&alias, &categoryID); err != nil {
+			r.Logger.Debug("Ошибка сканирования псевдонима категории", "error", err)
+			return nil, err
+		}
+		aliases[alias] = categoryID
+	}
+	if rows.Err() != nil {
+		r.Logger.Debug("Ошибка перебора псевдонимов категорий", "error", rows.Err())
+		return nil, rows.Err()
+	}
+	r.Logger.Debug("Псевдонимы категорий получены", "count", len(aliases), "timeSinnce", time.Since(now))
+	return aliases, nil
+}
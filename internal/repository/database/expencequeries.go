@@ -6,53 +6,142 @@ import (
 
 	"github.com/SobolevTim/finance_bot/internal/domain/expense"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/shopspring/decimal"
 )
 
-// CreateExpens создает новый расход
+// CreateExpens создает новый расход вместе с распределением по категориям
+// и изменяет балансы счетов balance в той же транзакции
 // возвращает ошибку, если не удалось создать расход
-func (r *Repository) CreateExpens(ctx context.Context, expense *expense.Expense) error {
+func (r *Repository) CreateExpens(ctx context.Context, expense *expense.Expense, balance []expense.BalanceChange) error {
 	r.Logger.Debug("Запись нового расхода в базу данных", "expense", expense)
-	query := `INSERT INTO expenses (user_id, category_id, amount, date, is_recurring, recurrence_rule, description) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	query := `INSERT INTO expenses (id, user_id, category_id, amount, date, is_recurring, recurrence_rule, description, currency, author_id, account_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
 
 	now := time.Now()
-	_, err := r.DB.Exec(ctx, query, expense.UserID, expense.CategoryID, expense.Ammount, expense.Date, expense.IsRecurring, expense.RecurrenceRule, expense.Description)
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		r.Logger.Debug("Не удалось начать транзакцию", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, expense.ID, expense.UserID, expense.CategoryID, expense.Ammount, expense.Date, expense.IsRecurring, expense.RecurrenceRule, expense.Description, expense.Currency, nullUUID(expense.AuthorID), nullUUID(expense.AccountID))
 	if err != nil {
 		r.Logger.Debug("Не удалось создать расход", "error", err)
 		return err
 	}
+	if err := saveSplits(ctx, tx, expense); err != nil {
+		r.Logger.Debug("Не удалось сохранить распределение расхода", "error", err)
+		return err
+	}
+	if err := saveReceipt(ctx, tx, expense); err != nil {
+		r.Logger.Debug("Не удалось сохранить чек расхода", "error", err)
+		return err
+	}
+	if err := applyBalance(ctx, tx, balance); err != nil {
+		r.Logger.Debug("Не удалось изменить баланс счета", "error", err)
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.Logger.Debug("Не удалось зафиксировать транзакцию", "error", err)
+		return err
+	}
 	r.Logger.Debug("Расход успешно создан", "expense", expense, "duration", time.Since(now))
 	return nil
 }
 
-// UpdateExpens обновляет существующий расход
+// CreateExpensesBatch создает несколько расходов в одной транзакции
+// возвращает ошибку, если не удалось создать хотя бы один расход; в этом случае ни один расход не создается
+func (r *Repository) CreateExpensesBatch(ctx context.Context, expenses []*expense.Expense) error {
+	r.Logger.Debug("Пакетная запись расходов в базу данных", "count", len(expenses))
+	query := `INSERT INTO expenses (user_id, category_id, amount, date, is_recurring, recurrence_rule, description, currency, author_id, account_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	now := time.Now()
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		r.Logger.Debug("Не удалось начать транзакцию", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	for _, e := range expenses {
+		batch.Queue(query, e.UserID, e.CategoryID, e.Ammount, e.Date, e.IsRecurring, e.RecurrenceRule, e.Description, e.Currency, nullUUID(e.AuthorID), nullUUID(e.AccountID))
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		r.Logger.Debug("Не удалось создать расходы", "error", err)
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.Logger.Debug("Не удалось зафиксировать транзакцию", "error", err)
+		return err
+	}
+	r.Logger.Debug("Расходы успешно созданы", "count", len(expenses), "duration", time.Since(now))
+	return nil
+}
+
+// UpdateExpens обновляет существующий расход, заменяет его распределение по категориям
+// и изменяет балансы счетов balance в той же транзакции
 // возвращает ошибку, если не удалось обновить расход
-func (r *Repository) UpdateExpens(ctx context.Context, expense *expense.Expense) error {
+func (r *Repository) UpdateExpens(ctx context.Context, expense *expense.Expense, balance []expense.BalanceChange) error {
 	r.Logger.Debug("Обновление расхода в базе данных", "expense", expense)
-	query := `UPDATE expenses SET category_id = $1, amount = $2, date = $3, is_recurring = $4, recurrence_rule = $5, description = $6 WHERE id = $7`
+	query := `UPDATE expenses SET category_id = $1, amount = $2, date = $3, is_recurring = $4, recurrence_rule = $5, description = $6, currency = $7, account_id = $8 WHERE id = $9`
 
 	now := time.Now()
-	_, err := r.DB.Exec(ctx, query, expense.CategoryID, expense.Ammount, expense.Date, expense.IsRecurring, expense.RecurrenceRule, expense.Description, expense.ID)
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		r.Logger.Debug("Не удалось начать транзакцию", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, expense.CategoryID, expense.Ammount, expense.Date, expense.IsRecurring, expense.RecurrenceRule, expense.Description, expense.Currency, nullUUID(expense.AccountID), expense.ID)
 	if err != nil {
 		r.Logger.Debug("Не удалось обновить расход", "error", err)
 		return err
 	}
+	if err := saveSplits(ctx, tx, expense); err != nil {
+		r.Logger.Debug("Не удалось сохранить распределение расхода", "error", err)
+		return err
+	}
+	if err := applyBalance(ctx, tx, balance); err != nil {
+		r.Logger.Debug("Не удалось изменить баланс счета", "error", err)
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.Logger.Debug("Не удалось зафиксировать транзакцию", "error", err)
+		return err
+	}
 	r.Logger.Debug("Расход успешно обновлен", "expense", expense, "duration", time.Since(now))
 	return nil
 }
 
-// DeleteExpens удаляет существующий расход
+// DeleteExpens удаляет существующий расход и изменяет балансы счетов balance в той же транзакции
 // возвращает ошибку, если не удалось удалить расход
-func (r *Repository) DeleteExpens(ctx context.Context, id uuid.UUID) error {
+func (r *Repository) DeleteExpens(ctx context.Context, id uuid.UUID, balance []expense.BalanceChange) error {
 	r.Logger.Debug("Удаление расхода из базы данных", "id", id)
 	query := `DELETE FROM expenses WHERE id = $1`
 
 	now := time.Now()
-	_, err := r.DB.Exec(ctx, query, id)
+	tx, err := r.DB.Begin(ctx)
 	if err != nil {
+		r.Logger.Debug("Не удалось начать транзакцию", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, query, id); err != nil {
 		r.Logger.Debug("Не удалось удалить расход", "error", err)
 		return err
 	}
+	if err := applyBalance(ctx, tx, balance); err != nil {
+		r.Logger.Debug("Не удалось изменить баланс счета", "error", err)
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.Logger.Debug("Не удалось зафиксировать транзакцию", "error", err)
+		return err
+	}
 	r.Logger.Debug("Расход успешно удален", "id", id, "duration", time.Since(now))
 	return nil
 }
@@ -61,25 +150,35 @@ func (r *Repository) DeleteExpens(ctx context.Context, id uuid.UUID) error {
 // возвращает ошибку, если не удалось получить расход
 func (r *Repository) GetExpenses(ctx context.Context, id uuid.UUID) (*expense.Expense, error) {
 	r.Logger.Debug("Получение расхода из базы данных", "id", id)
-	query := `SELECT id, user_id, category_id, amount, date, is_recurring, recurrence_rule, description FROM expenses WHERE id = $1`
+	query := `SELECT id, user_id, category_id, amount, date, is_recurring, recurrence_rule, description, currency, author_id, account_id FROM expenses WHERE id = $1`
 
 	now := time.Now()
 	row := r.DB.QueryRow(ctx, query, id)
-	expense := &expense.Expense{}
-	err := row.Scan(&expense.ID, &expense.UserID, &expense.CategoryID, &expense.Ammount, &expense.Date, &expense.IsRecurring, &expense.RecurrenceRule, &expense.Description)
+	e := &expense.Expense{}
+	var authorID, accountID uuid.NullUUID
+	err := row.Scan(&e.ID, &e.UserID, &e.CategoryID, &e.Ammount, &e.Date, &e.IsRecurring, &e.RecurrenceRule, &e.Description, &e.Currency, &authorID, &accountID)
 	if err != nil {
 		r.Logger.Debug("Не удалось получить расход", "error", err)
+		if err.Error() == "no rows in result set" {
+			return nil, expense.ErrorExpenseNotFound
+		}
+		return nil, err
+	}
+	e.AuthorID = authorID.UUID
+	e.AccountID = accountID.UUID
+	if err := r.loadSplits(ctx, []*expense.Expense{e}); err != nil {
+		r.Logger.Debug("Не удалось получить распределение расхода", "error", err)
 		return nil, err
 	}
-	r.Logger.Debug("Расход успешно получен", "expense", expense, "duration", time.Since(now))
-	return expense, nil
+	r.Logger.Debug("Расход успешно получен", "expense", e, "duration", time.Since(now))
+	return e, nil
 }
 
 // GetExpensesByUserID возвращает все расходы по ID пользователя
 // возвращает ошибку, если не удалось получить расходы
 func (r *Repository) GetExpensesByUserID(ctx context.Context, userID uuid.UUID) ([]*expense.Expense, error) {
 	r.Logger.Debug("Получение всех расходов из базы данных по ID пользователя", "userID", userID)
-	query := `SELECT id, user_id, category_id, amount, date, is_recurring, recurrence_rule, description FROM expenses WHERE user_id = $1`
+	query := `SELECT id, user_id, category_id, amount, date, is_recurring, recurrence_rule, description, currency, author_id, account_id FROM expenses WHERE user_id = $1`
 
 	now := time.Now()
 	rows, err := r.DB.Query(ctx, query, userID)
@@ -92,13 +191,20 @@ func (r *Repository) GetExpensesByUserID(ctx context.Context, userID uuid.UUID)
 	expenses := make([]*expense.Expense, 0)
 	for rows.Next() {
 		expense := &expense.Expense{}
-		err := rows.Scan(&expense.ID, &expense.UserID, &expense.CategoryID, &expense.Ammount, &expense.Date, &expense.IsRecurring, &expense.RecurrenceRule, &expense.Description)
+		var authorID, accountID uuid.NullUUID
+		err := rows.Scan(&expense.ID, &expense.UserID, &expense.CategoryID, &expense.Ammount, &expense.Date, &expense.IsRecurring, &expense.RecurrenceRule, &expense.Description, &expense.Currency, &authorID, &accountID)
 		if err != nil {
 			r.Logger.Debug("Не удалось получить расход", "error", err)
 			return nil, err
 		}
+		expense.AuthorID = authorID.UUID
+		expense.AccountID = accountID.UUID
 		expenses = append(expenses, expense)
 	}
+	if err := r.loadSplits(ctx, expenses); err != nil {
+		r.Logger.Debug("Не удалось получить распределение расходов", "error", err)
+		return nil, err
+	}
 	r.Logger.Debug("Расходы успешно получены", "expenses", expenses, "duration", time.Since(now))
 	return expenses, nil
 }
@@ -107,7 +213,7 @@ func (r *Repository) GetExpensesByUserID(ctx context.Context, userID uuid.UUID)
 // возвращает ошибку, если не удалось получить расходы
 func (r *Repository) GetExpensesByDate(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) ([]*expense.Expense, error) {
 	r.Logger.Debug("Получение всех расходов из базы данных по дате", "userID", userID, "startDate", startDate, "endDate", endDate)
-	query := `SELECT id, user_id, category_id, amount, date, is_recurring, recurrence_rule, description FROM expenses WHERE user_id = $1 AND date >= $2 AND date <= $3 ORDER BY date ASC`
+	query := `SELECT id, user_id, category_id, amount, date, is_recurring, recurrence_rule, description, currency, author_id, account_id FROM expenses WHERE user_id = $1 AND date >= $2 AND date <= $3 ORDER BY date ASC`
 
 	now := time.Now()
 	rows, err := r.DB.Query(ctx, query, userID, startDate, endDate)
@@ -120,23 +226,30 @@ func (r *Repository) GetExpensesByDate(ctx context.Context, userID uuid.UUID, st
 	expenses := make([]*expense.Expense, 0)
 	for rows.Next() {
 		expense := &expense.Expense{}
-		err := rows.Scan(&expense.ID, &expense.UserID, &expense.CategoryID, &expense.Ammount, &expense.Date, &expense.IsRecurring, &expense.RecurrenceRule, &expense.Description)
+		var authorID, accountID uuid.NullUUID
+		err := rows.Scan(&expense.ID, &expense.UserID, &expense.CategoryID, &expense.Ammount, &expense.Date, &expense.IsRecurring, &expense.RecurrenceRule, &expense.Description, &expense.Currency, &authorID, &accountID)
 		if err != nil {
 			r.Logger.Debug("Не удалось получить расход", "error", err)
 			return nil, err
 		}
+		expense.AuthorID = authorID.UUID
+		expense.AccountID = accountID.UUID
 		expenses = append(expenses, expense)
 	}
+	if err := r.loadSplits(ctx, expenses); err != nil {
+		r.Logger.Debug("Не удалось получить распределение расходов", "error", err)
+		return nil, err
+	}
 	r.Logger.Debug("Расходы успешно получены", "expenses", expenses, "duration", time.Since(now))
 	return expenses, nil
 }
 
-// GetExpensesByDate возвращает сумму расходов в диапазоне дат по ID пользователя
+// GetExpensesByDateForDay возвращает суммы расходов по дням и валютам в диапазоне дат по ID пользователя
 // возвращает ошибку, если не удалось получить расходы
 func (r *Repository) GetExpensesByDateForDay(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) ([]*expense.Expense, error) {
 	r.Logger.Debug("Получение всех расходов из базы данных по дате", "userID", userID, "startDate", startDate, "endDate", endDate)
-	query := `SELECT date, SUM(amount) as total_amount FROM expenses WHERE user_id = $1 AND date >= $2 AND date <= $3
-		GROUP BY date
+	query := `SELECT date, currency, SUM(amount) as total_amount FROM expenses WHERE user_id = $1 AND date >= $2 AND date <= $3
+		GROUP BY date, currency
 		ORDER BY date ASC`
 
 	now := time.Now()
@@ -151,16 +264,18 @@ func (r *Repository) GetExpensesByDateForDay(ctx context.Context, userID uuid.UU
 	for rows.Next() {
 		var dailyExpense struct {
 			Date        time.Time
+			Currency    string
 			TotalAmount decimal.Decimal
 		}
-		err := rows.Scan(&dailyExpense.Date, &dailyExpense.TotalAmount)
+		err := rows.Scan(&dailyExpense.Date, &dailyExpense.Currency, &dailyExpense.TotalAmount)
 		if err != nil {
 			r.Logger.Debug("Не удалось получить расход", "error", err)
 			return nil, err
 		}
 		expenses = append(expenses, &expense.Expense{
-			Date:    dailyExpense.Date,
-			Ammount: dailyExpense.TotalAmount,
+			Date:     dailyExpense.Date,
+			Currency: dailyExpense.Currency,
+			Ammount:  dailyExpense.TotalAmount,
 		})
 	}
 	r.Logger.Debug("Расходы успешно получены", "expenses", &expenses, "duration", time.Since(now))
@@ -171,7 +286,7 @@ func (r *Repository) GetExpensesByDateForDay(ctx context.Context, userID uuid.UU
 // возвращает ошибку, если не удалось получить расходы
 func (r *Repository) GetExpensesByTelegramID(ctx context.Context, telegramID int64) ([]*expense.Expense, error) {
 	r.Logger.Debug("Получение всех расходов из базы данных по ID пользователя Telegram", "telegramID", telegramID)
-	query := `SELECT e.id, e.user_id, e.category_id, e.amount, e.date, e.is_recurring, e.recurrence_rule, e.description FROM expenses e JOIN users u ON e.user_id = u.id WHERE u.telegram_id = $1`
+	query := `SELECT e.id, e.user_id, e.category_id, e.amount, e.date, e.is_recurring, e.recurrence_rule, e.description, e.currency, e.author_id, e.account_id FROM expenses e JOIN users u ON e.user_id = u.id WHERE u.telegram_id = $1`
 
 	now := time.Now()
 	rows, err := r.DB.Query(ctx, query, telegramID)
@@ -184,11 +299,14 @@ func (r *Repository) GetExpensesByTelegramID(ctx context.Context, telegramID int
 	expenses := make([]*expense.Expense, 0)
 	for rows.Next() {
 		expense := &expense.Expense{}
-		err := rows.Scan(&expense.ID, &expense.UserID, &expense.CategoryID, &expense.Ammount, &expense.Date, &expense.IsRecurring, &expense.RecurrenceRule, &expense.Description)
+		var authorID, accountID uuid.NullUUID
+		err := rows.Scan(&expense.ID, &expense.UserID, &expense.CategoryID, &expense.Ammount, &expense.Date, &expense.IsRecurring, &expense.RecurrenceRule, &expense.Description, &expense.Currency, &authorID, &accountID)
 		if err != nil {
 			r.Logger.Debug("Не удалось получить расход", "error", err)
 			return nil, err
 		}
+		expense.AuthorID = authorID.UUID
+		expense.AccountID = accountID.UUID
 		expenses = append(expenses, expense)
 	}
 	r.Logger.Debug("Расходы успешно получены", "expenses", expenses, "duration", time.Since(now))
@@ -199,7 +317,7 @@ func (r *Repository) GetExpensesByTelegramID(ctx context.Context, telegramID int
 // возвращает ошибку, если не удалось получить расходы
 func (r *Repository) GetExpensesByTelegramIDAndDate(ctx context.Context, telegramID int64, date string) ([]*expense.Expense, error) {
 	r.Logger.Debug("Получение всех расходов из базы данных по ID пользователя Telegram и дате", "telegramID", telegramID, "date", date)
-	query := `SELECT e.id, e.user_id, e.category_id, e.amount, e.date, e.is_recurring, e.recurrence_rule, e.description FROM expenses e JOIN users u ON e.user_id = u.id WHERE u.telegram_id = $1 AND e.date = $2`
+	query := `SELECT e.id, e.user_id, e.category_id, e.amount, e.date, e.is_recurring, e.recurrence_rule, e.description, e.currency, e.author_id, e.account_id FROM expenses e JOIN users u ON e.user_id = u.id WHERE u.telegram_id = $1 AND e.date = $2`
 
 	now := time.Now()
 	rows, err := r.DB.Query(ctx, query, telegramID, date)
@@ -212,13 +330,266 @@ func (r *Repository) GetExpensesByTelegramIDAndDate(ctx context.Context, telegra
 	expenses := make([]*expense.Expense, 0)
 	for rows.Next() {
 		expense := &expense.Expense{}
-		err := rows.Scan(&expense.ID, &expense.UserID, &expense.CategoryID, &expense.Ammount, &expense.Date, &expense.IsRecurring, &expense.RecurrenceRule, &expense.Description)
+		var authorID, accountID uuid.NullUUID
+		err := rows.Scan(&expense.ID, &expense.UserID, &expense.CategoryID, &expense.Ammount, &expense.Date, &expense.IsRecurring, &expense.RecurrenceRule, &expense.Description, &expense.Currency, &authorID, &accountID)
 		if err != nil {
 			r.Logger.Debug("Не удалось получить расход", "error", err)
 			return nil, err
 		}
+		expense.AuthorID = authorID.UUID
+		expense.AccountID = accountID.UUID
 		expenses = append(expenses, expense)
 	}
 	r.Logger.Debug("Расходы успешно получены", "expenses", expenses, "duration", time.Since(now))
 	return expenses, nil
 }
+
+// GetRecurringExpenses возвращает все повторяющиеся расходы
+// возвращает ошибку, если не удалось получить расходы
+func (r *Repository) GetRecurringExpenses(ctx context.Context) ([]*expense.Expense, error) {
+	r.Logger.Debug("Получение повторяющихся расходов из базы данных")
+	query := `SELECT id, user_id, category_id, amount, date, is_recurring, recurrence_rule, description, currency, author_id, account_id FROM expenses WHERE is_recurring = true`
+
+	now := time.Now()
+	rows, err := r.DB.Query(ctx, query)
+	if err != nil {
+		r.Logger.Debug("Не удалось получить повторяющиеся расходы", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	expenses := make([]*expense.Expense, 0)
+	for rows.Next() {
+		expense := &expense.Expense{}
+		var authorID, accountID uuid.NullUUID
+		err := rows.Scan(&expense.ID, &expense.UserID, &expense.CategoryID, &expense.Ammount, &expense.Date, &expense.IsRecurring, &expense.RecurrenceRule, &expense.Description, &expense.Currency, &authorID, &accountID)
+		if err != nil {
+			r.Logger.Debug("Не удалось получить расход", "error", err)
+			return nil, err
+		}
+		expense.AuthorID = authorID.UUID
+		expense.AccountID = accountID.UUID
+		expenses = append(expenses, expense)
+	}
+	if rows.Err() != nil {
+		r.Logger.Debug("Ошибка перебора повторяющихся расходов", "error", rows.Err())
+		return nil, rows.Err()
+	}
+	if err := r.loadSplits(ctx, expenses); err != nil {
+		r.Logger.Debug("Не удалось получить распределение расходов", "error", err)
+		return nil, err
+	}
+	r.Logger.Debug("Повторяющиеся расходы успешно получены", "count", len(expenses), "duration", time.Since(now))
+	return expenses, nil
+}
+
+// GetLastOccurrence возвращает дату последнего созданного вхождения повторяющегося расхода
+// если вхождений еще не было, возвращает дату самого расхода.
+// Дата хранится в самом расходе, поэтому удаленное или перенесенное вхождение не создается снова
+func (r *Repository) GetLastOccurrence(ctx context.Context, parentID uuid.UUID) (time.Time, error) {
+	r.Logger.Debug("Получение последнего вхождения повторяющегося расхода", "parentID", parentID)
+	query := `SELECT COALESCE(last_occurrence_date, date) FROM expenses WHERE id = $1`
+
+	now := time.Now()
+	var last time.Time
+	err := r.DB.QueryRow(ctx, query, parentID).Scan(&last)
+	if err != nil {
+		r.Logger.Debug("Не удалось получить последнее вхождение", "error", err)
+		return time.Time{}, err
+	}
+	r.Logger.Debug("Последнее вхождение получено", "parentID", parentID, "last", last, "duration", time.Since(now))
+	return last, nil
+}
+
+// CreateOccurrence создает вхождение повторяющегося расхода вместе с распределением по категориям,
+// изменяет балансы счетов balance и запоминает дату вхождения в исходном расходе.
+// Возвращает false, если вхождение на эту дату уже существует, балансы тогда не меняются
+func (r *Repository) CreateOccurrence(ctx context.Context, expense *expense.Expense, balance []expense.BalanceChange) (bool, error) {
+	r.Logger.Debug("Запись вхождения повторяющегося расхода", "expense", expense)
+	query := `INSERT INTO expenses (id, user_id, category_id, amount, date, is_recurring, recurrence_rule, description, parent_id, currency, author_id, account_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (parent_id, date) DO NOTHING`
+	lastQuery := `UPDATE expenses SET last_occurrence_date = $2 WHERE id = $1 AND (last_occurrence_date IS NULL OR last_occurrence_date < $2)`
+
+	now := time.Now()
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		r.Logger.Debug("Не удалось начать транзакцию", "error", err)
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, expense.ID, expense.UserID, expense.CategoryID, expense.Ammount, expense.Date, expense.IsRecurring, expense.RecurrenceRule, expense.Description, expense.ParentID, expense.Currency, nullUUID(expense.AuthorID), nullUUID(expense.AccountID))
+	if err != nil {
+		r.Logger.Debug("Не удалось создать вхождение", "error", err)
+		return false, err
+	}
+	if tag.RowsAffected() == 1 {
+		if err := saveSplits(ctx, tx, expense); err != nil {
+			r.Logger.Debug("Не удалось сохранить распределение вхождения", "error", err)
+			return false, err
+		}
+		if err := applyBalance(ctx, tx, balance); err != nil {
+			r.Logger.Debug("Не удалось изменить баланс счета", "error", err)
+			return false, err
+		}
+	}
+	if _, err := tx.Exec(ctx, lastQuery, expense.ParentID, expense.Date); err != nil {
+		r.Logger.Debug("Не удалось сохранить дату последнего вхождения", "error", err)
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.Logger.Debug("Не удалось зафиксировать транзакцию", "error", err)
+		return false, err
+	}
+	r.Logger.Debug("Вхождение записано", "expense", expense, "created", tag.RowsAffected() == 1, "duration", time.Since(now))
+	return tag.RowsAffected() == 1, nil
+}
+
+// SearchExpenses ищет траты пользователя по условиям filter, новые - первыми.
+//
+// Текст ищется полнотекстовым поиском по описанию с русской морфологией.
+// Границы периода сравниваются как timestamptz: даты трат и границы - полночь UTC,
+// поэтому результат не зависит от часового пояса сессии.
+// Возвращает страницу трат filter.Limit со сдвигом filter.Offset и общее число найденных трат
+func (r *Repository) SearchExpenses(ctx context.Context, userID uuid.UUID, filter *expense.SearchFilter) ([]*expense.Expense, int, error) {
+	r.Logger.Debug("Поиск расходов", "userID", userID, "filter", filter)
+	query := `SELECT id, user_id, category_id, amount, date, is_recurring, recurrence_rule, description, currency, author_id, account_id, COUNT(*) OVER()
+		FROM expenses
+		WHERE user_id = $1
+			AND ($2 = '' OR to_tsvector('russian', coalesce(description, '')) @@ plainto_tsquery('russian', $2))
+			AND ($3::uuid IS NULL OR category_id = $3
+				OR EXISTS (SELECT 1 FROM expense_splits s WHERE s.expense_id = expenses.id AND s.category_id = $3))
+			AND ($4::numeric IS NULL OR amount >= $4)
+			AND ($5::numeric IS NULL OR amount <= $5)
+			AND ($6::timestamptz IS NULL OR date >= $6)
+			AND ($7::timestamptz IS NULL OR date <= $7)
+		ORDER BY date DESC, created_at DESC
+		LIMIT $8 OFFSET $9`
+
+	now := time.Now()
+	rows, err := r.DB.Query(ctx, query, userID, filter.Text, nullUUID(filter.CategoryID), filter.MinAmount, filter.MaxAmount,
+		nullDate(filter.From), nullDate(filter.To), filter.Limit, filter.Offset)
+	if err != nil {
+		r.Logger.Debug("Не удалось найти расходы", "error", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	expenses := make([]*expense.Expense, 0)
+	var total int
+	for rows.Next() {
+		e := &expense.Expense{}
+		var authorID, accountID uuid.NullUUID
+		err := rows.Scan(&e.ID, &e.UserID, &e.CategoryID, &e.Ammount, &e.Date, &e.IsRecurring, &e.RecurrenceRule, &e.Description, &e.Currency, &authorID, &accountID, &total)
+		if err != nil {
+			r.Logger.Debug("Не удалось получить расход", "error", err)
+			return nil, 0, err
+		}
+		e.AuthorID = authorID.UUID
+		e.AccountID = accountID.UUID
+		expenses = append(expenses, e)
+	}
+	if rows.Err() != nil {
+		r.Logger.Debug("Ошибка перебора расходов", "error", rows.Err())
+		return nil, 0, rows.Err()
+	}
+	if err := r.loadSplits(ctx, expenses); err != nil {
+		r.Logger.Debug("Не удалось получить распределение расходов", "error", err)
+		return nil, 0, err
+	}
+	r.Logger.Debug("Расходы найдены", "count", len(expenses), "total", total, "duration", time.Since(now))
+	return expenses, total, nil
+}
+
+// saveSplits заменяет распределение траты e по категориям на e.Splits
+func saveSplits(ctx context.Context, db execer, e *expense.Expense) error {
+	if _, err := db.Exec(ctx, `DELETE FROM expense_splits WHERE expense_id = $1`, e.ID); err != nil {
+		return err
+	}
+	query := `INSERT INTO expense_splits (expense_id, category_id, amount) VALUES ($1, $2, $3)`
+	for _, split := range e.Splits {
+		if _, err := db.Exec(ctx, query, e.ID, split.CategoryID, split.Amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveReceipt сохраняет чек, по которому записана трата.
+// Возвращает expense.ErrDuplicateReceipt, если чек уже записан
+func saveReceipt(ctx context.Context, db execer, e *expense.Expense) error {
+	if e.Receipt == nil {
+		return nil
+	}
+	query := `INSERT INTO expense_receipts (expense_id, user_id, fn, fd, fp) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (user_id, fn, fd) DO NOTHING`
+	tag, err := db.Exec(ctx, query, e.ID, e.UserID, e.Receipt.FN, e.Receipt.FD, e.Receipt.FP)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return expense.ErrDuplicateReceipt
+	}
+	return nil
+}
+
+// ReceiptExists проверяет, записана ли у пользователя трата по чеку receipt
+func (r *Repository) ReceiptExists(ctx context.Context, userID uuid.UUID, receipt *expense.Receipt) (bool, error) {
+	r.Logger.Debug("Проверка чека в базе данных", "userID", userID, "fn", receipt.FN, "fd", receipt.FD)
+	query := `SELECT EXISTS (SELECT 1 FROM expense_receipts WHERE user_id = $1 AND fn = $2 AND fd = $3)`
+
+	now := time.Now()
+	var exists bool
+	if err := r.DB.QueryRow(ctx, query, userID, receipt.FN, receipt.FD).Scan(&exists); err != nil {
+		r.Logger.Debug("Не удалось проверить чек", "error", err)
+		return false, err
+	}
+	r.Logger.Debug("Чек проверен", "exists", exists, "duration", time.Since(now))
+	return exists, nil
+}
+
+// loadSplits заполняет распределение по категориям у трат expenses одним запросом
+func (r *Repository) loadSplits(ctx context.Context, expenses []*expense.Expense) error {
+	if len(expenses) == 0 {
+		return nil
+	}
+	byID := make(map[uuid.UUID]*expense.Expense, len(expenses))
+	ids := make([]uuid.UUID, 0, len(expenses))
+	for _, e := range expenses {
+		byID[e.ID] = e
+		ids = append(ids, e.ID)
+	}
+
+	query := `SELECT expense_id, category_id, amount FROM expense_splits WHERE expense_id = ANY($1) ORDER BY amount DESC, category_id`
+	rows, err := r.DB.Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var expenseID uuid.UUID
+		split := &expense.Split{}
+		if err := rows.Scan(&expenseID, &split.CategoryID, &split.Amount); err != nil {
+			return err
+		}
+		if e, ok := byID[expenseID]; ok {
+			e.Splits = append(e.Splits, split)
+		}
+	}
+	return rows.Err()
+}
+
+// nullUUID возвращает NULL для uuid.Nil
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
+
+// applyBalance изменяет балансы счетов в транзакции записи расхода
+func applyBalance(ctx context.Context, db execer, balance []expense.BalanceChange) error {
+	for _, b := range balance {
+		if err := adjustBalance(ctx, db, b.AccountID, b.Delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/goal"
+	"github.com/google/uuid"
+)
+
+// GoalCreate создает новую цель накоплений
+func (r *Repository) GoalCreate(ctx context.Context, g *goal.Goal) error {
+	r.Logger.Debug("Создание цели", "goal", g)
+	query := `INSERT INTO goals (id, user_id, name, target, saved, currency, deadline, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	now := time.Now()
+	_, err := r.DB.Exec(ctx, query, g.ID, g.UserID, g.Name, g.Target, g.Saved, g.Currency, g.Deadline, g.CreatedAt, g.UpdatedAt)
+	if err != nil {
+		r.Logger.Debug("Не удалось создать цель", "error", err)
+		return err
+	}
+	r.Logger.Debug("Цель создана", "goal", g, "duration", time.Since(now))
+	return nil
+}
+
+// GoalsGetByUser возвращает цели пользователя, ближайшие по сроку - первыми
+func (r *Repository) GoalsGetByUser(ctx context.Context, userID uuid.UUID) ([]*goal.Goal, error) {
+	r.Logger.Debug("Получение целей пользователя", "userID", userID)
+	query := `SELECT id, user_id, name, target, saved, currency, deadline, created_at, updated_at
+		FROM goals
+		WHERE user_id = $1
+		ORDER BY deadline ASC, created_at ASC`
+
+	now := time.Now()
+	rows, err := r.DB.Query(ctx, query, userID)
+	if err != nil {
+		r.Logger.Debug("Не удалось получить цели", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	goals := make([]*goal.Goal, 0)
+	for rows.Next() {
+		g := &goal.Goal{}
+		if err := rows.Scan(&g.ID, &g.UserID, &g.Name, &g.Target, &g.Saved, &g.Currency, &g.Deadline, &g.CreatedAt, &g.UpdatedAt); err != nil {
+			r.Logger.Debug("Не удалось получить цель", "error", err)
+			return nil, err
+		}
+		goals = append(goals, g)
+	}
+	if rows.Err() != nil {
+		r.Logger.Debug("Ошибка перебора целей", "error", rows.Err())
+		return nil, rows.Err()
+	}
+	r.Logger.Debug("Цели получены", "count", len(goals), "duration", time.Since(now))
+	return goals, nil
+}
+
+// GoalDelete удаляет цель вместе со взносами
+// возвращает goal.ErrGoalNotFound, если цели нет
+func (r *Repository) GoalDelete(ctx context.Context, id uuid.UUID) error {
+	r.Logger.Debug("Удаление цели", "id", id)
+	query := `DELETE FROM goals WHERE id = $1`
+
+	now := time.Now()
+	tag, err := r.DB.Exec(ctx, query, id)
+	if err != nil {
+		r.Logger.Debug("Не удалось удалить цель", "error", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return goal.ErrGoalNotFound
+	}
+	r.Logger.Debug("Цель удалена", "id", id, "duration", time.Since(now))
+	return nil
+}
+
+// GoalContribute записывает взнос и увеличивает накопленную сумму цели в одной транзакции
+// возвращает goal.ErrGoalNotFound, если цели нет
+func (r *Repository) GoalContribute(ctx context.Context, c *goal.Contribution) error {
+	r.Logger.Debug("Взнос в цель", "contribution", c)
+	insert := `INSERT INTO goal_contributions (id, goal_id, author_id, amount, date, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	update := `UPDATE goals SET saved = saved + $2, updated_at = NOW() WHERE id = $1`
+
+	now := time.Now()
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		r.Logger.Debug("Не удалось начать транзакцию", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, update, c.GoalID, c.Amount)
+	if err != nil {
+		r.Logger.Debug("Не удалось обновить цель", "error", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return goal.ErrGoalNotFound
+	}
+	if _, err := tx.Exec(ctx, insert, c.ID, c.GoalID, nullUUID(c.AuthorID), c.Amount, c.Date, c.CreatedAt); err != nil {
+		r.Logger.Debug("Не удалось записать взнос", "error", err)
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.Logger.Debug("Не удалось зафиксировать транзакцию", "error", err)
+		return err
+	}
+	r.Logger.Debug("Взнос записан", "contribution", c, "duration", time.Since(now))
+	return nil
+}
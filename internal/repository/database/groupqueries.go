@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/group"
+	"github.com/google/uuid"
+)
+
+// MemberAdd добавляет участника в группу
+// если участник уже есть в группе, ничего не меняет
+func (r *Repository) MemberAdd(ctx context.Context, member *group.Member) error {
+	r.Logger.Debug("Добавление участника группы", "groupID", member.GroupID, "userID", member.UserID, "role", member.Role)
+	query := `INSERT INTO group_members (group_id, user_id, role, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (group_id, user_id) DO NOTHING`
+
+	now := time.Now()
+	_, err := r.DB.Exec(ctx, query, member.GroupID, member.UserID, member.Role, member.CreatedAt)
+	if err != nil {
+		r.Logger.Debug("Не удалось добавить участника группы", "error", err)
+		return err
+	}
+	r.Logger.Debug("Участник группы добавлен", "groupID", member.GroupID, "userID", member.UserID, "duration", time.Since(now))
+	return nil
+}
+
+// MemberGet возвращает участника группы
+// возвращает group.ErrMemberNotFound, если пользователь не состоит в группе
+func (r *Repository) MemberGet(ctx context.Context, groupID, userID uuid.UUID) (*group.Member, error) {
+	r.Logger.Debug("Получение участника группы", "groupID", groupID, "userID", userID)
+	query := `SELECT m.group_id, m.user_id, m.role, m.created_at, u.user_name, u.first_name
+		FROM group_members m JOIN users u ON u.id = m.user_id
+		WHERE m.group_id = $1 AND m.user_id = $2`
+
+	now := time.Now()
+	m := &group.Member{}
+	err := r.DB.QueryRow(ctx, query, groupID, userID).Scan(&m.GroupID, &m.UserID, &m.Role, &m.CreatedAt, &m.UserName, &m.FirstName)
+	if err != nil {
+		r.Logger.Debug("Не удалось получить участника группы", "error", err)
+		if err.Error() == "no rows in result set" {
+			return nil, group.ErrMemberNotFound
+		}
+		return nil, err
+	}
+	r.Logger.Debug("Участник группы получен", "member", m, "duration", time.Since(now))
+	return m, nil
+}
+
+// MembersGetByGroup возвращает участников группы в порядке вступления
+func (r *Repository) MembersGetByGroup(ctx context.Context, groupID uuid.UUID) ([]*group.Member, error) {
+	r.Logger.Debug("Получение участников группы", "groupID", groupID)
+	query := `SELECT m.group_id, m.user_id, m.role, m.created_at, u.user_name, u.first_name
+		FROM group_members m JOIN users u ON u.id = m.user_id
+		WHERE m.group_id = $1
+		ORDER BY m.created_at ASC`
+
+	now := time.Now()
+	rows, err := r.DB.Query(ctx, query, groupID)
+	if err != nil {
+		r.Logger.Debug("Не удалось получить участников группы", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]*group.Member, 0)
+	for rows.Next() {
+		m := &group.Member{}
+		if err := rows.Scan(&m.GroupID, &m.UserID, &m.Role, &m.CreatedAt, &m.UserName, &m.FirstName); err != nil {
+			r.Logger.Debug("Не удалось получить участника группы", "error", err)
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	if rows.Err() != nil {
+		r.Logger.Debug("Ошибка перебора участников группы", "error", rows.Err())
+		return nil, rows.Err()
+	}
+	r.Logger.Debug("Участники группы получены", "count", len(members), "duration", time.Since(now))
+	return members, nil
+}
+
+// MemberUpdateRole обновляет роль участника группы
+func (r *Repository) MemberUpdateRole(ctx context.Context, member *group.Member) error {
+	r.Logger.Debug("Обновление роли участника группы", "groupID", member.GroupID, "userID", member.UserID, "role", member.Role)
+	query := `UPDATE group_members SET role = $1 WHERE group_id = $2 AND user_id = $3`
+
+	now := time.Now()
+	tag, err := r.DB.Exec(ctx, query, member.Role, member.GroupID, member.UserID)
+	if err != nil {
+		r.Logger.Debug("Не удалось обновить роль участника группы", "error", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return group.ErrMemberNotFound
+	}
+	r.Logger.Debug("Роль участника группы обновлена", "groupID", member.GroupID, "userID", member.UserID, "duration", time.Since(now))
+	return nil
+}
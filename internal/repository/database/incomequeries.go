@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/income"
+	"github.com/google/uuid"
+)
+
+// IncomeCreate записывает новый доход
+func (r *Repository) IncomeCreate(ctx context.Context, income *income.Income) error {
+	r.Logger.Debug("Запись нового дохода в базу данных", "income", income)
+	query := `INSERT INTO incomes (id, user_id, author_id, amount, currency, date, source, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	now := time.Now()
+	_, err := r.DB.Exec(ctx, query, income.ID, income.UserID, nullUUID(income.AuthorID), income.Amount, income.Currency, income.Date, income.Source, income.CreatedAt, income.UpdatedAt)
+	if err != nil {
+		r.Logger.Debug("Не удалось создать доход", "error", err)
+		return err
+	}
+	r.Logger.Debug("Доход успешно создан", "income", income, "duration", time.Since(now))
+	return nil
+}
+
+// IncomeDelete удаляет доход по ID
+// возвращает income.ErrIncomeNotFound, если дохода нет
+func (r *Repository) IncomeDelete(ctx context.Context, id uuid.UUID) error {
+	r.Logger.Debug("Удаление дохода", "id", id)
+	query := `DELETE FROM incomes WHERE id = $1`
+
+	now := time.Now()
+	tag, err := r.DB.Exec(ctx, query, id)
+	if err != nil {
+		r.Logger.Debug("Не удалось удалить доход", "error", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return income.ErrIncomeNotFound
+	}
+	r.Logger.Debug("Доход удален", "id", id, "duration", time.Since(now))
+	return nil
+}
+
+// IncomeGetByDate возвращает доходы пользователя в диапазоне дат
+func (r *Repository) IncomeGetByDate(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) ([]*income.Income, error) {
+	r.Logger.Debug("Получение доходов по дате", "userID", userID, "startDate", startDate, "endDate", endDate)
+	query := `SELECT id, user_id, author_id, amount, currency, date, source, created_at, updated_at
+		FROM incomes
+		WHERE user_id = $1 AND date >= $2 AND date <= $3
+		ORDER BY date ASC`
+
+	now := time.Now()
+	rows, err := r.DB.Query(ctx, query, userID, startDate, endDate)
+	if err != nil {
+		r.Logger.Debug("Не удалось получить доходы", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	incomes := make([]*income.Income, 0)
+	for rows.Next() {
+		i := &income.Income{}
+		var authorID uuid.NullUUID
+		if err := rows.Scan(&i.ID, &i.UserID, &authorID, &i.Amount, &i.Currency, &i.Date, &i.Source, &i.CreatedAt, &i.UpdatedAt); err != nil {
+			r.Logger.Debug("Не удалось получить доход", "error", err)
+			return nil, err
+		}
+		i.AuthorID = authorID.UUID
+		incomes = append(incomes, i)
+	}
+	if rows.Err() != nil {
+		r.Logger.Debug("Ошибка перебора доходов", "error", rows.Err())
+		return nil, rows.Err()
+	}
+	r.Logger.Debug("Доходы получены", "count", len(incomes), "duration", time.Since(now))
+	return incomes, nil
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/notification"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const notificationColumns = `n.user_id, n.daily_reminder, n.weekly_digest, n.monthly_report,
+	n.reminder_sent_on, n.digest_sent_on, n.report_sent_on, n.budget_thresholds, n.updated_at, u.telegram_id, u.timezone`
+
+// NotificationGet возвращает настройки уведомлений пользователя
+// возвращает nil, если настройки не сохранялись
+func (r *Repository) NotificationGet(ctx context.Context, userID uuid.UUID) (*notification.Settings, error) {
+	r.Logger.Debug("Получение настроек уведомлений", "userID", userID)
+	query := `SELECT ` + notificationColumns + `
+		FROM notification_settings n JOIN users u ON u.id = n.user_id
+		WHERE n.user_id = $1`
+
+	now := time.Now()
+	s, err := scanNotification(r.DB.QueryRow(ctx, query, userID))
+	if err != nil {
+		r.Logger.Debug("Не удалось получить настройки уведомлений", "error", err)
+		if err.Error() == "no rows in result set" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	r.Logger.Debug("Настройки уведомлений получены", "settings", s, "duration", time.Since(now))
+	return s, nil
+}
+
+// NotificationSave сохраняет настройки уведомлений пользователя
+func (r *Repository) NotificationSave(ctx context.Context, s *notification.Settings) error {
+	r.Logger.Debug("Сохранение настроек уведомлений", "settings", s)
+	query := `INSERT INTO notification_settings (user_id, daily_reminder, weekly_digest, monthly_report, reminder_sent_on, digest_sent_on, report_sent_on, budget_thresholds, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id) DO UPDATE SET
+			daily_reminder = EXCLUDED.daily_reminder,
+			weekly_digest = EXCLUDED.weekly_digest,
+			monthly_report = EXCLUDED.monthly_report,
+			reminder_sent_on = EXCLUDED.reminder_sent_on,
+			digest_sent_on = EXCLUDED.digest_sent_on,
+			report_sent_on = EXCLUDED.report_sent_on,
+			budget_thresholds = EXCLUDED.budget_thresholds,
+			updated_at = EXCLUDED.updated_at`
+
+	now := time.Now()
+	_, err := r.DB.Exec(ctx, query, s.UserID, s.DailyReminder, s.WeeklyDigest, s.MonthlyReport,
+		nullDate(s.ReminderSent), nullDate(s.DigestSent), nullDate(s.ReportSent), s.Thresholds, s.UpdatedAt)
+	if err != nil {
+		r.Logger.Debug("Не удалось сохранить настройки уведомлений", "error", err)
+		return err
+	}
+	r.Logger.Debug("Настройки уведомлений сохранены", "userID", s.UserID, "duration", time.Since(now))
+	return nil
+}
+
+// NotificationsGetEnabled возвращает настройки пользователей, у которых включено хотя бы одно уведомление
+func (r *Repository) NotificationsGetEnabled(ctx context.Context) ([]*notification.Settings, error) {
+	r.Logger.Debug("Получение включенных уведомлений")
+	query := `SELECT ` + notificationColumns + `
+		FROM notification_settings n JOIN users u ON u.id = n.user_id
+		WHERE n.daily_reminder OR n.weekly_digest OR n.monthly_report`
+
+	now := time.Now()
+	rows, err := r.DB.Query(ctx, query)
+	if err != nil {
+		r.Logger.Debug("Не удалось получить настройки уведомлений", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*notification.Settings, 0)
+	for rows.Next() {
+		s, err := scanNotification(rows)
+		if err != nil {
+			r.Logger.Debug("Не удалось получить настройки уведомлений", "error", err)
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	if rows.Err() != nil {
+		r.Logger.Debug("Ошибка перебора настроек уведомлений", "error", rows.Err())
+		return nil, rows.Err()
+	}
+	r.Logger.Debug("Включенные уведомления получены", "count", len(list), "duration", time.Since(now))
+	return list, nil
+}
+
+// scanNotification читает настройки уведомлений из строки с колонками notificationColumns
+func scanNotification(row pgx.Row) (*notification.Settings, error) {
+	s := &notification.Settings{}
+	var reminderSent, digestSent, reportSent *time.Time
+	err := row.Scan(&s.UserID, &s.DailyReminder, &s.WeeklyDigest, &s.MonthlyReport,
+		&reminderSent, &digestSent, &reportSent, &s.Thresholds, &s.UpdatedAt, &s.TelegramID, &s.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	if reminderSent != nil {
+		s.ReminderSent = *reminderSent
+	}
+	if digestSent != nil {
+		s.DigestSent = *digestSent
+	}
+	if reportSent != nil {
+		s.ReportSent = *reportSent
+	}
+	return s, nil
+}
+
+// nullDate возвращает NULL для нулевой даты
+func nullDate(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
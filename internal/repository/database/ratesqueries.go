@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeRate возвращает курс from -> to на дату date по таблице exchange_rates
+// возвращает currency.ErrRateNotFound, если курса на дату или раньше нет
+func (r *Repository) ExchangeRate(ctx context.Context, from, to string, date time.Time) (decimal.Decimal, error) {
+	r.Logger.Debug("Получение курса валют из базы данных", "from", from, "to", to, "date", date)
+	// Последний курс каждой из валют по каждой базовой валюте
+	query := `SELECT DISTINCT ON (base, currency) base, currency, rate FROM exchange_rates
+		WHERE currency IN ($1, $2) AND date <= $3
+		ORDER BY base, currency, date DESC`
+
+	now := time.Now()
+	rows, err := r.DB.Query(ctx, query, from, to, date)
+	if err != nil {
+		r.Logger.Debug("Не удалось получить курс валют", "error", err)
+		return decimal.Zero, err
+	}
+	defer rows.Close()
+
+	tables := make(map[string]*currency.Rates)
+	for rows.Next() {
+		var base, code string
+		var rate decimal.Decimal
+		if err := rows.Scan(&base, &code, &rate); err != nil {
+			r.Logger.Debug("Не удалось получить курс валют", "error", err)
+			return decimal.Zero, err
+		}
+		if tables[base] == nil {
+			tables[base] = &currency.Rates{Base: base, Values: make(map[string]decimal.Decimal)}
+		}
+		tables[base].Values[code] = rate
+	}
+	if rows.Err() != nil {
+		r.Logger.Debug("Ошибка перебора курсов валют", "error", rows.Err())
+		return decimal.Zero, rows.Err()
+	}
+
+	for _, t := range tables {
+		if rate, err := t.Rate(from, to); err == nil {
+			r.Logger.Debug("Курс валют получен", "from", from, "to", to, "rate", rate, "duration", time.Since(now))
+			return rate, nil
+		}
+	}
+	r.Logger.Debug("Курс валют не найден", "from", from, "to", to, "date", date, "duration", time.Since(now))
+	return decimal.Zero, currency.ErrRateNotFound
+}
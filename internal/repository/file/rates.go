@@ -0,0 +1,87 @@
+// Package file содержит репозитории, читающие данные из локальных файлов
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/shopspring/decimal"
+)
+
+// ratesFile - формат файла курсов валют:
+//
+//	{
+//	  "base": "RUB",
+//	  "rates": [
+//	    {"date": "2025-01-01", "values": {"USD": 101.68, "EUR": 106.1}}
+//	  ]
+//	}
+type ratesFile struct {
+	Base  string `json:"base"`
+	Rates []struct {
+		Date   string                     `json:"date"`
+		Values map[string]decimal.Decimal `json:"values"`
+	} `json:"rates"`
+}
+
+// RatesRepository - курсы валют из JSON-файла, не требует сети
+type RatesRepository struct {
+	tables []*currency.Rates // Отсортированы по дате
+	logger *slog.Logger
+}
+
+// NewRatesRepository загружает курсы валют из файла path
+func NewRatesRepository(path string, logger *slog.Logger) (*RatesRepository, error) {
+	logger.Info("Загрузка курсов валют из файла...", "path", path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла курсов валют: %w", err)
+	}
+
+	var f ratesFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("ошибка разбора файла курсов валют: %w", err)
+	}
+	base, err := currency.Normalize(f.Base)
+	if err != nil {
+		return nil, fmt.Errorf("неверная базовая валюта в файле курсов: %w", err)
+	}
+
+	tables := make([]*currency.Rates, 0, len(f.Rates))
+	for _, r := range f.Rates {
+		date, err := time.Parse(time.DateOnly, r.Date)
+		if err != nil {
+			return nil, fmt.Errorf("неверная дата курсов %q: %w", r.Date, err)
+		}
+		tables = append(tables, &currency.Rates{Base: base, Date: date, Values: r.Values})
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].Date.Before(tables[j].Date)
+	})
+
+	logger.Info("Курсы валют загружены", "base", base, "dates", len(tables))
+	return &RatesRepository{tables: tables, logger: logger}, nil
+}
+
+// ExchangeRate возвращает курс from -> to по последней таблице курсов не позже date
+func (r *RatesRepository) ExchangeRate(ctx context.Context, from, to string, date time.Time) (decimal.Decimal, error) {
+	r.logger.Debug("Получение курса валют из файла", "from", from, "to", to, "date", date)
+	for i := len(r.tables) - 1; i >= 0; i-- {
+		if r.tables[i].Date.After(date) {
+			continue
+		}
+		rate, err := r.tables[i].Rate(from, to)
+		if err != nil {
+			// В более старой таблице валюта может быть
+			continue
+		}
+		return rate, nil
+	}
+	return decimal.Zero, currency.ErrRateNotFound
+}
@@ -105,3 +105,103 @@ func (r *MemoryRepository) Delete(ctx context.Context, telegramID string) error
 	r.logger.Debug("Статус пользователя удален", "key", key, "Duration", time.Since(now))
 	return nil
 }
+
+// SetImportSession сохраняет загруженную выписку до подтверждения импорта
+//
+// Сессия хранится 1 час
+func (r *MemoryRepository) SetImportSession(ctx context.Context, ChatID string, session *status.ImportSession) error {
+	key := "import:" + ChatID
+	r.logger.Debug("Сохранение выписки в Redis", "TelegramID", ChatID, "file", session.FileName, "size", len(session.Data))
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		r.logger.Error("Ошибка сериализации данных", "error", err)
+		return err
+	}
+
+	now := time.Now()
+	err = r.rdb.Set(ctx, key, data, time.Hour*1).Err()
+	if err != nil {
+		r.logger.Error("Ошибка записи в Redis", "error", err, "Duration", time.Since(now))
+		return err
+	}
+
+	r.logger.Debug("Данные сохранены", "key", key, "Duration", time.Since(now))
+	return nil
+}
+
+// GetImportSession получает загруженную выписку
+//
+// Возвращает nil, если выписки нет или срок ее хранения истек
+func (r *MemoryRepository) GetImportSession(ctx context.Context, ChatID string) (*status.ImportSession, error) {
+	key := "import:" + ChatID
+	r.logger.Debug("Получение выписки из Redis", "key", key)
+	now := time.Now()
+	data, err := r.rdb.Get(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			r.logger.Debug("Данные не найдены", "key", key)
+			return nil, nil
+		}
+		r.logger.Error("Ошибка чтения из Redis", "error", err)
+		return nil, err
+	}
+
+	var session status.ImportSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		r.logger.Error("Ошибка десериализации", "error", err)
+		return nil, err
+	}
+	r.logger.Debug("Данные получены", "key", key, "Duration", time.Since(now))
+	return &session, nil
+}
+
+// DeleteImportSession удаляет загруженную выписку
+func (r *MemoryRepository) DeleteImportSession(ctx context.Context, ChatID string) error {
+	key := "import:" + ChatID
+	r.logger.Debug("Удаление выписки из Redis", "key", key)
+	now := time.Now()
+	err := r.rdb.Del(ctx, key).Err()
+	if err != nil {
+		r.logger.Error("Ошибка удаления выписки из Redis", "key", key, "error", err, "Duration", time.Since(now))
+		return err
+	}
+	r.logger.Debug("Выписка удалена", "key", key, "Duration", time.Since(now))
+	return nil
+}
+
+// SetSearchQuery сохраняет последний запрос поиска трат для перелистывания страниц
+//
+// Запрос хранится 1 час
+func (r *MemoryRepository) SetSearchQuery(ctx context.Context, ChatID string, query string) error {
+	key := "search:" + ChatID
+	r.logger.Debug("Сохранение запроса поиска в Redis", "key", key, "query", query)
+	now := time.Now()
+	err := r.rdb.Set(ctx, key, query, time.Hour*1).Err()
+	if err != nil {
+		r.logger.Error("Ошибка записи в Redis", "error", err, "Duration", time.Since(now))
+		return err
+	}
+	r.logger.Debug("Данные сохранены", "key", key, "Duration", time.Since(now))
+	return nil
+}
+
+// GetSearchQuery получает последний запрос поиска трат
+//
+// Возвращает пустую строку, если запроса нет или срок его хранения истек
+func (r *MemoryRepository) GetSearchQuery(ctx context.Context, ChatID string) (string, error) {
+	key := "search:" + ChatID
+	r.logger.Debug("Получение запроса поиска из Redis", "key", key)
+	now := time.Now()
+	query, err := r.rdb.Get(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			r.logger.Debug("Данные не найдены", "key", key)
+			return "", nil
+		}
+		r.logger.Error("Ошибка чтения из Redis", "error", err)
+		return "", err
+	}
+	r.logger.Debug("Данные получены", "key", key, "Duration", time.Since(now))
+	return query, nil
+}
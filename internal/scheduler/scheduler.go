@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Job задача планировщика
+type Job func(ctx context.Context) error
+
+type task struct {
+	name     string
+	interval time.Duration
+	job      Job
+}
+
+// Scheduler периодически запускает зарегистрированные задачи
+type Scheduler struct {
+	tasks  []task
+	logger *slog.Logger
+	wg     sync.WaitGroup
+}
+
+// New создает новый планировщик
+//
+// logger - логгер
+func New(logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		logger: logger,
+	}
+}
+
+// Add регистрирует задачу
+//
+// name - имя задачи для логов
+// interval - интервал между запусками
+// job - задача
+func (s *Scheduler) Add(name string, interval time.Duration, job Job) {
+	s.tasks = append(s.tasks, task{name: name, interval: interval, job: job})
+}
+
+// Start запускает все задачи в отдельных горутинах и сразу возвращает управление.
+//
+// Каждая задача выполняется сразу при старте, а затем с заданным интервалом,
+// пока не будет отменен ctx
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, t := range s.tasks {
+		s.wg.Add(1)
+		go s.run(ctx, t)
+	}
+	s.logger.Info("Планировщик запущен", "tasks", len(s.tasks))
+}
+
+// Wait ожидает завершения всех задач после отмены контекста
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) run(ctx context.Context, t task) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		s.exec(ctx, t)
+		select {
+		case <-ctx.Done():
+			s.logger.Debug("Задача остановлена", "task", t.name)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// exec выполняет задачу один раз, перехватывая панику
+func (s *Scheduler) exec(ctx context.Context, t task) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("Паника в задаче планировщика", "task", t.name, "panic", r)
+		}
+	}()
+
+	now := time.Now()
+	if err := t.job(ctx); err != nil {
+		s.logger.Error("Ошибка выполнения задачи", "task", t.name, "error", err, "duration", time.Since(now))
+		return
+	}
+	s.logger.Debug("Задача выполнена", "task", t.name, "duration", time.Since(now))
+}
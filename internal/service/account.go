@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/account"
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// CreateAccount создает счет пользователя
+//
+// accountType - тип счета из команды: "карта", "наличные", "вклад"...
+// balance - начальный баланс с необязательной валютой: "15000", "300 USD", пустой - 0 в валюте бюджета
+func (s *Service) CreateAccount(ctx context.Context, telegramID int64, accountType, name, balance string) (*account.Account, error) {
+	t, err := account.ParseType(accountType)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	amount, code := currency.SplitAmount(balance)
+	balanceDec := decimal.Zero
+	if amount != "" {
+		if balanceDec, err = decimal.NewFromString(amount); err != nil {
+			return nil, err
+		}
+	}
+
+	a, err := account.New(u.ID, name, t, balanceDec)
+	if err != nil {
+		return nil, err
+	}
+	if code == "" {
+		if code, err = s.userCurrency(ctx, u.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err := a.SetCurrency(code); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.findAccount(ctx, u.ID, a.Name); err == nil {
+		return nil, account.ErrDuplicateName
+	}
+
+	if err := s.aR.AccountCreate(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetAccounts возвращает счета пользователя с текущими балансами
+func (s *Service) GetAccounts(ctx context.Context, telegramID int64) ([]*account.Account, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+	return s.aR.AccountsGetByUser(ctx, u.ID)
+}
+
+// GetAccount возвращает счет пользователя по ID
+//
+// Если счет принадлежит другому пользователю, возвращает account.ErrAccountNotFound
+func (s *Service) GetAccount(ctx context.Context, telegramID int64, id string) (*account.Account, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+	return s.ownAccount(ctx, u.ID, id)
+}
+
+// TransferBetweenAccounts переводит amount в валюте счета from на счет to.
+//
+// Счета ищутся по названию без учета регистра. Для счетов в разных валютах
+// сумма зачисления считается по курсу на сегодня. Перевод не считается тратой
+func (s *Service) TransferBetweenAccounts(ctx context.Context, telegramID int64, amount decimal.Decimal, from, to string) (*TransferDTO, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	fromAccount, err := s.findAccount(ctx, u.ID, from)
+	if err != nil {
+		return nil, err
+	}
+	toAccount, err := s.findAccount(ctx, u.ID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	amountDec := currency.Round(amount, fromAccount.Currency)
+	toAmount, err := currency.Convert(ctx, s.rP, amountDec, fromAccount.Currency, toAccount.Currency, now)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := account.NewTransfer(fromAccount, toAccount, amountDec, toAmount, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.aR.TransferCreate(ctx, t); err != nil {
+		return nil, err
+	}
+
+	return &TransferDTO{
+		From:         fromAccount.Name,
+		To:           toAccount.Name,
+		Amount:       t.Amount,
+		FromCurrency: fromAccount.Currency,
+		ToAmount:     t.ToAmount,
+		ToCurrency:   toAccount.Currency,
+	}, nil
+}
+
+// findAccount ищет счет пользователя по названию без учета регистра
+func (s *Service) findAccount(ctx context.Context, userID uuid.UUID, name string) (*account.Account, error) {
+	accounts, err := s.aR.AccountsGetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	for _, a := range accounts {
+		if strings.EqualFold(a.Name, name) {
+			return a, nil
+		}
+	}
+	return nil, account.ErrAccountNotFound
+}
+
+// ownAccount возвращает счет пользователя userID по ID
+func (s *Service) ownAccount(ctx context.Context, userID uuid.UUID, id string) (*account.Account, error) {
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, account.ErrAccountNotFound
+	}
+	a, err := s.aR.AccountGet(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if a.UserID != userID {
+		return nil, account.ErrAccountNotFound
+	}
+	return a, nil
+}
+
+// accountCharge возвращает списание траты со счета, с которого она оплачена,
+// для записи в одной транзакции с тратой. Пустой результат - счет не указан.
+//
+// refund - вернуть сумму траты на счет (при удалении или изменении траты).
+// Сумма переводится в валюту счета по курсу на дату траты
+func (s *Service) accountCharge(ctx context.Context, e *expense.Expense, refund bool) ([]expense.BalanceChange, error) {
+	if e.AccountID == uuid.Nil {
+		return nil, nil
+	}
+	a, err := s.aR.AccountGet(ctx, e.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := currency.Convert(ctx, s.rP, e.Ammount, e.Currency, a.Currency, e.Date)
+	if err != nil {
+		return nil, err
+	}
+	amount = currency.Round(amount, a.Currency)
+	if !refund {
+		amount = amount.Neg()
+	}
+	return []expense.BalanceChange{{AccountID: a.ID, Delta: amount}}, nil
+}
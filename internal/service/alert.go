@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/budget"
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ErrBudgetAlerts - трата записана, но проверить пороги бюджета не удалось
+var ErrBudgetAlerts = errors.New("budget alerts check failed")
+
+// BudgetAlertDTO - предупреждение о пересечении порога трат
+type BudgetAlertDTO struct {
+	Category     string          // Категория, пустая - бюджет целиком
+	CategoryIcon string          // Иконка категории
+	Threshold    int             // Порог в процентах, budget.ThresholdOver - перерасход
+	Spent        decimal.Decimal // Потрачено с начала месяца
+	Limit        decimal.Decimal // Бюджет или лимит категории
+	Currency     string          // Валюта бюджета
+}
+
+// GetBudgetThresholds возвращает пороги предупреждений пользователя
+func (s *Service) GetBudgetThresholds(ctx context.Context, telegramID int64) ([]int, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+	return s.budgetThresholds(ctx, u.ID)
+}
+
+// SetBudgetThresholds устанавливает пороги предупреждений из строки вида "50 80 100"
+//
+// Перерасход отслеживается всегда и в пороги не входит
+func (s *Service) SetBudgetThresholds(ctx context.Context, telegramID int64, text string) ([]int, error) {
+	thresholds, err := budget.ParseThresholds(text)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+	settings, err := s.notificationSettings(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	settings.Thresholds = thresholds
+	if err := s.nR.NotificationSave(ctx, settings); err != nil {
+		return nil, err
+	}
+	return thresholds, nil
+}
+
+// checkBudgetAlerts сравнивает траты с начала месяца с бюджетом и лимитами категорий траты e.
+// Распределенная трата проверяется по лимиту каждой своей категории.
+//
+// Каждый пересеченный порог отмечается один раз за месяц. Если трата пересекла
+// сразу несколько порогов, возвращается предупреждение только о самом высоком
+func (s *Service) checkBudgetAlerts(ctx context.Context, e *expense.Expense) ([]*BudgetAlertDTO, error) {
+	b, err := s.currentBudget(ctx, e.UserID)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	// Трата за другой месяц не влияет на текущий бюджет
+	if e.Date.Before(b.StartDate) || e.Date.After(b.EndDate) {
+		return nil, nil
+	}
+
+	thresholds, err := s.budgetThresholds(ctx, e.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	expenses, err := s.eR.GetExpensesByDate(ctx, e.UserID, b.StartDate, b.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	dtos, err := s.expensesToDTO(ctx, expenses)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.convertExpenses(ctx, dtos, b.Currency); err != nil {
+		return nil, err
+	}
+
+	total := decimal.Zero
+	categorySpent := make(map[string]decimal.Decimal)
+	names := make(map[string]*ExpenseDTO)
+	for _, d := range allocations(dtos) {
+		total = total.Add(d.BaseAmount)
+		categorySpent[d.CategoryID] = categorySpent[d.CategoryID].Add(d.BaseAmount)
+		names[d.CategoryID] = d
+	}
+
+	alerts := make([]*BudgetAlertDTO, 0, 2)
+	threshold, err := s.markThresholds(ctx, b.ID, uuid.Nil, total, b.Total(), thresholds)
+	if err != nil {
+		return nil, err
+	}
+	if threshold != 0 {
+		alerts = append(alerts, &BudgetAlertDTO{
+			Threshold: threshold,
+			Spent:     total,
+			Limit:     b.Total(),
+			Currency:  b.Currency,
+		})
+	}
+
+	for _, part := range e.Allocations() {
+		limit, ok := b.CategoryLimit(part.CategoryID)
+		if !ok {
+			continue
+		}
+		id := part.CategoryID.String()
+		threshold, err := s.markThresholds(ctx, b.ID, part.CategoryID, categorySpent[id], limit, thresholds)
+		if err != nil {
+			return nil, err
+		}
+		if threshold != 0 {
+			alert := &BudgetAlertDTO{
+				Threshold: threshold,
+				Spent:     categorySpent[id],
+				Limit:     limit,
+				Currency:  b.Currency,
+			}
+			if d, ok := names[id]; ok {
+				alert.Category, alert.CategoryIcon = d.Category, d.CategoryIcon
+			}
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts, nil
+}
+
+// markThresholds отмечает пересеченные пороги и возвращает самый высокий из впервые пересеченных.
+// Возвращает 0, если новых порогов нет
+func (s *Service) markThresholds(ctx context.Context, budgetID, categoryID uuid.UUID, spent, limit decimal.Decimal, thresholds []int) (int, error) {
+	highest := 0
+	for _, pct := range budget.CrossedThresholds(spent, limit, thresholds) {
+		created, err := s.bR.BudgetAlertAdd(ctx, budgetID, categoryID, pct)
+		if err != nil {
+			return 0, err
+		}
+		if created {
+			highest = pct
+		}
+	}
+	return highest, nil
+}
+
+// budgetThresholds возвращает пороги предупреждений пользователя или пороги по умолчанию
+func (s *Service) budgetThresholds(ctx context.Context, userID uuid.UUID) ([]int, error) {
+	settings, err := s.nR.NotificationGet(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil || len(settings.Thresholds) == 0 {
+		return budget.DefaultThresholds, nil
+	}
+	return settings.Thresholds, nil
+}
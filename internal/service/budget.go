@@ -2,15 +2,27 @@ package service
 
 import (
 	"context"
+	"errors"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/SobolevTim/finance_bot/internal/domain/budget"
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/SobolevTim/finance_bot/internal/pkg/clock"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 )
 
+// UpdateBudgetByTgID устанавливает сумму бюджета на текущий месяц
+//
+// amount - сумма с необязательной валютой: "50000", "1500 USD".
+// Если бюджета нет, он создается в указанной валюте или в валюте по умолчанию.
+// Ручная установка суммы отключает расчет бюджета по доходам
 func (s *Service) UpdateBudgetByTgID(ctx context.Context, tgID int64, amount string) (*budget.Budget, error) {
+	amount, code := currency.SplitAmount(amount)
+
 	// Преобразование строки в decimal
 	amountDec, err := decimal.NewFromString(amount)
 	if err != nil {
@@ -24,13 +36,20 @@ func (s *Service) UpdateBudgetByTgID(ctx context.Context, tgID int64, amount str
 
 	if budget != nil {
 		budget.Amount = amountDec
+		budget.FromIncome = false
+		if code != "" {
+			budget.Currency = code
+		}
 		err := s.bR.BudgetUpdate(ctx, budget)
 		if err != nil {
 			return nil, err
 		}
 		return budget, nil
 	} else {
-		budget, err := s.CreateBudget(ctx, tgID, amount, "RUB")
+		if code == "" {
+			code = s.currency
+		}
+		budget, err := s.CreateBudget(ctx, tgID, amount, code)
 		if err != nil {
 			return nil, err
 		}
@@ -57,8 +76,8 @@ func (s *Service) CreateBudget(
 		return nil, budget.ErrUserNotFound
 	}
 	userID := user.ID
-	startDate := time.Date(time.Now().Year(), time.Now().Month(), 1, 0, 0, 0, 0, time.Now().Location())
-	endDate := startDate.AddDate(0, 1, -1)
+	// Текущий месяц в часовом поясе пользователя
+	startDate, endDate := clock.Month(s.today(user))
 
 	budget, err := budget.New(userID, amountDec, currency, startDate, endDate)
 	if err != nil {
@@ -74,7 +93,7 @@ func (s *Service) CreateBudget(
 
 // GetCurrentBudget возвращает текущий активный бюджет пользователя
 func (s *Service) GetCurrentBudget(ctx context.Context, userID uuid.UUID) (*budget.Budget, error) {
-	return s.bR.BudgetGetCurrent(ctx, userID)
+	return s.currentBudget(ctx, userID)
 }
 
 // UpdateBudget обновляет бюджет пользователя
@@ -109,6 +128,101 @@ func (s *Service) UpdateBudget(
 }
 
 func (s *Service) GetBudgetByTgID(ctx context.Context, tgID int64) (*budget.Budget, error) {
-	telegramID := strconv.FormatInt(tgID, 10)
-	return s.bR.BudgetGetByTgID(ctx, telegramID)
+	u, err := s.GetUserByTelegramID(ctx, tgID)
+	if errors.Is(err, user.ErrUserNotFound) || (err == nil && u == nil) {
+		// Незарегистрированный пользователь - бюджета нет
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.userBudget(ctx, u)
+}
+
+// SetCategoryLimit устанавливает месячный лимит для категории в текущем бюджете
+//
+// Нулевой лимит удаляет ограничение для категории
+func (s *Service) SetCategoryLimit(ctx context.Context, tgID int64, category string, amount string) (*budget.Budget, error) {
+	// Преобразование строки в decimal
+	limit, err := decimal.NewFromString(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.GetBudgetByTgID(ctx, tgID)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, budget.ErrBudgetNotFound
+	}
+
+	c, err := s.cR.CategoriesGetByName(ctx, b.UserID, category)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit.IsZero() {
+		b.RemoveCategory(c.ID)
+		if err := s.bR.BudgetDeleteCategoryLimit(ctx, b.ID, c.ID); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+
+	if err := b.AddCategory(c.ID, limit); err != nil {
+		return nil, err
+	}
+	if err := s.bR.BudgetSetCategoryLimit(ctx, b.ID, c.ID, limit); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// GetCategoryLimits возвращает траты и лимиты по категориям текущего бюджета
+//
+// expenses - траты за период бюджета с суммами в валюте бюджета (BaseAmount)
+func (s *Service) GetCategoryLimits(ctx context.Context, b *budget.Budget, expenses []*ExpenseDTO) ([]*CategoryLimitDTO, error) {
+	if b == nil || len(b.Categories) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(b.Categories))
+	for id := range b.Categories {
+		ids = append(ids, id)
+	}
+
+	categories, err := s.cR.CategoriesGetBuIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Суммирование трат по категориям, распределенные траты - по частям
+	spent := make(map[uuid.UUID]decimal.Decimal)
+	for _, e := range allocations(expenses) {
+		id, err := uuid.Parse(e.CategoryID)
+		if err != nil {
+			continue
+		}
+		spent[id] = spent[id].Add(e.BaseAmount)
+	}
+
+	limits := make([]*CategoryLimitDTO, 0, len(categories))
+	for _, c := range categories {
+		limits = append(limits, &CategoryLimitDTO{
+			CategoryID:   c.ID.String(),
+			Category:     c.Name,
+			CategoryIcon: c.Icon,
+			Limit:        b.Categories[c.ID],
+			Spent:        spent[c.ID],
+			Err:          b.CheckCategoryLimit(c.ID, spent[c.ID]),
+		})
+	}
+
+	sort.Slice(limits, func(i, j int) bool {
+		return limits[i].Category < limits[j].Category
+	})
+
+	return limits, nil
 }
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/budget"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/SobolevTim/finance_bot/internal/pkg/clock"
+	"github.com/shopspring/decimal"
+)
+
+// maxPeriodsPerRun ограничивает число периодов, создаваемых за раз для одного пользователя,
+// если бот долго не запускался
+const maxPeriodsPerRun = 60
+
+// BudgetPeriodDTO - бюджет за период и траты по нему
+type BudgetPeriodDTO struct {
+	TelegramID int64           // ID чата пользователя
+	StartDate  time.Time       // Начало периода
+	EndDate    time.Time       // Конец периода
+	Amount     decimal.Decimal // Сумма бюджета по шаблону
+	CarryOver  decimal.Decimal // Перенесено из прошлого периода, отрицательное - перерасход
+	Total      decimal.Decimal // Сумма с учетом переноса
+	Spent      decimal.Decimal // Потрачено за период
+	Currency   string          // Валюта бюджета
+	FromIncome bool            // Бюджет рассчитывается по доходам
+	Current    bool            // Текущий период
+}
+
+// RollBudgets создает бюджеты новых периодов для пользователей, у которых закончился период бюджета.
+//
+// Новый бюджет создается по шаблону прошлого: сумма, валюта, период и лимиты категорий.
+// Если включен перенос, остаток или перерасход прошлого периода переходит в новый.
+// Возвращает начавшиеся текущие периоды для уведомления пользователей
+func (s *Service) RollBudgets(ctx context.Context, now time.Time) ([]*BudgetPeriodDTO, error) {
+	// Самый восточный часовой пояс опережает UTC меньше чем на сутки
+	list, err := s.bR.BudgetsGetEnded(ctx, clock.Date(now, time.UTC).AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	started := make([]*BudgetPeriodDTO, 0)
+	for _, last := range list {
+		u, err := s.uR.UserGetByID(ctx, last.UserID)
+		if err != nil {
+			return started, err
+		}
+		today := clock.Date(now, u.Location())
+		created, err := s.rollBudget(ctx, last, today)
+		if err != nil {
+			return started, err
+		}
+		if len(created) == 0 {
+			continue
+		}
+
+		current := created[len(created)-1]
+		dto := budgetPeriodToDTO(current)
+		dto.Current = true
+		if dto.TelegramID, err = strconv.ParseInt(u.TelegramID, 10, 64); err != nil {
+			return started, err
+		}
+		started = append(started, dto)
+	}
+	return started, nil
+}
+
+// GetBudgetHistory возвращает текущий и до limit-1 прошлых периодов бюджета с тратами, от новых к старым
+func (s *Service) GetBudgetHistory(ctx context.Context, telegramID int64, limit int) ([]*BudgetPeriodDTO, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+	// Создание текущего периода, если прошлый закончился
+	if _, err := s.userBudget(ctx, u); err != nil {
+		return nil, err
+	}
+
+	today := s.today(u)
+	list, err := s.bR.BudgetsGetHistory(ctx, u.ID, today, limit)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]*BudgetPeriodDTO, 0, len(list))
+	for _, b := range list {
+		spent, err := s.budgetSpent(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+		dto := budgetPeriodToDTO(b)
+		dto.Spent = spent
+		dto.Current = b.IsActive(today)
+		history = append(history, dto)
+	}
+	return history, nil
+}
+
+// SetBudgetPeriod меняет период текущего бюджета: "10" - месяц с 10 числа, "2 недели" - две недели.
+// Следующие периоды создаются с этими же настройками
+func (s *Service) SetBudgetPeriod(ctx context.Context, telegramID int64, text string) (*budget.Budget, error) {
+	period, startDay, err := budget.ParsePeriod(text)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+	b, err := s.userBudget(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, budget.ErrBudgetNotFound
+	}
+
+	if err := b.SetPeriod(period, startDay, s.today(u)); err != nil {
+		return nil, err
+	}
+	b.UpdatedAt = s.clock.Now()
+	if err := s.bR.BudgetUpdate(ctx, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// SetBudgetRollover включает или выключает перенос остатка текущего бюджета в следующий период
+func (s *Service) SetBudgetRollover(ctx context.Context, telegramID int64, enabled bool) (*budget.Budget, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+	b, err := s.userBudget(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, budget.ErrBudgetNotFound
+	}
+
+	b.Rollover = enabled
+	b.UpdatedAt = s.clock.Now()
+	if err := s.bR.BudgetUpdate(ctx, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// currentPeriod возвращает даты текущего периода бюджета пользователя u,
+// а если бюджета нет - текущего календарного месяца
+func (s *Service) currentPeriod(ctx context.Context, u *user.User) (time.Time, time.Time, error) {
+	b, err := s.userBudget(ctx, u)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if b != nil {
+		return b.StartDate, b.EndDate, nil
+	}
+	start, end := clock.Month(s.today(u))
+	return start, end, nil
+}
+
+// rollBudget создает по шаблону last бюджеты следующих периодов, пока они не дойдут до даты today.
+// Возвращает созданные бюджеты по порядку
+func (s *Service) rollBudget(ctx context.Context, last *budget.Budget, today time.Time) ([]*budget.Budget, error) {
+	created := make([]*budget.Budget, 0)
+	for i := 0; last.EndDate.Before(today) && i < maxPeriodsPerRun; i++ {
+		spent, err := s.budgetSpent(ctx, last)
+		if err != nil {
+			return created, err
+		}
+		next := last.Next(spent, s.clock.Now())
+		ok, err := s.bR.BudgetCreateNext(ctx, next)
+		if err != nil {
+			return created, err
+		}
+		if !ok {
+			// Период уже создан параллельно
+			break
+		}
+		created = append(created, next)
+		last = next
+	}
+	return created, nil
+}
+
+// budgetSpent возвращает траты за период бюджета b в его валюте
+func (s *Service) budgetSpent(ctx context.Context, b *budget.Budget) (decimal.Decimal, error) {
+	expenses, err := s.eR.GetExpensesByDate(ctx, b.UserID, b.StartDate, b.EndDate)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	dtos, err := s.expensesToDTO(ctx, expenses)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if err := s.convertExpenses(ctx, dtos, b.Currency); err != nil {
+		return decimal.Zero, err
+	}
+
+	total := decimal.Zero
+	for _, d := range dtos {
+		total = total.Add(d.BaseAmount)
+	}
+	return total, nil
+}
+
+func budgetPeriodToDTO(b *budget.Budget) *BudgetPeriodDTO {
+	return &BudgetPeriodDTO{
+		StartDate:  b.StartDate,
+		EndDate:    b.EndDate,
+		Amount:     b.Amount,
+		CarryOver:  b.CarryOver,
+		Total:      b.Total(),
+		Currency:   b.Currency,
+		FromIncome: b.FromIncome,
+	}
+}
@@ -2,10 +2,162 @@ package service
 
 import (
 	"context"
+	"strings"
 
 	"github.com/SobolevTim/finance_bot/internal/domain/categories"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/google/uuid"
 )
 
 func (s *Service) GetDefaultCategories(ctx context.Context) ([]*categories.Categories, error) {
 	return s.cR.CategoriesGetDefaults(ctx)
 }
+
+// GetUserCategories возвращает базовые категории и категории пользователя
+func (s *Service) GetUserCategories(ctx context.Context, tgID int64) ([]*categories.Categories, error) {
+	u, err := s.GetUserByTelegramID(ctx, tgID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, user.ErrUserNotFound
+	}
+	return s.getUserCategories(ctx, u.ID)
+}
+
+// CreateCategory создает пользовательскую категорию
+//
+// Название должно быть уникальным среди базовых и пользовательских категорий
+func (s *Service) CreateCategory(ctx context.Context, tgID int64, name, icon string) (*categories.Categories, error) {
+	u, err := s.GetUserByTelegramID(ctx, tgID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	if err := s.checkCategoryName(ctx, u.ID, uuid.Nil, name); err != nil {
+		return nil, err
+	}
+
+	c, err := categories.New(u.ID, name, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.SetIcon(icon); err != nil {
+		return nil, err
+	}
+
+	if err := s.cR.CategoriesCreate(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// RenameCategory переименовывает пользовательскую категорию
+func (s *Service) RenameCategory(ctx context.Context, tgID int64, name, newName string) (*categories.Categories, error) {
+	u, c, err := s.getOwnCategory(ctx, tgID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkCategoryName(ctx, u.ID, c.ID, newName); err != nil {
+		return nil, err
+	}
+	if err := c.Rename(newName); err != nil {
+		return nil, err
+	}
+
+	if err := s.cR.CategoriesUpdate(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// SetCategoryIcon меняет иконку пользовательской категории
+func (s *Service) SetCategoryIcon(ctx context.Context, tgID int64, name, icon string) (*categories.Categories, error) {
+	_, c, err := s.getOwnCategory(ctx, tgID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.SetIcon(icon); err != nil {
+		return nil, err
+	}
+
+	if err := s.cR.CategoriesUpdate(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// DeleteCategory удаляет пользовательскую категорию
+//
+// Базовые категории удалить нельзя (categories.ErrDeleteDefaultCategory),
+// как и категории, по которым уже есть расходы (categories.ErrCategoryInUse)
+func (s *Service) DeleteCategory(ctx context.Context, tgID int64, name string) error {
+	_, c, err := s.getOwnCategory(ctx, tgID, name)
+	if err != nil {
+		return err
+	}
+	if c.IsDefault {
+		return categories.ErrDeleteDefaultCategory
+	}
+
+	used, err := s.cR.CategoriesIsUsed(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+	if used {
+		return categories.ErrCategoryInUse
+	}
+
+	return s.cR.CategoriesDelete(ctx, c.ID)
+}
+
+// getUserCategories возвращает базовые категории и категории пользователя одним списком
+func (s *Service) getUserCategories(ctx context.Context, userID uuid.UUID) ([]*categories.Categories, error) {
+	defaults, err := s.cR.CategoriesGetDefaults(ctx)
+	if err != nil {
+		return nil, err
+	}
+	own, err := s.cR.CategoriesGetForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return append(defaults, own...), nil
+}
+
+// getOwnCategory возвращает пользователя и доступную ему категорию по названию
+func (s *Service) getOwnCategory(ctx context.Context, tgID int64, name string) (*user.User, *categories.Categories, error) {
+	u, err := s.GetUserByTelegramID(ctx, tgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u == nil {
+		return nil, nil, user.ErrUserNotFound
+	}
+
+	c, err := s.cR.CategoriesGetByName(ctx, u.ID, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, c, nil
+}
+
+// checkCategoryName проверяет, что название не занято другой категорией пользователя
+//
+// exceptID - ID категории, которую переименовывают
+func (s *Service) checkCategoryName(ctx context.Context, userID, exceptID uuid.UUID, name string) error {
+	list, err := s.getUserCategories(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, c := range list {
+		if c.ID != exceptID && strings.EqualFold(c.Name, name) {
+			return categories.ErrDuplicateName
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/budget"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/SobolevTim/finance_bot/internal/pkg/clock"
+	"github.com/google/uuid"
+)
+
+// SetClock заменяет источник текущего времени, например на clock.Fixed в тестах
+func (s *Service) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// Now возвращает текущее время
+func (s *Service) Now() time.Time {
+	return s.clock.Now()
+}
+
+// Today возвращает сегодняшнюю дату в часовом поясе пользователя
+func (s *Service) Today(ctx context.Context, telegramID int64) (time.Time, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return time.Time{}, user.ErrUserNotFound
+	}
+	return s.today(u), nil
+}
+
+// GetTimezone возвращает часовой пояс пользователя
+func (s *Service) GetTimezone(ctx context.Context, telegramID int64) (string, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return "", user.ErrUserNotFound
+	}
+	return u.Timezone, nil
+}
+
+// SetTimezone меняет часовой пояс пользователя: "UTC+10", "UTC-3:30" или "Asia/Vladivostok".
+// Возвращает часовой пояс в сохраненном виде
+func (s *Service) SetTimezone(ctx context.Context, telegramID int64, tz string) (string, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return "", user.ErrUserNotFound
+	}
+	if err := u.UpdateTimezone(tz); err != nil {
+		return "", err
+	}
+	if err := s.uR.UserUpdate(ctx, u); err != nil {
+		return "", err
+	}
+	return u.Timezone, nil
+}
+
+// today возвращает сегодняшнюю дату в часовом поясе пользователя u
+func (s *Service) today(u *user.User) time.Time {
+	return clock.Date(s.clock.Now(), u.Location())
+}
+
+// currentBudget возвращает бюджет пользователя на сегодняшнюю дату его часового пояса
+func (s *Service) currentBudget(ctx context.Context, userID uuid.UUID) (*budget.Budget, error) {
+	u, err := s.uR.UserGetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, user.ErrUserNotFound
+	}
+	return s.userBudget(ctx, u)
+}
+
+// userBudget возвращает бюджет пользователя u на сегодняшнюю дату его часового пояса.
+// Если прошлый период закончился, по его шаблону создаются бюджеты следующих периодов
+func (s *Service) userBudget(ctx context.Context, u *user.User) (*budget.Budget, error) {
+	today := s.today(u)
+	b, err := s.bR.BudgetGetCurrent(ctx, u.ID, today)
+	if err != nil || b != nil {
+		return b, err
+	}
+
+	last, err := s.bR.BudgetGetLatest(ctx, u.ID)
+	if err != nil || last == nil {
+		return nil, err
+	}
+	if _, err := s.rollBudget(ctx, last, today); err != nil {
+		return nil, err
+	}
+	return s.bR.BudgetGetCurrent(ctx, u.ID, today)
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+// GetUserCurrency возвращает валюту текущего бюджета пользователя
+// или валюту по умолчанию, если бюджет не установлен
+func (s *Service) GetUserCurrency(ctx context.Context, telegramID int64) (string, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return "", user.ErrUserNotFound
+	}
+	return s.userCurrency(ctx, u.ID)
+}
+
+// userCurrency возвращает валюту текущего бюджета пользователя или валюту по умолчанию
+func (s *Service) userCurrency(ctx context.Context, userID uuid.UUID) (string, error) {
+	b, err := s.currentBudget(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if b != nil && b.Currency != "" {
+		return b.Currency, nil
+	}
+	return s.currency, nil
+}
+
+// convertExpenses заполняет BaseAmount трат и их частей суммой в валюте to по курсу на дату траты
+func (s *Service) convertExpenses(ctx context.Context, expenses []*ExpenseDTO, to string) error {
+	for _, e := range expenses {
+		amount, err := currency.Convert(ctx, s.rP, e.Amount, e.Currency, to, e.Date)
+		if err != nil {
+			return err
+		}
+		e.BaseAmount = amount
+		splitBaseAmounts(e, to)
+	}
+	return nil
+}
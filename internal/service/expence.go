@@ -2,16 +2,18 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"time"
 
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
 	"github.com/SobolevTim/finance_bot/internal/domain/expense"
 	"github.com/SobolevTim/finance_bot/internal/domain/user"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 )
 
-func (s *Service) CreateExpensByTelegramID(ctx context.Context, telegramID int64, amount float64, date time.Time, description string) error {
+func (s *Service) CreateExpensByTelegramID(ctx context.Context, telegramID int64, amount decimal.Decimal, date time.Time, description string) error {
 	// Преобразование int64 в строку
 	telegramIDStr := strconv.FormatInt(telegramID, 10)
 	// Получение пользователя по telegramID
@@ -24,9 +26,6 @@ func (s *Service) CreateExpensByTelegramID(ctx context.Context, telegramID int64
 		return user.ErrUserNotFound
 	}
 
-	// Преобразование строки в decimal
-	amountDec := decimal.NewFromFloat(amount)
-
 	// Получение категории по умолчанию
 	c, err := s.cR.CategoriesGetDefaultsByName(ctx, "Прочее")
 	if err != nil {
@@ -34,18 +33,24 @@ func (s *Service) CreateExpensByTelegramID(ctx context.Context, telegramID int64
 	}
 
 	// Создание новой траты
-	newExpens, err := expense.NewExpences(u.ID, c.ID, amountDec, date, false, "", description)
+	newExpens, err := expense.NewExpences(u.ID, c.ID, amount, date, false, "", description)
 	if err != nil {
 		return err
 	}
+	if err := s.setExpenseCurrency(ctx, newExpens, ""); err != nil {
+		return err
+	}
 	// Сохранение траты в базе данных
-	err = s.eR.CreateExpens(ctx, newExpens)
+	err = s.eR.CreateExpens(ctx, newExpens, nil)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// GetExpenses возвращает суммы трат пользователя по дням за период
+//
+// Суммы переводятся в валюту бюджета пользователя
 func (s *Service) GetExpenses(ctx context.Context, telegramID int64, startDate, endDate time.Time) ([]*ExpenseDTO, error) {
 	// Преобразование int64 в строку
 	telegramIDStr := strconv.FormatInt(telegramID, 10)
@@ -65,96 +70,301 @@ func (s *Service) GetExpenses(ctx context.Context, telegramID int64, startDate,
 		return nil, err
 	}
 
-	// Преобразование трат в DTO
+	cur, err := s.userCurrency(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Суммы по дням приходят отдельно для каждой валюты и складываются после перевода
 	expensesDTO := make([]*ExpenseDTO, 0, len(expenses))
 	for _, e := range expenses {
+		amount, err := currency.Convert(ctx, s.rP, e.Ammount, e.Currency, cur, e.Date)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(expensesDTO); n > 0 && expensesDTO[n-1].Date.Equal(e.Date) {
+			expensesDTO[n-1].Amount = expensesDTO[n-1].Amount.Add(amount)
+			expensesDTO[n-1].BaseAmount = expensesDTO[n-1].Amount
+			continue
+		}
 		expensesDTO = append(expensesDTO, &ExpenseDTO{
-			ID:          e.ID.String(),
-			UserID:      e.UserID.String(),
-			CategoryID:  e.CategoryID.String(),
-			Amount:      e.Ammount.InexactFloat64(),
-			Date:        e.Date,
-			IsRecurring: e.IsRecurring,
-			Recurrence:  e.RecurrenceRule,
-			Description: e.Description,
+			Amount:     amount,
+			Currency:   cur,
+			BaseAmount: amount,
+			Date:       e.Date,
 		})
 	}
 
 	return expensesDTO, nil
 }
 
-func (s *Service) AddExpense(ctx context.Context, telegramID int64, amount float64, date time.Time, category, description string) error {
+// AddExpense записывает трату пользователя
+//
+// author - пользователь, записавший трату; в групповом чате он становится участником группы.
+// currencyCode - валюта траты, пустая - валюта бюджета пользователя.
+// Для траты в другой валюте должен быть известен курс к валюте бюджета (иначе currency.ErrRateNotFound).
+// recurrenceRule - правило повторения (см. expense.RecurrenceRule), пустое для разовой траты.
+// accountID - ID счета, с которого списывается трата, пустой - счет не указан.
+// splits - распределение по категориям (см. PlanSplit), пустое - вся трата в category.
+// receipt - кассовый чек, с которого заполнена трата (см. StartReceiptExpense), nil - трата введена вручную;
+// если трата по чеку уже записана, возвращается expense.ErrDuplicateReceipt.
+// Возвращает предупреждения о впервые пересеченных порогах бюджета и лимита категории
+func (s *Service) AddExpense(ctx context.Context, telegramID int64, author AuthorDTO, amount decimal.Decimal, currencyCode string, date time.Time, category, description, recurrenceRule, accountID string, splits []*SplitEntryDTO, receipt *ReceiptEntryDTO) ([]*BudgetAlertDTO, error) {
 	// Преобразование int64 в строку
 	telegramIDStr := strconv.FormatInt(telegramID, 10)
 	// Получение пользователя по telegramID
 	u, err := s.uR.UserGetByTelegramID(ctx, telegramIDStr)
 	if err != nil {
-		return user.ErrUserNotFound
+		return nil, user.ErrUserNotFound
 	}
 
 	if u == nil {
-		return user.ErrUserNotFound
+		return nil, user.ErrUserNotFound
 	}
 
-	// Преобразование строки в decimal
-	amountDec := decimal.NewFromFloat(amount)
-
-	// Получение категории по умолчанию
-	c, err := s.cR.CategoriesGetDefaultsByName(ctx, category)
+	// Получение базовой или пользовательской категории
+	c, err := s.cR.CategoriesGetByName(ctx, u.ID, category)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Создание новой траты
-	newExpens, err := expense.NewExpences(u.ID, c.ID, amountDec, date, false, "", description)
+	newExpens, err := expense.NewExpences(u.ID, c.ID, amount, date, recurrenceRule != "", recurrenceRule, description)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	// Сохранение траты в базе данных
-	err = s.eR.CreateExpens(ctx, newExpens)
+	if newExpens.AuthorID, err = s.expenseAuthor(ctx, u, author); err != nil {
+		return nil, err
+	}
+	if err := s.setExpenseCurrency(ctx, newExpens, currencyCode); err != nil {
+		return nil, err
+	}
+	if err := s.setExpenseSplits(ctx, newExpens, splits); err != nil {
+		return nil, err
+	}
+	if receipt != nil {
+		newExpens.Receipt = &expense.Receipt{FN: receipt.FN, FD: receipt.FD, FP: receipt.FP}
+	}
+	if accountID != "" {
+		a, err := s.ownAccount(ctx, u.ID, accountID)
+		if err != nil {
+			return nil, err
+		}
+		newExpens.AccountID = a.ID
+	}
+	// Списание со счета рассчитывается до записи, чтобы трата не сохранилась без него
+	balance, err := s.accountCharge(ctx, newExpens, false)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	// Сохранение траты в базе данных вместе со списанием
+	if err := s.eR.CreateExpens(ctx, newExpens, balance); err != nil {
+		return nil, err
+	}
+	// Проверка порогов бюджета и лимита категории
+	alerts, err := s.checkBudgetAlerts(ctx, newExpens)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBudgetAlerts, err)
+	}
+	return alerts, nil
 }
 
-func (s *Service) GetExpensesByMonth(ctx context.Context, telegramID int64) ([]*ExpenseDTO, float64, error) {
+// GetExpensesByMonth возвращает траты за текущий период бюджета и их сумму в валюте бюджета.
+// Если бюджета нет, период - текущий календарный месяц
+func (s *Service) GetExpensesByMonth(ctx context.Context, telegramID int64) ([]*ExpenseDTO, decimal.Decimal, error) {
 	// Преобразование int64 в строку
 	telegramIDStr := strconv.FormatInt(telegramID, 10)
 	// Получение пользователя по telegramID
 	u, err := s.uR.UserGetByTelegramID(ctx, telegramIDStr)
 	if err != nil {
-		return nil, 0, user.ErrUserNotFound
+		return nil, decimal.Zero, user.ErrUserNotFound
 	}
 
 	if u == nil {
-		return nil, 0, user.ErrUserNotFound
+		return nil, decimal.Zero, user.ErrUserNotFound
 	}
 
-	// startDate - первый день текущего месяца
-	startDate := time.Date(time.Now().Year(), time.Now().Month(), 1, 0, 0, 0, 0, time.UTC)
-	// endDate - последний день текущего месяца
-	endDate := startDate.AddDate(0, 1, -1)
+	startDate, endDate, err := s.currentPeriod(ctx, u)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
 
-	// Получение трат за текущий месяц
+	// Получение трат за текущий период
 	expenses, err := s.eR.GetExpensesByDate(ctx, u.ID, startDate, endDate)
 	if err != nil {
-		return nil, 0, err
+		return nil, decimal.Zero, err
 	}
 
 	if len(expenses) == 0 {
-		return nil, 0, expense.ErrorExpenseNotFound
+		return nil, decimal.Zero, expense.ErrorExpenseNotFound
+	}
+
+	expensesDTO, err := s.expensesToDTO(ctx, expenses)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+
+	// Итог считается в валюте бюджета
+	cur, err := s.userCurrency(ctx, u.ID)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+	if err := s.convertExpenses(ctx, expensesDTO, cur); err != nil {
+		return nil, decimal.Zero, err
+	}
+
+	sum := decimal.Zero
+	for _, e := range expensesDTO {
+		sum = sum.Add(e.BaseAmount)
+	}
+
+	return expensesDTO, sum, nil
+}
+
+// GetExpensesByPeriod возвращает траты пользователя с категориями за период
+func (s *Service) GetExpensesByPeriod(ctx context.Context, telegramID int64, startDate, endDate time.Time) ([]*ExpenseDTO, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	expenses, err := s.eR.GetExpensesByDate(ctx, u.ID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.expensesToDTO(ctx, expenses)
+}
+
+// GetExpense возвращает трату пользователя по ID
+//
+// Чужая трата считается не найденной
+func (s *Service) GetExpense(ctx context.Context, telegramID int64, id string) (*ExpenseDTO, error) {
+	_, e, err := s.getOwnExpense(ctx, telegramID, id)
+	if err != nil {
+		return nil, err
 	}
 
+	expensesDTO, err := s.expensesToDTO(ctx, []*expense.Expense{e})
+	if err != nil {
+		return nil, err
+	}
+	return expensesDTO[0], nil
+}
+
+// UpdateExpense обновляет сумму, валюту, дату, категорию и описание траты пользователя
+//
+// currencyCode - валюта траты, пустая - валюта бюджета пользователя
+func (s *Service) UpdateExpense(ctx context.Context, telegramID int64, id string, amount decimal.Decimal, currencyCode string, date time.Time, category, description string) error {
+	u, e, err := s.getOwnExpense(ctx, telegramID, id)
+	if err != nil {
+		return err
+	}
+
+	c, err := s.cR.CategoriesGetByName(ctx, u.ID, category)
+	if err != nil {
+		return err
+	}
+
+	old := *e
+	e.Ammount = amount
+	e.Date = date
+	e.CategoryID = c.ID
+	e.Description = description
+	e.UpdatedAt = s.clock.Now()
+	if err := s.setExpenseCurrency(ctx, e, currencyCode); err != nil {
+		return err
+	}
+	// Распределение по категориям отменяется, если изменились сумма, валюта или категория
+	if e.IsSplit() && (!e.Ammount.Equal(old.Ammount) || e.Currency != old.Currency || e.CategoryID != old.CategoryID) {
+		e.Splits = nil
+	}
+
+	// Пересчет баланса счета: возврат старой суммы и списание новой в одной транзакции с изменением траты
+	refund, err := s.accountCharge(ctx, &old, true)
+	if err != nil {
+		return err
+	}
+	charge, err := s.accountCharge(ctx, e, false)
+	if err != nil {
+		return err
+	}
+	return s.eR.UpdateExpens(ctx, e, append(refund, charge...))
+}
+
+// DeleteExpense удаляет трату пользователя
+func (s *Service) DeleteExpense(ctx context.Context, telegramID int64, id string) error {
+	_, e, err := s.getOwnExpense(ctx, telegramID, id)
+	if err != nil {
+		return err
+	}
+
+	// Возврат суммы траты на счет в одной транзакции с удалением
+	refund, err := s.accountCharge(ctx, e, true)
+	if err != nil {
+		return err
+	}
+	return s.eR.DeleteExpens(ctx, e.ID, refund)
+}
+
+// setExpenseCurrency устанавливает валюту траты и проверяет, что ее можно перевести в валюту бюджета
+//
+// Пустой code - валюта бюджета пользователя
+func (s *Service) setExpenseCurrency(ctx context.Context, e *expense.Expense, code string) error {
+	cur, err := s.userCurrency(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+	if code == "" {
+		code = cur
+	}
+	if err := e.SetCurrency(code); err != nil {
+		return err
+	}
+	_, err = currency.Convert(ctx, s.rP, e.Ammount, e.Currency, cur, e.Date)
+	return err
+}
+
+// getOwnExpense возвращает пользователя и его трату по ID
+//
+// Если трата принадлежит другому пользователю, возвращает expense.ErrorExpenseNotFound
+func (s *Service) getOwnExpense(ctx context.Context, telegramID int64, id string) (*user.User, *expense.Expense, error) {
+	expenseID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, nil, expense.ErrorExpenseNotFound
+	}
+
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil {
+		return nil, nil, user.ErrUserNotFound
+	}
+
+	e, err := s.eR.GetExpenses(ctx, expenseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.UserID != u.ID {
+		return nil, nil, expense.ErrorExpenseNotFound
+	}
+	return u, e, nil
+}
+
+// expensesToDTO преобразует траты в DTO с названиями и иконками категорий
+func (s *Service) expensesToDTO(ctx context.Context, expenses []*expense.Expense) ([]*ExpenseDTO, error) {
 	// Получение Категорий с иконками
 	var ids []uuid.UUID
 
 	for _, e := range expenses {
 		ids = append(ids, e.CategoryID)
+		for _, split := range e.Splits {
+			ids = append(ids, split.CategoryID)
+		}
 	}
 
 	categories, err := s.cR.CategoriesGetBuIDs(ctx, ids)
-	sum := 0.0
+	if err != nil {
+		return nil, err
+	}
 
 	// Преобразование трат в DTO
 	expensesDTO := make([]*ExpenseDTO, 0, len(expenses))
@@ -168,20 +378,43 @@ func (s *Service) GetExpensesByMonth(ctx context.Context, telegramID int64) ([]*
 				break
 			}
 		}
-		sum += e.Ammount.InexactFloat64()
+		var splits []*SplitDTO
+		for _, split := range e.Splits {
+			dto := &SplitDTO{CategoryID: split.CategoryID.String(), Amount: split.Amount, BaseAmount: split.Amount}
+			for _, c := range categories {
+				if c.ID == split.CategoryID {
+					dto.CategoryIcon, dto.Category = c.Icon, c.Name
+					break
+				}
+			}
+			splits = append(splits, dto)
+		}
 		expensesDTO = append(expensesDTO, &ExpenseDTO{
 			ID:           e.ID.String(),
 			UserID:       e.UserID.String(),
 			CategoryID:   e.CategoryID.String(),
 			CategoryIcon: icon,
 			Category:     category,
-			Amount:       e.Ammount.InexactFloat64(),
+			Amount:       e.Ammount,
+			Currency:     e.Currency,
+			BaseAmount:   e.Ammount,
 			Date:         e.Date,
 			IsRecurring:  e.IsRecurring,
 			Recurrence:   e.RecurrenceRule,
 			Description:  e.Description,
+			AuthorID:     optionalID(e.AuthorID),
+			AccountID:    optionalID(e.AccountID),
+			Splits:       splits,
 		})
 	}
 
-	return expensesDTO, sum, nil
+	return expensesDTO, nil
+}
+
+// optionalID возвращает необязательный ID для DTO, пустой для uuid.Nil
+func optionalID(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+	return id.String()
 }
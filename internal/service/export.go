@@ -0,0 +1,67 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/SobolevTim/finance_bot/internal/pkg/export"
+)
+
+// ExportExpenses выгружает траты пользователя за период в файл формата format (csv или xlsx)
+//
+// Нулевой startDate означает выгрузку всех трат до endDate.
+// Возвращает имя файла и его содержимое
+func (s *Service) ExportExpenses(ctx context.Context, telegramID int64, startDate, endDate time.Time, format string) (string, []byte, error) {
+	expenses, err := s.GetExpensesByPeriod(ctx, telegramID, startDate, endDate)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(expenses) == 0 {
+		return "", nil, expense.ErrorExpenseNotFound
+	}
+
+	// Итоги по категориям считаются в валюте бюджета
+	cur, err := s.GetUserCurrency(ctx, telegramID)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := s.convertExpenses(ctx, expenses, cur); err != nil {
+		return "", nil, err
+	}
+
+	// Распределенная трата выгружается строкой на каждую часть
+	rows := make([]export.Row, 0, len(expenses))
+	for _, e := range allocations(expenses) {
+		rows = append(rows, export.Row{
+			Date:        e.Date,
+			Category:    e.Category,
+			Amount:      e.Amount,
+			Currency:    e.Currency,
+			BaseAmount:  e.BaseAmount,
+			Description: e.Description,
+		})
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case export.FormatCSV:
+		err = export.WriteCSV(&buf, rows)
+	case export.FormatXLSX:
+		err = export.WriteXLSX(&buf, rows, cur)
+	default:
+		return "", nil, fmt.Errorf("unsupported export format %q", format)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Для выгрузки всех трат в имени файла указываем дату первой траты
+	if startDate.IsZero() {
+		startDate = expenses[0].Date
+	}
+	name := fmt.Sprintf("expenses_%s_%s.%s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), format)
+	return name, buf.Bytes(), nil
+}
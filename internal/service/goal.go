@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/SobolevTim/finance_bot/internal/domain/goal"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// GoalDTO - цель накоплений с расчетом ежемесячного взноса
+type GoalDTO struct {
+	Goal       *goal.Goal
+	Progress   float64         // Доля накопленного от 0 до 1
+	MonthsLeft int             // Месяцев до срока, включая текущий
+	Monthly    decimal.Decimal // Взнос в месяц, нужный, чтобы успеть к сроку, в валюте цели
+	Suggested  decimal.Decimal // Рекомендуемый взнос в этом месяце с учетом остатка бюджета, в валюте цели
+}
+
+// GoalsOverviewDTO - цели пользователя и остаток бюджета, из которого предлагаются взносы
+type GoalsOverviewDTO struct {
+	Goals     []*GoalDTO
+	HasBudget bool            // Есть ли бюджет на текущий период
+	Headroom  decimal.Decimal // Остаток бюджета текущего периода, не меньше 0
+	Required  decimal.Decimal // Сумма нужных взносов по всем целям в валюте бюджета
+	Currency  string          // Валюта бюджета
+}
+
+// CreateGoal создает цель накоплений
+//
+// target - сумма с необязательной валютой: "150000", "2000 USD", без валюты - валюта бюджета
+// deadline - срок: "31.12.2026" или "12.2026"
+func (s *Service) CreateGoal(ctx context.Context, telegramID int64, target, name, deadline string) (*goal.Goal, error) {
+	due, err := goal.ParseDeadline(deadline)
+	if err != nil {
+		return nil, err
+	}
+	amount, code := currency.SplitAmount(target)
+	targetDec, err := decimal.NewFromString(strings.Replace(amount, ",", ".", 1))
+	if err != nil {
+		return nil, goal.ErrNonPositiveAmount
+	}
+
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	g, err := goal.New(u.ID, name, targetDec, due, s.today(u))
+	if err != nil {
+		return nil, err
+	}
+	if code == "" {
+		if code, err = s.userCurrency(ctx, u.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err := g.SetCurrency(code); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.findGoal(ctx, u.ID, g.Name); err == nil {
+		return nil, goal.ErrDuplicateName
+	}
+
+	if err := s.goR.GoalCreate(ctx, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// ContributeToGoal записывает взнос в цель по ее названию
+//
+// amount - сумма с необязательной валютой; если валюта отличается от валюты цели,
+// взнос пересчитывается по курсу на сегодня
+func (s *Service) ContributeToGoal(ctx context.Context, telegramID int64, author AuthorDTO, amount, name string) (*goal.Goal, error) {
+	value, code := currency.SplitAmount(amount)
+	amountDec, err := decimal.NewFromString(strings.Replace(value, ",", ".", 1))
+	if err != nil || !amountDec.IsPositive() {
+		return nil, goal.ErrNonPositiveAmount
+	}
+
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+	g, err := s.findGoal(ctx, u.ID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	today := s.today(u)
+	if code != "" {
+		if amountDec, err = currency.Convert(ctx, s.rP, amountDec, code, g.Currency, today); err != nil {
+			return nil, err
+		}
+	}
+	c, err := goal.NewContribution(g, amountDec, today)
+	if err != nil {
+		return nil, err
+	}
+	if c.AuthorID, err = s.expenseAuthor(ctx, u, author); err != nil {
+		return nil, err
+	}
+
+	if err := s.goR.GoalContribute(ctx, c); err != nil {
+		return nil, err
+	}
+	g.Saved = g.Saved.Add(c.Amount)
+	return g, nil
+}
+
+// DeleteGoal удаляет цель по названию вместе со взносами
+func (s *Service) DeleteGoal(ctx context.Context, telegramID int64, name string) (*goal.Goal, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+	g, err := s.findGoal(ctx, u.ID, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.goR.GoalDelete(ctx, g.ID); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// GetGoals возвращает цели пользователя с прогрессом и рекомендуемыми взносами.
+//
+// Нужные взносы по всем незавершенным целям сравниваются с остатком бюджета текущего периода:
+// если остатка не хватает, он делится между целями пропорционально нужным взносам
+func (s *Service) GetGoals(ctx context.Context, telegramID int64) (*GoalsOverviewDTO, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+	goals, err := s.goR.GoalsGetByUser(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	overview := &GoalsOverviewDTO{Goals: make([]*GoalDTO, 0, len(goals))}
+	if len(goals) == 0 {
+		return overview, nil
+	}
+
+	b, err := s.userBudget(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	headroom := decimal.Zero
+	if b != nil {
+		overview.HasBudget = true
+		overview.Currency = b.Currency
+		spent, err := s.budgetSpent(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+		if left := b.Total().Sub(spent); left.IsPositive() {
+			headroom = left
+		}
+	} else if overview.Currency, err = s.userCurrency(ctx, u.ID); err != nil {
+		return nil, err
+	}
+
+	today := s.today(u)
+	monthly := make([]decimal.Decimal, len(goals)) // нужный взнос в валюте бюджета
+	required := decimal.Zero
+	for i, g := range goals {
+		m := g.MonthlyContribution(today)
+		overview.Goals = append(overview.Goals, &GoalDTO{
+			Goal:       g,
+			Progress:   g.Progress(),
+			MonthsLeft: g.MonthsLeft(today),
+			Monthly:    m,
+			Suggested:  m,
+		})
+		if monthly[i], err = currency.Convert(ctx, s.rP, m, g.Currency, overview.Currency, today); err != nil {
+			return nil, err
+		}
+		required = required.Add(monthly[i])
+	}
+	overview.Headroom = headroom
+	overview.Required = required
+
+	if !overview.HasBudget || headroom.GreaterThanOrEqual(required) {
+		return overview, nil
+	}
+	for i, d := range overview.Goals {
+		if monthly[i].IsZero() {
+			continue
+		}
+		// Доля остатка бюджета пропорционально нужному взносу, в валюте цели
+		share := headroom.Mul(monthly[i]).Div(required)
+		suggested, err := currency.Convert(ctx, s.rP, share, overview.Currency, d.Goal.Currency, today)
+		if err != nil {
+			return nil, err
+		}
+		d.Suggested = suggested.RoundDown(currency.Decimals(d.Goal.Currency))
+	}
+	return overview, nil
+}
+
+// findGoal ищет цель пользователя по названию без учета регистра
+func (s *Service) findGoal(ctx context.Context, userID uuid.UUID, name string) (*goal.Goal, error) {
+	goals, err := s.goR.GoalsGetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	for _, g := range goals {
+		if strings.EqualFold(g.Name, name) {
+			return g, nil
+		}
+	}
+	return nil, goal.ErrGoalNotFound
+}
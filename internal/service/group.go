@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/group"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+// IsGroupChat сообщает, является ли чат групповым (ID группы отрицательный)
+func IsGroupChat(chatID int64) bool {
+	return chatID < 0
+}
+
+// JoinGroup добавляет автора в участники общего бюджета группового чата chatID
+// и возвращает его участие. Первый участник группы становится владельцем
+func (s *Service) JoinGroup(ctx context.Context, chatID int64, author AuthorDTO) (*group.Member, error) {
+	g, err := s.GetUserByTelegramID(ctx, chatID)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+	return s.joinGroup(ctx, g, author)
+}
+
+// GetGroupMembers возвращает участников общего бюджета группового чата
+func (s *Service) GetGroupMembers(ctx context.Context, chatID int64) ([]*group.Member, error) {
+	g, err := s.GetUserByTelegramID(ctx, chatID)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+	return s.gR.MembersGetByGroup(ctx, g.ID)
+}
+
+// CheckBudgetAccess проверяет, может ли author менять бюджет и лимиты чата chatID
+//
+// В личном чате бюджет меняет его владелец, в групповом - владелец группы и администраторы.
+// Возвращает group.ErrForbidden, если прав недостаточно
+func (s *Service) CheckBudgetAccess(ctx context.Context, chatID int64, author AuthorDTO) error {
+	if !IsGroupChat(chatID) {
+		return nil
+	}
+	if author.TelegramID == 0 {
+		return group.ErrForbidden
+	}
+	m, err := s.JoinGroup(ctx, chatID, author)
+	if err != nil {
+		return err
+	}
+	if !m.CanManageBudget() {
+		return group.ErrForbidden
+	}
+	return nil
+}
+
+// SetMemberRole назначает участнику группы userName роль roleName
+//
+// Роли меняет только владелец группы (иначе group.ErrForbidden)
+func (s *Service) SetMemberRole(ctx context.Context, chatID int64, actor AuthorDTO, userName, roleName string) (*group.Member, error) {
+	role, err := group.ParseRole(roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := s.GetUserByTelegramID(ctx, chatID)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+	m, err := s.joinGroup(ctx, g, actor)
+	if err != nil {
+		return nil, err
+	}
+	if !m.CanManageMembers() {
+		return nil, group.ErrForbidden
+	}
+
+	members, err := s.gR.MembersGetByGroup(ctx, g.ID)
+	if err != nil {
+		return nil, err
+	}
+	userName = strings.TrimPrefix(strings.TrimSpace(userName), "@")
+	for _, target := range members {
+		if !strings.EqualFold(target.UserName, userName) {
+			continue
+		}
+		if err := target.SetRole(role); err != nil {
+			return nil, err
+		}
+		if err := s.gR.MemberUpdateRole(ctx, target); err != nil {
+			return nil, err
+		}
+		return target, nil
+	}
+	return nil, group.ErrMemberNotFound
+}
+
+// GetMemberSpending распределяет траты группового чата по участникам
+//
+// expenses - траты с суммами в валюте бюджета (см. GetExpensesByMonth).
+// Участники отсортированы по убыванию суммы
+func (s *Service) GetMemberSpending(ctx context.Context, chatID int64, expenses []*ExpenseDTO) ([]*MemberSpendingDTO, error) {
+	members, err := s.GetGroupMembers(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	byAuthor := make(map[string]*MemberSpendingDTO)
+	for _, m := range members {
+		byAuthor[m.UserID.String()] = &MemberSpendingDTO{Member: m.DisplayName(), Role: m.Role}
+	}
+	for _, e := range expenses {
+		spending, ok := byAuthor[e.AuthorID]
+		if !ok {
+			// Траты, записанные до появления групп, и траты бывших участников
+			spending = &MemberSpendingDTO{Member: "Без автора"}
+			byAuthor[e.AuthorID] = spending
+		}
+		spending.Amount = spending.Amount.Add(e.BaseAmount)
+		spending.Count++
+	}
+
+	result := make([]*MemberSpendingDTO, 0, len(byAuthor))
+	for _, spending := range byAuthor {
+		result = append(result, spending)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Amount.Equal(result[j].Amount) {
+			return result[i].Amount.GreaterThan(result[j].Amount)
+		}
+		return result[i].Member < result[j].Member
+	})
+	return result, nil
+}
+
+// joinGroup добавляет автора в участники группы g, если его там еще нет
+func (s *Service) joinGroup(ctx context.Context, g *user.User, author AuthorDTO) (*group.Member, error) {
+	u, err := s.registerAuthor(ctx, author)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := s.gR.MemberGet(ctx, g.ID, u.ID)
+	if err == nil {
+		return m, nil
+	}
+	if !errors.Is(err, group.ErrMemberNotFound) {
+		return nil, err
+	}
+
+	members, err := s.gR.MembersGetByGroup(ctx, g.ID)
+	if err != nil {
+		return nil, err
+	}
+	role := group.RoleMember
+	if len(members) == 0 {
+		role = group.RoleOwner
+	}
+	m, err = group.NewMember(g.ID, u.ID, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.gR.MemberAdd(ctx, m); err != nil {
+		return nil, err
+	}
+	m.UserName = u.UserName
+	m.FirstName = u.FirstName
+	return m, nil
+}
+
+// registerAuthor возвращает пользователя автора, регистрируя его при первом обращении.
+//
+// У участника группы может не быть username или он может быть занят,
+// тогда используется имя вида "id123456"
+func (s *Service) registerAuthor(ctx context.Context, author AuthorDTO) (*user.User, error) {
+	userName := author.UserName
+	firstName := author.FirstName
+	if firstName == "" {
+		firstName = userName
+	}
+
+	u, err := s.RegisterUser(ctx, author.TelegramID, userName, firstName, author.LastName)
+	if errors.Is(err, user.ErrEmptyUserName) || errors.Is(err, user.ErrEmptyFirstName) || errors.Is(err, user.ErrDuplicateUserName) {
+		userName = "id" + strconv.FormatInt(author.TelegramID, 10)
+		if firstName == "" {
+			firstName = userName
+		}
+		u, err = s.RegisterUser(ctx, author.TelegramID, userName, firstName, author.LastName)
+	}
+	return u, err
+}
+
+// expenseAuthor возвращает ID автора траты в бюджете ledger
+//
+// В личном чате автор - сам владелец бюджета, в групповом - участник группы
+func (s *Service) expenseAuthor(ctx context.Context, ledger *user.User, author AuthorDTO) (uuid.UUID, error) {
+	chatID, err := strconv.ParseInt(ledger.TelegramID, 10, 64)
+	if err != nil || !IsGroupChat(chatID) || author.TelegramID == 0 {
+		return ledger.ID, nil
+	}
+	m, err := s.joinGroup(ctx, ledger, author)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return m.UserID, nil
+}
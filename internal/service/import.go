@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/categories"
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/SobolevTim/finance_bot/internal/domain/status"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/SobolevTim/finance_bot/internal/pkg/statement"
+	"github.com/google/uuid"
+)
+
+// importPreviewRows - количество строк в предпросмотре импорта
+const importPreviewRows = 5
+
+// importPlan - траты из выписки, разделенные на новые и дубликаты
+type importPlan struct {
+	expenses   []*expense.Expense
+	rows       []*ImportRowDTO
+	duplicates int
+	skipped    int
+}
+
+// StartImport сохраняет загруженную выписку и возвращает предпросмотр импорта
+//
+// Колонки определяются по заголовку. Если определить их не удалось,
+// предпросмотр возвращается с MappingDetected = false и без строк
+func (s *Service) StartImport(ctx context.Context, telegramID int64, fileName string, data []byte) (*ImportPreviewDTO, error) {
+	table, err := statement.Read(data)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping, ok := statement.DetectMapping(table.Header)
+	if !ok {
+		mapping = statement.Mapping{Date: -1, Amount: -1, Description: -1}
+	}
+
+	session := &status.ImportSession{
+		FileName:    fileName,
+		Data:        data,
+		Date:        mapping.Date,
+		Amount:      mapping.Amount,
+		Description: mapping.Description,
+	}
+	if err := s.setImportSession(ctx, telegramID, session); err != nil {
+		return nil, err
+	}
+
+	return s.importPreview(ctx, telegramID, session, table)
+}
+
+// GetImportPreview возвращает предпросмотр сохраненной выписки
+func (s *Service) GetImportPreview(ctx context.Context, telegramID int64) (*ImportPreviewDTO, error) {
+	session, err := s.getImportSession(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	table, err := statement.Read(session.Data)
+	if err != nil {
+		return nil, err
+	}
+	return s.importPreview(ctx, telegramID, session, table)
+}
+
+// SetImportMapping задает колонки выписки из ввода пользователя вида "1 3 5"
+// (дата, сумма, описание) и возвращает обновленный предпросмотр
+func (s *Service) SetImportMapping(ctx context.Context, telegramID int64, text string) (*ImportPreviewDTO, error) {
+	session, err := s.getImportSession(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	table, err := statement.Read(session.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping, err := statement.ParseMapping(text, len(table.Header))
+	if err != nil {
+		return nil, err
+	}
+	session.Date = mapping.Date
+	session.Amount = mapping.Amount
+	session.Description = mapping.Description
+	if err := s.setImportSession(ctx, telegramID, session); err != nil {
+		return nil, err
+	}
+
+	return s.importPreview(ctx, telegramID, session, table)
+}
+
+// ConfirmImport записывает новые траты из сохраненной выписки одним пакетом
+//
+// Строки, совпадающие с уже записанными тратами по дате, сумме и описанию, пропускаются.
+// Суммы выписки записываются в валюте бюджета, автором трат считается author
+func (s *Service) ConfirmImport(ctx context.Context, telegramID int64, author AuthorDTO) (*ImportResultDTO, error) {
+	session, err := s.getImportSession(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	table, err := statement.Read(session.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	plan, err := s.planImport(ctx, u.ID, session, table)
+	if err != nil {
+		return nil, err
+	}
+	authorID, err := s.expenseAuthor(ctx, u, author)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range plan.expenses {
+		e.AuthorID = authorID
+	}
+	if len(plan.expenses) > 0 {
+		if err := s.eR.CreateExpensesBatch(ctx, plan.expenses); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.CancelImport(ctx, telegramID); err != nil {
+		return nil, err
+	}
+
+	return &ImportResultDTO{
+		Imported:   len(plan.expenses),
+		Duplicates: plan.duplicates,
+		Skipped:    plan.skipped,
+	}, nil
+}
+
+// CancelImport удаляет сохраненную выписку
+func (s *Service) CancelImport(ctx context.Context, telegramID int64) error {
+	if telegramID == 0 {
+		return status.ErrEmptyTelegramID
+	}
+	return s.sR.DeleteImportSession(ctx, strconv.FormatInt(telegramID, 10))
+}
+
+// importPreview формирует предпросмотр импорта по сохраненной выписке
+func (s *Service) importPreview(ctx context.Context, telegramID int64, session *status.ImportSession, table *statement.Table) (*ImportPreviewDTO, error) {
+	preview := &ImportPreviewDTO{
+		FileName:    session.FileName,
+		Header:      table.Header,
+		Date:        session.Date,
+		Amount:      session.Amount,
+		Description: session.Description,
+	}
+	if session.Date < 0 || session.Amount < 0 {
+		return preview, nil
+	}
+	preview.MappingDetected = true
+
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	plan, err := s.planImport(ctx, u.ID, session, table)
+	if err != nil {
+		return nil, err
+	}
+	preview.Rows = plan.rows
+	if len(preview.Rows) > importPreviewRows {
+		preview.Rows = preview.Rows[:importPreviewRows]
+	}
+	preview.Total = len(plan.expenses)
+	preview.Duplicates = plan.duplicates
+	preview.Skipped = plan.skipped
+	return preview, nil
+}
+
+// planImport разбирает выписку, подбирает категории и отделяет дубликаты.
+//
+// Каждая уже записанная трата "поглощает" одну совпавшую строку выписки,
+// поэтому повторный импорт того же файла ничего не добавит,
+// а две одинаковые покупки за день в новой выписке сохранятся обе
+func (s *Service) planImport(ctx context.Context, userID uuid.UUID, session *status.ImportSession, table *statement.Table) (*importPlan, error) {
+	parsed, err := statement.Parse(table, statement.Mapping{
+		Date:        session.Date,
+		Amount:      session.Amount,
+		Description: session.Description,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.getUserCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := s.cR.CategoriesAliasGetForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	fallback, err := s.cR.CategoriesGetByName(ctx, userID, fallbackCategory)
+	if err != nil {
+		return nil, err
+	}
+	// Суммы выписки считаются в валюте бюджета
+	cur, err := s.userCurrency(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := parsed.Rows[0].Date, parsed.Rows[0].Date
+	for _, r := range parsed.Rows {
+		if r.Date.Before(start) {
+			start = r.Date
+		}
+		if r.Date.After(end) {
+			end = r.Date
+		}
+	}
+	existing, err := s.eR.GetExpensesByDate(ctx, userID, start, end)
+	if err != nil && !errors.Is(err, expense.ErrorExpenseNotFound) {
+		return nil, err
+	}
+	seen := make(map[string]int, len(existing))
+	for _, e := range existing {
+		seen[importKey(e.Date, e.Ammount.StringFixed(2), e.Currency, e.Description)]++
+	}
+
+	plan := &importPlan{skipped: parsed.Skipped}
+	for _, r := range parsed.Rows {
+		key := importKey(r.Date, r.Amount.StringFixed(2), cur, r.Description)
+		if seen[key] > 0 {
+			seen[key]--
+			plan.duplicates++
+			continue
+		}
+
+		c := matchDescription(r.Description, list, aliases)
+		if c == nil {
+			c = fallback
+		}
+		e, err := expense.NewExpences(userID, c.ID, r.Amount, r.Date, false, "", r.Description)
+		if err != nil {
+			plan.skipped++
+			continue
+		}
+		e.Currency = cur
+		plan.expenses = append(plan.expenses, e)
+		plan.rows = append(plan.rows, &ImportRowDTO{
+			Date:        r.Date,
+			Amount:      r.Amount,
+			Currency:    cur,
+			Category:    c.Name,
+			Description: r.Description,
+		})
+	}
+	return plan, nil
+}
+
+// matchDescription подбирает категорию по первому совпавшему слову описания
+func matchDescription(description string, list []*categories.Categories, aliases map[string]uuid.UUID) *categories.Categories {
+	for _, w := range strings.Fields(description) {
+		if c := categories.MatchWord(strings.Trim(w, ".,;:\"'()«»"), list, aliases); c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// importKey - ключ для поиска дубликатов: дата, сумма, валюта и описание без учета регистра
+func importKey(date time.Time, amount, code, description string) string {
+	return date.Format(time.DateOnly) + "|" + amount + "|" + code + "|" + strings.ToLower(strings.TrimSpace(description))
+}
+
+func (s *Service) setImportSession(ctx context.Context, telegramID int64, session *status.ImportSession) error {
+	if telegramID == 0 {
+		return status.ErrEmptyTelegramID
+	}
+	return s.sR.SetImportSession(ctx, strconv.FormatInt(telegramID, 10), session)
+}
+
+// getImportSession возвращает сохраненную выписку или status.ErrImportNotFound
+func (s *Service) getImportSession(ctx context.Context, telegramID int64) (*status.ImportSession, error) {
+	if telegramID == 0 {
+		return nil, status.ErrEmptyTelegramID
+	}
+	session, err := s.sR.GetImportSession(ctx, strconv.FormatInt(telegramID, 10))
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, status.ErrImportNotFound
+	}
+	return session, nil
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/budget"
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/SobolevTim/finance_bot/internal/domain/income"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/SobolevTim/finance_bot/internal/pkg/quickadd"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// IncomeDTO - доход пользователя
+type IncomeDTO struct {
+	ID         string          // ID дохода
+	Amount     decimal.Decimal // Сумма
+	Currency   string          // Валюта дохода
+	BaseAmount decimal.Decimal // Сумма в валюте бюджета
+	Date       time.Time       // Дата поступления
+	Source     string          // Источник дохода
+}
+
+// AddIncome записывает доход из сообщения пользователя: "50000 зарплата", "300$ фриланс вчера".
+//
+// Слова сообщения, кроме суммы, валюты и даты, становятся источником дохода.
+// Если бюджет рассчитывается по доходам, он пересчитывается и возвращается вторым значением,
+// иначе возвращается nil. Возвращает quickadd.ErrNoAmount, если в сообщении нет суммы
+func (s *Service) AddIncome(ctx context.Context, telegramID int64, author AuthorDTO, text string) (*IncomeDTO, *budget.Budget, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, nil, user.ErrUserNotFound
+	}
+
+	parsed, err := quickadd.Parse(text, s.today(u))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newIncome, err := income.New(u.ID, parsed.Amount, parsed.Date, strings.Join(parsed.Words, " "))
+	if err != nil {
+		return nil, nil, err
+	}
+	if newIncome.AuthorID, err = s.expenseAuthor(ctx, u, author); err != nil {
+		return nil, nil, err
+	}
+
+	cur, err := s.userCurrency(ctx, u.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	code := parsed.Currency
+	if code == "" {
+		code = cur
+	}
+	if err := newIncome.SetCurrency(code); err != nil {
+		return nil, nil, err
+	}
+	baseAmount, err := currency.Convert(ctx, s.rP, newIncome.Amount, newIncome.Currency, cur, newIncome.Date)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.iR.IncomeCreate(ctx, newIncome); err != nil {
+		return nil, nil, err
+	}
+
+	b, err := s.syncIncomeBudget(ctx, u.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dto := incomeToDTO(newIncome)
+	dto.BaseAmount = baseAmount
+	return dto, b, nil
+}
+
+// GetIncomeByMonth возвращает доходы за текущий период бюджета и их сумму в валюте бюджета
+func (s *Service) GetIncomeByMonth(ctx context.Context, telegramID int64) ([]*IncomeDTO, decimal.Decimal, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, decimal.Zero, user.ErrUserNotFound
+	}
+
+	cur, err := s.userCurrency(ctx, u.ID)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+
+	incomes, total, err := s.monthIncome(ctx, u, cur)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+	return incomes, total, nil
+}
+
+// SetBudgetFromIncome устанавливает текущий бюджет равным доходам, полученным с начала периода.
+//
+// После этого бюджет пересчитывается при каждой записи дохода, пока сумма не будет задана вручную.
+// Возвращает income.ErrNoIncome, если доходов в этом периоде еще нет
+func (s *Service) SetBudgetFromIncome(ctx context.Context, telegramID int64) (*budget.Budget, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	cur, err := s.userCurrency(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	_, total, err := s.monthIncome(ctx, u, cur)
+	if err != nil {
+		return nil, err
+	}
+	if !total.IsPositive() {
+		return nil, income.ErrNoIncome
+	}
+
+	b, err := s.userBudget(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		if b, err = s.CreateBudget(ctx, telegramID, total.String(), cur); err != nil {
+			return nil, err
+		}
+	}
+
+	b.Amount = total
+	b.FromIncome = true
+	b.UpdatedAt = s.clock.Now()
+	if err := s.bR.BudgetUpdate(ctx, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// syncIncomeBudget пересчитывает текущий бюджет, если он рассчитывается по доходам.
+// Возвращает nil, если бюджета нет или его сумма задана вручную
+func (s *Service) syncIncomeBudget(ctx context.Context, userID uuid.UUID) (*budget.Budget, error) {
+	b, err := s.currentBudget(ctx, userID)
+	if err != nil || b == nil || !b.FromIncome {
+		return nil, err
+	}
+
+	_, total, err := s.periodIncome(ctx, userID, b.Currency, b.StartDate, b.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	if !total.IsPositive() {
+		return b, nil
+	}
+
+	b.Amount = total
+	b.UpdatedAt = s.clock.Now()
+	if err := s.bR.BudgetUpdate(ctx, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// monthIncome возвращает доходы за текущий период бюджета и их сумму в валюте cur
+func (s *Service) monthIncome(ctx context.Context, u *user.User, cur string) ([]*IncomeDTO, decimal.Decimal, error) {
+	startDate, endDate, err := s.currentPeriod(ctx, u)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+
+	return s.periodIncome(ctx, u.ID, cur, startDate, endDate)
+}
+
+// periodIncome возвращает доходы за период и их сумму в валюте cur
+func (s *Service) periodIncome(ctx context.Context, userID uuid.UUID, cur string, startDate, endDate time.Time) ([]*IncomeDTO, decimal.Decimal, error) {
+	incomes, err := s.iR.IncomeGetByDate(ctx, userID, startDate, endDate)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+
+	total := decimal.Zero
+	dtos := make([]*IncomeDTO, 0, len(incomes))
+	for _, i := range incomes {
+		amount, err := currency.Convert(ctx, s.rP, i.Amount, i.Currency, cur, i.Date)
+		if err != nil {
+			return nil, decimal.Zero, err
+		}
+		total = total.Add(amount)
+
+		dto := incomeToDTO(i)
+		dto.BaseAmount = amount
+		dtos = append(dtos, dto)
+	}
+	return dtos, total, nil
+}
+
+func incomeToDTO(i *income.Income) *IncomeDTO {
+	return &IncomeDTO{
+		ID:       i.ID.String(),
+		Amount:   i.Amount,
+		Currency: i.Currency,
+		Date:     i.Date,
+		Source:   i.Source,
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/notification"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// topCategoriesInReport - число категорий в итогах месяца
+const topCategoriesInReport = 5
+
+// NotificationDTO - уведомление, готовое к отправке пользователю
+type NotificationDTO struct {
+	TelegramID int64                  // ID чата пользователя
+	Kind       string                 // Вид уведомления, см. notification.Kind*
+	Currency   string                 // Валюта сумм
+	StartDate  time.Time              // Начало периода отчета
+	EndDate    time.Time              // Конец периода отчета
+	Days       []*ExpenseDTO          // Суммы трат по дням (обзор недели)
+	Categories []*CategorySpendingDTO // Самые крупные категории (итоги месяца)
+	Spent      decimal.Decimal        // Потрачено за период
+	Income     decimal.Decimal        // Доходы за период (итоги месяца)
+}
+
+// CategorySpendingDTO - траты по категории за период
+type CategorySpendingDTO struct {
+	Category     string          // Категория
+	CategoryIcon string          // Иконка категории
+	Amount       decimal.Decimal // Сумма в валюте бюджета
+}
+
+// GetNotificationSettings возвращает настройки уведомлений пользователя.
+// Если настройки не сохранялись, все уведомления выключены
+func (s *Service) GetNotificationSettings(ctx context.Context, telegramID int64) (*notification.Settings, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+	return s.notificationSettings(ctx, u.ID)
+}
+
+// ToggleNotification включает или выключает уведомление kind и возвращает новые настройки
+func (s *Service) ToggleNotification(ctx context.Context, telegramID int64, kind string) (*notification.Settings, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	settings, err := s.notificationSettings(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := settings.Toggle(kind); err != nil {
+		return nil, err
+	}
+	if err := s.nR.NotificationSave(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// ProcessNotifications возвращает уведомления, которые пора отправить к моменту now.
+//
+// Время отправки считается по часовому поясу каждого пользователя (User.Timezone).
+// Уведомление отмечается отправленным до возврата, поэтому повторный запуск
+// в тот же день его не повторит. Напоминание не отправляется, если за день уже есть траты
+func (s *Service) ProcessNotifications(ctx context.Context, now time.Time) ([]*NotificationDTO, error) {
+	list, err := s.nR.NotificationsGetEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*NotificationDTO, 0)
+	for _, settings := range list {
+		local := now.In(user.ParseLocation(settings.Timezone))
+		changed := false
+		for _, kind := range notification.Kinds {
+			if !settings.Due(kind, local) {
+				continue
+			}
+			n, err := s.buildNotification(ctx, settings, kind, local)
+			if err != nil {
+				return result, err
+			}
+			settings.MarkSent(kind, local)
+			changed = true
+			if n != nil {
+				result = append(result, n)
+			}
+		}
+		if changed {
+			if err := s.nR.NotificationSave(ctx, settings); err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// buildNotification собирает уведомление kind на день local.
+// Возвращает nil, если отправлять нечего
+func (s *Service) buildNotification(ctx context.Context, settings *notification.Settings, kind string, local time.Time) (*NotificationDTO, error) {
+	telegramID, err := strconv.ParseInt(settings.TelegramID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := s.userCurrency(ctx, settings.UserID)
+	if err != nil {
+		return nil, err
+	}
+	today := notification.Day(local)
+	n := &NotificationDTO{TelegramID: telegramID, Kind: kind, Currency: cur}
+
+	switch kind {
+	case notification.KindDailyReminder:
+		expenses, err := s.eR.GetExpensesByDate(ctx, settings.UserID, today, today)
+		if err != nil {
+			return nil, err
+		}
+		if len(expenses) > 0 {
+			return nil, nil
+		}
+		n.StartDate, n.EndDate = today, today
+	case notification.KindWeeklyDigest:
+		// Прошлая неделя: с понедельника по воскресенье
+		n.StartDate = today.AddDate(0, 0, -7)
+		n.EndDate = today.AddDate(0, 0, -1)
+		if n.Days, err = s.GetExpenses(ctx, telegramID, n.StartDate, n.EndDate); err != nil {
+			return nil, err
+		}
+		for _, d := range n.Days {
+			n.Spent = n.Spent.Add(d.Amount)
+		}
+	case notification.KindMonthlyReport:
+		// Прошлый месяц целиком
+		n.StartDate = today.AddDate(0, -1, 0)
+		n.EndDate = today.AddDate(0, 0, -1)
+		if err := s.fillMonthlyReport(ctx, settings.UserID, n); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, notification.ErrUnknownKind
+	}
+	return n, nil
+}
+
+// fillMonthlyReport заполняет итоги месяца: траты, доходы и крупнейшие категории
+func (s *Service) fillMonthlyReport(ctx context.Context, userID uuid.UUID, n *NotificationDTO) error {
+	expenses, err := s.eR.GetExpensesByDate(ctx, userID, n.StartDate, n.EndDate)
+	if err != nil {
+		return err
+	}
+	dtos, err := s.expensesToDTO(ctx, expenses)
+	if err != nil {
+		return err
+	}
+	if err := s.convertExpenses(ctx, dtos, n.Currency); err != nil {
+		return err
+	}
+
+	byCategory := make(map[string]*CategorySpendingDTO)
+	for _, e := range allocations(dtos) {
+		n.Spent = n.Spent.Add(e.BaseAmount)
+		c, ok := byCategory[e.Category]
+		if !ok {
+			c = &CategorySpendingDTO{Category: e.Category, CategoryIcon: e.CategoryIcon}
+			byCategory[e.Category] = c
+		}
+		c.Amount = c.Amount.Add(e.BaseAmount)
+	}
+	for _, c := range byCategory {
+		n.Categories = append(n.Categories, c)
+	}
+	sort.Slice(n.Categories, func(i, j int) bool {
+		return n.Categories[i].Amount.GreaterThan(n.Categories[j].Amount)
+	})
+	if len(n.Categories) > topCategoriesInReport {
+		n.Categories = n.Categories[:topCategoriesInReport]
+	}
+
+	_, income, err := s.periodIncome(ctx, userID, n.Currency, n.StartDate, n.EndDate)
+	if err != nil {
+		return err
+	}
+	n.Income = income
+	return nil
+}
+
+// notificationSettings возвращает сохраненные настройки уведомлений или настройки по умолчанию
+func (s *Service) notificationSettings(ctx context.Context, userID uuid.UUID) (*notification.Settings, error) {
+	settings, err := s.nR.NotificationGet(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = notification.New(userID)
+	}
+	return settings, nil
+}
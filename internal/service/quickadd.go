@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/categories"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/SobolevTim/finance_bot/internal/pkg/quickadd"
+)
+
+// fallbackCategory - категория для быстрой записи, если ни одно слово не совпало
+const fallbackCategory = "Прочее"
+
+// ParseQuickExpense разбирает быструю запись траты из сообщения пользователя.
+//
+// Первое слово, совпавшее с категорией (по названию, ключевому слову или псевдониму),
+// становится категорией, остальные слова - примечанием.
+// Возвращает запись на шаге "confirm" или quickadd.ErrNoAmount, если сообщение не похоже на трату
+func (s *Service) ParseQuickExpense(ctx context.Context, telegramID int64, text string) (*ExpenseEntryDTO, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	parsed, err := quickadd.Parse(text, s.today(u))
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.getUserCategories(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := s.cR.CategoriesAliasGetForUser(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	category := fallbackCategory
+	note := make([]string, 0, len(parsed.Words))
+	matched := false
+	for _, w := range parsed.Words {
+		if !matched {
+			if c := categories.MatchWord(w, list, aliases); c != nil {
+				category = c.Name
+				matched = true
+				continue
+			}
+		}
+		note = append(note, w)
+	}
+
+	cur := parsed.Currency
+	if cur == "" {
+		if cur, err = s.userCurrency(ctx, u.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &ExpenseEntryDTO{
+		Date:     parsed.Date,
+		Amount:   parsed.Amount,
+		Currency: cur,
+		Category: category,
+		Note:     strings.Join(note, " "),
+		Step:     "confirm",
+	}, nil
+}
+
+// SetCategoryAlias сохраняет слово как псевдоним категории для быстрой записи
+func (s *Service) SetCategoryAlias(ctx context.Context, telegramID int64, alias, category string) (*categories.Categories, error) {
+	alias = categories.NormalizeAlias(alias)
+	if alias == "" {
+		return nil, categories.ErrEmptyAlias
+	}
+
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+	list, err := s.getUserCategories(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Название категории сравнивается без учета регистра
+	for _, c := range list {
+		if strings.EqualFold(c.Name, strings.TrimSpace(category)) {
+			if err := s.cR.CategoriesAliasSet(ctx, u.ID, c.ID, alias); err != nil {
+				return nil, err
+			}
+			return c, nil
+		}
+	}
+	return nil, categories.ErrCategoryNotFound
+}
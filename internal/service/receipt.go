@@ -0,0 +1,79 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/SobolevTim/finance_bot/internal/pkg/clock"
+	"github.com/SobolevTim/finance_bot/internal/pkg/qr"
+	"github.com/SobolevTim/finance_bot/internal/pkg/receipt"
+)
+
+// receiptCurrency - валюта кассовых чеков ФНС
+const receiptCurrency = "RUB"
+
+// ReceiptEntryDTO - фискальные реквизиты чека в диалоге записи
+type ReceiptEntryDTO struct {
+	FN string // Номер фискального накопителя
+	FD string // Номер фискального документа
+	FP string // Фискальный признак документа
+}
+
+// ScanReceipt распознает QR-код кассового чека на фото и начинает запись траты по нему (см. StartReceiptExpense).
+//
+// Возвращает qr.ErrNotFound, если кода на фото нет, и qr.ErrUnreadable, если код не читается
+func (s *Service) ScanReceipt(ctx context.Context, telegramID int64, photo []byte) (*ExpenseEntryDTO, error) {
+	img, _, err := image.Decode(bytes.NewReader(photo))
+	if err != nil {
+		return nil, fmt.Errorf("decode photo: %w", err)
+	}
+	text, err := qr.Decode(img)
+	if err != nil {
+		return nil, err
+	}
+	return s.StartReceiptExpense(ctx, telegramID, text)
+}
+
+// StartReceiptExpense начинает запись траты по строке из QR-кода кассового чека.
+//
+// Дата и сумма берутся из чека, пользователю остается выбрать категорию:
+// запись сохраняется на шаге "category". Возвращает ошибки receipt.Parse
+// и expense.ErrDuplicateReceipt, если трата по этому чеку уже записана
+func (s *Service) StartReceiptExpense(ctx context.Context, telegramID int64, text string) (*ExpenseEntryDTO, error) {
+	r, err := receipt.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+	fiscal := &expense.Receipt{FN: r.FN, FD: r.FD, FP: r.FP}
+	exists, err := s.eR.ReceiptExists(ctx, u.ID, fiscal)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, expense.ErrDuplicateReceipt
+	}
+
+	entry := &ExpenseEntryDTO{
+		Date:     clock.Date(r.Time, time.UTC),
+		Amount:   r.Sum,
+		Currency: receiptCurrency,
+		Receipt:  &ReceiptEntryDTO{FN: r.FN, FD: r.FD, FP: r.FP},
+		Step:     "category",
+	}
+	if err := s.SetExpenseStatus(ctx, telegramID, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
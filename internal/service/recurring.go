@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/SobolevTim/finance_bot/internal/pkg/clock"
+)
+
+// maxOccurrencesPerRun ограничивает число вхождений одной траты за запуск,
+// чтобы ошибка в правиле не привела к бесконечной генерации
+const maxOccurrencesPerRun = 400
+
+// ProcessRecurringExpenses создает вхождения повторяющихся трат, наступившие к моменту now.
+//
+// Пропущенные за время простоя вхождения тоже создаются. Повторный запуск
+// не создает дубликатов: вхождение на дату уникально для исходной траты.
+// Возвращает созданные вхождения для уведомления пользователей
+func (s *Service) ProcessRecurringExpenses(ctx context.Context, now time.Time) ([]*RecurringExpenseDTO, error) {
+	templates, err := s.eR.GetRecurringExpenses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	created := make([]*RecurringExpenseDTO, 0)
+	for _, t := range templates {
+		rule, err := expense.ParseRecurrenceRule(t.RecurrenceRule)
+		if err != nil {
+			// Некорректное правило не должно останавливать обработку остальных трат
+			continue
+		}
+
+		last, err := s.eR.GetLastOccurrence(ctx, t.ID)
+		if err != nil {
+			return created, err
+		}
+		u, err := s.uR.UserGetByID(ctx, t.UserID)
+		if err != nil {
+			return created, err
+		}
+		// Вхождение наступает в полночь по часовому поясу пользователя
+		today := clock.Date(now, u.Location())
+
+		var occurrences []*expense.Expense
+		for next, i := rule.Next(last), 0; !next.After(today) && i < maxOccurrencesPerRun; next, i = rule.Next(next), i+1 {
+			o := t.NewOccurrence(next)
+			balance, err := s.accountCharge(ctx, o, false)
+			if errors.Is(err, currency.ErrRateNotFound) {
+				// Без курса к валюте счета вхождение создается при следующем запуске,
+				// остальные траты обрабатываются как обычно
+				break
+			}
+			if err != nil {
+				return created, err
+			}
+			ok, err := s.eR.CreateOccurrence(ctx, o, balance)
+			if err != nil {
+				return created, err
+			}
+			if !ok {
+				continue
+			}
+			occurrences = append(occurrences, o)
+		}
+		if len(occurrences) == 0 {
+			continue
+		}
+
+		telegramID, err := strconv.ParseInt(u.TelegramID, 10, 64)
+		if err != nil {
+			return created, err
+		}
+		c, err := s.cR.CategoriesGetByID(ctx, t.CategoryID)
+		if err != nil {
+			return created, err
+		}
+
+		for _, o := range occurrences {
+			created = append(created, &RecurringExpenseDTO{
+				TelegramID:   telegramID,
+				Category:     c.Name,
+				CategoryIcon: c.Icon,
+				Amount:       o.Ammount,
+				Currency:     o.Currency,
+				Date:         o.Date,
+				Recurrence:   t.RecurrenceRule,
+				Description:  o.Description,
+			})
+		}
+	}
+
+	return created, nil
+}
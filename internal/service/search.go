@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/categories"
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+)
+
+// SearchResultDTO - страница результатов поиска трат
+type SearchResultDTO struct {
+	Query    string        // Запрос поиска
+	Expenses []*ExpenseDTO // Траты страницы, новые - первыми
+	Total    int           // Всего найдено трат
+	Page     int           // Номер страницы с нуля
+	Pages    int           // Число страниц
+}
+
+// SearchExpenses ищет траты по запросу query (см. expense.ParseSearchQuery) и возвращает страницу page.
+//
+// Запрос сохраняется, чтобы листать страницы через SearchExpensesPage
+func (s *Service) SearchExpenses(ctx context.Context, telegramID int64, query string, page int) (*SearchResultDTO, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	f, err := expense.ParseSearchQuery(query, s.today(u))
+	if err != nil {
+		return nil, err
+	}
+	if f.Category != "" {
+		list, err := s.getUserCategories(ctx, u.ID)
+		if err != nil {
+			return nil, err
+		}
+		aliases, err := s.cR.CategoriesAliasGetForUser(ctx, u.ID)
+		if err != nil {
+			return nil, err
+		}
+		c := categories.MatchWord(f.Category, list, aliases)
+		if c == nil {
+			return nil, categories.ErrCategoryNotFound
+		}
+		f.CategoryID = c.ID
+	}
+	if page < 0 {
+		page = 0
+	}
+	f.Offset = page * f.Limit
+
+	expenses, total, err := s.eR.SearchExpenses(ctx, u.ID, f)
+	if err != nil {
+		return nil, err
+	}
+	dtos, err := s.expensesToDTO(ctx, expenses)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sR.SetSearchQuery(ctx, strconv.FormatInt(telegramID, 10), query); err != nil {
+		return nil, err
+	}
+
+	return &SearchResultDTO{
+		Query:    query,
+		Expenses: dtos,
+		Total:    total,
+		Page:     page,
+		Pages:    (total + f.Limit - 1) / f.Limit,
+	}, nil
+}
+
+// SearchExpensesPage повторяет последний запрос поиска и возвращает страницу page.
+//
+// Если запрос не сохранен или срок его хранения истек, возвращает expense.ErrEmptySearch
+func (s *Service) SearchExpensesPage(ctx context.Context, telegramID int64, page int) (*SearchResultDTO, error) {
+	query, err := s.sR.GetSearchQuery(ctx, strconv.FormatInt(telegramID, 10))
+	if err != nil {
+		return nil, err
+	}
+	if query == "" {
+		return nil, expense.ErrEmptySearch
+	}
+	return s.SearchExpenses(ctx, telegramID, query, page)
+}
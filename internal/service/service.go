@@ -3,41 +3,150 @@ package service
 import (
 	"time"
 
+	"github.com/SobolevTim/finance_bot/internal/domain/account"
 	"github.com/SobolevTim/finance_bot/internal/domain/budget"
 	"github.com/SobolevTim/finance_bot/internal/domain/categories"
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
 	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/SobolevTim/finance_bot/internal/domain/goal"
+	"github.com/SobolevTim/finance_bot/internal/domain/group"
+	"github.com/SobolevTim/finance_bot/internal/domain/income"
+	"github.com/SobolevTim/finance_bot/internal/domain/notification"
 	"github.com/SobolevTim/finance_bot/internal/domain/status"
 	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/SobolevTim/finance_bot/internal/pkg/clock"
+	"github.com/shopspring/decimal"
 )
 
 // Service реализует бизнес-логику budget
 type Service struct {
-	uR user.Repository
-	bR budget.Repository
-	sR status.Repository
-	eR expense.Repository
-	cR categories.Repository
+	uR  user.Repository
+	bR  budget.Repository
+	sR  status.Repository
+	eR  expense.Repository
+	cR  categories.Repository
+	gR  group.Repository
+	iR  income.Repository
+	aR  account.Repository
+	nR  notification.Repository
+	goR goal.Repository
+	rP  currency.RateProvider
+
+	clock    clock.Clock // Источник текущего времени
+	currency string      // Валюта бюджета по умолчанию
 }
 
 type ExpenseDTO struct {
-	ID           string    // ID траты
-	UserID       string    // ID пользователя
-	CategoryID   string    // ID категории
-	Category     string    // Категория
-	CategoryIcon string    // Иконка категории
-	Amount       float64   // Сумма
-	Date         time.Time // Дата
-	IsRecurring  bool      // Повторяющаяся
-	Recurrence   string    // Периодичность
-	Description  string    // Описание
+	ID           string          // ID траты
+	UserID       string          // ID пользователя
+	CategoryID   string          // ID категории
+	Category     string          // Категория
+	CategoryIcon string          // Иконка категории
+	Amount       decimal.Decimal // Сумма
+	Currency     string          // Валюта траты
+	BaseAmount   decimal.Decimal // Сумма в валюте бюджета
+	Date         time.Time       // Дата
+	IsRecurring  bool            // Повторяющаяся
+	Recurrence   string          // Периодичность
+	Description  string          // Описание
+	AuthorID     string          // ID пользователя, записавшего трату, пустой - неизвестен
+	AccountID    string          // ID счета, с которого оплачена трата, пустой - не указан
+	Splits       []*SplitDTO     // Части по категориям, пустой - трата не распределена
+}
+
+// AuthorDTO - пользователь Telegram, выполняющий действие.
+//
+// В личном чате совпадает с владельцем бюджета, в групповом - один из участников
+type AuthorDTO struct {
+	TelegramID int64  // ID пользователя Telegram
+	UserName   string // Имя пользователя в Telegram
+	FirstName  string // Имя
+	LastName   string // Фамилия
+}
+
+// MemberSpendingDTO - траты участника группы за период
+type MemberSpendingDTO struct {
+	Member string          // Имя участника
+	Role   string          // Роль участника, пустая - автор не состоит в группе
+	Amount decimal.Decimal // Сумма в валюте бюджета
+	Count  int             // Количество трат
+}
+
+// CategoryLimitDTO содержит траты и лимит по категории за текущий период
+type CategoryLimitDTO struct {
+	CategoryID   string          // ID категории
+	Category     string          // Категория
+	CategoryIcon string          // Иконка категории
+	Limit        decimal.Decimal // Лимит
+	Spent        decimal.Decimal // Потрачено
+	Err          error           // budget.ErrCategoryLimitExceeded, если лимит превышен
+}
+
+// RecurringExpenseDTO - созданное вхождение повторяющейся траты
+type RecurringExpenseDTO struct {
+	TelegramID   int64           // ID чата пользователя
+	Category     string          // Категория
+	CategoryIcon string          // Иконка категории
+	Amount       decimal.Decimal // Сумма
+	Currency     string          // Валюта
+	Date         time.Time       // Дата вхождения
+	Recurrence   string          // Правило повторения
+	Description  string          // Описание
+}
+
+// TransferDTO - перевод между счетами
+type TransferDTO struct {
+	From         string          // Название счета списания
+	To           string          // Название счета зачисления
+	Amount       decimal.Decimal // Сумма списания
+	FromCurrency string          // Валюта счета списания
+	ToAmount     decimal.Decimal // Сумма зачисления
+	ToCurrency   string          // Валюта счета зачисления
+}
+
+// ImportRowDTO - строка выписки, подготовленная к импорту
+type ImportRowDTO struct {
+	Date        time.Time       // Дата
+	Amount      decimal.Decimal // Сумма
+	Currency    string          // Валюта
+	Category    string          // Категория, подобранная по описанию
+	Description string          // Описание
+}
+
+// ImportPreviewDTO - предпросмотр импорта выписки
+type ImportPreviewDTO struct {
+	FileName        string          // Имя файла
+	Header          []string        // Заголовок таблицы
+	Date            int             // Номер колонки с датой, -1 - не задана
+	Amount          int             // Номер колонки с суммой, -1 - не задана
+	Description     int             // Номер колонки с описанием, -1 - не задана
+	MappingDetected bool            // Удалось ли сопоставить колонки
+	Rows            []*ImportRowDTO // Первые строки для предпросмотра
+	Total           int             // Количество новых трат
+	Duplicates      int             // Количество уже записанных трат
+	Skipped         int             // Количество пропущенных строк (доходы и ошибки разбора)
+}
+
+// ImportResultDTO - результат импорта выписки
+type ImportResultDTO struct {
+	Imported   int // Количество записанных трат
+	Duplicates int // Количество уже записанных трат
+	Skipped    int // Количество пропущенных строк
 }
 
 type ExpenseEntryDTO struct {
-	Date     time.Time
-	Amount   float64
-	Category string
-	Note     string
-	Step     string // Текущий шаг: "date", "date_input", "amount", "category", "note", "note_input", "confirm"
+	ExpenseID  string // ID редактируемой траты, пустой при записи новой
+	Date       time.Time
+	Amount     decimal.Decimal
+	Currency   string // Валюта траты, пустая - валюта бюджета
+	Category   string
+	Note       string
+	Recurrence string           // Правило повторения, пустое для разовой траты
+	AccountID  string           // ID счета, с которого оплачена трата, пустой - не указан
+	Account    string           // Название счета для подтверждения
+	Splits     []*SplitEntryDTO // Распределение по категориям, пустое - вся трата в Category
+	Receipt    *ReceiptEntryDTO // Кассовый чек, с которого заполнена трата, nil - трата вводится вручную
+	Step       string           // Текущий шаг: "date", "date_input", "amount", "category", "note", "note_input", "recurring", "account", "confirm", "split", "edit", "edit_amount", "edit_date", "edit_note", "edit_category"
 }
 
 func NewService(userRepo user.Repository,
@@ -45,12 +154,27 @@ func NewService(userRepo user.Repository,
 	statusRepo status.Repository,
 	expenseRepo expense.Repository,
 	categoriesRepo categories.Repository,
+	groupRepo group.Repository,
+	incomeRepo income.Repository,
+	accountRepo account.Repository,
+	notificationRepo notification.Repository,
+	goalRepo goal.Repository,
+	rateProvider currency.RateProvider,
+	defaultCurrency string,
 ) *Service {
 	return &Service{
-		uR: userRepo,
-		bR: budgetRepo,
-		sR: statusRepo,
-		eR: expenseRepo,
-		cR: categoriesRepo,
+		uR:       userRepo,
+		bR:       budgetRepo,
+		sR:       statusRepo,
+		eR:       expenseRepo,
+		cR:       categoriesRepo,
+		gR:       groupRepo,
+		iR:       incomeRepo,
+		aR:       accountRepo,
+		nR:       notificationRepo,
+		goR:      goalRepo,
+		rP:       rateProvider,
+		clock:    clock.System(),
+		currency: defaultCurrency,
 	}
 }
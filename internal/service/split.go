@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/categories"
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/SobolevTim/finance_bot/internal/pkg/calc"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ErrSplitFormat - текст распределения не разобран: нужна категория и сумма в каждой части
+var ErrSplitFormat = errors.New("invalid split format")
+
+// splitSeparator разделяет части распределения: ";", перевод строки или запятая с пробелом.
+// Запятая без пробела остается десятичной: "Еда 1200,50, Одежда 800"
+var splitSeparator = regexp.MustCompile(`[;\n]|,\s+`)
+
+// SplitEntryDTO - часть траты в диалоге записи
+type SplitEntryDTO struct {
+	Category string          // Название категории
+	Amount   decimal.Decimal // Сумма в валюте траты
+}
+
+// SplitDTO - часть распределенной траты
+type SplitDTO struct {
+	CategoryID   string          // ID категории
+	Category     string          // Категория
+	CategoryIcon string          // Иконка категории
+	Amount       decimal.Decimal // Сумма в валюте траты
+	BaseAmount   decimal.Decimal // Сумма в валюте бюджета
+}
+
+// PlanSplit разбирает распределение траты из диалога записи по категориям: "Хозтовары 500, Одежда 800".
+//
+// Сумма части может быть выражением. Одну часть можно указать без суммы - ей достанется остаток.
+// Если категория траты entry.Category не упомянута, остаток достается ей.
+// Сумма частей должна совпасть с суммой траты, иначе возвращается expense.ErrSplitSumMismatch.
+// Результат записывается в entry.Splits, категорией записи становится самая крупная часть
+func (s *Service) PlanSplit(ctx context.Context, telegramID int64, entry *ExpenseEntryDTO, text string) error {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return user.ErrUserNotFound
+	}
+
+	list, err := s.getUserCategories(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	aliases, err := s.cR.CategoriesAliasGetForUser(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+
+	total := currency.Round(entry.Amount, entry.Currency)
+	var parts []*SplitEntryDTO
+	var rest *SplitEntryDTO // Часть без суммы
+	mentioned := make(map[string]bool)
+	sum := decimal.Zero
+	for _, raw := range splitSeparator.Split(text, -1) {
+		fields := strings.Fields(raw)
+		if len(fields) == 0 {
+			continue
+		}
+
+		name := strings.Join(fields, " ")
+		var amount decimal.Decimal
+		hasAmount := false
+		if len(fields) > 1 && calc.IsExpression(fields[len(fields)-1]) {
+			if amount, err = calc.Calculate(fields[len(fields)-1]); err != nil {
+				return err
+			}
+			name, hasAmount = strings.Join(fields[:len(fields)-1], " "), true
+		}
+
+		c := categories.MatchWord(name, list, aliases)
+		if c == nil {
+			return categories.ErrCategoryNotFound
+		}
+		part := &SplitEntryDTO{Category: c.Name, Amount: amount}
+		if !hasAmount {
+			if rest != nil {
+				return ErrSplitFormat
+			}
+			rest = part
+		}
+		mentioned[c.Name] = true
+		sum = sum.Add(currency.Round(amount, entry.Currency))
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return ErrSplitFormat
+	}
+
+	switch {
+	case rest != nil:
+		rest.Amount = total.Sub(sum)
+	case !mentioned[entry.Category] && total.GreaterThan(sum):
+		parts = append([]*SplitEntryDTO{{Category: entry.Category, Amount: total.Sub(sum)}}, parts...)
+	}
+
+	// Проверка правил распределения на черновике траты
+	draft := &expense.Expense{Ammount: total, Currency: entry.Currency}
+	splits := make([]*expense.Split, 0, len(parts))
+	byID := make(map[uuid.UUID]string, len(parts))
+	for _, p := range parts {
+		c := categories.MatchWord(p.Category, list, aliases)
+		splits = append(splits, &expense.Split{CategoryID: c.ID, Amount: p.Amount})
+		byID[c.ID] = c.Name
+	}
+	if err := draft.SetSplits(splits); err != nil {
+		return err
+	}
+
+	entry.Splits = make([]*SplitEntryDTO, 0, len(draft.Splits))
+	for _, split := range draft.Splits {
+		entry.Splits = append(entry.Splits, &SplitEntryDTO{Category: byID[split.CategoryID], Amount: split.Amount})
+	}
+	entry.Category = byID[draft.CategoryID]
+	return nil
+}
+
+// setExpenseSplits распределяет трату e по частям из диалога записи
+func (s *Service) setExpenseSplits(ctx context.Context, e *expense.Expense, parts []*SplitEntryDTO) error {
+	splits := make([]*expense.Split, 0, len(parts))
+	for _, p := range parts {
+		c, err := s.cR.CategoriesGetByName(ctx, e.UserID, p.Category)
+		if err != nil {
+			return err
+		}
+		splits = append(splits, &expense.Split{CategoryID: c.ID, Amount: p.Amount})
+	}
+	return e.SetSplits(splits)
+}
+
+// allocations разворачивает распределенные траты в части по категориям.
+//
+// Часть получает копию траты с категорией и суммами части, остальные траты возвращаются как есть.
+// Отчеты и лимиты по категориям считаются по результату
+func allocations(expenses []*ExpenseDTO) []*ExpenseDTO {
+	result := make([]*ExpenseDTO, 0, len(expenses))
+	for _, e := range expenses {
+		if len(e.Splits) == 0 {
+			result = append(result, e)
+			continue
+		}
+		for _, split := range e.Splits {
+			part := *e
+			part.CategoryID, part.Category, part.CategoryIcon = split.CategoryID, split.Category, split.CategoryIcon
+			part.Amount, part.BaseAmount = split.Amount, split.BaseAmount
+			part.Splits = nil
+			result = append(result, &part)
+		}
+	}
+	return result
+}
+
+// splitBaseAmounts делит сумму траты в валюте бюджета между частями пропорционально их суммам.
+// Последняя часть получает остаток, чтобы сумма частей совпала с суммой траты
+func splitBaseAmounts(e *ExpenseDTO, cur string) {
+	if len(e.Splits) == 0 {
+		return
+	}
+	rest := e.BaseAmount
+	for i, split := range e.Splits {
+		if i == len(e.Splits)-1 {
+			split.BaseAmount = rest
+			continue
+		}
+		split.BaseAmount = currency.Round(e.BaseAmount.Mul(split.Amount).Div(e.Amount), cur)
+		rest = rest.Sub(split.BaseAmount)
+	}
+}
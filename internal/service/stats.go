@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/SobolevTim/finance_bot/internal/pkg/chart"
+	"github.com/SobolevTim/finance_bot/internal/pkg/clock"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Виды диаграмм отчета
+const (
+	ChartCategories = "categories" // Траты по категориям
+	ChartDaily      = "daily"      // Траты по дням против среднего на день
+	ChartTrend      = "trend"      // Траты по месяцам
+)
+
+// trendMonths - число месяцев на диаграмме трат по месяцам, включая текущий
+const trendMonths = 6
+
+// OtherCategory - название категории, в которую объединяются мелкие категории на диаграмме
+const OtherCategory = "Прочее"
+
+// DaySpendingDTO - траты за день или месяц
+type DaySpendingDTO struct {
+	Date   time.Time       // День или первое число месяца
+	Amount decimal.Decimal // Сумма в валюте бюджета
+}
+
+// StatsDTO - данные для диаграмм отчета по текущему периоду бюджета
+type StatsDTO struct {
+	StartDate  time.Time
+	EndDate    time.Time
+	Currency   string                 // Валюта бюджета
+	Total      decimal.Decimal        // Потрачено за период
+	Allowance  decimal.Decimal        // Бюджет на день, 0 - бюджет не установлен
+	Categories []*CategorySpendingDTO // По убыванию суммы, не больше len(chart.Palette)
+	Daily      []*DaySpendingDTO      // Каждый день периода
+	Trend      []*DaySpendingDTO      // Последние trendMonths месяцев по порядку
+}
+
+// GetStats собирает данные для диаграмм: траты текущего периода по категориям и дням,
+// а также траты по месяцам за последние trendMonths месяцев
+func (s *Service) GetStats(ctx context.Context, telegramID int64) (*StatsDTO, error) {
+	u, err := s.GetUserByTelegramID(ctx, telegramID)
+	if err != nil || u == nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	stats := &StatsDTO{}
+	b, err := s.userBudget(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if b != nil {
+		stats.StartDate, stats.EndDate, stats.Currency = b.StartDate, b.EndDate, b.Currency
+	} else {
+		stats.StartDate, stats.EndDate = clock.Month(s.today(u))
+		if stats.Currency, err = s.userCurrency(ctx, u.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Траты периода по категориям и дням
+	expenses, err := s.convertedExpenses(ctx, u.ID, stats.StartDate, stats.EndDate, stats.Currency)
+	if err != nil {
+		return nil, err
+	}
+	days := int(stats.EndDate.Sub(stats.StartDate).Hours()/24) + 1
+	stats.Daily = make([]*DaySpendingDTO, days)
+	for i := range stats.Daily {
+		stats.Daily[i] = &DaySpendingDTO{Date: stats.StartDate.AddDate(0, 0, i)}
+	}
+	byCategory := make(map[string]*CategorySpendingDTO)
+	for _, e := range allocations(expenses) {
+		stats.Total = stats.Total.Add(e.BaseAmount)
+		if i := int(e.Date.Sub(stats.StartDate).Hours() / 24); i >= 0 && i < days {
+			stats.Daily[i].Amount = stats.Daily[i].Amount.Add(e.BaseAmount)
+		}
+		c, ok := byCategory[e.Category]
+		if !ok {
+			c = &CategorySpendingDTO{Category: e.Category, CategoryIcon: e.CategoryIcon}
+			byCategory[e.Category] = c
+		}
+		c.Amount = c.Amount.Add(e.BaseAmount)
+	}
+	stats.Categories = topCategories(byCategory, len(chart.Palette))
+	if b != nil {
+		stats.Allowance = b.Total().Div(decimal.NewFromInt(int64(days)))
+	}
+
+	// Траты по месяцам
+	first, _ := clock.Month(s.today(u))
+	first = first.AddDate(0, -(trendMonths - 1), 0)
+	_, last := clock.Month(s.today(u))
+	expenses, err = s.convertedExpenses(ctx, u.ID, first, last, stats.Currency)
+	if err != nil {
+		return nil, err
+	}
+	stats.Trend = make([]*DaySpendingDTO, trendMonths)
+	for i := range stats.Trend {
+		stats.Trend[i] = &DaySpendingDTO{Date: first.AddDate(0, i, 0)}
+	}
+	for _, e := range expenses {
+		i := (e.Date.Year()-first.Year())*12 + int(e.Date.Month()-first.Month())
+		if i >= 0 && i < trendMonths {
+			stats.Trend[i].Amount = stats.Trend[i].Amount.Add(e.BaseAmount)
+		}
+	}
+	return stats, nil
+}
+
+// RenderStatsChart рисует PNG-диаграмму вида kind по данным stats.
+//
+// Цвет категории i на диаграмме ChartCategories совпадает с chart.Marks[i].
+// Если тратить нечего, возвращает chart.ErrNoData
+func (s *Service) RenderStatsChart(stats *StatsDTO, kind string) ([]byte, error) {
+	switch kind {
+	case ChartCategories:
+		values := make([]float64, 0, len(stats.Categories))
+		for _, c := range stats.Categories {
+			values = append(values, c.Amount.InexactFloat64())
+		}
+		return chart.Pie(values)
+	case ChartDaily:
+		values := make([]float64, 0, len(stats.Daily))
+		labels := make([]string, 0, len(stats.Daily))
+		for _, d := range stats.Daily {
+			values = append(values, d.Amount.InexactFloat64())
+			labels = append(labels, fmt.Sprint(d.Date.Day()))
+		}
+		return chart.Bars(values, labels, stats.Allowance.InexactFloat64())
+	case ChartTrend:
+		values := make([]float64, 0, len(stats.Trend))
+		labels := make([]string, 0, len(stats.Trend))
+		for _, m := range stats.Trend {
+			values = append(values, m.Amount.InexactFloat64())
+			labels = append(labels, m.Date.Format("01.06"))
+		}
+		return chart.Trend(values, labels)
+	default:
+		return nil, fmt.Errorf("unsupported chart %q", kind)
+	}
+}
+
+// convertedExpenses возвращает траты пользователя за период с суммами в валюте cur
+func (s *Service) convertedExpenses(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time, cur string) ([]*ExpenseDTO, error) {
+	expenses, err := s.eR.GetExpensesByDate(ctx, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	dtos, err := s.expensesToDTO(ctx, expenses)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.convertExpenses(ctx, dtos, cur); err != nil {
+		return nil, err
+	}
+	return dtos, nil
+}
+
+// topCategories сортирует категории по убыванию суммы и объединяет все после limit-1 в OtherCategory
+func topCategories(byCategory map[string]*CategorySpendingDTO, limit int) []*CategorySpendingDTO {
+	categories := make([]*CategorySpendingDTO, 0, len(byCategory))
+	for _, c := range byCategory {
+		categories = append(categories, c)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Amount.Equal(categories[j].Amount) {
+			return categories[i].Category < categories[j].Category
+		}
+		return categories[i].Amount.GreaterThan(categories[j].Amount)
+	})
+	if len(categories) <= limit {
+		return categories
+	}
+
+	other := &CategorySpendingDTO{Category: OtherCategory, CategoryIcon: "📦"}
+	for _, c := range categories[limit-1:] {
+		other.Amount = other.Amount.Add(c.Amount)
+	}
+	return append(categories[:limit-1], other)
+}
@@ -39,11 +39,20 @@ func (s *Service) SetExpenseStatus(ctx context.Context, id int64, expenseEntryDT
 	tgID := strconv.FormatInt(id, 10)
 
 	expenseEntry := &status.ExpenseEntry{
-		Date:     expenseEntryDTO.Date,
-		Amount:   expenseEntryDTO.Amount,
-		Category: expenseEntryDTO.Category,
-		Note:     expenseEntryDTO.Note,
-		Step:     expenseEntryDTO.Step,
+		ExpenseID:  expenseEntryDTO.ExpenseID,
+		Date:       expenseEntryDTO.Date,
+		Amount:     expenseEntryDTO.Amount,
+		Currency:   expenseEntryDTO.Currency,
+		Category:   expenseEntryDTO.Category,
+		Note:       expenseEntryDTO.Note,
+		Recurrence: expenseEntryDTO.Recurrence,
+		Step:       expenseEntryDTO.Step,
+	}
+	for _, split := range expenseEntryDTO.Splits {
+		expenseEntry.Splits = append(expenseEntry.Splits, status.SplitEntry{Category: split.Category, Amount: split.Amount})
+	}
+	if r := expenseEntryDTO.Receipt; r != nil {
+		expenseEntry.Receipt = &status.ReceiptEntry{FN: r.FN, FD: r.FD, FP: r.FP}
 	}
 
 	err := s.sR.SetExpenseStatus(ctx, tgID, expenseEntry)
@@ -67,11 +76,20 @@ func (s *Service) GetExpenseStatus(ctx context.Context, id int64) (*ExpenseEntry
 	}
 
 	expenseEntryDTO := &ExpenseEntryDTO{
-		Date:     expenseEntry.Date,
-		Amount:   expenseEntry.Amount,
-		Category: expenseEntry.Category,
-		Note:     expenseEntry.Note,
-		Step:     expenseEntry.Step,
+		ExpenseID:  expenseEntry.ExpenseID,
+		Date:       expenseEntry.Date,
+		Amount:     expenseEntry.Amount,
+		Currency:   expenseEntry.Currency,
+		Category:   expenseEntry.Category,
+		Note:       expenseEntry.Note,
+		Recurrence: expenseEntry.Recurrence,
+		Step:       expenseEntry.Step,
+	}
+	for _, split := range expenseEntry.Splits {
+		expenseEntryDTO.Splits = append(expenseEntryDTO.Splits, &SplitEntryDTO{Category: split.Category, Amount: split.Amount})
+	}
+	if r := expenseEntry.Receipt; r != nil {
+		expenseEntryDTO.Receipt = &ReceiptEntryDTO{FN: r.FN, FD: r.FD, FP: r.FP}
 	}
 
 	return expenseEntryDTO, nil
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_expenses_recurring;
+DROP INDEX IF EXISTS idx_expenses_parent_date;
+ALTER TABLE expenses DROP COLUMN IF EXISTS last_occurrence_date;
+ALTER TABLE expenses DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,9 @@
+-- Связь вхождений повторяющихся расходов с исходным расходом
+ALTER TABLE expenses ADD COLUMN parent_id UUID REFERENCES expenses(id) ON DELETE SET NULL;
+
+-- Дата последнего созданного вхождения: удаление или перенос вхождения не приводит к его повторному созданию
+ALTER TABLE expenses ADD COLUMN last_occurrence_date TIMESTAMPTZ;
+
+-- Одно вхождение на дату защищает от повторного создания после простоя
+CREATE UNIQUE INDEX idx_expenses_parent_date ON expenses(parent_id, date);
+CREATE INDEX idx_expenses_recurring ON expenses(is_recurring) WHERE is_recurring = true;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS exchange_rates;
+ALTER TABLE expenses DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,11 @@
+-- Валюта траты (ISO 4217), существующие траты записаны в рублях
+ALTER TABLE expenses ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'RUB';
+
+-- Курсы валют: сколько единиц base стоит одна единица currency на дату
+CREATE TABLE exchange_rates (
+    base VARCHAR(3) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    date DATE NOT NULL,
+    rate NUMERIC(18, 8) NOT NULL CHECK (rate > 0),
+    PRIMARY KEY (base, currency, date)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE expenses DROP COLUMN IF EXISTS author_id;
+DROP TABLE IF EXISTS group_members;
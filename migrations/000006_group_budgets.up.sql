@@ -0,0 +1,12 @@
+-- Участники общего бюджета группового чата.
+-- group_id - пользователь группового чата, user_id - участник
+CREATE TABLE group_members (
+    group_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'admin', 'member')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (group_id, user_id)
+);
+
+-- Пользователь, записавший трату. NULL - трата записана до появления групп
+ALTER TABLE expenses ADD COLUMN author_id UUID REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE budgets DROP COLUMN IF EXISTS from_income;
+DROP TABLE IF EXISTS incomes;
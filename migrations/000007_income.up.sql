@@ -0,0 +1,17 @@
+-- Доходы пользователя
+CREATE TABLE incomes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    amount NUMERIC(15,2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'RUB',
+    date DATE NOT NULL,
+    source VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_incomes_user_date ON incomes(user_id, date);
+
+-- Бюджет равен сумме доходов за месяц и пересчитывается при записи дохода
+ALTER TABLE budgets ADD COLUMN from_income BOOLEAN NOT NULL DEFAULT false;
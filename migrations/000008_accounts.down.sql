@@ -0,0 +1,4 @@
+ALTER TABLE expenses DROP CONSTRAINT IF EXISTS expenses_account_id_fkey;
+ALTER TABLE expenses RENAME COLUMN account_id TO card_id;
+DROP TABLE IF EXISTS transfers;
+DROP TABLE IF EXISTS accounts;
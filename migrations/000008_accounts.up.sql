@@ -0,0 +1,32 @@
+-- Счета пользователя: наличные, карты, накопительные счета
+CREATE TABLE accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(16) NOT NULL CHECK (type IN ('cash', 'debit', 'credit', 'savings')),
+    currency VARCHAR(3) NOT NULL DEFAULT 'RUB',
+    balance NUMERIC(15,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_accounts_user ON accounts(user_id);
+
+-- Переводы между счетами, не учитываются в тратах
+CREATE TABLE transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    to_account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    amount NUMERIC(15,2) NOT NULL CHECK (amount > 0),
+    to_amount NUMERIC(15,2) NOT NULL CHECK (to_amount > 0),
+    date TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (from_account_id <> to_account_id)
+);
+
+CREATE INDEX idx_transfers_user_date ON transfers(user_id, date);
+
+-- Счет, с которого оплачена трата. NULL - счет не указан
+ALTER TABLE expenses RENAME COLUMN card_id TO account_id;
+ALTER TABLE expenses ADD CONSTRAINT expenses_account_id_fkey FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE SET NULL;
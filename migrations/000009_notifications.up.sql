@@ -0,0 +1,12 @@
+-- Настройки уведомлений пользователя. Нет строки - все уведомления выключены
+CREATE TABLE notification_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    daily_reminder BOOLEAN NOT NULL DEFAULT false,
+    weekly_digest BOOLEAN NOT NULL DEFAULT false,
+    monthly_report BOOLEAN NOT NULL DEFAULT false,
+    -- Дни последней отправки в часовом поясе пользователя
+    reminder_sent_on DATE,
+    digest_sent_on DATE,
+    report_sent_on DATE,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+ALTER TABLE notification_settings DROP COLUMN IF EXISTS budget_thresholds;
+DROP TABLE IF EXISTS budget_alerts;
@@ -0,0 +1,13 @@
+-- Пересеченные пороги трат, чтобы не повторять предупреждения.
+-- category_id = '00000000-0000-0000-0000-000000000000' - бюджет целиком.
+-- Бюджет создается на каждый период (месяц, с дня зарплаты или две недели), поэтому отметки обнуляются вместе с ним
+CREATE TABLE budget_alerts (
+    budget_id UUID NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+    category_id UUID NOT NULL,
+    threshold INTEGER NOT NULL CHECK (threshold > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (budget_id, category_id, threshold)
+);
+
+-- Пороги предупреждений в процентах. NULL - пороги по умолчанию
+ALTER TABLE notification_settings ADD COLUMN budget_thresholds INTEGER[];
@@ -0,0 +1,4 @@
+ALTER TABLE budgets DROP COLUMN IF EXISTS carry_over;
+ALTER TABLE budgets DROP COLUMN IF EXISTS rollover;
+ALTER TABLE budgets DROP COLUMN IF EXISTS start_day;
+ALTER TABLE budgets DROP COLUMN IF EXISTS period;
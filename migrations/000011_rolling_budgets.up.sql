@@ -0,0 +1,8 @@
+-- Период бюджета: месяц с дня start_day или две недели.
+-- Бюджет следующего периода создается по шаблону последнего
+ALTER TABLE budgets ADD COLUMN period VARCHAR(16) NOT NULL DEFAULT 'monthly';
+ALTER TABLE budgets ADD COLUMN start_day SMALLINT NOT NULL DEFAULT 1 CHECK (start_day BETWEEN 1 AND 28);
+
+-- Перенос остатка: carry_over - остаток прошлого периода, отрицательный при перерасходе
+ALTER TABLE budgets ADD COLUMN rollover BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE budgets ADD COLUMN carry_over NUMERIC(15,2) NOT NULL DEFAULT 0;
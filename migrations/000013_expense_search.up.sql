@@ -0,0 +1,3 @@
+-- Полнотекстовый поиск по описанию трат с русской морфологией.
+-- Выражение должно совпадать с условием в SearchExpenses
+CREATE INDEX idx_expenses_description_fts ON expenses USING GIN (to_tsvector('russian', coalesce(description, '')));
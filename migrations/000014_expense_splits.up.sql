@@ -0,0 +1,10 @@
+-- Распределение трат по категориям: части одной покупки в разных категориях.
+-- Сумма частей равна сумме траты, отчеты и лимиты по категориям считаются по частям
+CREATE TABLE expense_splits (
+    expense_id UUID NOT NULL REFERENCES expenses(id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE RESTRICT,
+    amount NUMERIC(15,2) NOT NULL CHECK (amount > 0),
+    PRIMARY KEY (expense_id, category_id)
+);
+
+CREATE INDEX idx_expense_splits_category ON expense_splits(category_id);
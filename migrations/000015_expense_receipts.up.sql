@@ -0,0 +1,11 @@
+-- Кассовые чеки, по которым записаны траты. Номер фискального накопителя
+-- и номер документа определяют чек, поэтому один чек записывается один раз
+CREATE TABLE expense_receipts (
+    expense_id UUID PRIMARY KEY REFERENCES expenses(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    fn VARCHAR(32) NOT NULL,
+    fd VARCHAR(16) NOT NULL,
+    fp VARCHAR(16) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, fn, fd)
+);
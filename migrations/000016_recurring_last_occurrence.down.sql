@@ -0,0 +1,2 @@
+-- Колонка last_occurrence_date принадлежит миграции 000003 и удаляется при ее откате
+SELECT 1;
@@ -0,0 +1,9 @@
+-- Дата последнего созданного вхождения для баз, где миграция 000003 применена до появления колонки.
+-- Для уже созданных вхождений берется самое позднее из сохранившихся
+ALTER TABLE expenses ADD COLUMN IF NOT EXISTS last_occurrence_date TIMESTAMPTZ;
+
+UPDATE expenses e
+SET last_occurrence_date = o.last_date
+FROM (SELECT parent_id, MAX(date) AS last_date FROM expenses WHERE parent_id IS NOT NULL GROUP BY parent_id) o
+WHERE e.id = o.parent_id
+    AND (e.last_occurrence_date IS NULL OR e.last_occurrence_date < o.last_date);
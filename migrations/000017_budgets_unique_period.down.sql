@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_budgets_user_start;
@@ -0,0 +1,23 @@
+-- Один бюджет на период пользователя: перенос периода из задачи и из обработчика чата
+-- может выполняться одновременно.
+-- Дубликаты, созданные до появления ограничения, не удаляются автоматически: у них могут
+-- быть разные суммы и лимиты. Миграция прерывается со списком дубликатов, их нужно объединить вручную
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(format('user_id=%s start_date=%s budgets=%s', user_id, start_date, ids), E'\n')
+    INTO duplicates
+    FROM (
+        SELECT user_id, start_date, string_agg(id::text, ', ' ORDER BY created_at) AS ids
+        FROM budgets
+        GROUP BY user_id, start_date
+        HAVING COUNT(*) > 1
+    ) d;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'найдены бюджеты с одинаковым периодом, объедините их перед миграцией:%', E'\n' || duplicates;
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX idx_budgets_user_start ON budgets(user_id, start_date);
@@ -0,0 +1,191 @@
+package telegram_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/delivery/telegram"
+	"github.com/SobolevTim/finance_bot/internal/pkg/config"
+	"github.com/mymmrac/telego"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "123456789:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+// fakeTelegram - локальный сервер Bot API, запоминающий вызванные методы
+type fakeTelegram struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	calls   map[string][]map[string]any
+	updates []json.RawMessage // Обновления, которые вернет первый вызов getUpdates
+}
+
+func newFakeTelegram(t *testing.T) *fakeTelegram {
+	f := &fakeTelegram{calls: make(map[string][]map[string]any)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := strings.TrimPrefix(r.URL.Path, "/bot"+testToken+"/")
+		body, _ := io.ReadAll(r.Body)
+		params := map[string]any{}
+		_ = json.Unmarshal(body, &params)
+
+		f.mu.Lock()
+		f.calls[method] = append(f.calls[method], params)
+		updates := f.updates
+		if method == "getUpdates" {
+			f.updates = nil
+		}
+		f.mu.Unlock()
+
+		var result any = true
+		switch method {
+		case "getUpdates":
+			if updates == nil {
+				updates = []json.RawMessage{}
+			}
+			result = updates
+		case "getMe":
+			result = map[string]any{"id": 123456789, "is_bot": true, "first_name": "test", "username": "test_bot"}
+		case "sendMessage":
+			result = map[string]any{"message_id": 1, "date": 0, "chat": map[string]any{"id": params["chat_id"], "type": "private"}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeTelegram) AddUpdate(update string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updates = append(f.updates, json.RawMessage(update))
+}
+
+func (f *fakeTelegram) Calls(method string) []map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]any(nil), f.calls[method]...)
+}
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func postUpdate(url, secret, body string) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	if secret != "" {
+		req.Header.Set(telego.WebhookSecretTokenHeader, secret)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func TestStartWebhook(t *testing.T) {
+	tg := newFakeTelegram(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	bot, err := telegram.NewBot(testToken, nil, logger, false, telego.WithAPIServer(tg.URL))
+	require.NoError(t, err)
+
+	cfg := config.WebhookConfig{
+		URL:         "https://example.com/webhook",
+		Listen:      freeAddr(t),
+		Path:        "/webhook",
+		SecretToken: "secret_token-1",
+	}
+	endpoint := "http://" + cfg.Listen + cfg.Path
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- bot.StartWebhook(ctx, cfg) }()
+
+	// Webhook регистрируется в Telegram с адресом и секретом
+	require.Eventually(t, func() bool { return len(tg.Calls("setWebhook")) == 1 }, 2*time.Second, 10*time.Millisecond)
+	set := tg.Calls("setWebhook")[0]
+	assert.Equal(t, cfg.URL, set["url"])
+	assert.Equal(t, cfg.SecretToken, set["secret_token"])
+
+	// Запросы без верного секрета отклоняются
+	require.Eventually(t, func() bool {
+		code, err := postUpdate(endpoint, "wrong", `{"update_id":1}`)
+		return err == nil && code == http.StatusUnauthorized
+	}, 2*time.Second, 10*time.Millisecond)
+	code, err := postUpdate(endpoint, "", `{"update_id":1}`)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// Обновление с верным секретом попадает в общие обработчики
+	update := `{"update_id":2,"message":{"message_id":1,"date":0,"chat":{"id":42,"type":"private"},"text":"/help"}}`
+	code, err = postUpdate(endpoint, cfg.SecretToken, update)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	require.Eventually(t, func() bool { return len(tg.Calls("sendMessage")) == 1 }, 2*time.Second, 10*time.Millisecond)
+	assert.EqualValues(t, 42, tg.Calls("sendMessage")[0]["chat_id"])
+
+	// После отмены контекста сервер останавливается, а webhook удаляется
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook не остановился")
+	}
+	assert.Len(t, tg.Calls("deleteWebhook"), 1)
+	_, err = postUpdate(endpoint, cfg.SecretToken, update)
+	assert.Error(t, err)
+}
+
+func TestStartBot_LongPolling(t *testing.T) {
+	tg := newFakeTelegram(t)
+	tg.AddUpdate(`{"update_id":1,"message":{"message_id":1,"date":0,"chat":{"id":42,"type":"private"},"text":"/help"}}`)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	bot, err := telegram.NewBot(testToken, nil, logger, false, telego.WithAPIServer(tg.URL))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- bot.StartBot(ctx, config.TGConfig{TypePolling: "longpolling", ShutdownTimeout: time.Second})
+	}()
+
+	require.Eventually(t, func() bool { return len(tg.Calls("sendMessage")) == 1 }, 2*time.Second, 10*time.Millisecond)
+	assert.EqualValues(t, 42, tg.Calls("sendMessage")[0]["chat_id"])
+
+	// После отмены контекста StartBot возвращает управление
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("longpolling не остановился")
+	}
+}
+
+func TestStartBot_UnknownType(t *testing.T) {
+	tg := newFakeTelegram(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	bot, err := telegram.NewBot(testToken, nil, logger, false, telego.WithAPIServer(tg.URL))
+	require.NoError(t, err)
+
+	assert.Error(t, bot.StartBot(context.Background(), config.TGConfig{TypePolling: "push"}))
+}
@@ -0,0 +1,63 @@
+package account_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/account"
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAccount(t *testing.T) {
+	userID := uuid.New()
+
+	a, err := account.New(userID, "  Тинькофф ", account.TypeDebit, decimal.NewFromInt(15000))
+	require.NoError(t, err)
+	assert.Equal(t, "Тинькофф", a.Name)
+	assert.Equal(t, currency.Default, a.Currency)
+	assert.True(t, a.Balance.Equal(decimal.NewFromInt(15000)))
+
+	_, err = account.New(userID, " ", account.TypeCash, decimal.Zero)
+	assert.ErrorIs(t, err, account.ErrEmptyName)
+	_, err = account.New(userID, "Кошелек", "wallet", decimal.Zero)
+	assert.ErrorIs(t, err, account.ErrInvalidType)
+}
+
+func TestParseType(t *testing.T) {
+	for text, want := range map[string]string{"карта": account.TypeDebit, " Наличные ": account.TypeCash, "кредитка": account.TypeCredit, "вклад": account.TypeSavings, "savings": account.TypeSavings} {
+		got, err := account.ParseType(text)
+		require.NoError(t, err, text)
+		assert.Equal(t, want, got, text)
+	}
+
+	_, err := account.ParseType("крипта")
+	assert.ErrorIs(t, err, account.ErrInvalidType)
+}
+
+func TestNewTransfer(t *testing.T) {
+	userID := uuid.New()
+	cash, err := account.New(userID, "Наличные", account.TypeCash, decimal.NewFromInt(1000))
+	require.NoError(t, err)
+	card, err := account.New(userID, "Карта", account.TypeDebit, decimal.Zero)
+	require.NoError(t, err)
+
+	tr, err := account.NewTransfer(cash, card, decimal.NewFromInt(500), decimal.NewFromInt(500), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, cash.ID, tr.FromID)
+	assert.Equal(t, card.ID, tr.ToID)
+	assert.Equal(t, userID, tr.UserID)
+
+	_, err = account.NewTransfer(cash, cash, decimal.NewFromInt(500), decimal.NewFromInt(500), time.Now())
+	assert.ErrorIs(t, err, account.ErrSameAccount)
+	_, err = account.NewTransfer(cash, card, decimal.Zero, decimal.Zero, time.Now())
+	assert.ErrorIs(t, err, account.ErrNonPositiveAmount)
+
+	other, err := account.New(uuid.New(), "Чужая", account.TypeDebit, decimal.Zero)
+	require.NoError(t, err)
+	_, err = account.NewTransfer(cash, other, decimal.NewFromInt(500), decimal.NewFromInt(500), time.Now())
+	assert.ErrorIs(t, err, account.ErrAccountNotFound)
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/SobolevTim/finance_bot/internal/domain/budget"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,3 +19,21 @@ func TestBudget_IsActive(t *testing.T) {
 
 	assert.True(t, budget.IsActive(now), "бюджет должен быть активен")
 }
+
+func TestBudget_CheckCategoryLimit(t *testing.T) {
+	now := time.Now()
+	b, err := budget.New(uuid.New(), decimal.NewFromInt(10000), "RUB", now, now.AddDate(0, 1, 0))
+	assert.NoError(t, err)
+
+	categoryID := uuid.New()
+	assert.ErrorIs(t, b.CheckCategoryLimit(categoryID, decimal.NewFromInt(100)), budget.ErrCategoryNotFound)
+
+	assert.NoError(t, b.AddCategory(categoryID, decimal.NewFromInt(500)))
+	assert.NoError(t, b.CheckCategoryLimit(categoryID, decimal.NewFromInt(500)), "лимит не превышен")
+	assert.ErrorIs(t, b.CheckCategoryLimit(categoryID, decimal.NewFromInt(501)), budget.ErrCategoryLimitExceeded)
+
+	assert.ErrorIs(t, b.AddCategory(categoryID, decimal.NewFromInt(-1)), budget.ErrNegativeBudget)
+
+	b.RemoveCategory(categoryID)
+	assert.ErrorIs(t, b.CheckCategoryLimit(categoryID, decimal.NewFromInt(100)), budget.ErrCategoryNotFound)
+}
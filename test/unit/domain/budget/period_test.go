@@ -0,0 +1,112 @@
+package budget_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/budget"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		text     string
+		period   string
+		startDay int
+		err      error
+	}{
+		{"месяц", budget.PeriodMonthly, 1, nil},
+		{"10", budget.PeriodMonthly, 10, nil},
+		{"Месяц 25", budget.PeriodMonthly, 25, nil},
+		{"2 недели", budget.PeriodBiweekly, 1, nil},
+		{"biweekly", budget.PeriodBiweekly, 1, nil},
+		{"31", "", 0, budget.ErrInvalidStartDay},
+		{"неделя", "", 0, budget.ErrInvalidPeriod},
+		{"", "", 0, budget.ErrInvalidPeriod},
+	}
+	for _, tt := range tests {
+		period, startDay, err := budget.ParsePeriod(tt.text)
+		assert.ErrorIs(t, err, tt.err, tt.text)
+		assert.Equal(t, tt.period, period, tt.text)
+		assert.Equal(t, tt.startDay, startDay, tt.text)
+	}
+}
+
+func TestBudget_SetPeriod(t *testing.T) {
+	t.Run("payday keeps start and extends to period end", func(t *testing.T) {
+		b, _ := budget.New(uuid.New(), decimal.NewFromInt(50000), "RUB", date(2025, 10, 1), date(2025, 10, 31))
+		err := b.SetPeriod(budget.PeriodMonthly, 10, date(2025, 10, 18))
+		assert.NoError(t, err)
+		assert.Equal(t, date(2025, 10, 1), b.StartDate)
+		assert.Equal(t, date(2025, 11, 9), b.EndDate)
+	})
+
+	t.Run("biweekly from budget start", func(t *testing.T) {
+		b, _ := budget.New(uuid.New(), decimal.NewFromInt(20000), "RUB", date(2025, 10, 1), date(2025, 10, 31))
+		err := b.SetPeriod(budget.PeriodBiweekly, 0, date(2025, 10, 18))
+		assert.NoError(t, err)
+		assert.Equal(t, date(2025, 10, 28), b.EndDate)
+	})
+
+	t.Run("period never ends on start date", func(t *testing.T) {
+		b, _ := budget.New(uuid.New(), decimal.NewFromInt(20000), "RUB", date(2025, 10, 9), date(2025, 10, 31))
+		err := b.SetPeriod(budget.PeriodMonthly, 10, date(2025, 10, 9))
+		assert.NoError(t, err)
+		assert.Equal(t, date(2025, 11, 9), b.EndDate)
+	})
+
+	t.Run("invalid start day", func(t *testing.T) {
+		b, _ := budget.New(uuid.New(), decimal.NewFromInt(20000), "RUB", date(2025, 10, 1), date(2025, 10, 31))
+		assert.ErrorIs(t, b.SetPeriod(budget.PeriodMonthly, 30, date(2025, 10, 18)), budget.ErrInvalidStartDay)
+	})
+}
+
+func TestBudget_Next(t *testing.T) {
+	categoryID := uuid.New()
+
+	t.Run("monthly with rollover", func(t *testing.T) {
+		b, _ := budget.New(uuid.New(), decimal.NewFromInt(50000), "RUB", date(2025, 10, 1), date(2025, 11, 9))
+		b.Period, b.StartDay, b.Rollover = budget.PeriodMonthly, 10, true
+		b.CarryOver = decimal.NewFromInt(1000)
+		_ = b.AddCategory(categoryID, decimal.NewFromInt(5000))
+
+		now := time.Date(2025, 11, 10, 3, 0, 0, 0, time.UTC)
+		next := b.Next(decimal.NewFromInt(45000), now)
+		assert.NotEqual(t, b.ID, next.ID)
+		assert.Equal(t, now, next.CreatedAt)
+		assert.Equal(t, date(2025, 11, 10), next.StartDate)
+		assert.Equal(t, date(2025, 12, 9), next.EndDate)
+		assert.True(t, decimal.NewFromInt(6000).Equal(next.CarryOver))
+		assert.True(t, decimal.NewFromInt(56000).Equal(next.Total()))
+		assert.True(t, decimal.NewFromInt(5000).Equal(next.Categories[categoryID]))
+		assert.True(t, next.Rollover)
+	})
+
+	t.Run("deficit is carried over", func(t *testing.T) {
+		b, _ := budget.New(uuid.New(), decimal.NewFromInt(1000), "RUB", date(2025, 10, 1), date(2025, 10, 31))
+		b.Rollover = true
+		next := b.Next(decimal.NewFromInt(1300), time.Now())
+		assert.True(t, decimal.NewFromInt(-300).Equal(next.CarryOver))
+		assert.Equal(t, date(2025, 11, 30), next.EndDate)
+	})
+
+	t.Run("without rollover", func(t *testing.T) {
+		b, _ := budget.New(uuid.New(), decimal.NewFromInt(1000), "RUB", date(2025, 10, 1), date(2025, 10, 31))
+		next := b.Next(decimal.NewFromInt(200), time.Now())
+		assert.True(t, next.CarryOver.IsZero())
+	})
+
+	t.Run("biweekly", func(t *testing.T) {
+		b, _ := budget.New(uuid.New(), decimal.NewFromInt(1000), "RUB", date(2025, 10, 1), date(2025, 10, 14))
+		b.Period = budget.PeriodBiweekly
+		next := b.Next(decimal.Zero, time.Now())
+		assert.Equal(t, date(2025, 10, 15), next.StartDate)
+		assert.Equal(t, date(2025, 10, 28), next.EndDate)
+	})
+}
@@ -0,0 +1,31 @@
+package budget_test
+
+import (
+	"testing"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/budget"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseThresholds(t *testing.T) {
+	thresholds, err := budget.ParseThresholds("90, 50% 90")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{50, 90}, thresholds)
+
+	for _, text := range []string{"", "0", "101", "abc", "50 -10"} {
+		_, err := budget.ParseThresholds(text)
+		assert.ErrorIs(t, err, budget.ErrInvalidThreshold, text)
+	}
+}
+
+func TestCrossedThresholds(t *testing.T) {
+	limit := decimal.NewFromInt(1000)
+	thresholds := []int{50, 80, 100}
+
+	assert.Empty(t, budget.CrossedThresholds(decimal.NewFromInt(499), limit, thresholds))
+	assert.Equal(t, []int{50}, budget.CrossedThresholds(decimal.NewFromInt(500), limit, thresholds))
+	assert.Equal(t, []int{50, 80, 100}, budget.CrossedThresholds(decimal.NewFromInt(1000), limit, thresholds))
+	assert.Equal(t, []int{50, 80, 100, budget.ThresholdOver}, budget.CrossedThresholds(decimal.NewFromFloat(1000.01), limit, thresholds))
+	assert.Empty(t, budget.CrossedThresholds(decimal.NewFromInt(10), decimal.Zero, thresholds))
+}
@@ -0,0 +1,88 @@
+package categories_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/categories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCategory(t *testing.T) {
+	t.Run("valid category", func(t *testing.T) {
+		c, err := categories.New(uuid.New(), "Питомец", false)
+		assert.NoError(t, err)
+		assert.Equal(t, "Питомец", c.Name)
+		assert.Equal(t, categories.DefaultIcon, c.Icon)
+	})
+
+	t.Run("empty name", func(t *testing.T) {
+		_, err := categories.New(uuid.New(), "", false)
+		assert.ErrorIs(t, err, categories.ErrEmptyName)
+	})
+
+	t.Run("name too long", func(t *testing.T) {
+		_, err := categories.New(uuid.New(), strings.Repeat("a", categories.MaxNameLength+1), false)
+		assert.ErrorIs(t, err, categories.ErrNameTooLong)
+	})
+}
+
+func TestRename(t *testing.T) {
+	t.Run("user category", func(t *testing.T) {
+		c, _ := categories.New(uuid.New(), "Питомец", false)
+		assert.NoError(t, c.Rename("Кот"))
+		assert.Equal(t, "Кот", c.Name)
+	})
+
+	t.Run("default category", func(t *testing.T) {
+		c, _ := categories.New(uuid.Nil, "Еда", true)
+		assert.ErrorIs(t, c.Rename("Продукты"), categories.ErrUpdateDefaultCategory)
+		assert.Equal(t, "Еда", c.Name)
+	})
+}
+
+func TestSetIcon(t *testing.T) {
+	c, _ := categories.New(uuid.New(), "Питомец", false)
+
+	t.Run("valid icon", func(t *testing.T) {
+		assert.NoError(t, c.SetIcon("🐶"))
+		assert.Equal(t, "🐶", c.Icon)
+	})
+
+	t.Run("empty icon resets to default", func(t *testing.T) {
+		assert.NoError(t, c.SetIcon(""))
+		assert.Equal(t, categories.DefaultIcon, c.Icon)
+	})
+
+	t.Run("icon too long", func(t *testing.T) {
+		assert.ErrorIs(t, c.SetIcon(strings.Repeat("🐶", 20)), categories.ErrIconTooLong)
+	})
+}
+
+func TestMatchWord(t *testing.T) {
+	food, _ := categories.New(uuid.Nil, "Еда", true)
+	transport, _ := categories.New(uuid.Nil, "Транспорт", true)
+	pet, _ := categories.New(uuid.New(), "Питомец", false)
+	list := []*categories.Categories{food, transport, pet}
+	aliases := map[string]uuid.UUID{"корм": pet.ID}
+
+	tests := []struct {
+		word string
+		want *categories.Categories
+	}{
+		{word: "еда", want: food},
+		{word: "Питомец", want: pet},
+		{word: "кофе", want: food},
+		{word: "кофейня", want: food},
+		{word: "такси", want: transport},
+		{word: "корм", want: pet},
+		{word: "Корм", want: pet},
+		{word: "носки", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			assert.Equal(t, tt.want, categories.MatchWord(tt.word, list, aliases))
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package currency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{"usd": "USD", "$": "USD", "евро": "EUR", "руб.": "RUB", "₸": "KZT"} {
+		got, err := currency.Normalize(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	_, err := currency.Normalize("кофе")
+	assert.ErrorIs(t, err, currency.ErrUnknownCurrency)
+}
+
+func TestSplitAmount(t *testing.T) {
+	tests := []struct {
+		text   string
+		amount string
+		code   string
+	}{
+		{text: "20 USD", amount: "20", code: "USD"},
+		{text: "20usd", amount: "20", code: "USD"},
+		{text: "20$", amount: "20", code: "USD"},
+		{text: "$20", amount: "20", code: "USD"},
+		{text: "150+20 €", amount: "150+20", code: "EUR"},
+		{text: "150+20", amount: "150+20", code: ""},
+		{text: "50000", amount: "50000", code: ""},
+	}
+	for _, tt := range tests {
+		amount, code := currency.SplitAmount(tt.text)
+		assert.Equal(t, tt.amount, amount, tt.text)
+		assert.Equal(t, tt.code, code, tt.text)
+	}
+}
+
+func TestRates_Rate(t *testing.T) {
+	r := &currency.Rates{Base: "RUB", Values: map[string]decimal.Decimal{
+		"USD": decimal.NewFromInt(100),
+		"EUR": decimal.NewFromInt(110),
+	}}
+
+	rate, err := r.Rate("USD", "RUB")
+	require.NoError(t, err)
+	assert.Equal(t, "100", rate.String())
+
+	rate, err = r.Rate("RUB", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "0.01", rate.String())
+
+	rate, err = r.Rate("EUR", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1", rate.String(), "кросс-курс через базовую валюту")
+
+	_, err = r.Rate("GBP", "RUB")
+	assert.ErrorIs(t, err, currency.ErrRateNotFound)
+}
+
+type stubProvider struct {
+	rates *currency.Rates
+	calls int
+}
+
+func (p *stubProvider) ExchangeRate(ctx context.Context, from, to string, date time.Time) (decimal.Decimal, error) {
+	p.calls++
+	return p.rates.Rate(from, to)
+}
+
+func TestRoundAndFormat(t *testing.T) {
+	tests := []struct {
+		amount  string
+		code    string
+		rounded string
+		text    string
+	}{
+		{amount: "350", code: "RUB", rounded: "350", text: "350.00₽"},
+		{amount: "19.995", code: "USD", rounded: "20", text: "20.00$"},
+		{amount: "10.005", code: "", rounded: "10.01", text: "10.01₽"},
+		{amount: "-10.005", code: "EUR", rounded: "-10.01", text: "-10.01€"},
+		{amount: "3.3333333333333333", code: "RUB", rounded: "3.33", text: "3.33₽"},
+		{amount: "1234.5", code: "JPY", rounded: "1235", text: "1235JP¥"},
+		{amount: "99.4", code: "JPY", rounded: "99", text: "99JP¥"},
+	}
+	for _, tt := range tests {
+		amount := decimal.RequireFromString(tt.amount)
+		assert.Equal(t, tt.rounded, currency.Round(amount, tt.code).String(), tt.amount+" "+tt.code)
+		assert.Equal(t, tt.text, currency.Format(amount, tt.code), tt.amount+" "+tt.code)
+	}
+	assert.Equal(t, int32(2), currency.Decimals("RUB"))
+	assert.Equal(t, int32(0), currency.Decimals("JPY"))
+}
+
+func TestConvert(t *testing.T) {
+	p := &stubProvider{rates: &currency.Rates{Base: "RUB", Values: map[string]decimal.Decimal{"USD": decimal.RequireFromString("92.5")}}}
+	ctx := context.Background()
+
+	got, err := currency.Convert(ctx, p, decimal.NewFromInt(20), "USD", "RUB", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "1850", got.String())
+
+	got, err = currency.Convert(ctx, p, decimal.NewFromInt(20), "RUB", "RUB", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "20", got.String())
+	assert.Equal(t, 1, p.calls, "для одной валюты курс не запрашивается")
+
+	_, err = currency.Convert(ctx, p, decimal.NewFromInt(20), "EUR", "RUB", time.Now())
+	assert.ErrorIs(t, err, currency.ErrRateNotFound)
+
+	got, err = currency.Convert(ctx, p, decimal.NewFromInt(1000), "RUB", "USD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "10.81", got.String(), "сумма округляется до центов")
+}
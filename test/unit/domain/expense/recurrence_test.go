@@ -0,0 +1,106 @@
+package expense_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseRecurrenceRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		wantErr error
+	}{
+		{name: "monthly", rule: "FREQ=MONTHLY;BYMONTHDAY=5"},
+		{name: "weekly", rule: "FREQ=WEEKLY;BYDAY=MO"},
+		{name: "daily with interval", rule: "FREQ=DAILY;INTERVAL=2"},
+		{name: "rrule prefix and lower case", rule: "rrule:freq=monthly;bymonthday=31"},
+		{name: "empty", rule: "", wantErr: expense.ErrEmptyRecurrenceRule},
+		{name: "no freq", rule: "BYMONTHDAY=5", wantErr: expense.ErrInvalidRecurrenceRule},
+		{name: "unsupported freq", rule: "FREQ=YEARLY", wantErr: expense.ErrInvalidRecurrenceRule},
+		{name: "bad month day", rule: "FREQ=MONTHLY;BYMONTHDAY=32", wantErr: expense.ErrInvalidRecurrenceRule},
+		{name: "bad weekday", rule: "FREQ=WEEKLY;BYDAY=XX", wantErr: expense.ErrInvalidRecurrenceRule},
+		{name: "byday with monthly", rule: "FREQ=MONTHLY;BYDAY=MO", wantErr: expense.ErrInvalidRecurrenceRule},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := expense.ParseRecurrenceRule(tt.rule)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRecurrenceRule_Next(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		after time.Time
+		want  time.Time
+	}{
+		{name: "monthly later this month", rule: "FREQ=MONTHLY;BYMONTHDAY=5", after: date(2025, 3, 1), want: date(2025, 3, 5)},
+		{name: "monthly next month", rule: "FREQ=MONTHLY;BYMONTHDAY=5", after: date(2025, 3, 5), want: date(2025, 4, 5)},
+		{name: "monthly clamps to last day", rule: "FREQ=MONTHLY;BYMONTHDAY=31", after: date(2025, 1, 31), want: date(2025, 2, 28)},
+		{name: "monthly restores day after short month", rule: "FREQ=MONTHLY;BYMONTHDAY=31", after: date(2025, 2, 28), want: date(2025, 3, 31)},
+		{name: "monthly across year", rule: "FREQ=MONTHLY;BYMONTHDAY=10", after: date(2024, 12, 10), want: date(2025, 1, 10)},
+		{name: "monthly interval", rule: "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=1", after: date(2025, 1, 1), want: date(2025, 4, 1)},
+		{name: "weekly on monday", rule: "FREQ=WEEKLY;BYDAY=MO", after: date(2025, 3, 19), want: date(2025, 3, 24)},
+		{name: "weekly from monday", rule: "FREQ=WEEKLY;BYDAY=MO", after: date(2025, 3, 24), want: date(2025, 3, 31)},
+		{name: "biweekly", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", after: date(2025, 3, 21), want: date(2025, 4, 4)},
+		{name: "daily", rule: "FREQ=DAILY", after: date(2025, 2, 28), want: date(2025, 3, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := expense.ParseRecurrenceRule(tt.rule)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, r.Next(tt.after))
+		})
+	}
+}
+
+func TestRuleBuilders(t *testing.T) {
+	assert.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=5", expense.MonthlyRule(5))
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO", expense.WeeklyRule(time.Monday))
+}
+
+func TestNewExpences_Recurring(t *testing.T) {
+	_, err := expense.NewExpences(uuid.New(), uuid.New(), decimal.NewFromInt(100), date(2025, 3, 5), true, "", "")
+	assert.ErrorIs(t, err, expense.ErrEmptyRecurrenceRule)
+
+	_, err = expense.NewExpences(uuid.New(), uuid.New(), decimal.NewFromInt(100), date(2025, 3, 5), true, "FREQ=HOURLY", "")
+	assert.ErrorIs(t, err, expense.ErrInvalidRecurrenceRule)
+
+	e, err := expense.NewExpences(uuid.New(), uuid.New(), decimal.NewFromInt(100), date(2025, 3, 5), true, expense.MonthlyRule(5), "подписка")
+	assert.NoError(t, err)
+
+	e.AccountID = uuid.New()
+	o := e.NewOccurrence(date(2025, 4, 5))
+	assert.Equal(t, e.ID, o.ParentID)
+	assert.Equal(t, e.AccountID, o.AccountID, "вхождение списывается с того же счета")
+	assert.False(t, o.IsRecurring)
+	assert.Equal(t, e.Description, o.Description)
+	assert.NotEqual(t, e.ID, o.ID)
+}
+
+func TestExpense_SetCurrencyRoundsAmount(t *testing.T) {
+	e, err := expense.NewExpences(uuid.New(), uuid.New(), decimal.RequireFromString("333.335"), date(2025, 3, 5), false, "", "")
+	assert.NoError(t, err)
+
+	assert.NoError(t, e.SetCurrency("RUB"))
+	assert.Equal(t, "333.34", e.Ammount.String())
+
+	assert.NoError(t, e.SetCurrency("JPY"))
+	assert.Equal(t, "333", e.Ammount.String())
+}
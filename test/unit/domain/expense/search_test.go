@@ -0,0 +1,64 @@
+package expense_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	today := date(2026, time.October, 18)
+
+	f, err := expense.ParseSearchQuery("аптека в феврале", today)
+	require.NoError(t, err)
+	assert.Equal(t, "аптека", f.Text)
+	assert.Equal(t, date(2026, time.February, 1), f.From)
+	assert.Equal(t, date(2026, time.February, 28), f.To)
+	assert.Equal(t, expense.SearchPageSize, f.Limit)
+
+	// Месяц позже текущего без года - прошлогодний
+	f, err = expense.ParseSearchQuery("декабрь", today)
+	require.NoError(t, err)
+	assert.Equal(t, date(2025, time.December, 1), f.From)
+	assert.Empty(t, f.Text)
+
+	f, err = expense.ParseSearchQuery("мая 2024 подарок", today)
+	require.NoError(t, err)
+	assert.Equal(t, date(2024, time.May, 1), f.From)
+	assert.Equal(t, date(2024, time.May, 31), f.To)
+	assert.Equal(t, "подарок", f.Text)
+
+	f, err = expense.ParseSearchQuery("#бытовая_химия 500..100 01.03.2026-15.03.2026", today)
+	require.NoError(t, err)
+	assert.Equal(t, "бытовая химия", f.Category)
+	assert.True(t, f.MinAmount.Decimal.Equal(decimal.NewFromInt(100)))
+	assert.True(t, f.MaxAmount.Decimal.Equal(decimal.NewFromInt(500)))
+	assert.Equal(t, date(2026, time.March, 1), f.From)
+	assert.Equal(t, date(2026, time.March, 15), f.To)
+	assert.Empty(t, f.Text)
+
+	f, err = expense.ParseSearchQuery(">1500,5 <3000 02.2026", today)
+	require.NoError(t, err)
+	assert.True(t, f.MinAmount.Valid)
+	assert.True(t, f.MinAmount.Decimal.Equal(decimal.RequireFromString("1500.5")))
+	assert.True(t, f.MaxAmount.Decimal.Equal(decimal.NewFromInt(3000)))
+	assert.Equal(t, date(2026, time.February, 28), f.To)
+
+	f, err = expense.ParseSearchQuery("кофе 15.09.2026", today)
+	require.NoError(t, err)
+	assert.Equal(t, "кофе", f.Text)
+	assert.Equal(t, f.From, f.To)
+	assert.False(t, f.MinAmount.Valid)
+
+	// Предлог без месяца остается частью текста
+	f, err = expense.ParseSearchQuery("кофе с молоком", today)
+	require.NoError(t, err)
+	assert.Equal(t, "кофе с молоком", f.Text)
+
+	_, err = expense.ParseSearchQuery("  ", today)
+	assert.ErrorIs(t, err, expense.ErrEmptySearch)
+}
@@ -0,0 +1,110 @@
+package expense_test
+
+import (
+	"testing"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetSplits(t *testing.T) {
+	food, home, clothes := uuid.New(), uuid.New(), uuid.New()
+	tests := []struct {
+		name    string
+		splits  []*expense.Split
+		wantErr error
+	}{
+		{
+			name:    "single part",
+			splits:  []*expense.Split{{CategoryID: food, Amount: decimal.NewFromInt(2000)}},
+			wantErr: expense.ErrSplitTooFew,
+		},
+		{
+			name: "sum mismatch",
+			splits: []*expense.Split{
+				{CategoryID: food, Amount: decimal.NewFromInt(1000)},
+				{CategoryID: home, Amount: decimal.NewFromInt(500)},
+			},
+			wantErr: expense.ErrSplitSumMismatch,
+		},
+		{
+			name: "duplicate category",
+			splits: []*expense.Split{
+				{CategoryID: food, Amount: decimal.NewFromInt(1000)},
+				{CategoryID: food, Amount: decimal.NewFromInt(1000)},
+			},
+			wantErr: expense.ErrSplitDuplicateCategory,
+		},
+		{
+			name: "zero part",
+			splits: []*expense.Split{
+				{CategoryID: food, Amount: decimal.NewFromInt(2000)},
+				{CategoryID: home, Amount: decimal.Zero},
+			},
+			wantErr: expense.ErrSplitNonPositive,
+		},
+		{
+			name: "parts rounded to kopecks",
+			splits: []*expense.Split{
+				{CategoryID: food, Amount: decimal.RequireFromString("1199.999")},
+				{CategoryID: home, Amount: decimal.NewFromInt(500)},
+				{CategoryID: clothes, Amount: decimal.NewFromInt(300)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &expense.Expense{CategoryID: home, Ammount: decimal.NewFromInt(2000), Currency: "RUB"}
+			err := e.SetSplits(tt.splits)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.False(t, e.IsSplit())
+				assert.Equal(t, home, e.CategoryID)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, e.IsSplit())
+			assert.Equal(t, food, e.CategoryID, "категория траты - самая крупная часть")
+			assert.True(t, e.Splits[0].Amount.Equal(decimal.NewFromInt(1200)))
+		})
+	}
+}
+
+func TestSetSplitsClear(t *testing.T) {
+	food, home := uuid.New(), uuid.New()
+	e := &expense.Expense{CategoryID: food, Ammount: decimal.NewFromInt(100), Currency: "RUB"}
+	require.NoError(t, e.SetSplits([]*expense.Split{
+		{CategoryID: food, Amount: decimal.NewFromInt(60)},
+		{CategoryID: home, Amount: decimal.NewFromInt(40)},
+	}))
+
+	require.NoError(t, e.SetSplits(nil))
+	assert.False(t, e.IsSplit())
+	assert.Equal(t, food, e.CategoryID)
+}
+
+func TestAllocations(t *testing.T) {
+	food, home := uuid.New(), uuid.New()
+	e := &expense.Expense{CategoryID: food, Ammount: decimal.NewFromInt(100), Currency: "RUB"}
+
+	parts := e.Allocations()
+	require.Len(t, parts, 1)
+	assert.Equal(t, food, parts[0].CategoryID)
+	assert.True(t, parts[0].Amount.Equal(decimal.NewFromInt(100)))
+
+	require.NoError(t, e.SetSplits([]*expense.Split{
+		{CategoryID: food, Amount: decimal.NewFromInt(30)},
+		{CategoryID: home, Amount: decimal.NewFromInt(70)},
+	}))
+	parts = e.Allocations()
+	require.Len(t, parts, 2)
+	sum := decimal.Zero
+	for _, p := range parts {
+		sum = sum.Add(p.Amount)
+	}
+	assert.True(t, sum.Equal(e.Ammount))
+	assert.Equal(t, home, e.CategoryID)
+}
@@ -0,0 +1,94 @@
+package goal_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/SobolevTim/finance_bot/internal/domain/goal"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestNewGoal(t *testing.T) {
+	userID := uuid.New()
+	today := date(2026, time.March, 15)
+
+	g, err := goal.New(userID, "  Отпуск ", decimal.NewFromInt(120000), date(2026, time.December, 31), today)
+	require.NoError(t, err)
+	assert.Equal(t, "Отпуск", g.Name)
+	assert.Equal(t, currency.Default, g.Currency)
+	assert.True(t, g.Saved.IsZero())
+
+	_, err = goal.New(userID, " ", decimal.NewFromInt(1000), today, today)
+	assert.ErrorIs(t, err, goal.ErrEmptyName)
+	_, err = goal.New(userID, "Машина", decimal.Zero, today, today)
+	assert.ErrorIs(t, err, goal.ErrNonPositiveAmount)
+	_, err = goal.New(userID, "Машина", decimal.NewFromInt(1000), today.AddDate(0, 0, -1), today)
+	assert.ErrorIs(t, err, goal.ErrDeadlinePassed)
+}
+
+func TestParseDeadline(t *testing.T) {
+	d, err := goal.ParseDeadline("31.12.2026")
+	require.NoError(t, err)
+	assert.Equal(t, date(2026, time.December, 31), d)
+
+	d, err = goal.ParseDeadline("02.2028")
+	require.NoError(t, err)
+	assert.Equal(t, date(2028, time.February, 29), d)
+
+	_, err = goal.ParseDeadline("к лету")
+	assert.ErrorIs(t, err, goal.ErrInvalidDeadline)
+}
+
+func TestGoalProgress(t *testing.T) {
+	g := &goal.Goal{Target: decimal.NewFromInt(1000), Saved: decimal.NewFromInt(250)}
+	assert.InDelta(t, 0.25, g.Progress(), 1e-9)
+	assert.True(t, g.Remaining().Equal(decimal.NewFromInt(750)))
+	assert.False(t, g.IsReached())
+
+	g.Saved = decimal.NewFromInt(1200)
+	assert.Equal(t, 1.0, g.Progress())
+	assert.True(t, g.Remaining().IsZero())
+	assert.True(t, g.IsReached())
+}
+
+func TestMonthlyContribution(t *testing.T) {
+	g := &goal.Goal{
+		Target:   decimal.NewFromInt(1000),
+		Saved:    decimal.NewFromInt(100),
+		Deadline: date(2026, time.June, 30),
+	}
+	today := date(2026, time.March, 15)
+
+	// Март, апрель, май и июнь
+	assert.Equal(t, 4, g.MonthsLeft(today))
+	assert.True(t, g.MonthlyContribution(today).Equal(decimal.NewFromInt(225)))
+
+	// Остаток не делится нацело - взнос округляется вверх
+	g.Target = decimal.NewFromInt(1001)
+	assert.True(t, g.MonthlyContribution(today).Equal(decimal.RequireFromString("225.25")))
+
+	// Срок прошел - нужно внести весь остаток
+	late := date(2026, time.July, 1)
+	assert.Equal(t, 0, g.MonthsLeft(late))
+	assert.True(t, g.MonthlyContribution(late).Equal(decimal.NewFromInt(901)))
+}
+
+func TestNewContribution(t *testing.T) {
+	g := &goal.Goal{ID: uuid.New(), UserID: uuid.New()}
+
+	c, err := goal.NewContribution(g, decimal.NewFromInt(500), date(2026, time.March, 15))
+	require.NoError(t, err)
+	assert.Equal(t, g.ID, c.GoalID)
+	assert.Equal(t, g.UserID, c.AuthorID)
+
+	_, err = goal.NewContribution(g, decimal.NewFromInt(-5), date(2026, time.March, 15))
+	assert.ErrorIs(t, err, goal.ErrNonPositiveAmount)
+}
@@ -0,0 +1,65 @@
+package group_test
+
+import (
+	"testing"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/group"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMember(t *testing.T) {
+	m, err := group.NewMember(uuid.New(), uuid.New(), group.RoleOwner)
+	require.NoError(t, err)
+	assert.Equal(t, group.RoleOwner, m.Role)
+
+	_, err = group.NewMember(uuid.New(), uuid.New(), "guest")
+	assert.ErrorIs(t, err, group.ErrInvalidRole)
+}
+
+func TestParseRole(t *testing.T) {
+	for text, want := range map[string]string{"admin": group.RoleAdmin, " Админ ": group.RoleAdmin, "member": group.RoleMember, "участник": group.RoleMember} {
+		role, err := group.ParseRole(text)
+		require.NoError(t, err, text)
+		assert.Equal(t, want, role, text)
+	}
+
+	_, err := group.ParseRole("owner")
+	assert.ErrorIs(t, err, group.ErrInvalidRole, "владельца назначить нельзя")
+}
+
+func TestMember_Permissions(t *testing.T) {
+	tests := []struct {
+		role          string
+		manageBudget  bool
+		manageMembers bool
+	}{
+		{group.RoleOwner, true, true},
+		{group.RoleAdmin, true, false},
+		{group.RoleMember, false, false},
+	}
+	for _, tt := range tests {
+		m := &group.Member{Role: tt.role}
+		assert.Equal(t, tt.manageBudget, m.CanManageBudget(), tt.role)
+		assert.Equal(t, tt.manageMembers, m.CanManageMembers(), tt.role)
+	}
+}
+
+func TestMember_SetRole(t *testing.T) {
+	m := &group.Member{Role: group.RoleMember}
+	require.NoError(t, m.SetRole(group.RoleAdmin))
+	assert.Equal(t, group.RoleAdmin, m.Role)
+
+	assert.ErrorIs(t, m.SetRole(group.RoleOwner), group.ErrOwnerRole)
+	assert.ErrorIs(t, m.SetRole("guest"), group.ErrInvalidRole)
+
+	owner := &group.Member{Role: group.RoleOwner}
+	assert.ErrorIs(t, owner.SetRole(group.RoleMember), group.ErrOwnerRole)
+	assert.Equal(t, group.RoleOwner, owner.Role)
+}
+
+func TestMember_DisplayName(t *testing.T) {
+	assert.Equal(t, "@anna", (&group.Member{UserName: "anna", FirstName: "Анна"}).DisplayName())
+	assert.Equal(t, "Анна", (&group.Member{FirstName: "Анна"}).DisplayName())
+}
@@ -0,0 +1,41 @@
+package income_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/SobolevTim/finance_bot/internal/domain/income"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIncome(t *testing.T) {
+	userID := uuid.New()
+	date := time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	i, err := income.New(userID, decimal.NewFromInt(50000), date, "зарплата")
+	require.NoError(t, err)
+	assert.Equal(t, userID, i.UserID)
+	assert.Equal(t, userID, i.AuthorID, "по умолчанию автор - владелец бюджета")
+	assert.Equal(t, currency.Default, i.Currency)
+	assert.Equal(t, "зарплата", i.Source)
+
+	_, err = income.New(userID, decimal.Zero, date, "")
+	assert.ErrorIs(t, err, income.ErrNonPositiveAmount)
+	_, err = income.New(userID, decimal.NewFromInt(-100), date, "")
+	assert.ErrorIs(t, err, income.ErrNonPositiveAmount)
+}
+
+func TestIncomeSetCurrency(t *testing.T) {
+	i, err := income.New(uuid.New(), decimal.NewFromInt(300), time.Now(), "фриланс")
+	require.NoError(t, err)
+
+	require.NoError(t, i.SetCurrency("$"))
+	assert.Equal(t, "USD", i.Currency)
+
+	assert.ErrorIs(t, i.SetCurrency("тугрики-2"), currency.ErrUnknownCurrency)
+	assert.Equal(t, "USD", i.Currency)
+}
@@ -0,0 +1,63 @@
+package notification_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/notification"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToggle(t *testing.T) {
+	s := notification.New(uuid.New())
+	for _, kind := range notification.Kinds {
+		assert.False(t, s.Enabled(kind), "уведомления выключены по умолчанию")
+	}
+
+	require.NoError(t, s.Toggle(notification.KindWeeklyDigest))
+	assert.True(t, s.Enabled(notification.KindWeeklyDigest))
+	require.NoError(t, s.Toggle(notification.KindWeeklyDigest))
+	assert.False(t, s.Enabled(notification.KindWeeklyDigest))
+
+	assert.ErrorIs(t, s.Toggle("hourly"), notification.ErrUnknownKind)
+}
+
+func TestDailyReminderDue(t *testing.T) {
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	s := notification.New(uuid.New())
+	require.NoError(t, s.Toggle(notification.KindDailyReminder))
+
+	evening := time.Date(2025, 3, 5, notification.ReminderHour, 30, 0, 0, loc)
+	assert.False(t, s.Due(notification.KindDailyReminder, evening.Add(-time.Hour)), "рано")
+	assert.True(t, s.Due(notification.KindDailyReminder, evening))
+
+	s.MarkSent(notification.KindDailyReminder, evening)
+	assert.False(t, s.Due(notification.KindDailyReminder, evening.Add(time.Hour)), "уже отправлено сегодня")
+	assert.True(t, s.Due(notification.KindDailyReminder, evening.AddDate(0, 0, 1)), "на следующий день снова")
+}
+
+func TestWeeklyAndMonthlyDue(t *testing.T) {
+	s := notification.New(uuid.New())
+	require.NoError(t, s.Toggle(notification.KindWeeklyDigest))
+	require.NoError(t, s.Toggle(notification.KindMonthlyReport))
+
+	monday := time.Date(2025, 3, 3, notification.DigestHour, 0, 0, 0, time.UTC)
+	assert.True(t, s.Due(notification.KindWeeklyDigest, monday))
+	assert.False(t, s.Due(notification.KindWeeklyDigest, monday.AddDate(0, 0, 1)), "только по понедельникам")
+	assert.False(t, s.Due(notification.KindMonthlyReport, monday), "не первое число")
+
+	first := time.Date(2025, 4, 1, notification.DigestHour, 0, 0, 0, time.UTC)
+	assert.True(t, s.Due(notification.KindMonthlyReport, first))
+	s.MarkSent(notification.KindMonthlyReport, first)
+	assert.False(t, s.Due(notification.KindMonthlyReport, first.Add(time.Hour)))
+}
+
+func TestDueDisabled(t *testing.T) {
+	s := notification.New(uuid.New())
+	evening := time.Date(2025, 3, 3, 22, 0, 0, 0, time.UTC)
+	for _, kind := range notification.Kinds {
+		assert.False(t, s.Due(kind, evening), kind)
+	}
+}
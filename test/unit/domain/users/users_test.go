@@ -2,6 +2,8 @@ package user_test
 
 import (
 	"testing"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/SobolevTim/finance_bot/internal/domain/user"
 	"github.com/stretchr/testify/assert"
@@ -47,8 +49,51 @@ func TestUpdateTimezone(t *testing.T) {
 		assert.Equal(t, "UTC+5", u.Timezone)
 	})
 
+	t.Run("IANA name", func(t *testing.T) {
+		err := u.UpdateTimezone("Asia/Vladivostok")
+		assert.NoError(t, err)
+		assert.Equal(t, "Asia/Vladivostok", u.Timezone)
+	})
+
+	t.Run("normalized offset", func(t *testing.T) {
+		err := u.UpdateTimezone(" utc+05:30 ")
+		assert.NoError(t, err)
+		assert.Equal(t, "UTC+5:30", u.Timezone)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		for _, tz := range []string{"Invalid/Timezone", "UTC+15", "Moscow", "../etc/passwd"} {
+			err := u.UpdateTimezone(tz)
+			assert.ErrorIs(t, err, user.ErrInvalidTimezoneFormat, tz)
+		}
+	})
+}
+
+func TestParseLocation(t *testing.T) {
+	utc := time.Date(2025, 3, 5, 20, 0, 0, 0, time.UTC)
+
+	t.Run("positive offset", func(t *testing.T) {
+		local := utc.In(user.ParseLocation("UTC+3"))
+		assert.Equal(t, 23, local.Hour())
+	})
+
+	t.Run("negative offset", func(t *testing.T) {
+		local := utc.In(user.ParseLocation("UTC-5"))
+		assert.Equal(t, 15, local.Hour())
+	})
+
+	t.Run("half-hour offset", func(t *testing.T) {
+		local := utc.In(user.ParseLocation("UTC+5:30"))
+		assert.Equal(t, 1, local.Hour())
+		assert.Equal(t, 30, local.Minute())
+	})
+
+	t.Run("IANA name", func(t *testing.T) {
+		local := utc.In(user.ParseLocation("Europe/Moscow"))
+		assert.Equal(t, 23, local.Hour())
+	})
+
 	t.Run("invalid format", func(t *testing.T) {
-		err := u.UpdateTimezone("Invalid/Timezone")
-		assert.ErrorIs(t, err, user.ErrInvalidTimezoneFormat)
+		assert.Equal(t, time.UTC, user.ParseLocation("Mars/Olympus"))
 	})
 }
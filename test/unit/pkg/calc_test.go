@@ -4,175 +4,178 @@ import (
 	"testing"
 
 	"github.com/SobolevTim/finance_bot/internal/pkg/calc"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCalculate(t *testing.T) {
 	tests := []struct {
 		name       string
 		expression string
-		want       float64
+		want       string
 		wantErr    bool
 	}{
 		{
 			name:       "simple",
 			expression: "2+2",
-			want:       4,
+			want:       "4",
 			wantErr:    false,
 		},
 		{
 			name:       "simple with spaces",
 			expression: " 2 + 2 ",
-			want:       4,
+			want:       "4",
 			wantErr:    false,
 		},
 		{
 			name:       "simple with brackets",
 			expression: "(3-1)*2",
-			want:       4,
+			want:       "4",
 			wantErr:    false,
 		},
 		{
 			name:       "complex with brackets and spaces",
 			expression: " ( 3 - 1 ) * 2 ",
-			want:       4,
+			want:       "4",
 			wantErr:    false,
 		},
 		{
 			name:       "simple with percent",
 			expression: "100+10%",
-			want:       110,
+			want:       "110",
 			wantErr:    false,
 		},
 		{
 			name:       "simple with percent and spaces",
 			expression: " 100 + 10 % ",
-			want:       110,
+			want:       "110",
 			wantErr:    false,
 		},
 		{
 			name:       "complex with percent and brackets",
 			expression: "(100-10%)*2-50%",
-			want:       90,
+			want:       "90",
 			wantErr:    false,
 		},
 		{
 			name:       "complex with percent and brackets and spaces",
 			expression: " ( 100 - 10 % ) * 2 - 50 % ",
-			want:       90,
+			want:       "90",
 			wantErr:    false,
 		},
 		{
 			name:       "simple pow",
 			expression: "2^2",
-			want:       4,
+			want:       "4",
 			wantErr:    false,
 		},
 		{
 			name:       "simple pow with spaces",
 			expression: " 2 ^ 2 ",
-			want:       4,
+			want:       "4",
 			wantErr:    false,
 		},
 		{
 			name:       "complex pow",
 			expression: "2^2^2",
-			want:       16,
+			want:       "16",
 			wantErr:    false,
 		},
 		{
 			name:       "complex pow with persent",
 			expression: "2^2^2+50%",
-			want:       24,
+			want:       "24",
 			wantErr:    false,
 		},
 		{
 			name:       "complex with milti percent",
 			expression: "(100-10%)*2-50%",
-			want:       90,
+			want:       "90",
 			wantErr:    false,
 		},
 		{
 			name:       "error: division by zero",
 			expression: "1/0",
-			want:       0,
+			want:       "0",
 			wantErr:    true,
 		},
 		{
 			name:       "error: invalid expression",
 			expression: "1/0+",
-			want:       0,
+			want:       "0",
 			wantErr:    true,
 		},
 		{
 			name:       "error: invalid expression with brackets",
 			expression: "(1/0+1)",
-			want:       0,
+			want:       "0",
 			wantErr:    true,
 		},
 		{
 			name:       "error: invalid expression with percent",
 			expression: "100+10%+",
-			want:       0,
+			want:       "0",
 			wantErr:    true,
 		},
 		{
 			name:       "error: invalid expression with pow",
 			expression: "2^2^",
-			want:       0,
+			want:       "0",
 			wantErr:    true,
 		},
 		{
 			name:       "error: invalid expression with pow and percent",
 			expression: "2^2^2+50%+",
-			want:       0,
+			want:       "0",
 			wantErr:    true,
 		},
 		{
 			name:       "error: incorrect brackets",
 			expression: "1+1))",
-			want:       0,
+			want:       "0",
 			wantErr:    true,
 		},
 		{
 			name:       "error: incorrect brackets with spaces",
 			expression: " 1 + 1 ) ) ",
-			want:       0,
+			want:       "0",
 			wantErr:    true,
 		},
 		{
 			name:       "error: percentage with multiplication operator",
 			expression: "100*10%",
-			want:       0,
+			want:       "0",
 			wantErr:    true,
 		},
 		{
 			name:       "error: percentage without preceding operator",
 			expression: "100%",
-			want:       0,
+			want:       "0",
 			wantErr:    true,
 		},
 		{
 			name:       "unbalanced parentheses auto fix",
 			expression: "((1+1)",
-			want:       2,
+			want:       "2",
 			wantErr:    false,
 		},
 		{
 			name:       "invalid symbol",
 			expression: "1+1a",
-			want:       0,
+			want:       "0",
 			wantErr:    true,
 		},
 		{
 			name:       "decimal numbers",
 			expression: "1.1+1.1",
-			want:       2.2,
+			want:       "2.2",
 			wantErr:    false,
 		},
 		{
 			name:       "decimal numbers with percent",
 			expression: "1.5+10.5%",
-			want:       1.6575,
+			want:       "1.6575",
 			wantErr:    false,
 		},
 	}
@@ -183,7 +186,7 @@ func TestCalculate(t *testing.T) {
 				t.Errorf("Calculate() test %s error = %v, wantErr %v", tt.name, err, tt.wantErr)
 				return
 			}
-			if got != tt.want {
+			if got.String() != tt.want {
 				t.Errorf("Calculate() = %v, want %v", got, tt.want)
 			}
 		})
@@ -194,37 +197,147 @@ func TestCalculate(t *testing.T) {
 func TestFormatNumber(t *testing.T) {
 	tests := []struct {
 		name string
-		num  float64
+		num  string
 		want string
 	}{
 		{
 			name: "integer",
-			num:  5.0,
+			num:  "5.0",
 			want: "5",
 		},
 		{
 			name: "decimal with rounding",
-			num:  3.1415926535,
+			num:  "3.1415926535",
 			want: "3.14159",
 		},
 		{
 			name: "no decimal part after rounding",
-			num:  2.0000000001,
+			num:  "2.0000000001",
 			want: "2",
 		},
 		{
 			name: "rounding to five decimal places",
-			num:  2.123456789,
+			num:  "2.123456789",
 			want: "2.12346",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := calc.FormatNumber(tt.num)
+			got := calc.FormatNumber(decimal.RequireFromString(tt.num))
 			if got != tt.want {
 				t.Errorf("FormatNumber(%v) = %v, want %v", tt.num, got, tt.want)
 			}
 		})
 	}
 }
+
+func TestCalculateParser(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       string
+	}{
+		{name: "unary minus", expression: "-5+3", want: "-2"},
+		{name: "unary minus after operator", expression: "2*-3", want: "-6"},
+		{name: "unary minus before pow", expression: "-2^2", want: "-4"},
+		{name: "implicit multiplication", expression: "3(200)", want: "600"},
+		{name: "implicit multiplication after brackets", expression: "(1+1)(2+3)", want: "10"},
+		{name: "multiplier latin", expression: "3x150", want: "450"},
+		{name: "multiplier cyrillic", expression: "3х150", want: "450"},
+		{name: "multiplier sign", expression: "3×150", want: "450"},
+		{name: "units", expression: "150*3шт", want: "450"},
+		{name: "units with dot", expression: "3 шт. x 150", want: "450"},
+		{name: "round", expression: "round(10/3)", want: "3"},
+		{name: "round digits", expression: "round(10/3, 2)", want: "3.33"},
+		{name: "min max", expression: "min(5, 2, 8)+max(1;4)", want: "6"},
+		{name: "function with decimals", expression: "max(1.5, 2)", want: "2"},
+		{name: "abs", expression: "abs(-7)", want: "7"},
+		{name: "decimal comma", expression: "1,5+1", want: "2.5"},
+		{name: "thousands separator", expression: "1 000 + 2 500", want: "3500"},
+		{name: "percent after unary", expression: "200-(50+50%)", want: "125"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calc.Calculate(tt.expression)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestCalculateWith(t *testing.T) {
+	got, err := calc.CalculateWith("цена*3+доставка", map[string]decimal.Decimal{"цена": decimal.NewFromInt(150), "доставка": decimal.NewFromInt(99)})
+	require.NoError(t, err)
+	assert.Equal(t, "549", got.String())
+
+	_, err = calc.CalculateWith("цена*3", nil)
+	assert.ErrorIs(t, err, calc.ErrUnknownVariable)
+}
+
+func TestCalculateDecimalExact(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{expression: "0.1+0.2", want: "0.3"},
+		{expression: "19.99*3", want: "59.97"},
+		{expression: "100-33.33*3", want: "0.01"},
+		{expression: "1.1*1.1", want: "1.21"},
+		{expression: "1000-0.01-999.99", want: "0"},
+		{expression: "1.15+10%", want: "1.265"},
+		{expression: "10/4", want: "2.5"},
+		{expression: "round(2.675, 2)", want: "2.68"},
+		{expression: "2^-2", want: "0.25"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := calc.Calculate(tt.expression)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+
+	_, err := calc.Calculate("10^100000")
+	assert.ErrorIs(t, err, calc.ErrInvalidResult)
+	_, err = calc.Calculate("(-8)^0.5")
+	assert.ErrorIs(t, err, calc.ErrInvalidResult)
+}
+
+func TestCalculateErrorPosition(t *testing.T) {
+	tests := []struct {
+		expression string
+		err        error
+		marked     string
+	}{
+		{expression: "100+*5", err: calc.ErrUnexpectedToken, marked: "100+[*]5"},
+		{expression: "1+1)", err: calc.ErrUnbalancedParens, marked: "1+1[)]"},
+		{expression: "10/0", err: calc.ErrDivisionByZero, marked: "10[/]0"},
+		{expression: "100*10%", err: calc.ErrInvalidPercent, marked: "100*10[%]"},
+		{expression: "sqr(4)", err: calc.ErrUnknownFunction, marked: "[sqr](4)"},
+		{expression: "round()", err: calc.ErrArgumentCount, marked: "[round]()"},
+		{expression: "1+1a", err: calc.ErrUnknownVariable, marked: "1+1[a]"},
+		{expression: "5+", err: calc.ErrUnexpectedEnd, marked: "5+[]"},
+		{expression: "1.2.3", err: calc.ErrUnexpectedToken, marked: "1.2[.]3"},
+		{expression: "  ", err: calc.ErrEmpty, marked: "  []"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := calc.Calculate(tt.expression)
+			require.ErrorIs(t, err, tt.err)
+
+			var calcErr *calc.Error
+			require.ErrorAs(t, err, &calcErr)
+			assert.Equal(t, tt.marked, calc.Mark(tt.expression, err))
+		})
+	}
+}
+
+func TestIsExpression(t *testing.T) {
+	assert.True(t, calc.IsExpression("1200+300"))
+	assert.True(t, calc.IsExpression("3x150"))
+	assert.True(t, calc.IsExpression("150*3шт"))
+	assert.False(t, calc.IsExpression("кофе"))
+	assert.False(t, calc.IsExpression("20usd"))
+	assert.False(t, calc.IsExpression("+"))
+}
@@ -0,0 +1,64 @@
+package chart_test
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/SobolevTim/finance_bot/internal/pkg/chart"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPie(t *testing.T) {
+	data, err := chart.Pie([]float64{500, 300, 200})
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, img.Bounds().Dx(), img.Bounds().Dy())
+
+	// Первый сектор начинается сверху и закрашен первым цветом палитры
+	r, g, b, _ := img.At(img.Bounds().Dx()/2+5, 40).RGBA()
+	want := chart.Palette[0]
+	assert.Equal(t, [3]uint32{uint32(want.R), uint32(want.G), uint32(want.B)}, [3]uint32{r >> 8, g >> 8, b >> 8})
+
+	_, err = chart.Pie([]float64{0, 0})
+	assert.ErrorIs(t, err, chart.ErrNoData)
+	_, err = chart.Pie(nil)
+	assert.ErrorIs(t, err, chart.ErrNoData)
+}
+
+func TestBars(t *testing.T) {
+	data, err := chart.Bars([]float64{100, 0, 350, 90}, []string{"1", "2", "3", "4"}, 120)
+	require.NoError(t, err)
+	_, err = png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	_, err = chart.Bars([]float64{0, 0}, nil, 100)
+	assert.ErrorIs(t, err, chart.ErrNoData)
+}
+
+func TestTrend(t *testing.T) {
+	data, err := chart.Trend([]float64{42000, 0, 51000}, []string{"08.26", "09.26", "10.26"})
+	require.NoError(t, err)
+	_, err = png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	_, err = chart.Trend(nil, nil)
+	assert.ErrorIs(t, err, chart.ErrNoData)
+}
+
+func TestCompact(t *testing.T) {
+	tests := map[float64]string{
+		0:       "0",
+		950.4:   "950",
+		1000:    "1k",
+		12500:   "12.5k",
+		1250000: "1.2M",
+	}
+	for v, want := range tests {
+		assert.Equal(t, want, chart.Compact(v), v)
+	}
+	assert.Len(t, chart.Marks, len(chart.Palette))
+}
@@ -0,0 +1,30 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/SobolevTim/finance_bot/internal/pkg/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixed(t *testing.T) {
+	now := time.Date(2025, 3, 27, 15, 30, 0, 0, time.UTC)
+	assert.Equal(t, now, clock.Fixed(now).Now())
+}
+
+func TestDate(t *testing.T) {
+	// 08:00 по UTC+10 - еще 22:00 предыдущего дня в UTC
+	now := time.Date(2025, 3, 26, 22, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2025, 3, 27, 0, 0, 0, 0, time.UTC), clock.Date(now, user.ParseLocation("UTC+10")))
+	assert.Equal(t, time.Date(2025, 3, 26, 0, 0, 0, 0, time.UTC), clock.Date(now, time.UTC))
+	assert.Equal(t, time.Date(2025, 3, 26, 0, 0, 0, 0, time.UTC), clock.Date(now, user.ParseLocation("UTC-5")))
+}
+
+func TestMonth(t *testing.T) {
+	start, end := clock.Month(time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), end)
+}
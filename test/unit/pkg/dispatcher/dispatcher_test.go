@@ -0,0 +1,118 @@
+package dispatcher_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/pkg/dispatcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestDispatcher_KeepsOrderPerKey(t *testing.T) {
+	d := dispatcher.New(4, 10, logger)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	got := map[int64][]int{}
+	for i := 0; i < 50; i++ {
+		for _, key := range []int64{1, 2, -1003} {
+			i, key := i, key
+			require.NoError(t, d.Dispatch(ctx, key, func(context.Context) {
+				mu.Lock()
+				got[key] = append(got[key], i)
+				mu.Unlock()
+			}))
+		}
+	}
+	d.Close()
+
+	for _, key := range []int64{1, 2, -1003} {
+		require.Len(t, got[key], 50)
+		for i, v := range got[key] {
+			assert.Equal(t, i, v, "ключ %d", key)
+		}
+	}
+	assert.EqualValues(t, 150, d.Stats().Processed)
+}
+
+func TestDispatcher_RunsKeysConcurrently(t *testing.T) {
+	d := dispatcher.New(2, 10, logger)
+	defer d.Close()
+	ctx := context.Background()
+
+	// Медленная задача чата 1 не блокирует чат 2
+	release := make(chan struct{})
+	require.NoError(t, d.Dispatch(ctx, 1, func(context.Context) { <-release }))
+	done := make(chan struct{})
+	require.NoError(t, d.Dispatch(ctx, 2, func(context.Context) { close(done) }))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("задача чата 2 ожидает задачу чата 1")
+	}
+	assert.EqualValues(t, 1, d.Stats().InFlight)
+	close(release)
+}
+
+func TestDispatcher_RecoversPanic(t *testing.T) {
+	d := dispatcher.New(1, 10, logger)
+	ctx := context.Background()
+
+	ran := false
+	require.NoError(t, d.Dispatch(ctx, 1, func(context.Context) { panic("boom") }))
+	require.NoError(t, d.Dispatch(ctx, 1, func(context.Context) { ran = true }))
+	d.Close()
+
+	assert.True(t, ran, "паника не останавливает обработчик")
+	stats := d.Stats()
+	assert.EqualValues(t, 1, stats.Panics)
+	assert.EqualValues(t, 2, stats.Processed)
+}
+
+func TestDispatcher_BoundedQueue(t *testing.T) {
+	d := dispatcher.New(1, 1, logger)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	bg := context.Background()
+
+	require.NoError(t, d.Dispatch(bg, 1, func(context.Context) { close(started); <-release }))
+	<-started
+	require.NoError(t, d.Dispatch(bg, 1, func(context.Context) {}))
+	assert.Equal(t, 1, d.Stats().Queued)
+	assert.Equal(t, 1, d.Stats().MaxQueue)
+
+	// Очередь заполнена: Dispatch ждет места до отмены контекста
+	ctx, cancel := context.WithTimeout(bg, 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, d.Dispatch(ctx, 1, func(context.Context) {}), context.DeadlineExceeded)
+
+	close(release)
+	d.Close()
+	assert.EqualValues(t, 1, d.Stats().Dropped)
+	assert.ErrorIs(t, d.Dispatch(bg, 1, func(context.Context) {}), dispatcher.ErrClosed)
+}
+
+func TestDispatcher_DropsCancelledTasks(t *testing.T) {
+	d := dispatcher.New(1, 10, logger)
+	release := make(chan struct{})
+	bg := context.Background()
+
+	require.NoError(t, d.Dispatch(bg, 1, func(context.Context) { <-release }))
+	ctx, cancel := context.WithCancel(bg)
+	ran := false
+	require.NoError(t, d.Dispatch(ctx, 1, func(context.Context) { ran = true }))
+	cancel()
+	close(release)
+	d.Close()
+
+	assert.False(t, ran)
+	assert.EqualValues(t, 1, d.Stats().Dropped)
+}
@@ -0,0 +1,72 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/pkg/export"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var rows = []export.Row{
+	{Date: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), Category: "Еда", Amount: decimal.RequireFromString("350"), Currency: "RUB", BaseAmount: decimal.RequireFromString("350"), Description: "кофе, булочка"},
+	{Date: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC), Category: "Транспорт", Amount: decimal.RequireFromString("1"), Currency: "USD", BaseAmount: decimal.RequireFromString("100")},
+	{Date: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC), Category: "Еда", Amount: decimal.RequireFromString("550.5"), Currency: "RUB", BaseAmount: decimal.RequireFromString("550.5"), Description: "<ужин> & чай"},
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteCSV(&buf, rows))
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "\ufeff"), "CSV должен начинаться с BOM")
+	lines := strings.Split(strings.TrimSpace(strings.TrimPrefix(out, "\ufeff")), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "Дата,Категория,Сумма,Валюта,Описание", lines[0])
+	assert.Equal(t, `01.03.2025,Еда,350.00,RUB,"кофе, булочка"`, lines[1])
+	assert.Equal(t, "02.03.2025,Транспорт,1.00,USD,", lines[2])
+}
+
+func TestSummarize(t *testing.T) {
+	summary := export.Summarize(rows)
+	require.Len(t, summary, 2)
+	assert.Equal(t, "Еда", summary[0].Category)
+	assert.Equal(t, "900.5", summary[0].Total.String())
+	assert.InDelta(t, 900.5/1000.5*100, summary[0].Share, 0.001)
+	assert.Equal(t, "Транспорт", summary[1].Category)
+	assert.Equal(t, "100", summary[1].Total.String(), "итоги считаются в валюте итогов")
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteXLSX(&buf, rows, "RUB"))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		assert.Contains(t, files, name)
+	}
+	assert.Contains(t, files["xl/workbook.xml"], `name="Итоги"`)
+	assert.Contains(t, files["xl/worksheets/sheet1.xml"], "&lt;ужин&gt; &amp; чай", "текст должен экранироваться")
+	assert.Contains(t, files["xl/worksheets/sheet1.xml"], `<c r="C4" s="2"><v>550.5</v></c>`)
+	assert.Contains(t, files["xl/worksheets/sheet2.xml"], "Сумма, RUB")
+	assert.Contains(t, files["xl/worksheets/sheet2.xml"], "Итого")
+	assert.Contains(t, files["xl/worksheets/sheet2.xml"], `<v>1000.5</v>`)
+}
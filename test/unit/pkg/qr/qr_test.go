@@ -0,0 +1,57 @@
+package qr_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/SobolevTim/finance_bot/internal/pkg/qr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const receiptText = "t=20240115T1830&s=1234.50&fn=9960440300123456&i=12345&fp=1234567890&n=1"
+
+func loadFixture(t *testing.T) image.Image {
+	t.Helper()
+	f, err := os.Open("testdata/receipt.png")
+	require.NoError(t, err)
+	defer f.Close()
+	img, err := png.Decode(f)
+	require.NoError(t, err)
+	return img
+}
+
+func TestDecode(t *testing.T) {
+	text, err := qr.Decode(loadFixture(t))
+	require.NoError(t, err)
+	assert.Equal(t, receiptText, text)
+}
+
+func TestDecodeRotated(t *testing.T) {
+	src := loadFixture(t)
+	b := src.Bounds()
+	// Поворот на 90 градусов по часовой стрелке на сером фоне большего размера
+	dst := image.NewGray(image.Rect(0, 0, b.Dy()+100, b.Dx()+100))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{color.Gray{Y: 230}}, image.Point{}, draw.Src)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			dst.Set(50+b.Max.Y-1-y, 50+x, src.At(x, y))
+		}
+	}
+
+	text, err := qr.Decode(dst)
+	require.NoError(t, err)
+	assert.Equal(t, receiptText, text)
+}
+
+func TestDecodeNotFound(t *testing.T) {
+	blank := image.NewGray(image.Rect(0, 0, 200, 200))
+	draw.Draw(blank, blank.Bounds(), image.White, image.Point{}, draw.Src)
+
+	_, err := qr.Decode(blank)
+	assert.ErrorIs(t, err, qr.ErrNotFound)
+}
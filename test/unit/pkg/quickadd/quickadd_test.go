@@ -0,0 +1,78 @@
+package quickadd_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/pkg/quickadd"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2025, 3, 27, 15, 30, 0, 0, time.UTC)
+	today := time.Date(2025, 3, 27, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		text    string
+		amount  string
+		date    time.Time
+		words   []string
+		wantErr error
+	}{
+		{name: "amount and category", text: "350 кофе", amount: "350", date: today, words: []string{"кофе"}},
+		{name: "expression and yesterday", text: "1200+300 такси вчера", amount: "1500", date: today.AddDate(0, 0, -1), words: []string{"такси"}},
+		{name: "expression with spaces", text: "1200 + 300 такси", amount: "1500", date: today, words: []string{"такси"}},
+		{name: "date first", text: "25.03 500 еда", amount: "500", date: time.Date(2025, 3, 25, 0, 0, 0, 0, time.UTC), words: []string{"еда"}},
+		{name: "date with year", text: "25.03.2024 500 еда", amount: "500", date: time.Date(2024, 3, 25, 0, 0, 0, 0, time.UTC), words: []string{"еда"}},
+		{name: "future date goes to last year", text: "30.12 500 подарки", amount: "500", date: time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC), words: []string{"подарки"}},
+		{name: "single decimal is amount", text: "25.03 еда", amount: "25.03", date: today, words: []string{"еда"}},
+		{name: "word date first", text: "позавчера 200 метро", amount: "200", date: today.AddDate(0, 0, -2), words: []string{"метро"}},
+		{name: "note words kept", text: "500 еда обед с коллегами", amount: "500", date: today, words: []string{"еда", "обед", "с", "коллегами"}},
+		{name: "multiplier shorthand", text: "3x150 кофе", amount: "450", date: today, words: []string{"кофе"}},
+		{name: "amount with units", text: "150*3шт пирожки", amount: "450", date: today, words: []string{"пирожки"}},
+		{name: "plain text", text: "привет, как дела?", wantErr: quickadd.ErrNoAmount},
+		{name: "text with number inside", text: "встретимся в 5", wantErr: quickadd.ErrNoAmount},
+		{name: "only date", text: "вчера такси", wantErr: quickadd.ErrNoAmount},
+		{name: "zero amount", text: "0 кофе", wantErr: quickadd.ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := quickadd.Parse(tt.text, now)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, decimal.RequireFromString(tt.amount).Equal(got.Amount), "amount %s", got.Amount)
+			assert.Equal(t, tt.date, got.Date)
+			assert.Equal(t, tt.words, got.Words)
+		})
+	}
+}
+
+func TestParseCurrency(t *testing.T) {
+	now := time.Date(2025, 3, 27, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		text     string
+		amount   string
+		currency string
+		words    []string
+	}{
+		{text: "20 USD кофе", amount: "20", currency: "USD", words: []string{"кофе"}},
+		{text: "20$ кофе", amount: "20", currency: "USD", words: []string{"кофе"}},
+		{text: "10+5 евро такси", amount: "15", currency: "EUR", words: []string{"такси"}},
+		{text: "350 кофе", amount: "350", currency: "", words: []string{"кофе"}},
+	}
+	for _, tt := range tests {
+		res, err := quickadd.Parse(tt.text, now)
+		if !assert.NoError(t, err, tt.text) {
+			continue
+		}
+		assert.True(t, decimal.RequireFromString(tt.amount).Equal(res.Amount), tt.text)
+		assert.Equal(t, tt.currency, res.Currency, tt.text)
+		assert.Equal(t, tt.words, res.Words, tt.text)
+	}
+}
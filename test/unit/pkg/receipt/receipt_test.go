@@ -0,0 +1,55 @@
+package receipt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/pkg/receipt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	r, err := receipt.Parse("t=20240115T1830&s=1234.50&fn=9960440300123456&i=12345&fp=1234567890&n=1")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 15, 18, 30, 0, 0, time.UTC), r.Time)
+	assert.Equal(t, "1234.5", r.Sum.String())
+	assert.Equal(t, "9960440300123456", r.FN)
+	assert.Equal(t, "12345", r.FD)
+	assert.Equal(t, "1234567890", r.FP)
+	assert.Equal(t, receipt.OperationPurchase, r.Operation)
+}
+
+func TestParseVariants(t *testing.T) {
+	// Секунды, ссылка на проверку чека, заглавные параметры и запятая в сумме
+	r, err := receipt.Parse(" https://check.example/?T=20240115t183005&S=99,90&FN=1&I=2&FP=3 ")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 15, 18, 30, 5, 0, time.UTC), r.Time)
+	assert.Equal(t, "99.9", r.Sum.String())
+	assert.Equal(t, 0, r.Operation)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		err  error
+	}{
+		{"кофе 250", receipt.ErrNotReceipt},
+		{"s=100&i=1&fp=2", receipt.ErrNotReceipt},
+		{"t=20240115T1830&s=100&fn=abc&i=1&fp=2", receipt.ErrInvalidReceipt},
+		{"t=20240115&s=100&fn=1&i=1&fp=2", receipt.ErrInvalidReceipt},
+		{"t=20240115T1830&s=-5&fn=1&i=1&fp=2", receipt.ErrInvalidReceipt},
+		{"t=20240115T1830&s=100&fn=1&i=1&fp=2&n=7", receipt.ErrInvalidReceipt},
+		{"t=20240115T1830&s=100&fn=1&i=1&fp=2&n=2", receipt.ErrNotPurchase},
+	}
+	for _, tt := range tests {
+		_, err := receipt.Parse(tt.text)
+		assert.ErrorIs(t, err, tt.err, tt.text)
+	}
+}
+
+func TestIsReceipt(t *testing.T) {
+	assert.True(t, receipt.IsReceipt("t=20240115T1830&s=100&fn=1&i=1&fp=2&n=1"))
+	assert.False(t, receipt.IsReceipt("кофе 250"))
+	assert.False(t, receipt.IsReceipt("100+50"))
+}
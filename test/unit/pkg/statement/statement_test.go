@@ -0,0 +1,81 @@
+package statement_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/pkg/statement"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAndDetectMapping(t *testing.T) {
+	data := []byte("\ufeffДата операции;Дата платежа;Сумма операции;Валюта;Описание\n" +
+		"01.03.2025 12:30:00;02.03.2025;-350,00;RUB;Кофейня\n" +
+		"02.03.2025 09:00:00;03.03.2025;-1 200,50;RUB;Такси\n" +
+		"03.03.2025 10:00:00;03.03.2025;50000,00;RUB;Зарплата\n")
+
+	table, err := statement.Read(data)
+	require.NoError(t, err)
+	assert.Len(t, table.Header, 5)
+	assert.Len(t, table.Rows, 3)
+
+	m, ok := statement.DetectMapping(table.Header)
+	require.True(t, ok)
+	assert.Equal(t, statement.Mapping{Date: 0, Amount: 2, Description: 4}, m)
+
+	res, err := statement.Parse(table, m)
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 2, "поступления не импортируются")
+	assert.Equal(t, 1, res.Skipped)
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), res.Rows[0].Date)
+	assert.Equal(t, "350", res.Rows[0].Amount.String())
+	assert.Equal(t, "1200.5", res.Rows[1].Amount.String())
+	assert.Equal(t, "Такси", res.Rows[1].Description)
+}
+
+func TestReadWindows1251(t *testing.T) {
+	// "Дата,Сумма\n01.03.2025,100" в Windows-1251
+	data := []byte{0xC4, 0xE0, 0xF2, 0xE0, ',', 0xD1, 0xF3, 0xEC, 0xEC, 0xE0, '\n', '0', '1', '.', '0', '3', '.', '2', '0', '2', '5', ',', '1', '0', '0', '\n'}
+
+	table, err := statement.Read(data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Дата", "Сумма"}, table.Header)
+
+	res, err := statement.Parse(table, statement.Mapping{Date: 0, Amount: 1, Description: -1})
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 1, "без отрицательных сумм все строки считаются расходами")
+}
+
+func TestDetectMappingFails(t *testing.T) {
+	_, ok := statement.DetectMapping([]string{"col1", "col2"})
+	assert.False(t, ok)
+}
+
+func TestParseMapping(t *testing.T) {
+	m, err := statement.ParseMapping("1 3 5", 5)
+	require.NoError(t, err)
+	assert.Equal(t, statement.Mapping{Date: 0, Amount: 2, Description: 4}, m)
+
+	m, err = statement.ParseMapping("2,1", 2)
+	require.NoError(t, err)
+	assert.Equal(t, statement.Mapping{Date: 1, Amount: 0, Description: -1}, m)
+
+	for _, text := range []string{"1", "1 6", "1 1", "a b", "1 2 3 4"} {
+		_, err := statement.ParseMapping(text, 5)
+		assert.ErrorIs(t, err, statement.ErrInvalidMapping, text)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := map[string]string{
+		"-1 234,56":   "-1234.56",
+		"1234.56 RUB": "1234.56",
+		"−350":        "-350",
+	}
+	for in, want := range tests {
+		got, err := statement.ParseAmount(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got.String(), in)
+	}
+}
@@ -0,0 +1,66 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/SobolevTim/finance_bot/internal/service"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOwnershipService возвращает сервис с двумя пользователями и тратой первого из них
+func newOwnershipService() (*service.Service, *expenseRepo, *expense.Expense) {
+	owner := &user.User{ID: uuid.New(), TelegramID: "1", Timezone: "UTC"}
+	stranger := &user.User{ID: uuid.New(), TelegramID: "2", Timezone: "UTC"}
+	e := &expense.Expense{
+		ID:         uuid.New(),
+		UserID:     owner.ID,
+		CategoryID: uuid.New(),
+		Ammount:    decimal.NewFromInt(300),
+		Currency:   "RUB",
+		Date:       time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	eR := &expenseRepo{
+		expenses: map[uuid.UUID]*expense.Expense{e.ID: e},
+		last:     map[uuid.UUID]time.Time{},
+	}
+	s := service.NewService(&userRepo{users: []*user.User{owner, stranger}}, nil, nil, eR, categoriesRepo{}, nil, nil, nil, nil, nil, nil, "RUB")
+	return s, eR, e
+}
+
+func TestForeignExpenseIsNotFound(t *testing.T) {
+	ctx := context.Background()
+	s, eR, e := newOwnershipService()
+
+	_, err := s.GetExpense(ctx, 2, e.ID.String())
+	assert.ErrorIs(t, err, expense.ErrorExpenseNotFound)
+
+	err = s.UpdateExpense(ctx, 2, e.ID.String(), decimal.NewFromInt(1), "", e.Date, "Связь", "чужая")
+	assert.ErrorIs(t, err, expense.ErrorExpenseNotFound)
+
+	err = s.DeleteExpense(ctx, 2, e.ID.String())
+	assert.ErrorIs(t, err, expense.ErrorExpenseNotFound)
+
+	// Трата владельца не изменилась
+	require.Contains(t, eR.expenses, e.ID)
+	assert.True(t, decimal.NewFromInt(300).Equal(eR.expenses[e.ID].Ammount))
+	assert.Empty(t, eR.expenses[e.ID].Description)
+}
+
+func TestDeleteOwnExpense(t *testing.T) {
+	ctx := context.Background()
+	s, eR, e := newOwnershipService()
+
+	// Некорректный ID из callback-данных не приводит к удалению
+	assert.ErrorIs(t, s.DeleteExpense(ctx, 1, "not-a-uuid"), expense.ErrorExpenseNotFound)
+	require.Contains(t, eR.expenses, e.ID)
+
+	require.NoError(t, s.DeleteExpense(ctx, 1, e.ID.String()))
+	assert.NotContains(t, eR.expenses, e.ID)
+}
@@ -0,0 +1,130 @@
+package service_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/account"
+	"github.com/SobolevTim/finance_bot/internal/domain/categories"
+	"github.com/SobolevTim/finance_bot/internal/domain/currency"
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// expenseRepo хранит траты в памяти и, как база данных, запоминает
+// дату последнего вхождения в повторяющейся трате
+type expenseRepo struct {
+	expense.Repository
+	expenses map[uuid.UUID]*expense.Expense
+	last     map[uuid.UUID]time.Time
+}
+
+func (r *expenseRepo) GetExpenses(_ context.Context, id uuid.UUID) (*expense.Expense, error) {
+	e, ok := r.expenses[id]
+	if !ok {
+		return nil, expense.ErrorExpenseNotFound
+	}
+	copied := *e
+	return &copied, nil
+}
+
+func (r *expenseRepo) DeleteExpens(_ context.Context, id uuid.UUID, _ []expense.BalanceChange) error {
+	delete(r.expenses, id)
+	return nil
+}
+
+func (r *expenseRepo) GetRecurringExpenses(_ context.Context) ([]*expense.Expense, error) {
+	var result []*expense.Expense
+	for _, e := range r.expenses {
+		if e.IsRecurring {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+func (r *expenseRepo) GetLastOccurrence(_ context.Context, parentID uuid.UUID) (time.Time, error) {
+	if last, ok := r.last[parentID]; ok {
+		return last, nil
+	}
+	return r.expenses[parentID].Date, nil
+}
+
+func (r *expenseRepo) CreateOccurrence(_ context.Context, e *expense.Expense, _ []expense.BalanceChange) (bool, error) {
+	if e.Date.After(r.last[e.ParentID]) {
+		r.last[e.ParentID] = e.Date
+	}
+	for _, existing := range r.expenses {
+		if existing.ParentID == e.ParentID && existing.Date.Equal(e.Date) {
+			return false, nil
+		}
+	}
+	r.expenses[e.ID] = e
+	return true, nil
+}
+
+// occurrences возвращает вхождения повторяющейся траты parentID
+func (r *expenseRepo) occurrences(parentID uuid.UUID) []*expense.Expense {
+	var result []*expense.Expense
+	for _, e := range r.expenses {
+		if e.ParentID == parentID {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// userRepo хранит пользователей в памяти
+type userRepo struct {
+	user.Repository
+	users []*user.User
+}
+
+func (r *userRepo) UserGetByID(_ context.Context, id uuid.UUID) (*user.User, error) {
+	for _, u := range r.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, user.ErrUserNotFound
+}
+
+func (r *userRepo) UserGetByTelegramID(_ context.Context, telegramID string) (*user.User, error) {
+	for _, u := range r.users {
+		if u.TelegramID == telegramID {
+			return u, nil
+		}
+	}
+	return nil, user.ErrUserNotFound
+}
+
+type categoriesRepo struct {
+	categories.Repository
+}
+
+func (categoriesRepo) CategoriesGetByID(_ context.Context, id uuid.UUID) (*categories.Categories, error) {
+	return &categories.Categories{ID: id, Name: "Связь", Icon: "📱"}, nil
+}
+
+type accountRepo struct {
+	account.Repository
+	accounts []*account.Account
+}
+
+func (r *accountRepo) AccountGet(_ context.Context, id uuid.UUID) (*account.Account, error) {
+	for _, a := range r.accounts {
+		if a.ID == id {
+			return a, nil
+		}
+	}
+	return nil, account.ErrAccountNotFound
+}
+
+// noRates не знает ни одного курса
+type noRates struct{}
+
+func (noRates) ExchangeRate(context.Context, string, string, time.Time) (decimal.Decimal, error) {
+	return decimal.Zero, currency.ErrRateNotFound
+}
@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SobolevTim/finance_bot/internal/domain/account"
+	"github.com/SobolevTim/finance_bot/internal/domain/expense"
+	"github.com/SobolevTim/finance_bot/internal/domain/user"
+	"github.com/SobolevTim/finance_bot/internal/service"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeletedOccurrenceIsNotRecreated(t *testing.T) {
+	ctx := context.Background()
+	u := &user.User{ID: uuid.New(), TelegramID: "42", Timezone: "UTC"}
+	template := &expense.Expense{
+		ID:             uuid.New(),
+		UserID:         u.ID,
+		CategoryID:     uuid.New(),
+		Ammount:        decimal.NewFromInt(500),
+		Currency:       "RUB",
+		Date:           time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+		IsRecurring:    true,
+		RecurrenceRule: "FREQ=MONTHLY;BYMONTHDAY=5",
+	}
+	eR := &expenseRepo{
+		expenses: map[uuid.UUID]*expense.Expense{template.ID: template},
+		last:     map[uuid.UUID]time.Time{},
+	}
+	s := service.NewService(&userRepo{users: []*user.User{u}}, nil, nil, eR, categoriesRepo{}, nil, nil, nil, nil, nil, nil, "RUB")
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	created, err := s.ProcessRecurringExpenses(ctx, now)
+	require.NoError(t, err)
+	require.Len(t, created, 2)
+
+	// Удаляем самое новое вхождение - за март
+	var march *expense.Expense
+	for _, o := range eR.occurrences(template.ID) {
+		if o.Date.Month() == time.March {
+			march = o
+		}
+	}
+	require.NotNil(t, march)
+	require.NoError(t, s.DeleteExpense(ctx, 42, march.ID.String()))
+
+	created, err = s.ProcessRecurringExpenses(ctx, now)
+	require.NoError(t, err)
+	assert.Empty(t, created)
+	assert.Len(t, eR.occurrences(template.ID), 1)
+
+	// Следующее вхождение создается в срок
+	created, err = s.ProcessRecurringExpenses(ctx, now.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	require.Len(t, created, 1)
+	assert.Equal(t, time.Date(2024, 4, 5, 0, 0, 0, 0, time.UTC), created[0].Date)
+}
+
+func TestMissingAccountRateSkipsOnlyThatExpense(t *testing.T) {
+	ctx := context.Background()
+	u := &user.User{ID: uuid.New(), TelegramID: "42", Timezone: "UTC"}
+	usd := &account.Account{ID: uuid.New(), UserID: u.ID, Currency: "USD"}
+	newTemplate := func(accountID uuid.UUID) *expense.Expense {
+		return &expense.Expense{
+			ID:             uuid.New(),
+			UserID:         u.ID,
+			CategoryID:     uuid.New(),
+			AccountID:      accountID,
+			Ammount:        decimal.NewFromInt(500),
+			Currency:       "RUB",
+			Date:           time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+			IsRecurring:    true,
+			RecurrenceRule: "FREQ=MONTHLY;BYMONTHDAY=5",
+		}
+	}
+	withAccount, plain := newTemplate(usd.ID), newTemplate(uuid.Nil)
+	eR := &expenseRepo{
+		expenses: map[uuid.UUID]*expense.Expense{withAccount.ID: withAccount, plain.ID: plain},
+		last:     map[uuid.UUID]time.Time{},
+	}
+	s := service.NewService(&userRepo{users: []*user.User{u}}, nil, nil, eR, categoriesRepo{}, nil, nil,
+		&accountRepo{accounts: []*account.Account{usd}}, nil, nil, noRates{}, "RUB")
+
+	created, err := s.ProcessRecurringExpenses(ctx, time.Date(2024, 2, 10, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Len(t, created, 1)
+	assert.Empty(t, eR.occurrences(withAccount.ID))
+	assert.Len(t, eR.occurrences(plain.ID), 1)
+}